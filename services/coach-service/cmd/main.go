@@ -10,9 +10,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/powerlifting-coach-app/coach-service/internal/clients"
 	"github.com/powerlifting-coach-app/coach-service/internal/config"
 	"github.com/powerlifting-coach-app/coach-service/internal/database"
 	"github.com/powerlifting-coach-app/coach-service/internal/handlers"
+	"github.com/powerlifting-coach-app/coach-service/internal/models"
+	"github.com/powerlifting-coach-app/coach-service/internal/queue"
 	"github.com/powerlifting-coach-app/coach-service/internal/repository"
 	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
 	"github.com/rs/zerolog"
@@ -27,7 +30,10 @@ func main() {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	}
 
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load config")
+	}
 	log.Info().Str("port", cfg.Port).Msg("Starting coach service")
 
 	db, err := database.New(cfg.DatabaseURL)
@@ -41,7 +47,61 @@ func main() {
 	}
 
 	coachRepo := repository.NewCoachRepository(db.DB)
-	coachHandlers := handlers.NewCoachHandlers(coachRepo)
+	coachHandlers := handlers.NewCoachHandlers(
+		coachRepo,
+		clients.NewUserClient(cfg.UserService),
+		clients.NewProgramClient(cfg.ProgramService),
+		clients.NewVideoClient(cfg.VideoService),
+	)
+	auditRetention := time.Duration(cfg.AuditLogRetentionDays) * 24 * time.Hour
+	coachHandlers.SetAuditLogRetention(auditRetention)
+
+	eventConsumer, err := queue.NewEventConsumer(cfg.RabbitMQURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create event consumer")
+	}
+	defer eventConsumer.Close()
+
+	feedbackEventHandlers := handlers.NewFeedbackEventHandlers(coachRepo)
+	eventConsumer.RegisterHandler("coach.feedback.incorporated", feedbackEventHandlers.HandleFeedbackIncorporated)
+
+	coachHandlers.SetEventPublisher(eventConsumer)
+
+	accessEventHandlers := handlers.NewAccessEventHandlers(coachRepo, eventConsumer)
+	eventConsumer.RegisterHandler("coach.data.accessed", accessEventHandlers.HandleCoachDataAccessed)
+	eventConsumer.RegisterHandler("access.granted", accessEventHandlers.HandleAccessGranted)
+
+	routingKeys := []string{
+		"coach.feedback.incorporated",
+		"coach.data.accessed",
+		"access.granted",
+	}
+
+	if err := eventConsumer.StartConsuming("coach-service.events", routingKeys); err != nil {
+		log.Fatal().Err(err).Msg("Failed to start consuming events")
+	}
+
+	// Records of coaches accessing athlete data are purged past the retention
+	// window; a retention of zero keeps them indefinitely
+	if auditRetention > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				purged, err := coachRepo.PurgePermissionLog(auditRetention)
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to purge access log")
+					continue
+				}
+				if purged > 0 {
+					log.Info().Int64("entries", purged).Msg("Purged access log")
+				}
+			}
+		}()
+	} else {
+		log.Info().Msg("Access log retention disabled, records are kept indefinitely")
+	}
 
 	router := gin.Default()
 
@@ -71,6 +131,7 @@ func main() {
 		coaches := v1.Group("/coaches")
 		{
 			coaches.GET("/dashboard", coachHandlers.GetDashboard)
+			coaches.GET("/athletes", coachHandlers.GetCoachedAthletes)
 			coaches.GET("/notifications", coachHandlers.GetNotifications)
 			coaches.PUT("/notifications/:id/read", coachHandlers.MarkNotificationRead)
 			
@@ -82,11 +143,24 @@ func main() {
 			
 			// Notes management
 			coaches.POST("/notes", coachHandlers.CreateNote)
-			coaches.GET("/athletes/:athlete_id/notes", coachHandlers.GetAthleteNotes)
+			coaches.GET("/athletes/:athlete_id/notes", coachHandlers.AuditAthleteAccess(models.ActionDataViewed, "notes"), coachHandlers.GetAthleteNotes)
 			
 			// Progress tracking
 			coaches.POST("/progress", coachHandlers.TrackProgress)
-			coaches.GET("/athletes/:athlete_id/progress", coachHandlers.GetAthleteProgress)
+			coaches.GET("/athletes/:athlete_id/progress", coachHandlers.AuditAthleteAccess(models.ActionDataViewed, "progress"), coachHandlers.GetAthleteProgress)
+
+			// Teams
+			coaches.POST("/teams", coachHandlers.CreateTeam)
+			coaches.GET("/teams", coachHandlers.GetTeams)
+			coaches.GET("/teams/:id", coachHandlers.GetTeam)
+			coaches.PUT("/teams/:id", coachHandlers.UpdateTeam)
+			coaches.DELETE("/teams/:id", coachHandlers.DeleteTeam)
+			coaches.POST("/teams/:id/members", coachHandlers.AddTeamMembers)
+			coaches.DELETE("/teams/:id/members/:athlete_id", coachHandlers.RemoveTeamMember)
+			coaches.POST("/teams/:id/programs", coachHandlers.AssignTeamProgram)
+			coaches.GET("/teams/:id/analytics", coachHandlers.GetTeamAnalytics)
+			coaches.POST("/teams/:id/announcements", coachHandlers.CreateTeamAnnouncement)
+			coaches.GET("/teams/:id/announcements", coachHandlers.GetTeamAnnouncements)
 		}
 
 		// Athlete endpoints for feedback
@@ -95,16 +169,21 @@ func main() {
 			athletes.GET("/feedback", coachHandlers.GetMyFeedbackAsAthlete)
 			athletes.GET("/feedback/:id", coachHandlers.GetFeedback)
 			athletes.POST("/feedback/:id/respond", coachHandlers.RespondToFeedback)
+			athletes.GET("/announcements", coachHandlers.GetMyAnnouncements)
+			athletes.GET("/access-log", coachHandlers.GetMyAccessLog)
+			athletes.GET("/bodyweight", coachHandlers.GetMyBodyweight)
 		}
 
 		// Relationship endpoints
 		relationships := v1.Group("/relationships")
 		{
 			relationships.POST("", coachHandlers.SendRelationshipRequest)
+			relationships.POST("/access-code/check", coachHandlers.CheckAccessCode)
 			relationships.GET("", coachHandlers.GetMyRelationships)
 			relationships.GET("/:id", coachHandlers.GetRelationship)
 			relationships.POST("/:id/accept", coachHandlers.AcceptRelationshipRequest)
 			relationships.DELETE("/:id", coachHandlers.TerminateRelationship)
+			relationships.PUT("/:id/scopes", coachHandlers.UpdateRelationshipScopes)
 		}
 
 		// Coach profile endpoints
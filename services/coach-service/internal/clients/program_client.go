@@ -0,0 +1,178 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProgramClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+type AthleteCompliance struct {
+	AthleteID            uuid.UUID  `json:"athlete_id"`
+	ActivePrograms       int        `json:"active_programs_count"`
+	LastActiveAt         *time.Time `json:"last_active_at"`
+	Compliance7DayPct    *float64   `json:"compliance_7d_pct"`
+	Compliance28DayPct   *float64   `json:"compliance_28d_pct"`
+	SessionsMissed7Day   int        `json:"sessions_missed_7d"`
+	SessionsMissed28Day  int        `json:"sessions_missed_28d"`
+	RPEDrift             *float64   `json:"rpe_drift"`
+	PersonalRecords28Day int        `json:"personal_records_28d"`
+}
+
+type ComplianceListResponse struct {
+	Athletes []AthleteCompliance `json:"athletes"`
+}
+
+type AssignProgramRequest struct {
+	TemplateID      *uuid.UUID  `json:"template_id,omitempty"`
+	SourceProgramID *uuid.UUID  `json:"source_program_id,omitempty"`
+	AthleteIDs      []uuid.UUID `json:"athlete_ids"`
+	StartDate       time.Time   `json:"start_date"`
+	Name            *string     `json:"name,omitempty"`
+}
+
+// ProgramAssignment is program service's outcome for one athlete: created,
+// skipped or failed
+type ProgramAssignment struct {
+	AthleteID uuid.UUID              `json:"athlete_id"`
+	Status    string                 `json:"status"`
+	ProgramID *uuid.UUID             `json:"program_id,omitempty"`
+	Maxes     map[string]interface{} `json:"maxes,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+type AssignProgramResponse struct {
+	Assignments []ProgramAssignment `json:"assignments"`
+}
+
+func NewProgramClient(baseURL string) *ProgramClient {
+	return &ProgramClient{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// complianceBatchSize is the most athletes program service takes per request
+const complianceBatchSize = 100
+
+// GetAthleteCompliance returns recent compliance for the athletes; program
+// service leaves out any the caller doesn't coach
+func (c *ProgramClient) GetAthleteCompliance(ctx context.Context, authToken string, athleteIDs []uuid.UUID) ([]AthleteCompliance, error) {
+	var compliance []AthleteCompliance
+	for start := 0; start < len(athleteIDs); start += complianceBatchSize {
+		end := start + complianceBatchSize
+		if end > len(athleteIDs) {
+			end = len(athleteIDs)
+		}
+
+		batch, err := c.getAthleteCompliance(ctx, authToken, athleteIDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		compliance = append(compliance, batch...)
+	}
+
+	return compliance, nil
+}
+
+func (c *ProgramClient) getAthleteCompliance(ctx context.Context, authToken string, athleteIDs []uuid.UUID) ([]AthleteCompliance, error) {
+	query := url.Values{}
+	query.Set("athlete_id", joinIDs(athleteIDs))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/analytics/compliance?%s", c.baseURL, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", authToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("program service returned status %d", resp.StatusCode)
+	}
+
+	var complianceResp ComplianceListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&complianceResp); err != nil {
+		return nil, err
+	}
+
+	return complianceResp.Athletes, nil
+}
+
+// AssignProgram has program service give each athlete their own copy of a
+// template or program, with loads from that athlete's best lifts
+func (c *ProgramClient) AssignProgram(ctx context.Context, authToken string, assignReq AssignProgramRequest) ([]ProgramAssignment, error) {
+	body, err := json.Marshal(assignReq)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/api/v1/programs/assign", c.baseURL), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", authToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Error != "" {
+			return nil, &ServiceError{StatusCode: resp.StatusCode, Message: errResp.Error}
+		}
+		return nil, &ServiceError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("program service returned status %d", resp.StatusCode)}
+	}
+
+	var assignResp AssignProgramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&assignResp); err != nil {
+		return nil, err
+	}
+
+	return assignResp.Assignments, nil
+}
+
+// ServiceError is a request another service rejected, so its status and
+// message can be passed on to the caller
+type ServiceError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ServiceError) Error() string {
+	return e.Message
+}
+
+func joinIDs(ids []uuid.UUID) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = id.String()
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,76 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+type User struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+	Name  string    `json:"name"`
+}
+
+type AthleteProfile struct {
+	WeightKg          *float64   `json:"weight_kg"`
+	CompetitionDate   *time.Time `json:"competition_date"`
+	AccessCode        *string    `json:"access_code"`
+	TargetWeightClass *string    `json:"target_weight_class"`
+}
+
+type AthleteAccount struct {
+	User           User            `json:"user"`
+	AthleteProfile *AthleteProfile `json:"athlete_profile"`
+}
+
+type AthleteListResponse struct {
+	Athletes []AthleteAccount `json:"athletes"`
+}
+
+func NewUserClient(baseURL string) *UserClient {
+	return &UserClient{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// GetCoachAthletes returns the athletes that granted the calling coach access,
+// with their profiles
+func (c *UserClient) GetCoachAthletes(ctx context.Context, authToken string) ([]AthleteAccount, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/users/coach/athletes", c.baseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", authToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user service returned status %d", resp.StatusCode)
+	}
+
+	var athletesResp AthleteListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&athletesResp); err != nil {
+		return nil, err
+	}
+
+	return athletesResp.Athletes, nil
+}
@@ -0,0 +1,76 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type VideoClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+type Video struct {
+	ID               uuid.UUID `json:"id"`
+	AthleteID        uuid.UUID `json:"athlete_id"`
+	OriginalFilename string    `json:"original_filename"`
+	Status           string    `json:"status"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type VideoListResponse struct {
+	Videos []Video `json:"videos"`
+}
+
+func NewVideoClient(baseURL string) *VideoClient {
+	return &VideoClient{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// GetAthleteVideos returns the processed videos the athletes uploaded in the
+// last days days; video service leaves out any the caller doesn't coach
+func (c *VideoClient) GetAthleteVideos(ctx context.Context, authToken string, athleteIDs []uuid.UUID, days int) ([]Video, error) {
+	if len(athleteIDs) == 0 {
+		return nil, nil
+	}
+
+	query := url.Values{}
+	query.Set("athlete_id", joinIDs(athleteIDs))
+	query.Set("days", strconv.Itoa(days))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/videos/athletes?%s", c.baseURL, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", authToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("video service returned status %d", resp.StatusCode)
+	}
+
+	var videosResp VideoListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&videosResp); err != nil {
+		return nil, err
+	}
+
+	return videosResp.Videos, nil
+}
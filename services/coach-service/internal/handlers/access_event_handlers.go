@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/coach-service/internal/models"
+	"github.com/powerlifting-coach-app/coach-service/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// CoachDataAccessedEvent is published by services holding athlete data when a
+// coach views or changes it
+type CoachDataAccessedEvent struct {
+	SchemaVersion     string `json:"schema_version"`
+	EventType         string `json:"event_type"`
+	ClientGeneratedID string `json:"client_generated_id"`
+	UserID            string `json:"user_id"`
+	Timestamp         string `json:"timestamp"`
+	SourceService     string `json:"source_service"`
+	Data              struct {
+		CoachID      uuid.UUID               `json:"coach_id"`
+		AthleteID    uuid.UUID               `json:"athlete_id"`
+		Action       models.PermissionAction `json:"action"`
+		ResourceType string                  `json:"resource_type"`
+		ResourceID   *uuid.UUID              `json:"resource_id"`
+		IPAddress    string                  `json:"ip_address"`
+		UserAgent    string                  `json:"user_agent"`
+	} `json:"data"`
+}
+
+// AccessGrantedEvent is published by user-service when a coach redeems an
+// athlete's access code. The coach and athlete sit at the top level, next to
+// the usual envelope, which is where notification-service reads them.
+type AccessGrantedEvent struct {
+	SchemaVersion     string    `json:"schema_version"`
+	EventType         string    `json:"event_type"`
+	ClientGeneratedID string    `json:"client_generated_id"`
+	UserID            string    `json:"user_id"`
+	Timestamp         string    `json:"timestamp"`
+	SourceService     string    `json:"source_service"`
+	CoachID           uuid.UUID `json:"coach_id"`
+	AthleteID         uuid.UUID `json:"athlete_id"`
+	AccessCode        string    `json:"access_code"`
+	GrantedAt         time.Time `json:"granted_at"`
+}
+
+type EventPublisher interface {
+	PublishEvent(routingKey string, event interface{}) error
+}
+
+type AccessEventHandlers struct {
+	coachRepo *repository.CoachRepository
+	publisher EventPublisher
+}
+
+func NewAccessEventHandlers(coachRepo *repository.CoachRepository, publisher EventPublisher) *AccessEventHandlers {
+	return &AccessEventHandlers{
+		coachRepo: coachRepo,
+		publisher: publisher,
+	}
+}
+
+// HandleCoachDataAccessed adds another service's record of a coach accessing
+// athlete data to the athlete's access history
+func (h *AccessEventHandlers) HandleCoachDataAccessed(ctx context.Context, payload []byte) error {
+	var event CoachDataAccessedEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	data := event.Data
+	if data.CoachID == uuid.Nil || data.AthleteID == uuid.Nil {
+		return fmt.Errorf("coach_id and athlete_id are required")
+	}
+	switch data.Action {
+	case models.ActionDataViewed, models.ActionProgramModified:
+	default:
+		return fmt.Errorf("unsupported action: %s", data.Action)
+	}
+
+	entry := &models.PermissionLogEntry{
+		CoachID:      data.CoachID,
+		AthleteID:    data.AthleteID,
+		Action:       data.Action,
+		ResourceType: auditString(data.ResourceType, 0),
+		ResourceID:   data.ResourceID,
+		PerformedBy:  data.CoachID,
+		IPAddress:    auditString(data.IPAddress, maxIPAddressLength),
+		UserAgent:    auditString(data.UserAgent, 0),
+	}
+	if accessedAt, err := time.Parse(time.RFC3339, event.Timestamp); err == nil {
+		entry.LoggedAt = accessedAt.UTC()
+	}
+
+	logged, err := h.coachRepo.RecordCoachAccess(entry)
+	if err != nil {
+		return err
+	}
+	if !logged {
+		log.Debug().
+			Str("coach_id", data.CoachID.String()).
+			Str("athlete_id", data.AthleteID.String()).
+			Str("source_service", event.SourceService).
+			Msg("Coach access not logged: no relationship or already logged recently")
+	}
+
+	return nil
+}
+
+// HandleAccessGranted turns a redeemed access code into an active relationship,
+// so access codes grant the same, scoped access as an accepted request
+func (h *AccessEventHandlers) HandleAccessGranted(ctx context.Context, payload []byte) error {
+	var event AccessGrantedEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	if event.CoachID == uuid.Nil || event.AthleteID == uuid.Nil || event.AccessCode == "" {
+		return fmt.Errorf("coach_id, athlete_id and access_code are required")
+	}
+	grantedAt := event.GrantedAt
+	if grantedAt.IsZero() {
+		grantedAt = time.Now()
+	}
+
+	relationshipID, err := h.coachRepo.ActivateAccessCodeRelationship(event.CoachID, event.AthleteID, event.AccessCode, grantedAt)
+	if err != nil {
+		return err
+	}
+	if relationshipID == nil {
+		log.Info().
+			Str("coach_id", event.CoachID.String()).
+			Str("athlete_id", event.AthleteID.String()).
+			Msg("Access code not applied: relationship already active, code reused or in cooldown")
+		return nil
+	}
+
+	if err := h.coachRepo.RecordRelationshipAction(*relationshipID, models.ActionAccessGranted, event.AthleteID, nil, nil); err != nil {
+		log.Error().Err(err).Str("relationship_id", relationshipID.String()).Msg("Failed to record relationship action")
+	}
+
+	relationship, err := h.coachRepo.GetRelationshipByID(*relationshipID)
+	if err != nil {
+		return fmt.Errorf("failed to get activated relationship: %w", err)
+	}
+	publishRelationshipChanged(h.publisher, relationship, event.CoachID)
+
+	return nil
+}
+
+// publishRelationshipChanged tells other services a relationship's status or
+// scopes changed. user-service keeps its list of coached athletes from it.
+func publishRelationshipChanged(publisher EventPublisher, relationship *models.CoachAthleteRelationship, changedBy uuid.UUID) {
+	if publisher == nil {
+		return
+	}
+
+	event := map[string]interface{}{
+		"schema_version":      "1.0.0",
+		"event_type":          "coach.relationship.changed",
+		"client_generated_id": uuid.New().String(),
+		"user_id":             changedBy.String(),
+		"timestamp":           time.Now().UTC().Format(time.RFC3339),
+		"source_service":      "coach-service",
+		"data": map[string]interface{}{
+			"relationship_id": relationship.ID.String(),
+			"coach_id":        relationship.CoachID.String(),
+			"athlete_id":      relationship.AthleteID.String(),
+			"status":          relationship.Status,
+			"scopes":          relationship.Scopes,
+			"source":          relationship.Source,
+		},
+	}
+	if err := publisher.PublishEvent("coach.relationship.changed", event); err != nil {
+		log.Error().Err(err).Str("relationship_id", relationship.ID.String()).Msg("Failed to publish coach.relationship.changed event")
+	}
+}
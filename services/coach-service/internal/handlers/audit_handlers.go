@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/coach-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// maxIPAddressLength fits the ip_address column, which holds a full IPv6 address
+const maxIPAddressLength = 45
+
+// AuditAthleteAccess logs a coach's successful request for the athlete named
+// by the :athlete_id path parameter in the athlete's access history
+func (h *CoachHandlers) AuditAthleteAccess(action models.PermissionAction, resourceType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest || middleware.GetUserType(c) != "coach" {
+			return
+		}
+
+		athleteID, err := uuid.Parse(c.Param("athlete_id"))
+		if err != nil {
+			return
+		}
+		coachID, err := uuid.Parse(middleware.GetUserID(c))
+		if err != nil {
+			return
+		}
+
+		ipAddress, userAgent := requestOrigin(c)
+		h.recordAccess(&models.PermissionLogEntry{
+			CoachID:      coachID,
+			AthleteID:    athleteID,
+			Action:       action,
+			ResourceType: &resourceType,
+			PerformedBy:  coachID,
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+}
+
+// GetMyAccessLog shows the athlete which coaches accessed their data, what and
+// when. coach_id and action narrow the entries.
+func (h *CoachHandlers) GetMyAccessLog(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	athleteUUID, _ := uuid.Parse(userID)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+
+	var coachID *uuid.UUID
+	if raw := c.Query("coach_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coach ID"})
+			return
+		}
+		coachID = &parsed
+	}
+
+	var action *models.PermissionAction
+	if raw := c.Query("action"); raw != "" {
+		a := models.PermissionAction(raw)
+		switch a {
+		case models.ActionAccessGranted, models.ActionAccessRevoked, models.ActionDataViewed, models.ActionProgramModified,
+			models.ActionScopesChanged:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown action: " + raw})
+			return
+		}
+		action = &a
+	}
+
+	entries, totalCount, err := h.coachRepo.GetPermissionLogForAthlete(athleteUUID, coachID, action, page, pageSize)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get access log")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AccessLogResponse{
+		Entries:       entries,
+		TotalCount:    totalCount,
+		Page:          page,
+		PageSize:      pageSize,
+		RetentionDays: int(h.auditRetention / (24 * time.Hour)),
+	})
+}
+
+// recordAccess writes an access log entry. Failures are logged rather than
+// surfaced since auditing must not fail the request it describes.
+func (h *CoachHandlers) recordAccess(entry *models.PermissionLogEntry) {
+	if _, err := h.coachRepo.RecordCoachAccess(entry); err != nil {
+		log.Error().Err(err).
+			Str("coach_id", entry.CoachID.String()).
+			Str("athlete_id", entry.AthleteID.String()).
+			Msg("Failed to record coach access")
+	}
+}
+
+// recordRelationshipAction logs access being granted or revoked by the caller
+func (h *CoachHandlers) recordRelationshipAction(c *gin.Context, relationshipID uuid.UUID, action models.PermissionAction, performedBy uuid.UUID) {
+	ipAddress, userAgent := requestOrigin(c)
+	if err := h.coachRepo.RecordRelationshipAction(relationshipID, action, performedBy, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("relationship_id", relationshipID.String()).Msg("Failed to record relationship action")
+	}
+}
+
+// requestOrigin is the caller's IP address and user agent as stored in the log
+func requestOrigin(c *gin.Context) (*string, *string) {
+	return auditString(c.ClientIP(), maxIPAddressLength), auditString(c.Request.UserAgent(), 0)
+}
+
+func auditString(value string, maxLength int) *string {
+	if value == "" {
+		return nil
+	}
+	if maxLength > 0 && len(value) > maxLength {
+		value = value[:maxLength]
+	}
+	return &value
+}
@@ -1,27 +1,57 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/coach-service/internal/clients"
 	"github.com/powerlifting-coach-app/coach-service/internal/models"
 	"github.com/powerlifting-coach-app/coach-service/internal/repository"
 	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/scoring"
 	"github.com/rs/zerolog/log"
 )
 
 type CoachHandlers struct {
-	coachRepo *repository.CoachRepository
+	coachRepo     *repository.CoachRepository
+	userClient    *clients.UserClient
+	programClient *clients.ProgramClient
+	videoClient   *clients.VideoClient
+	publisher     EventPublisher
+	// auditRetention is how long coach data access records are kept
+	auditRetention time.Duration
 }
 
-func NewCoachHandlers(coachRepo *repository.CoachRepository) *CoachHandlers {
+func NewCoachHandlers(
+	coachRepo *repository.CoachRepository,
+	userClient *clients.UserClient,
+	programClient *clients.ProgramClient,
+	videoClient *clients.VideoClient,
+) *CoachHandlers {
 	return &CoachHandlers{
-		coachRepo: coachRepo,
+		coachRepo:      coachRepo,
+		userClient:     userClient,
+		programClient:  programClient,
+		videoClient:    videoClient,
+		auditRetention: 365 * 24 * time.Hour,
 	}
 }
 
+// SetAuditLogRetention sets how long coach data access records are kept; zero
+// keeps them indefinitely
+func (h *CoachHandlers) SetAuditLogRetention(retention time.Duration) {
+	h.auditRetention = retention
+}
+
+// SetEventPublisher lets relationship changes be announced to other services
+func (h *CoachHandlers) SetEventPublisher(publisher EventPublisher) {
+	h.publisher = publisher
+}
+
 func (h *CoachHandlers) CreateFeedback(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	userType := middleware.GetUserType(c)
@@ -450,6 +480,39 @@ func (h *CoachHandlers) GetAthleteProgress(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"progress": progress})
 }
 
+// GetMyBodyweight returns the athlete's bodyweight recorded nearest to date
+// (YYYY-MM-DD, today when left out) so results from that day can be scored
+func (h *CoachHandlers) GetMyBodyweight(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	athleteUUID, _ := uuid.Parse(userID)
+
+	date := time.Now()
+	if raw := c.Query("date"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	entry, err := h.coachRepo.GetBodyweightNearDate(athleteUUID, date)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get bodyweight")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bodyweight"})
+		return
+	}
+	if entry == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No bodyweight recorded"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bodyweight_kg": *entry.BodyWeightKg,
+		"recorded_on":   entry.TrackingDate.Format("2006-01-02"),
+	})
+}
+
 func (h *CoachHandlers) GetNotifications(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	userType := middleware.GetUserType(c)
@@ -500,53 +563,6 @@ func (h *CoachHandlers) MarkNotificationRead(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
 }
 
-func (h *CoachHandlers) GetDashboard(c *gin.Context) {
-	userID := middleware.GetUserID(c)
-	userType := middleware.GetUserType(c)
-	
-	if userType != "coach" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only coaches can view dashboard"})
-		return
-	}
-
-	coachUUID, _ := uuid.Parse(userID)
-
-	// Get recent notifications
-	notifications, err := h.coachRepo.GetNotificationsByCoachID(coachUUID, 10, false)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get notifications for dashboard")
-		notifications = []models.CoachNotification{}
-	}
-
-	// Get pending feedback (non-incorporated AI feedback)
-	pendingFeedback, _, err := h.coachRepo.GetFeedbackByCoachID(coachUUID, 1, 10, nil, nil)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get pending feedback for dashboard")
-		pendingFeedback = []models.CoachFeedback{}
-	}
-
-	// TODO: Get athlete overviews from user service
-	athletes := []models.AthleteOverview{}
-	
-	// Count unread notifications
-	unreadCount := 0
-	for _, notif := range notifications {
-		if !notif.IsRead {
-			unreadCount++
-		}
-	}
-
-	dashboard := models.CoachDashboard{
-		Athletes:            athletes,
-		RecentNotifications: notifications,
-		PendingFeedback:     pendingFeedback,
-		TotalAthletes:       len(athletes),
-		UnreadNotifications: unreadCount,
-	}
-
-	c.JSON(http.StatusOK, dashboard)
-}
-
 func (h *CoachHandlers) SendRelationshipRequest(c *gin.Context) {
 	var req models.SendRelationshipRequestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -554,6 +570,11 @@ func (h *CoachHandlers) SendRelationshipRequest(c *gin.Context) {
 		return
 	}
 
+	if err := validateScopes(req.Scopes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	userID := middleware.GetUserID(c)
 	athleteUUID, _ := uuid.Parse(userID)
 
@@ -561,6 +582,7 @@ func (h *CoachHandlers) SendRelationshipRequest(c *gin.Context) {
 		CoachID:        req.CoachID,
 		AthleteID:      athleteUUID,
 		RequestMessage: req.RequestMessage,
+		Scopes:         req.Scopes,
 	}
 
 	if err := h.coachRepo.SendRelationshipRequest(relationship); err != nil {
@@ -589,12 +611,15 @@ func (h *CoachHandlers) AcceptRelationshipRequest(c *gin.Context) {
 		return
 	}
 
+	h.recordRelationshipAction(c, relationshipID, models.ActionAccessGranted, acceptedBy)
+
 	relationship, err := h.coachRepo.GetRelationshipByID(relationshipID)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get updated relationship")
 		c.JSON(http.StatusOK, gin.H{"message": "Relationship accepted"})
 		return
 	}
+	publishRelationshipChanged(h.publisher, relationship, acceptedBy)
 
 	c.JSON(http.StatusOK, relationship)
 }
@@ -622,9 +647,102 @@ func (h *CoachHandlers) TerminateRelationship(c *gin.Context) {
 		return
 	}
 
+	h.recordRelationshipAction(c, relationshipID, models.ActionAccessRevoked, terminatedBy)
+
+	if relationship, err := h.coachRepo.GetRelationshipByID(relationshipID); err == nil {
+		publishRelationshipChanged(h.publisher, relationship, terminatedBy)
+	} else {
+		log.Error().Err(err).Msg("Failed to get terminated relationship")
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Relationship terminated"})
 }
 
+// UpdateRelationshipScopes lets the athlete change what their coach may do,
+// e.g. keep the coach on their program but stop them seeing videos
+func (h *CoachHandlers) UpdateRelationshipScopes(c *gin.Context) {
+	relationshipID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid relationship ID"})
+		return
+	}
+
+	var req models.UpdateScopesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateScopes(req.Scopes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	athleteUUID, _ := uuid.Parse(userID)
+
+	relationship, err := h.coachRepo.GetRelationshipByID(relationshipID)
+	if err != nil || relationship.AthleteID != athleteUUID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Relationship not found"})
+		return
+	}
+
+	if err := h.coachRepo.UpdateRelationshipScopes(relationshipID, req.Scopes); err != nil {
+		log.Error().Err(err).Msg("Failed to update relationship scopes")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.recordRelationshipAction(c, relationshipID, models.ActionScopesChanged, athleteUUID)
+
+	relationship.Scopes = req.Scopes
+	publishRelationshipChanged(h.publisher, relationship, athleteUUID)
+
+	c.JSON(http.StatusOK, relationship)
+}
+
+// CheckAccessCode tells the calling coach whether redeeming an athlete's
+// access code would start a relationship. user-service checks before it
+// accepts a code, since the relationship itself is created asynchronously.
+func (h *CoachHandlers) CheckAccessCode(c *gin.Context) {
+	if middleware.GetUserType(c) != "coach" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only coaches can redeem access codes"})
+		return
+	}
+
+	var req models.CheckAccessCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coachUUID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	eligibility, err := h.coachRepo.CheckAccessCodeEligibility(coachUUID, req.AthleteID, req.AccessCode)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check access code eligibility")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check access code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, eligibility)
+}
+
+// validateScopes rejects scopes the services don't know about
+func validateScopes(scopes []models.AccessScope) error {
+	for _, scope := range scopes {
+		switch scope {
+		case models.ScopeViewHistory, models.ScopeEditProgram, models.ScopeViewVideos, models.ScopeDirectMessage:
+		default:
+			return fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+	return nil
+}
+
 func (h *CoachHandlers) GetMyRelationships(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	userType := middleware.GetUserType(c)
@@ -655,6 +773,45 @@ func (h *CoachHandlers) GetMyRelationships(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"relationships": relationships})
 }
 
+// GetCoachedAthletes lists the coach's active athletes. Program and video
+// service check coach access, and the scopes it covers, against it.
+func (h *CoachHandlers) GetCoachedAthletes(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	userType := middleware.GetUserType(c)
+
+	if userType != "coach" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only coaches can list their athletes"})
+		return
+	}
+
+	coachUUID, _ := uuid.Parse(userID)
+	status := models.StatusActive
+	relationships, err := h.coachRepo.GetRelationshipsByCoachID(coachUUID, &status)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get coached athletes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get athletes"})
+		return
+	}
+
+	assignments := make([]models.CoachingAssignment, 0, len(relationships))
+	for _, rel := range relationships {
+		startDate := rel.RequestedAt
+		if rel.AcceptedAt != nil {
+			startDate = *rel.AcceptedAt
+		}
+		assignments = append(assignments, models.CoachingAssignment{
+			ID:        rel.ID,
+			CoachID:   rel.CoachID,
+			AthleteID: rel.AthleteID,
+			Status:    rel.Status,
+			Scopes:    rel.Scopes,
+			StartDate: startDate,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"assignments": assignments})
+}
+
 func (h *CoachHandlers) GetRelationship(c *gin.Context) {
 	relationshipIDStr := c.Param("id")
 	relationshipID, err := uuid.Parse(relationshipIDStr)
@@ -754,6 +911,12 @@ func (h *CoachHandlers) CreateSuccessStory(c *gin.Context) {
 		Federation:      req.Federation,
 		Placement:       req.Placement,
 		IsPublic:        req.IsPublic,
+		BodyWeightKg:    req.BodyWeightKg,
+	}
+
+	if err := applySuccessStoryScores(story, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	if err := h.coachRepo.CreateSuccessStory(story); err != nil {
@@ -765,6 +928,52 @@ func (h *CoachHandlers) CreateSuccessStory(c *gin.Context) {
 	c.JSON(http.StatusCreated, story)
 }
 
+// applySuccessStoryScores normalises sex/equipment and, when the total, bodyweight
+// and sex are all known, stores DOTS, Wilks and IPF GL points with the story
+func applySuccessStoryScores(story *models.CoachSuccessStory, req models.CreateSuccessStoryRequest) error {
+	var equipmentStr string
+	if req.Equipment != nil {
+		equipmentStr = *req.Equipment
+	}
+	equipment, err := scoring.ParseEquipment(equipmentStr)
+	if err != nil {
+		return err
+	}
+	eq := string(equipment)
+	story.Equipment = &eq
+
+	if req.Sex == nil {
+		return nil
+	}
+	sex, err := scoring.ParseSex(*req.Sex)
+	if err != nil {
+		return err
+	}
+	sx := string(sex)
+	story.Sex = &sx
+
+	if req.TotalKg == nil || req.BodyWeightKg == nil {
+		return nil
+	}
+
+	scores, err := scoring.Calculate(scoring.Input{
+		Sex:          sex,
+		Equipment:    equipment,
+		Event:        scoring.EventTotal,
+		BodyweightKg: *req.BodyWeightKg,
+		LiftedKg:     *req.TotalKg,
+	})
+	if err != nil {
+		return err
+	}
+
+	story.DOTS = &scores.DOTS
+	story.Wilks = &scores.Wilks
+	story.Wilks2020 = &scores.Wilks2020
+	story.IPFGLPoints = &scores.IPFGLPoints
+	return nil
+}
+
 func (h *CoachHandlers) GetSuccessStories(c *gin.Context) {
 	coachIDStr := c.Param("coach_id")
 	coachID, err := uuid.Parse(coachIDStr)
@@ -0,0 +1,512 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/coach-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// dashboardVideoDays is how far back an upload still counts as awaiting review
+const dashboardVideoDays = 28
+
+// Alert thresholds
+const (
+	lowCompliancePct     = 75.0
+	veryLowCompliancePct = 50.0
+	missedSessionsAlert  = 2
+	missedSessionsHigh   = 3
+	rpeDriftAlert        = 1.0
+	rpeDriftHigh         = 1.5
+	inactiveDays         = 7
+	longInactiveDays     = 14
+	meetApproachingDays  = 21
+	meetImminentDays     = 7
+	cutDeadlineDays      = 14
+	staleVideoDays       = 3
+)
+
+var severityRank = map[models.AlertSeverity]int{
+	models.SeverityLow:    1,
+	models.SeverityMedium: 2,
+	models.SeverityHigh:   3,
+}
+
+var alertTypes = map[models.AlertType]bool{
+	models.AlertLowCompliance:    true,
+	models.AlertMissedSessions:   true,
+	models.AlertRPEDrift:         true,
+	models.AlertInactive:         true,
+	models.AlertMeetApproaching:  true,
+	models.AlertOverWeightClass:  true,
+	models.AlertUnreviewedVideos: true,
+}
+
+// dashboardSort reads the metric an athlete is sorted by; athletes without it
+// sort last in either direction
+type dashboardSort struct {
+	value      func(models.AthleteOverview) *float64
+	descending bool
+}
+
+var dashboardSorts = map[string]dashboardSort{
+	"attention":       {func(o models.AthleteOverview) *float64 { return floatPtr(float64(o.AttentionScore)) }, true},
+	"compliance_7d":   {func(o models.AthleteOverview) *float64 { return o.Compliance7DayPct }, false},
+	"compliance_28d":  {func(o models.AthleteOverview) *float64 { return o.Compliance28DayPct }, false},
+	"sessions_missed": {func(o models.AthleteOverview) *float64 { return floatPtr(float64(o.SessionsMissed7Day)) }, true},
+	"rpe_drift": {func(o models.AthleteOverview) *float64 {
+		if o.RPEDrift == nil {
+			return nil
+		}
+		return floatPtr(math.Abs(*o.RPEDrift))
+	}, true},
+	"days_to_meet": {func(o models.AthleteOverview) *float64 {
+		if o.DaysToMeet == nil {
+			return nil
+		}
+		return floatPtr(float64(*o.DaysToMeet))
+	}, false},
+	"kg_over_class":     {func(o models.AthleteOverview) *float64 { return o.KgOverClass }, true},
+	"unreviewed_videos": {func(o models.AthleteOverview) *float64 { return floatPtr(float64(o.UnreviewedVideos)) }, true},
+	"last_active": {func(o models.AthleteOverview) *float64 {
+		if o.LastActiveAt == nil {
+			return nil
+		}
+		return floatPtr(float64(o.LastActiveAt.Unix()))
+	}, false},
+}
+
+// GetDashboard lists the coach's athletes with their compliance, missed
+// sessions, RPE drift, days to meet, bodyweight against class and unreviewed
+// videos, flagging who needs attention. sort orders the athletes (attention by
+// default, or name or any metric in dashboardSorts) and order overrides its
+// direction. alert (comma separated types), min_severity and needs_attention
+// narrow the athletes listed; the totals and alert counts cover everyone.
+func (h *CoachHandlers) GetDashboard(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	userType := middleware.GetUserType(c)
+
+	if userType != "coach" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only coaches can view dashboard"})
+		return
+	}
+
+	sortKey := c.DefaultQuery("sort", "attention")
+	descending := false
+	if sortKey != "name" {
+		sortBy, ok := dashboardSorts[sortKey]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown sort: " + sortKey})
+			return
+		}
+		descending = sortBy.descending
+	}
+	switch c.Query("order") {
+	case "":
+	case "asc":
+		descending = false
+	case "desc":
+		descending = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+
+	filterTypes := make(map[models.AlertType]bool)
+	for _, raw := range strings.Split(c.Query("alert"), ",") {
+		alertType := models.AlertType(strings.TrimSpace(raw))
+		if alertType == "" {
+			continue
+		}
+		if !alertTypes[alertType] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown alert type: " + string(alertType)})
+			return
+		}
+		filterTypes[alertType] = true
+	}
+
+	minSeverity := 0
+	if raw := c.Query("min_severity"); raw != "" {
+		rank, ok := severityRank[models.AlertSeverity(raw)]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_severity must be low, medium or high"})
+			return
+		}
+		minSeverity = rank
+	}
+	if c.Query("needs_attention") == "true" && minSeverity == 0 {
+		minSeverity = severityRank[models.SeverityLow]
+	}
+
+	coachUUID, _ := uuid.Parse(userID)
+
+	// Get recent notifications
+	notifications, err := h.coachRepo.GetNotificationsByCoachID(coachUUID, 10, false)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get notifications for dashboard")
+		notifications = []models.CoachNotification{}
+	}
+
+	// Get pending feedback (non-incorporated AI feedback)
+	pendingFeedback, _, err := h.coachRepo.GetFeedbackByCoachID(coachUUID, 1, 10, nil, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get pending feedback for dashboard")
+		pendingFeedback = []models.CoachFeedback{}
+	}
+
+	athletes, warnings := h.athleteOverviews(c, coachUUID, time.Now())
+
+	alertCounts := make(map[models.AlertType]int)
+	needingAttention := 0
+	listed := []models.AthleteOverview{}
+	for _, athlete := range athletes {
+		for _, alert := range athlete.Alerts {
+			alertCounts[alert.Type]++
+		}
+		if len(athlete.Alerts) > 0 {
+			needingAttention++
+		}
+		if matchesAlertFilter(athlete, filterTypes, minSeverity) {
+			listed = append(listed, athlete)
+		}
+	}
+	sortOverviews(listed, sortKey, descending)
+
+	// Count unread notifications
+	unreadCount := 0
+	for _, notif := range notifications {
+		if !notif.IsRead {
+			unreadCount++
+		}
+	}
+
+	dashboard := models.CoachDashboard{
+		Athletes:            listed,
+		RecentNotifications: notifications,
+		PendingFeedback:     pendingFeedback,
+		TotalAthletes:       len(athletes),
+		UnreadNotifications: unreadCount,
+		NeedingAttention:    needingAttention,
+		AlertCounts:         alertCounts,
+		Warnings:            warnings,
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// athleteOverviews gathers the coach's athletes from their active
+// relationships, names them from user-service, then fills in metrics from this
+// service, program-service and video-service. A service that can't be reached
+// leaves its metrics empty and adds a warning instead of failing the dashboard.
+func (h *CoachHandlers) athleteOverviews(c *gin.Context, coachID uuid.UUID, now time.Time) ([]models.AthleteOverview, []string) {
+	ctx := c.Request.Context()
+	authToken := c.GetHeader("Authorization")
+	var warnings []string
+
+	overviews := make(map[uuid.UUID]*models.AthleteOverview)
+	var athleteIDs []uuid.UUID
+	athlete := func(athleteID uuid.UUID) *models.AthleteOverview {
+		if overview, ok := overviews[athleteID]; ok {
+			return overview
+		}
+		overview := &models.AthleteOverview{AthleteID: athleteID, Alerts: []models.AthleteAlert{}}
+		overviews[athleteID] = overview
+		athleteIDs = append(athleteIDs, athleteID)
+		return overview
+	}
+
+	status := models.StatusActive
+	relationships, err := h.coachRepo.GetRelationshipsByCoachID(coachID, &status)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get relationships for dashboard")
+		warnings = append(warnings, "Coaching relationships are unavailable")
+	}
+	for _, rel := range relationships {
+		overview := athlete(rel.AthleteID)
+		overview.AccessGrantedAt = rel.RequestedAt
+		if rel.AcceptedAt != nil {
+			overview.AccessGrantedAt = *rel.AcceptedAt
+		}
+	}
+
+	if h.userClient != nil {
+		accounts, err := h.userClient.GetCoachAthletes(ctx, authToken)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to get athlete profiles for dashboard")
+			warnings = append(warnings, "Athlete profiles are unavailable")
+		}
+		for _, account := range accounts {
+			// Relationships decide who is coached; user-service only names them
+			overview, ok := overviews[account.User.ID]
+			if !ok {
+				continue
+			}
+			overview.Name = account.User.Name
+			overview.Email = account.User.Email
+			if profile := account.AthleteProfile; profile != nil {
+				if profile.AccessCode != nil {
+					overview.AccessCode = *profile.AccessCode
+				}
+				overview.CompetitionDate = profile.CompetitionDate
+				overview.WeightClass = profile.TargetWeightClass
+				overview.BodyWeightKg = profile.WeightKg
+			}
+		}
+	}
+
+	if progress, err := h.coachRepo.GetLatestProgressByCoachID(coachID); err != nil {
+		log.Error().Err(err).Msg("Failed to get athlete progress for dashboard")
+	} else {
+		for athleteID, p := range progress {
+			if overview, ok := overviews[athleteID]; ok {
+				p := p
+				overview.RecentProgress = &p
+				if p.BodyWeightKg != nil {
+					overview.BodyWeightKg = p.BodyWeightKg
+				}
+			}
+		}
+	}
+
+	if counts, err := h.coachRepo.GetUnansweredFeedbackCounts(coachID); err != nil {
+		log.Error().Err(err).Msg("Failed to get feedback counts for dashboard")
+	} else {
+		for athleteID, count := range counts {
+			if overview, ok := overviews[athleteID]; ok {
+				overview.UnreadFeedback = count
+			}
+		}
+	}
+
+	if h.programClient != nil && len(athleteIDs) > 0 {
+		compliance, err := h.programClient.GetAthleteCompliance(ctx, authToken, athleteIDs)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to get athlete compliance for dashboard")
+			warnings = append(warnings, "Training compliance is unavailable")
+		}
+		for _, metrics := range compliance {
+			overview, ok := overviews[metrics.AthleteID]
+			if !ok {
+				continue
+			}
+			overview.ActivePrograms = metrics.ActivePrograms
+			overview.LastActiveAt = metrics.LastActiveAt
+			overview.Compliance7DayPct = metrics.Compliance7DayPct
+			overview.Compliance28DayPct = metrics.Compliance28DayPct
+			overview.SessionsMissed7Day = metrics.SessionsMissed7Day
+			overview.SessionsMissed28Day = metrics.SessionsMissed28Day
+			overview.RPEDrift = metrics.RPEDrift
+		}
+	}
+
+	if h.videoClient != nil && len(athleteIDs) > 0 {
+		videos, err := h.videoClient.GetAthleteVideos(ctx, authToken, athleteIDs, dashboardVideoDays)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to get athlete videos for dashboard")
+			warnings = append(warnings, "Video reviews are unavailable")
+		}
+
+		videoIDs := make([]uuid.UUID, 0, len(videos))
+		for _, video := range videos {
+			videoIDs = append(videoIDs, video.ID)
+		}
+		reviewed, err := h.coachRepo.GetReviewedVideoIDs(coachID, videoIDs)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get reviewed videos for dashboard")
+			warnings = append(warnings, "Video reviews are unavailable")
+			videos = nil
+		}
+
+		for _, video := range videos {
+			overview, ok := overviews[video.AthleteID]
+			if !ok || reviewed[video.ID] {
+				continue
+			}
+			overview.UnreviewedVideos++
+			if overview.OldestUnreviewedVideoAt == nil || video.CreatedAt.Before(*overview.OldestUnreviewedVideoAt) {
+				uploadedAt := video.CreatedAt
+				overview.OldestUnreviewedVideoAt = &uploadedAt
+			}
+		}
+	}
+
+	athletes := make([]models.AthleteOverview, 0, len(athleteIDs))
+	for _, athleteID := range athleteIDs {
+		overview := overviews[athleteID]
+		if overview.CompetitionDate != nil {
+			if days := calendarDaysUntil(now, *overview.CompetitionDate); days >= 0 {
+				overview.DaysToMeet = &days
+			}
+		}
+		if overview.WeightClass != nil {
+			overview.WeightClassLimitKg = weightClassLimitKg(*overview.WeightClass)
+		}
+		if overview.BodyWeightKg != nil && overview.WeightClassLimitKg != nil {
+			overview.KgOverClass = floatPtr(math.Round((*overview.BodyWeightKg-*overview.WeightClassLimitKg)*10) / 10)
+		}
+
+		overview.Alerts = athleteAlerts(*overview, now)
+		for _, alert := range overview.Alerts {
+			overview.AttentionScore += severityRank[alert.Severity]
+		}
+		athletes = append(athletes, *overview)
+	}
+
+	return athletes, warnings
+}
+
+// athleteAlerts flags what about an athlete needs the coach's attention
+func athleteAlerts(o models.AthleteOverview, now time.Time) []models.AthleteAlert {
+	alerts := []models.AthleteAlert{}
+	flag := func(alertType models.AlertType, severity models.AlertSeverity, format string, args ...interface{}) {
+		alerts = append(alerts, models.AthleteAlert{
+			Type:     alertType,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if o.Compliance7DayPct != nil && *o.Compliance7DayPct < lowCompliancePct {
+		severity := models.SeverityMedium
+		if *o.Compliance7DayPct < veryLowCompliancePct {
+			severity = models.SeverityHigh
+		}
+		flag(models.AlertLowCompliance, severity, "Completed %.0f%% of sessions due in the last 7 days", *o.Compliance7DayPct)
+	}
+
+	if o.SessionsMissed7Day >= missedSessionsAlert {
+		severity := models.SeverityMedium
+		if o.SessionsMissed7Day >= missedSessionsHigh {
+			severity = models.SeverityHigh
+		}
+		flag(models.AlertMissedSessions, severity, "Missed %d sessions in the last 7 days", o.SessionsMissed7Day)
+	}
+
+	if o.RPEDrift != nil && math.Abs(*o.RPEDrift) >= rpeDriftAlert {
+		severity := models.SeverityMedium
+		if math.Abs(*o.RPEDrift) >= rpeDriftHigh {
+			severity = models.SeverityHigh
+		}
+		direction := "above"
+		if *o.RPEDrift < 0 {
+			direction = "below"
+		}
+		flag(models.AlertRPEDrift, severity, "RPE running %.1f %s target over the last 28 days", math.Abs(*o.RPEDrift), direction)
+	}
+
+	if o.ActivePrograms > 0 {
+		if o.LastActiveAt == nil {
+			flag(models.AlertInactive, models.SeverityMedium, "Hasn't logged a session yet")
+		} else if days := calendarDaysUntil(*o.LastActiveAt, now); days >= inactiveDays {
+			severity := models.SeverityMedium
+			if days >= longInactiveDays {
+				severity = models.SeverityHigh
+			}
+			flag(models.AlertInactive, severity, "No session logged in %d days", days)
+		}
+	}
+
+	if o.DaysToMeet != nil && *o.DaysToMeet <= meetApproachingDays {
+		severity := models.SeverityLow
+		if *o.DaysToMeet <= meetImminentDays {
+			severity = models.SeverityMedium
+		}
+		flag(models.AlertMeetApproaching, severity, "Meet in %d days", *o.DaysToMeet)
+	}
+
+	if o.KgOverClass != nil && *o.KgOverClass > 0 {
+		severity := models.SeverityMedium
+		if o.DaysToMeet != nil && *o.DaysToMeet <= cutDeadlineDays {
+			severity = models.SeverityHigh
+		}
+		flag(models.AlertOverWeightClass, severity, "%.1f kg over the %s class", *o.KgOverClass, *o.WeightClass)
+	}
+
+	if o.UnreviewedVideos > 0 {
+		severity := models.SeverityLow
+		if o.OldestUnreviewedVideoAt != nil && now.Sub(*o.OldestUnreviewedVideoAt) >= staleVideoDays*24*time.Hour {
+			severity = models.SeverityMedium
+		}
+		flag(models.AlertUnreviewedVideos, severity, "%d videos awaiting review", o.UnreviewedVideos)
+	}
+
+	return alerts
+}
+
+// matchesAlertFilter reports whether the athlete has an alert of one of the
+// types (any type when none are given) at or above minSeverity. With neither
+// set every athlete matches.
+func matchesAlertFilter(o models.AthleteOverview, types map[models.AlertType]bool, minSeverity int) bool {
+	if len(types) == 0 && minSeverity == 0 {
+		return true
+	}
+	for _, alert := range o.Alerts {
+		if (len(types) == 0 || types[alert.Type]) && severityRank[alert.Severity] >= minSeverity {
+			return true
+		}
+	}
+	return false
+}
+
+// sortOverviews orders athletes by the sort key, breaking ties by name
+func sortOverviews(athletes []models.AthleteOverview, key string, descending bool) {
+	sort.SliceStable(athletes, func(i, j int) bool {
+		a, b := athletes[i], athletes[j]
+		if sortBy, ok := dashboardSorts[key]; ok {
+			va, vb := sortBy.value(a), sortBy.value(b)
+			switch {
+			case va == nil && vb != nil:
+				return false
+			case va != nil && vb == nil:
+				return true
+			case va != nil && vb != nil && *va != *vb:
+				if descending {
+					return *va > *vb
+				}
+				return *va < *vb
+			}
+		}
+
+		nameA, nameB := strings.ToLower(a.Name), strings.ToLower(b.Name)
+		if key == "name" && descending {
+			return nameA > nameB
+		}
+		return nameA < nameB
+	})
+}
+
+// weightClassLimitKg reads the upper limit of a class such as "83", "-83kg"
+// or "83 kg"; open classes such as "120+" have none
+func weightClassLimitKg(class string) *float64 {
+	value := strings.ToLower(strings.TrimSpace(class))
+	if value == "" || strings.Contains(value, "+") || strings.Contains(value, "lb") {
+		return nil
+	}
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(value, "-"), "kg"))
+
+	limit, err := strconv.ParseFloat(value, 64)
+	if err != nil || limit <= 0 {
+		return nil
+	}
+	return &limit
+}
+
+// calendarDaysUntil counts calendar days from one date to another
+func calendarDaysUntil(from, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(math.Round(toDay.Sub(fromDay).Hours() / 24))
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
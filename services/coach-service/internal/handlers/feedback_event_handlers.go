@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/coach-service/internal/models"
+	"github.com/powerlifting-coach-app/coach-service/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+type FeedbackIncorporatedEvent struct {
+	SchemaVersion     string `json:"schema_version"`
+	EventType         string `json:"event_type"`
+	ClientGeneratedID string `json:"client_generated_id"`
+	UserID            string `json:"user_id"`
+	Timestamp         string `json:"timestamp"`
+	SourceService     string `json:"source_service"`
+	Data              struct {
+		FeedbackIDs    []string   `json:"feedback_ids"`
+		Source         string     `json:"source"`
+		ConversationID *uuid.UUID `json:"conversation_id"`
+		ProgramID      *uuid.UUID `json:"program_id"`
+		ProgramName    *string    `json:"program_name"`
+		ProgramVersion *int       `json:"program_version"`
+	} `json:"data"`
+}
+
+type FeedbackEventHandlers struct {
+	coachRepo *repository.CoachRepository
+}
+
+func NewFeedbackEventHandlers(coachRepo *repository.CoachRepository) *FeedbackEventHandlers {
+	return &FeedbackEventHandlers{
+		coachRepo: coachRepo,
+	}
+}
+
+// HandleFeedbackIncorporated marks the feedback program-service's AI acted on and
+// tells each coach where it was applied
+func (h *FeedbackEventHandlers) HandleFeedbackIncorporated(ctx context.Context, payload []byte) error {
+	var event FeedbackIncorporatedEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	athleteID, err := uuid.Parse(event.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid user_id: %w", err)
+	}
+
+	incorporation := models.Incorporation{
+		Source:         event.Data.Source,
+		ProgramID:      event.Data.ProgramID,
+		ProgramName:    event.Data.ProgramName,
+		ProgramVersion: event.Data.ProgramVersion,
+		ConversationID: event.Data.ConversationID,
+	}
+
+	for _, rawID := range event.Data.FeedbackIDs {
+		feedbackID, err := uuid.Parse(rawID)
+		if err != nil {
+			log.Warn().Str("feedback_id", rawID).Msg("Skipping invalid feedback id")
+			continue
+		}
+
+		feedback, err := h.coachRepo.GetFeedbackByID(feedbackID)
+		if err != nil {
+			log.Warn().Err(err).Str("feedback_id", rawID).Msg("Skipping unknown feedback")
+			continue
+		}
+
+		// The AI only sees the athlete's own feedback; anything else is a bad citation
+		if feedback.AthleteID != athleteID {
+			log.Warn().
+				Str("feedback_id", rawID).
+				Str("athlete_id", athleteID.String()).
+				Msg("Ignoring incorporated feedback that belongs to another athlete")
+			continue
+		}
+
+		marked, err := h.coachRepo.MarkFeedbackIncorporated(feedbackID, incorporation)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			continue
+		}
+
+		if err := h.notifyCoach(feedback, incorporation); err != nil {
+			log.Error().Err(err).Str("feedback_id", rawID).Msg("Failed to notify coach of incorporated feedback")
+		}
+	}
+
+	return nil
+}
+
+func (h *FeedbackEventHandlers) notifyCoach(feedback *models.CoachFeedback, incorporation models.Incorporation) error {
+	message := fmt.Sprintf("Your feedback %q was applied in an AI coaching chat", feedback.Title)
+	if incorporation.ProgramName != nil {
+		applied := *incorporation.ProgramName
+		if incorporation.ProgramVersion != nil {
+			applied = fmt.Sprintf("%s v%d", applied, *incorporation.ProgramVersion)
+		}
+		message = fmt.Sprintf("Your feedback %q was applied in %s", feedback.Title, applied)
+	}
+
+	notification := &models.CoachNotification{
+		CoachID:          feedback.CoachID,
+		AthleteID:        feedback.AthleteID,
+		NotificationType: "feedback_incorporated",
+		Title:            "Feedback applied",
+		Message:          message,
+	}
+	if incorporation.ProgramID != nil {
+		referenceType := "program"
+		notification.ReferenceType = &referenceType
+		notification.ReferenceID = incorporation.ProgramID
+	} else {
+		referenceType := "feedback"
+		notification.ReferenceType = &referenceType
+		notification.ReferenceID = &feedback.ID
+	}
+
+	return h.coachRepo.CreateNotification(notification)
+}
@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/coach-service/internal/clients"
+	"github.com/powerlifting-coach-app/coach-service/internal/models"
+	"github.com/powerlifting-coach-app/coach-service/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// CreateTeam creates a team, optionally with its first members. Every member
+// must be one of the coach's active athletes.
+func (h *CoachHandlers) CreateTeam(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	userType := middleware.GetUserType(c)
+
+	if userType != "coach" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only coaches can create teams"})
+		return
+	}
+
+	var req models.CreateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coachUUID, _ := uuid.Parse(userID)
+	if !h.checkTeamMembers(c, coachUUID, req.AthleteIDs) {
+		return
+	}
+
+	team := &models.CoachTeam{
+		CoachID:     coachUUID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := h.coachRepo.CreateTeam(team); err != nil {
+		if errors.Is(err, repository.ErrDuplicateTeamName) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to create team")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create team"})
+		return
+	}
+
+	if len(req.AthleteIDs) > 0 {
+		if err := h.coachRepo.AddTeamMembers(team.ID, req.AthleteIDs); err != nil {
+			log.Error().Err(err).Msg("Failed to add team members")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Team created but its members could not be added"})
+			return
+		}
+	}
+
+	members, err := h.coachRepo.GetTeamMembers(team.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get team members")
+		members = []models.TeamMember{}
+	}
+	team.Members = members
+	team.MemberCount = len(members)
+
+	c.JSON(http.StatusCreated, team)
+}
+
+func (h *CoachHandlers) GetTeams(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	userType := middleware.GetUserType(c)
+
+	if userType != "coach" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only coaches can view teams"})
+		return
+	}
+
+	coachUUID, _ := uuid.Parse(userID)
+	teams, err := h.coachRepo.GetTeamsByCoachID(coachUUID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get teams")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get teams"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"teams": teams})
+}
+
+func (h *CoachHandlers) GetTeam(c *gin.Context) {
+	team, ok := h.ownTeam(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.coachRepo.GetTeamMembers(team.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get team members")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get team"})
+		return
+	}
+	team.Members = members
+
+	c.JSON(http.StatusOK, team)
+}
+
+func (h *CoachHandlers) UpdateTeam(c *gin.Context) {
+	team, ok := h.ownTeam(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != nil {
+		team.Name = *req.Name
+	}
+	if req.Description != nil {
+		team.Description = req.Description
+	}
+
+	if err := h.coachRepo.UpdateTeam(team); err != nil {
+		if errors.Is(err, repository.ErrDuplicateTeamName) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to update team")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+// DeleteTeam removes the team and its announcements; members keep their
+// coaching relationships and any programs already assigned
+func (h *CoachHandlers) DeleteTeam(c *gin.Context) {
+	team, ok := h.ownTeam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.coachRepo.DeleteTeam(team.ID); err != nil {
+		log.Error().Err(err).Msg("Failed to delete team")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Team deleted"})
+}
+
+func (h *CoachHandlers) AddTeamMembers(c *gin.Context) {
+	team, ok := h.ownTeam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddTeamMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkTeamMembers(c, team.CoachID, req.AthleteIDs) {
+		return
+	}
+
+	if err := h.coachRepo.AddTeamMembers(team.ID, req.AthleteIDs); err != nil {
+		log.Error().Err(err).Msg("Failed to add team members")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add team members"})
+		return
+	}
+
+	members, err := h.coachRepo.GetTeamMembers(team.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get team members")
+		c.JSON(http.StatusOK, gin.H{"message": "Members added"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+func (h *CoachHandlers) RemoveTeamMember(c *gin.Context) {
+	team, ok := h.ownTeam(c)
+	if !ok {
+		return
+	}
+
+	athleteID, err := uuid.Parse(c.Param("athlete_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid athlete ID"})
+		return
+	}
+
+	if err := h.coachRepo.RemoveTeamMember(team.ID, athleteID); err != nil {
+		log.Error().Err(err).Msg("Failed to remove team member")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// AssignTeamProgram gives every current member their own copy of a template or
+// program through program-service, which works out loads from each athlete's
+// best lifts. Members no longer coached are skipped.
+func (h *CoachHandlers) AssignTeamProgram(c *gin.Context) {
+	team, ok := h.ownTeam(c)
+	if !ok {
+		return
+	}
+
+	var req models.AssignTeamProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.TemplateID == nil) == (req.SourceProgramID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either template_id or source_program_id"})
+		return
+	}
+
+	members, err := h.coachRepo.GetTeamMembers(team.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get team members")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get team members"})
+		return
+	}
+	if len(members) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Team has no members"})
+		return
+	}
+
+	athleteIDs := make([]uuid.UUID, len(members))
+	for i, member := range members {
+		athleteIDs[i] = member.AthleteID
+	}
+
+	assignments, err := h.programClient.AssignProgram(c.Request.Context(), c.GetHeader("Authorization"), clients.AssignProgramRequest{
+		TemplateID:      req.TemplateID,
+		SourceProgramID: req.SourceProgramID,
+		AthleteIDs:      athleteIDs,
+		StartDate:       req.StartDate,
+		Name:            req.Name,
+	})
+	if err != nil {
+		var serviceErr *clients.ServiceError
+		if errors.As(err, &serviceErr) && serviceErr.StatusCode < http.StatusInternalServerError {
+			c.JSON(serviceErr.StatusCode, gin.H{"error": serviceErr.Message})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to assign team program")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to assign program"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"team_id": team.ID, "assignments": assignments})
+}
+
+// GetTeamAnalytics rolls up the members' compliance and personal records over
+// the last 28 days
+func (h *CoachHandlers) GetTeamAnalytics(c *gin.Context) {
+	team, ok := h.ownTeam(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.coachRepo.GetTeamMembers(team.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get team members")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get team analytics"})
+		return
+	}
+
+	analytics := models.TeamAnalytics{
+		TeamID:      team.ID,
+		MemberCount: len(members),
+		Members:     []models.TeamMemberAnalytics{},
+	}
+	if len(members) == 0 {
+		c.JSON(http.StatusOK, analytics)
+		return
+	}
+
+	athleteIDs := make([]uuid.UUID, len(members))
+	for i, member := range members {
+		athleteIDs[i] = member.AthleteID
+	}
+
+	compliance, err := h.programClient.GetAthleteCompliance(c.Request.Context(), c.GetHeader("Authorization"), athleteIDs)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get athlete compliance for team analytics")
+		analytics.Warnings = append(analytics.Warnings, "Training compliance is unavailable")
+	}
+
+	var total7Day, total28Day float64
+	var count7Day, count28Day int
+	for _, metrics := range compliance {
+		analytics.Members = append(analytics.Members, models.TeamMemberAnalytics{
+			AthleteID:            metrics.AthleteID,
+			LastActiveAt:         metrics.LastActiveAt,
+			Compliance7DayPct:    metrics.Compliance7DayPct,
+			Compliance28DayPct:   metrics.Compliance28DayPct,
+			SessionsMissed28Day:  metrics.SessionsMissed28Day,
+			PersonalRecords28Day: metrics.PersonalRecords28Day,
+		})
+		analytics.TotalSessionsMissed28Day += metrics.SessionsMissed28Day
+		analytics.TotalPersonalRecords28Day += metrics.PersonalRecords28Day
+		if metrics.Compliance7DayPct != nil {
+			total7Day += *metrics.Compliance7DayPct
+			count7Day++
+		}
+		if metrics.Compliance28DayPct != nil {
+			total28Day += *metrics.Compliance28DayPct
+			count28Day++
+		}
+	}
+	if count7Day > 0 {
+		analytics.AverageCompliance7DayPct = floatPtr(math.Round(total7Day/float64(count7Day)*10) / 10)
+	}
+	if count28Day > 0 {
+		analytics.AverageCompliance28DayPct = floatPtr(math.Round(total28Day/float64(count28Day)*10) / 10)
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+func (h *CoachHandlers) CreateTeamAnnouncement(c *gin.Context) {
+	team, ok := h.ownTeam(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	announcement := &models.TeamAnnouncement{
+		TeamID:   team.ID,
+		TeamName: team.Name,
+		CoachID:  team.CoachID,
+		Title:    req.Title,
+		Content:  req.Content,
+	}
+
+	if err := h.coachRepo.CreateTeamAnnouncement(announcement); err != nil {
+		log.Error().Err(err).Msg("Failed to create announcement")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create announcement"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, announcement)
+}
+
+func (h *CoachHandlers) GetTeamAnnouncements(c *gin.Context) {
+	team, ok := h.ownTeam(c)
+	if !ok {
+		return
+	}
+
+	limit := announcementLimit(c)
+	announcements, err := h.coachRepo.GetTeamAnnouncements(team.ID, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get announcements")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get announcements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"announcements": announcements})
+}
+
+// GetMyAnnouncements lists announcements from the teams the athlete is on
+func (h *CoachHandlers) GetMyAnnouncements(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	athleteUUID, _ := uuid.Parse(userID)
+
+	limit := announcementLimit(c)
+	announcements, err := h.coachRepo.GetAnnouncementsForAthlete(athleteUUID, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get announcements")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get announcements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"announcements": announcements})
+}
+
+// ownTeam loads the team named in the path and checks the caller is the coach
+// who owns it, writing the error response when not
+func (h *CoachHandlers) ownTeam(c *gin.Context) (*models.CoachTeam, bool) {
+	if middleware.GetUserType(c) != "coach" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only coaches can manage teams"})
+		return nil, false
+	}
+
+	teamID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return nil, false
+	}
+
+	coachUUID, _ := uuid.Parse(middleware.GetUserID(c))
+	team, err := h.coachRepo.GetTeamByID(teamID)
+	if err != nil || team.CoachID != coachUUID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return nil, false
+	}
+
+	return team, true
+}
+
+// checkTeamMembers verifies every athlete has an active relationship with the
+// coach, writing the error response when not
+func (h *CoachHandlers) checkTeamMembers(c *gin.Context, coachID uuid.UUID, athleteIDs []uuid.UUID) bool {
+	if len(athleteIDs) == 0 {
+		return true
+	}
+
+	status := models.StatusActive
+	relationships, err := h.coachRepo.GetRelationshipsByCoachID(coachID, &status)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get relationships for team")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check athletes"})
+		return false
+	}
+
+	coached := make(map[uuid.UUID]bool, len(relationships))
+	for _, rel := range relationships {
+		coached[rel.AthleteID] = true
+	}
+
+	notCoached := []uuid.UUID{}
+	for _, athleteID := range athleteIDs {
+		if !coached[athleteID] {
+			notCoached = append(notCoached, athleteID)
+		}
+	}
+	if len(notCoached) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":       "Teams can only include athletes you actively coach",
+			"athlete_ids": notCoached,
+		})
+		return false
+	}
+
+	return true
+}
+
+func announcementLimit(c *gin.Context) int {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return limit
+}
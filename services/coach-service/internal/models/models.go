@@ -36,10 +36,21 @@ type CoachFeedback struct {
 	IsPrivate        bool             `json:"is_private" db:"is_private"`
 	IncorporatedByAI bool             `json:"incorporated_by_ai" db:"incorporated_by_ai"`
 	IncorporatedAt   *time.Time       `json:"incorporated_at" db:"incorporated_at"`
+	Incorporation    *Incorporation   `json:"incorporation,omitempty"`
 	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time        `json:"updated_at" db:"updated_at"`
 }
 
+// Incorporation records where the AI acted on a piece of feedback, e.g.
+// "applied in program v3"
+type Incorporation struct {
+	Source         string     `json:"source" db:"incorporated_source"`
+	ProgramID      *uuid.UUID `json:"program_id,omitempty" db:"incorporated_program_id"`
+	ProgramName    *string    `json:"program_name,omitempty" db:"incorporated_program_name"`
+	ProgramVersion *int       `json:"program_version,omitempty" db:"incorporated_program_version"`
+	ConversationID *uuid.UUID `json:"conversation_id,omitempty" db:"incorporated_conversation_id"`
+}
+
 type CoachAthleteNote struct {
 	ID         uuid.UUID  `json:"id" db:"id"`
 	CoachID    uuid.UUID  `json:"coach_id" db:"coach_id"`
@@ -152,16 +163,65 @@ type FeedbackListResponse struct {
 	PageSize   int             `json:"page_size"`
 }
 
+type AlertType string
+type AlertSeverity string
+
+const (
+	AlertLowCompliance    AlertType = "low_compliance"
+	AlertMissedSessions   AlertType = "missed_sessions"
+	AlertRPEDrift         AlertType = "rpe_drift"
+	AlertInactive         AlertType = "inactive"
+	AlertMeetApproaching  AlertType = "meet_approaching"
+	AlertOverWeightClass  AlertType = "over_weight_class"
+	AlertUnreviewedVideos AlertType = "unreviewed_videos"
+)
+
+const (
+	SeverityLow    AlertSeverity = "low"
+	SeverityMedium AlertSeverity = "medium"
+	SeverityHigh   AlertSeverity = "high"
+)
+
+// AthleteAlert is one reason a coach should look at an athlete today
+type AthleteAlert struct {
+	Type     AlertType     `json:"type"`
+	Severity AlertSeverity `json:"severity"`
+	Message  string        `json:"message"`
+}
+
+// AthleteOverview is one row of the coach dashboard. Compliance, missed
+// sessions and RPE drift come from program-service and unreviewed videos from
+// video-service; they stay empty when that service couldn't be reached or
+// doesn't recognise the coach's access to the athlete.
 type AthleteOverview struct {
-	AthleteID        uuid.UUID  `json:"athlete_id"`
-	Name             string     `json:"name"`
-	Email            string     `json:"email"`
-	AccessCode       string     `json:"access_code"`
-	AccessGrantedAt  time.Time  `json:"access_granted_at"`
-	LastActiveAt     *time.Time `json:"last_active_at"`
-	UnreadFeedback   int        `json:"unread_feedback_count"`
-	ActivePrograms   int        `json:"active_programs_count"`
-	RecentProgress   *AthleteProgressTracking `json:"recent_progress"`
+	AthleteID           uuid.UUID                `json:"athlete_id"`
+	Name                string                   `json:"name"`
+	Email               string                   `json:"email"`
+	AccessCode          string                   `json:"access_code"`
+	AccessGrantedAt     time.Time                `json:"access_granted_at"`
+	LastActiveAt        *time.Time               `json:"last_active_at"`
+	UnreadFeedback      int                      `json:"unread_feedback_count"`
+	ActivePrograms      int                      `json:"active_programs_count"`
+	RecentProgress      *AthleteProgressTracking `json:"recent_progress"`
+	Compliance7DayPct   *float64                 `json:"compliance_7d_pct"`
+	Compliance28DayPct  *float64                 `json:"compliance_28d_pct"`
+	SessionsMissed7Day  int                      `json:"sessions_missed_7d"`
+	SessionsMissed28Day int                      `json:"sessions_missed_28d"`
+	// RPEDrift is actual minus target RPE over 28 days; positive is harder
+	RPEDrift        *float64   `json:"rpe_drift"`
+	CompetitionDate *time.Time `json:"competition_date"`
+	DaysToMeet      *int       `json:"days_to_meet"`
+	// BodyWeightKg is the latest tracked weight, else the athlete's profile
+	BodyWeightKg       *float64 `json:"body_weight_kg"`
+	WeightClass        *string  `json:"weight_class"`
+	WeightClassLimitKg *float64 `json:"weight_class_limit_kg"`
+	// KgOverClass is body weight minus the class limit; positive means a cut
+	KgOverClass             *float64       `json:"kg_over_class"`
+	UnreviewedVideos        int            `json:"unreviewed_videos_count"`
+	OldestUnreviewedVideoAt *time.Time     `json:"oldest_unreviewed_video_at"`
+	Alerts                  []AthleteAlert `json:"alerts"`
+	// AttentionScore weighs the alerts by severity so the roster can be triaged
+	AttentionScore int `json:"attention_score"`
 }
 
 type CoachDashboard struct {
@@ -170,6 +230,74 @@ type CoachDashboard struct {
 	PendingFeedback     []CoachFeedback     `json:"pending_feedback"`
 	TotalAthletes       int                 `json:"total_athletes"`
 	UnreadNotifications int                 `json:"unread_notifications"`
+	// NeedingAttention counts athletes with at least one alert, before filtering
+	NeedingAttention int               `json:"athletes_needing_attention"`
+	AlertCounts      map[AlertType]int `json:"alert_counts"`
+	// Warnings name the metrics left out because a service couldn't be reached
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// CoachingAssignment is an active coach-athlete pairing in the shape other
+// services check coach access against
+type CoachingAssignment struct {
+	ID        uuid.UUID          `json:"id"`
+	CoachID   uuid.UUID          `json:"coach_id"`
+	AthleteID uuid.UUID          `json:"athlete_id"`
+	Status    RelationshipStatus `json:"status"`
+	Scopes    []AccessScope      `json:"scopes"`
+	StartDate time.Time          `json:"start_date"`
+}
+
+// CoachTeam groups a coach's athletes so programs and announcements can go
+// out to all of them at once
+type CoachTeam struct {
+	ID          uuid.UUID    `json:"id"`
+	CoachID     uuid.UUID    `json:"coach_id"`
+	Name        string       `json:"name"`
+	Description *string      `json:"description"`
+	MemberCount int          `json:"member_count"`
+	Members     []TeamMember `json:"members,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type TeamMember struct {
+	AthleteID uuid.UUID `json:"athlete_id"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+type TeamAnnouncement struct {
+	ID        uuid.UUID `json:"id"`
+	TeamID    uuid.UUID `json:"team_id"`
+	TeamName  string    `json:"team_name,omitempty"`
+	CoachID   uuid.UUID `json:"coach_id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TeamMemberAnalytics is one athlete's share of the team analytics
+type TeamMemberAnalytics struct {
+	AthleteID            uuid.UUID  `json:"athlete_id"`
+	LastActiveAt         *time.Time `json:"last_active_at"`
+	Compliance7DayPct    *float64   `json:"compliance_7d_pct"`
+	Compliance28DayPct   *float64   `json:"compliance_28d_pct"`
+	SessionsMissed28Day  int        `json:"sessions_missed_28d"`
+	PersonalRecords28Day int        `json:"personal_records_28d"`
+}
+
+// TeamAnalytics rolls up the members' last 28 days of training. Averages only
+// cover members with sessions due in the window.
+type TeamAnalytics struct {
+	TeamID                    uuid.UUID             `json:"team_id"`
+	MemberCount               int                   `json:"member_count"`
+	AverageCompliance7DayPct  *float64              `json:"average_compliance_7d_pct"`
+	AverageCompliance28DayPct *float64              `json:"average_compliance_28d_pct"`
+	TotalSessionsMissed28Day  int                   `json:"total_sessions_missed_28d"`
+	TotalPersonalRecords28Day int                   `json:"total_personal_records_28d"`
+	Members                   []TeamMemberAnalytics `json:"members"`
+	// Warnings name the metrics left out because a service couldn't be reached
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type RelationshipStatus string
@@ -180,6 +308,27 @@ const (
 	StatusTerminated RelationshipStatus = "terminated"
 )
 
+// AccessScope is something an active coach may do with an athlete's data
+type AccessScope string
+
+const (
+	ScopeViewHistory   AccessScope = "view_history"
+	ScopeEditProgram   AccessScope = "edit_program"
+	ScopeViewVideos    AccessScope = "view_videos"
+	ScopeDirectMessage AccessScope = "direct_message"
+)
+
+// AllScopes is what a new relationship grants unless the athlete narrows it
+var AllScopes = []AccessScope{ScopeViewHistory, ScopeEditProgram, ScopeViewVideos, ScopeDirectMessage}
+
+// RelationshipSource is how a relationship was started
+type RelationshipSource string
+
+const (
+	SourceRequest    RelationshipSource = "request"
+	SourceAccessCode RelationshipSource = "access_code"
+)
+
 type CoachAthleteRelationship struct {
 	ID                 uuid.UUID          `json:"id" db:"id"`
 	CoachID            uuid.UUID          `json:"coach_id" db:"coach_id"`
@@ -192,10 +341,47 @@ type CoachAthleteRelationship struct {
 	TerminatedBy       *uuid.UUID         `json:"terminated_by" db:"terminated_by"`
 	TerminationReason  *string            `json:"termination_reason" db:"termination_reason"`
 	CooldownUntil      *time.Time         `json:"cooldown_until" db:"cooldown_until"`
+	Scopes             []AccessScope      `json:"scopes" db:"scopes"`
+	Source             RelationshipSource `json:"source" db:"source"`
 	CreatedAt          time.Time          `json:"created_at" db:"created_at"`
 	UpdatedAt          time.Time          `json:"updated_at" db:"updated_at"`
 }
 
+type PermissionAction string
+
+const (
+	ActionAccessGranted   PermissionAction = "access_granted"
+	ActionAccessRevoked   PermissionAction = "access_revoked"
+	ActionDataViewed      PermissionAction = "data_viewed"
+	ActionProgramModified PermissionAction = "program_modified"
+	ActionScopesChanged   PermissionAction = "scopes_changed"
+)
+
+// PermissionLogEntry records a coach gaining or losing access to an athlete,
+// or viewing or changing their data
+type PermissionLogEntry struct {
+	ID             uuid.UUID        `json:"id"`
+	RelationshipID uuid.UUID        `json:"relationship_id"`
+	CoachID        uuid.UUID        `json:"coach_id"`
+	AthleteID      uuid.UUID        `json:"athlete_id"`
+	Action         PermissionAction `json:"action"`
+	ResourceType   *string          `json:"resource_type"`
+	ResourceID     *uuid.UUID       `json:"resource_id"`
+	PerformedBy    uuid.UUID        `json:"performed_by"`
+	IPAddress      *string          `json:"ip_address"`
+	UserAgent      *string          `json:"user_agent"`
+	LoggedAt       time.Time        `json:"logged_at"`
+}
+
+type AccessLogResponse struct {
+	Entries    []PermissionLogEntry `json:"entries"`
+	TotalCount int                  `json:"total_count"`
+	Page       int                  `json:"page"`
+	PageSize   int                  `json:"page_size"`
+	// RetentionDays is how far back entries are kept; 0 means indefinitely
+	RetentionDays int `json:"retention_days"`
+}
+
 type CoachCertification struct {
 	ID                      uuid.UUID  `json:"id" db:"id"`
 	CoachID                 uuid.UUID  `json:"coach_id" db:"coach_id"`
@@ -220,6 +406,13 @@ type CoachSuccessStory struct {
 	Federation      *string    `json:"federation" db:"federation"`
 	Placement       *int       `json:"placement" db:"placement"`
 	IsPublic        bool       `json:"is_public" db:"is_public"`
+	BodyWeightKg    *float64   `json:"body_weight_kg" db:"body_weight_kg"`
+	Sex             *string    `json:"sex" db:"sex"`
+	Equipment       *string    `json:"equipment" db:"equipment"`
+	DOTS            *float64   `json:"dots" db:"dots"`
+	Wilks           *float64   `json:"wilks" db:"wilks"`
+	Wilks2020       *float64   `json:"wilks_2020" db:"wilks_2020"`
+	IPFGLPoints     *float64   `json:"ipf_gl_points" db:"ipf_gl_points"`
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 }
 
@@ -238,6 +431,32 @@ type CoachProfile struct {
 type SendRelationshipRequestRequest struct {
 	CoachID        uuid.UUID `json:"coach_id" binding:"required"`
 	RequestMessage *string   `json:"request_message"`
+	// Scopes the coach gets once accepted; all of them when left out
+	Scopes []AccessScope `json:"scopes"`
+}
+
+type CheckAccessCodeRequest struct {
+	AthleteID  uuid.UUID `json:"athlete_id" binding:"required"`
+	AccessCode string    `json:"access_code" binding:"required"`
+}
+
+// Reasons an access code can't start a relationship right now
+const (
+	AccessCodeAlreadyActive = "already_active"
+	AccessCodeAlreadyUsed   = "code_already_used"
+	AccessCodeCooldown      = "cooldown"
+)
+
+// AccessCodeEligibility says whether redeeming an access code would start or
+// accept the coach's relationship with the athlete, and why not otherwise
+type AccessCodeEligibility struct {
+	Eligible      bool       `json:"eligible"`
+	Reason        string     `json:"reason,omitempty"`
+	CooldownUntil *time.Time `json:"cooldown_until,omitempty"`
+}
+
+type UpdateScopesRequest struct {
+	Scopes []AccessScope `json:"scopes" binding:"required"`
 }
 
 type AcceptRelationshipRequest struct {
@@ -266,4 +485,36 @@ type CreateSuccessStoryRequest struct {
 	Federation      *string    `json:"federation"`
 	Placement       *int       `json:"placement"`
 	IsPublic        bool       `json:"is_public"`
-}
\ No newline at end of file
+	BodyWeightKg    *float64   `json:"body_weight_kg"`
+	Sex             *string    `json:"sex"`
+	Equipment       *string    `json:"equipment"` // classic (default) or equipped
+}
+
+type CreateTeamRequest struct {
+	Name        string      `json:"name" binding:"required,max=255"`
+	Description *string     `json:"description"`
+	AthleteIDs  []uuid.UUID `json:"athlete_ids"`
+}
+
+type UpdateTeamRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description"`
+}
+
+type AddTeamMembersRequest struct {
+	AthleteIDs []uuid.UUID `json:"athlete_ids" binding:"required,min=1"`
+}
+
+type CreateAnnouncementRequest struct {
+	Title   string `json:"title" binding:"required,max=255"`
+	Content string `json:"content" binding:"required"`
+}
+
+// AssignTeamProgramRequest gives every team member their own copy of a
+// template or program, with loads worked out from their best lifts
+type AssignTeamProgramRequest struct {
+	TemplateID      *uuid.UUID `json:"template_id"`
+	SourceProgramID *uuid.UUID `json:"source_program_id"`
+	StartDate       time.Time  `json:"start_date" binding:"required"`
+	Name            *string    `json:"name"`
+}
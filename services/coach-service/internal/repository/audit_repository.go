@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/coach-service/internal/models"
+)
+
+// auditViewWindow stops the same view being logged again while a coach keeps
+// a page open or it polls
+const auditViewWindow = "5 minutes"
+
+// RecordCoachAccess logs a coach acting on an athlete's data against their
+// relationship. Nothing is logged without a relationship, or when the same
+// view was already logged within auditViewWindow. LoggedAt defaults to now. It
+// reports whether a row was written.
+func (r *CoachRepository) RecordCoachAccess(entry *models.PermissionLogEntry) (bool, error) {
+	query := `
+		INSERT INTO relationship_permission_log (relationship_id, action, resource_type, resource_id,
+		                                         performed_by, ip_address, user_agent, logged_at)
+		SELECT r.id, $3::varchar, $4::varchar, $5::uuid, $6, $7, $8, COALESCE($9::timestamp, NOW())
+		FROM coach_athlete_relationships r
+		WHERE r.coach_id = $1 AND r.athlete_id = $2
+		  AND NOT ($3::varchar = '` + string(models.ActionDataViewed) + `' AND EXISTS (
+		      SELECT 1 FROM relationship_permission_log l
+		      WHERE l.relationship_id = r.id
+		        AND l.action = $3::varchar
+		        AND l.resource_type IS NOT DISTINCT FROM $4::varchar
+		        AND l.resource_id IS NOT DISTINCT FROM $5::uuid
+		        AND l.performed_by = $6
+		        AND l.logged_at > NOW() - INTERVAL '` + auditViewWindow + `'))
+		RETURNING id, relationship_id, logged_at`
+
+	var loggedAt *time.Time
+	if !entry.LoggedAt.IsZero() {
+		loggedAt = &entry.LoggedAt
+	}
+
+	err := r.db.QueryRow(query,
+		entry.CoachID, entry.AthleteID, entry.Action, entry.ResourceType, entry.ResourceID,
+		entry.PerformedBy, entry.IPAddress, entry.UserAgent, loggedAt,
+	).Scan(&entry.ID, &entry.RelationshipID, &entry.LoggedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to record coach access: %w", err)
+	}
+
+	return true, nil
+}
+
+// RecordRelationshipAction logs access being granted or revoked on a relationship
+func (r *CoachRepository) RecordRelationshipAction(relationshipID uuid.UUID, action models.PermissionAction, performedBy uuid.UUID, ipAddress, userAgent *string) error {
+	query := `
+		INSERT INTO relationship_permission_log (relationship_id, action, resource_type,
+		                                         performed_by, ip_address, user_agent)
+		VALUES ($1, $2, 'relationship', $3, $4, $5)`
+
+	if _, err := r.db.Exec(query, relationshipID, action, performedBy, ipAddress, userAgent); err != nil {
+		return fmt.Errorf("failed to record relationship action: %w", err)
+	}
+
+	return nil
+}
+
+// GetPermissionLogForAthlete lists who accessed the athlete's data, newest
+// first, optionally narrowed to one coach or action
+func (r *CoachRepository) GetPermissionLogForAthlete(athleteID uuid.UUID, coachID *uuid.UUID, action *models.PermissionAction, page, pageSize int) ([]models.PermissionLogEntry, int, error) {
+	where := "r.athlete_id = $1"
+	args := []interface{}{athleteID}
+	if coachID != nil {
+		args = append(args, *coachID)
+		where += fmt.Sprintf(" AND r.coach_id = $%d", len(args))
+	}
+	if action != nil {
+		args = append(args, *action)
+		where += fmt.Sprintf(" AND l.action = $%d", len(args))
+	}
+
+	countQuery := `
+		SELECT COUNT(*)
+		FROM relationship_permission_log l
+		JOIN coach_athlete_relationships r ON r.id = l.relationship_id
+		WHERE ` + where
+
+	var totalCount int
+	if err := r.db.QueryRow(countQuery, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count access log: %w", err)
+	}
+
+	offset := (page - 1) * pageSize
+	args = append(args, pageSize, offset)
+	query := fmt.Sprintf(`
+		SELECT l.id, l.relationship_id, r.coach_id, r.athlete_id, l.action, l.resource_type,
+		       l.resource_id, l.performed_by, l.ip_address, l.user_agent, l.logged_at
+		FROM relationship_permission_log l
+		JOIN coach_athlete_relationships r ON r.id = l.relationship_id
+		WHERE %s
+		ORDER BY l.logged_at DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get access log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.PermissionLogEntry{}
+	for rows.Next() {
+		var e models.PermissionLogEntry
+		err := rows.Scan(
+			&e.ID, &e.RelationshipID, &e.CoachID, &e.AthleteID, &e.Action, &e.ResourceType,
+			&e.ResourceID, &e.PerformedBy, &e.IPAddress, &e.UserAgent, &e.LoggedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan access log entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, totalCount, nil
+}
+
+// PurgePermissionLog deletes access records older than retention. A retention
+// that isn't positive deletes nothing.
+func (r *CoachRepository) PurgePermissionLog(retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	result, err := r.db.Exec(`DELETE FROM relationship_permission_log WHERE logged_at < $1`, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge access log: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -43,15 +44,20 @@ func (r *CoachRepository) GetFeedbackByID(id uuid.UUID) (*models.CoachFeedback,
 	query := `
 		SELECT id, coach_id, athlete_id, feedback_type, priority, title, content,
 		       reference_type, reference_id, tags, is_private, incorporated_by_ai,
-		       incorporated_at, created_at, updated_at
+		       incorporated_at, incorporated_source, incorporated_program_id,
+		       incorporated_program_name, incorporated_program_version,
+		       incorporated_conversation_id, created_at, updated_at
 		FROM coach_feedback WHERE id = $1`
 
 	feedback := &models.CoachFeedback{}
+	var incorporation incorporationColumns
 	err := r.db.QueryRow(query, id).Scan(
 		&feedback.ID, &feedback.CoachID, &feedback.AthleteID, &feedback.FeedbackType,
 		&feedback.Priority, &feedback.Title, &feedback.Content, &feedback.ReferenceType,
 		&feedback.ReferenceID, pq.Array(&feedback.Tags), &feedback.IsPrivate,
 		&feedback.IncorporatedByAI, &feedback.IncorporatedAt,
+		&incorporation.source, &incorporation.programID, &incorporation.programName,
+		&incorporation.programVersion, &incorporation.conversationID,
 		&feedback.CreatedAt, &feedback.UpdatedAt,
 	)
 
@@ -61,6 +67,7 @@ func (r *CoachRepository) GetFeedbackByID(id uuid.UUID) (*models.CoachFeedback,
 		}
 		return nil, fmt.Errorf("failed to get feedback: %w", err)
 	}
+	feedback.Incorporation = incorporation.toModel()
 
 	return feedback, nil
 }
@@ -99,7 +106,9 @@ func (r *CoachRepository) GetFeedbackByCoachID(coachID uuid.UUID, page, pageSize
 	query := fmt.Sprintf(`
 		SELECT id, coach_id, athlete_id, feedback_type, priority, title, content,
 		       reference_type, reference_id, tags, is_private, incorporated_by_ai,
-		       incorporated_at, created_at, updated_at
+		       incorporated_at, incorporated_source, incorporated_program_id,
+		       incorporated_program_name, incorporated_program_version,
+		       incorporated_conversation_id, created_at, updated_at
 		FROM coach_feedback 
 		WHERE %s 
 		ORDER BY created_at DESC 
@@ -116,16 +125,20 @@ func (r *CoachRepository) GetFeedbackByCoachID(coachID uuid.UUID, page, pageSize
 	var feedbacks []models.CoachFeedback
 	for rows.Next() {
 		var feedback models.CoachFeedback
+		var incorporation incorporationColumns
 		err := rows.Scan(
 			&feedback.ID, &feedback.CoachID, &feedback.AthleteID, &feedback.FeedbackType,
 			&feedback.Priority, &feedback.Title, &feedback.Content, &feedback.ReferenceType,
 			&feedback.ReferenceID, pq.Array(&feedback.Tags), &feedback.IsPrivate,
 			&feedback.IncorporatedByAI, &feedback.IncorporatedAt,
+			&incorporation.source, &incorporation.programID, &incorporation.programName,
+			&incorporation.programVersion, &incorporation.conversationID,
 			&feedback.CreatedAt, &feedback.UpdatedAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan feedback: %w", err)
 		}
+		feedback.Incorporation = incorporation.toModel()
 
 		feedbacks = append(feedbacks, feedback)
 	}
@@ -148,7 +161,9 @@ func (r *CoachRepository) GetFeedbackByAthleteID(athleteID uuid.UUID, page, page
 	query := `
 		SELECT id, coach_id, athlete_id, feedback_type, priority, title, content,
 		       reference_type, reference_id, tags, is_private, incorporated_by_ai,
-		       incorporated_at, created_at, updated_at
+		       incorporated_at, incorporated_source, incorporated_program_id,
+		       incorporated_program_name, incorporated_program_version,
+		       incorporated_conversation_id, created_at, updated_at
 		FROM coach_feedback 
 		WHERE athlete_id = $1 AND is_private = false 
 		ORDER BY created_at DESC 
@@ -163,16 +178,20 @@ func (r *CoachRepository) GetFeedbackByAthleteID(athleteID uuid.UUID, page, page
 	var feedbacks []models.CoachFeedback
 	for rows.Next() {
 		var feedback models.CoachFeedback
+		var incorporation incorporationColumns
 		err := rows.Scan(
 			&feedback.ID, &feedback.CoachID, &feedback.AthleteID, &feedback.FeedbackType,
 			&feedback.Priority, &feedback.Title, &feedback.Content, &feedback.ReferenceType,
 			&feedback.ReferenceID, pq.Array(&feedback.Tags), &feedback.IsPrivate,
 			&feedback.IncorporatedByAI, &feedback.IncorporatedAt,
+			&incorporation.source, &incorporation.programID, &incorporation.programName,
+			&incorporation.programVersion, &incorporation.conversationID,
 			&feedback.CreatedAt, &feedback.UpdatedAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan feedback: %w", err)
 		}
+		feedback.Incorporation = incorporation.toModel()
 
 		feedbacks = append(feedbacks, feedback)
 	}
@@ -200,19 +219,54 @@ func (r *CoachRepository) UpdateFeedback(feedback *models.CoachFeedback) error {
 	return nil
 }
 
-func (r *CoachRepository) MarkFeedbackIncorporated(feedbackID uuid.UUID) error {
+// MarkFeedbackIncorporated records that the AI acted on the feedback. It returns
+// false when the feedback was already marked, so callers only notify once.
+func (r *CoachRepository) MarkFeedbackIncorporated(feedbackID uuid.UUID, incorporation models.Incorporation) (bool, error) {
 	query := `
 		UPDATE coach_feedback SET
 			incorporated_by_ai = true,
-			incorporated_at = NOW()
-		WHERE id = $1`
+			incorporated_at = NOW(),
+			incorporated_source = $2,
+			incorporated_program_id = $3,
+			incorporated_program_name = $4,
+			incorporated_program_version = $5,
+			incorporated_conversation_id = $6
+		WHERE id = $1 AND incorporated_by_ai = false`
+
+	result, err := r.db.Exec(query, feedbackID, incorporation.Source, incorporation.ProgramID,
+		incorporation.ProgramName, incorporation.ProgramVersion, incorporation.ConversationID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark feedback as incorporated: %w", err)
+	}
 
-	_, err := r.db.Exec(query, feedbackID)
+	rows, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to mark feedback as incorporated: %w", err)
+		return false, fmt.Errorf("failed to mark feedback as incorporated: %w", err)
 	}
 
-	return nil
+	return rows > 0, nil
+}
+
+// incorporationColumns scans the nullable incorporated_* columns
+type incorporationColumns struct {
+	source         sql.NullString
+	programID      *uuid.UUID
+	programName    *string
+	programVersion *int
+	conversationID *uuid.UUID
+}
+
+func (c incorporationColumns) toModel() *models.Incorporation {
+	if !c.source.Valid {
+		return nil
+	}
+	return &models.Incorporation{
+		Source:         c.source.String,
+		ProgramID:      c.programID,
+		ProgramName:    c.programName,
+		ProgramVersion: c.programVersion,
+		ConversationID: c.conversationID,
+	}
 }
 
 func (r *CoachRepository) CreateNote(note *models.CoachAthleteNote) error {
@@ -465,14 +519,46 @@ func (r *CoachRepository) GetAthleteProgress(coachID, athleteID uuid.UUID, limit
 	return progress, nil
 }
 
+// GetBodyweightNearDate returns the progress entry with a bodyweight whose
+// tracking date is closest to date, from any of the athlete's coaches, or nil
+// when none was ever recorded
+func (r *CoachRepository) GetBodyweightNearDate(athleteID uuid.UUID, date time.Time) (*models.AthleteProgressTracking, error) {
+	query := `
+		SELECT id, coach_id, athlete_id, tracking_date, body_weight_kg, created_at
+		FROM athlete_progress_tracking
+		WHERE athlete_id = $1 AND body_weight_kg IS NOT NULL
+		ORDER BY ABS(tracking_date - $2::date), tracking_date DESC, created_at DESC
+		LIMIT 1`
+
+	var p models.AthleteProgressTracking
+	err := r.db.QueryRow(query, athleteID, date).Scan(
+		&p.ID, &p.CoachID, &p.AthleteID, &p.TrackingDate, &p.BodyWeightKg, &p.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get bodyweight near date: %w", err)
+	}
+
+	return &p, nil
+}
+
 func (r *CoachRepository) SendRelationshipRequest(relationship *models.CoachAthleteRelationship) error {
+	if len(relationship.Scopes) == 0 {
+		relationship.Scopes = models.AllScopes
+	}
+	relationship.Status = models.StatusPending
+	relationship.Source = models.SourceRequest
+
 	query := `
-		INSERT INTO coach_athlete_relationships (coach_id, athlete_id, status, request_message)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO coach_athlete_relationships (coach_id, athlete_id, status, request_message, scopes, source)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, requested_at, created_at, updated_at`
 
 	err := r.db.QueryRow(query,
-		relationship.CoachID, relationship.AthleteID, models.StatusPending, relationship.RequestMessage,
+		relationship.CoachID, relationship.AthleteID, relationship.Status, relationship.RequestMessage,
+		scopeArray(relationship.Scopes), relationship.Source,
 	).Scan(&relationship.ID, &relationship.RequestedAt, &relationship.CreatedAt, &relationship.UpdatedAt)
 
 	if err != nil {
@@ -546,7 +632,7 @@ func (r *CoachRepository) GetRelationshipsByCoachID(coachID uuid.UUID, status *m
 		query = `
 			SELECT id, coach_id, athlete_id, status, request_message, requested_at,
 			       accepted_at, terminated_at, terminated_by, termination_reason,
-			       cooldown_until, created_at, updated_at
+			       cooldown_until, scopes, source, created_at, updated_at
 			FROM coach_athlete_relationships
 			WHERE coach_id = $1 AND status = $2
 			ORDER BY created_at DESC`
@@ -555,7 +641,7 @@ func (r *CoachRepository) GetRelationshipsByCoachID(coachID uuid.UUID, status *m
 		query = `
 			SELECT id, coach_id, athlete_id, status, request_message, requested_at,
 			       accepted_at, terminated_at, terminated_by, termination_reason,
-			       cooldown_until, created_at, updated_at
+			       cooldown_until, scopes, source, created_at, updated_at
 			FROM coach_athlete_relationships
 			WHERE coach_id = $1
 			ORDER BY created_at DESC`
@@ -574,7 +660,8 @@ func (r *CoachRepository) GetRelationshipsByCoachID(coachID uuid.UUID, status *m
 		err := rows.Scan(
 			&rel.ID, &rel.CoachID, &rel.AthleteID, &rel.Status, &rel.RequestMessage,
 			&rel.RequestedAt, &rel.AcceptedAt, &rel.TerminatedAt, &rel.TerminatedBy,
-			&rel.TerminationReason, &rel.CooldownUntil, &rel.CreatedAt, &rel.UpdatedAt,
+			&rel.TerminationReason, &rel.CooldownUntil, (*scopeArray)(&rel.Scopes), &rel.Source,
+			&rel.CreatedAt, &rel.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan relationship: %w", err)
@@ -593,7 +680,7 @@ func (r *CoachRepository) GetRelationshipsByAthleteID(athleteID uuid.UUID, statu
 		query = `
 			SELECT id, coach_id, athlete_id, status, request_message, requested_at,
 			       accepted_at, terminated_at, terminated_by, termination_reason,
-			       cooldown_until, created_at, updated_at
+			       cooldown_until, scopes, source, created_at, updated_at
 			FROM coach_athlete_relationships
 			WHERE athlete_id = $1 AND status = $2
 			ORDER BY created_at DESC`
@@ -602,7 +689,7 @@ func (r *CoachRepository) GetRelationshipsByAthleteID(athleteID uuid.UUID, statu
 		query = `
 			SELECT id, coach_id, athlete_id, status, request_message, requested_at,
 			       accepted_at, terminated_at, terminated_by, termination_reason,
-			       cooldown_until, created_at, updated_at
+			       cooldown_until, scopes, source, created_at, updated_at
 			FROM coach_athlete_relationships
 			WHERE athlete_id = $1
 			ORDER BY created_at DESC`
@@ -621,7 +708,8 @@ func (r *CoachRepository) GetRelationshipsByAthleteID(athleteID uuid.UUID, statu
 		err := rows.Scan(
 			&rel.ID, &rel.CoachID, &rel.AthleteID, &rel.Status, &rel.RequestMessage,
 			&rel.RequestedAt, &rel.AcceptedAt, &rel.TerminatedAt, &rel.TerminatedBy,
-			&rel.TerminationReason, &rel.CooldownUntil, &rel.CreatedAt, &rel.UpdatedAt,
+			&rel.TerminationReason, &rel.CooldownUntil, (*scopeArray)(&rel.Scopes), &rel.Source,
+			&rel.CreatedAt, &rel.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan relationship: %w", err)
@@ -636,7 +724,7 @@ func (r *CoachRepository) GetRelationshipByID(relationshipID uuid.UUID) (*models
 	query := `
 		SELECT id, coach_id, athlete_id, status, request_message, requested_at,
 		       accepted_at, terminated_at, terminated_by, termination_reason,
-		       cooldown_until, created_at, updated_at
+		       cooldown_until, scopes, source, created_at, updated_at
 		FROM coach_athlete_relationships
 		WHERE id = $1`
 
@@ -644,7 +732,8 @@ func (r *CoachRepository) GetRelationshipByID(relationshipID uuid.UUID) (*models
 	err := r.db.QueryRow(query, relationshipID).Scan(
 		&rel.ID, &rel.CoachID, &rel.AthleteID, &rel.Status, &rel.RequestMessage,
 		&rel.RequestedAt, &rel.AcceptedAt, &rel.TerminatedAt, &rel.TerminatedBy,
-		&rel.TerminationReason, &rel.CooldownUntil, &rel.CreatedAt, &rel.UpdatedAt,
+		&rel.TerminationReason, &rel.CooldownUntil, (*scopeArray)(&rel.Scopes), &rel.Source,
+		&rel.CreatedAt, &rel.UpdatedAt,
 	)
 
 	if err != nil {
@@ -711,14 +800,16 @@ func (r *CoachRepository) CreateSuccessStory(story *models.CoachSuccessStory) er
 	query := `
 		INSERT INTO coach_success_stories (coach_id, athlete_name, achievement, competition_name,
 		                                   competition_date, total_kg, weight_class, federation,
-		                                   placement, is_public)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		                                   placement, is_public, body_weight_kg, sex, equipment,
+		                                   dots, wilks, wilks_2020, ipf_gl_points)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		RETURNING id, created_at`
 
 	err := r.db.QueryRow(query,
 		story.CoachID, story.AthleteName, story.Achievement, story.CompetitionName,
 		story.CompetitionDate, story.TotalKg, story.WeightClass, story.Federation,
-		story.Placement, story.IsPublic,
+		story.Placement, story.IsPublic, story.BodyWeightKg, story.Sex, story.Equipment,
+		story.DOTS, story.Wilks, story.Wilks2020, story.IPFGLPoints,
 	).Scan(&story.ID, &story.CreatedAt)
 
 	if err != nil {
@@ -733,14 +824,16 @@ func (r *CoachRepository) GetSuccessStoriesByCoachID(coachID uuid.UUID, publicOn
 	if publicOnly {
 		query = `
 			SELECT id, coach_id, athlete_name, achievement, competition_name, competition_date,
-			       total_kg, weight_class, federation, placement, is_public, created_at
+			       total_kg, weight_class, federation, placement, is_public, body_weight_kg,
+			       sex, equipment, dots, wilks, wilks_2020, ipf_gl_points, created_at
 			FROM coach_success_stories
 			WHERE coach_id = $1 AND is_public = true
 			ORDER BY created_at DESC`
 	} else {
 		query = `
 			SELECT id, coach_id, athlete_name, achievement, competition_name, competition_date,
-			       total_kg, weight_class, federation, placement, is_public, created_at
+			       total_kg, weight_class, federation, placement, is_public, body_weight_kg,
+			       sex, equipment, dots, wilks, wilks_2020, ipf_gl_points, created_at
 			FROM coach_success_stories
 			WHERE coach_id = $1
 			ORDER BY created_at DESC`
@@ -759,7 +852,9 @@ func (r *CoachRepository) GetSuccessStoriesByCoachID(coachID uuid.UUID, publicOn
 			&story.ID, &story.CoachID, &story.AthleteName, &story.Achievement,
 			&story.CompetitionName, &story.CompetitionDate, &story.TotalKg,
 			&story.WeightClass, &story.Federation, &story.Placement,
-			&story.IsPublic, &story.CreatedAt,
+			&story.IsPublic, &story.BodyWeightKg, &story.Sex, &story.Equipment,
+			&story.DOTS, &story.Wilks, &story.Wilks2020, &story.IPFGLPoints,
+			&story.CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan success story: %w", err)
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/powerlifting-coach-app/coach-service/internal/models"
+)
+
+// GetLatestProgressByCoachID returns the most recent progress entry the coach
+// tracked for each of their athletes
+func (r *CoachRepository) GetLatestProgressByCoachID(coachID uuid.UUID) (map[uuid.UUID]models.AthleteProgressTracking, error) {
+	query := `
+		SELECT DISTINCT ON (athlete_id)
+		       id, coach_id, athlete_id, tracking_date, body_weight_kg,
+		       squat_max_kg, bench_max_kg, deadlift_max_kg, total_kg,
+		       notes, measurements, created_at
+		FROM athlete_progress_tracking
+		WHERE coach_id = $1
+		ORDER BY athlete_id, tracking_date DESC, created_at DESC`
+
+	rows, err := r.db.Query(query, coachID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest athlete progress: %w", err)
+	}
+	defer rows.Close()
+
+	progress := make(map[uuid.UUID]models.AthleteProgressTracking)
+	for rows.Next() {
+		var p models.AthleteProgressTracking
+		var measurementsJSON []byte
+
+		err := rows.Scan(
+			&p.ID, &p.CoachID, &p.AthleteID, &p.TrackingDate,
+			&p.BodyWeightKg, &p.SquatMaxKg, &p.BenchMaxKg,
+			&p.DeadliftMaxKg, &p.TotalKg, &p.Notes,
+			&measurementsJSON, &p.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan athlete progress: %w", err)
+		}
+
+		if len(measurementsJSON) > 0 {
+			json.Unmarshal(measurementsJSON, &p.Measurements)
+		}
+
+		progress[p.AthleteID] = p
+	}
+
+	return progress, nil
+}
+
+// GetUnansweredFeedbackCounts counts, per athlete, the coach's feedback the
+// athlete hasn't responded to or acknowledged
+func (r *CoachRepository) GetUnansweredFeedbackCounts(coachID uuid.UUID) (map[uuid.UUID]int, error) {
+	query := `
+		SELECT f.athlete_id, COUNT(*)
+		FROM coach_feedback f
+		WHERE f.coach_id = $1
+		  AND NOT EXISTS (SELECT 1 FROM feedback_responses fr WHERE fr.feedback_id = f.id)
+		GROUP BY f.athlete_id`
+
+	rows, err := r.db.Query(query, coachID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unanswered feedback counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var athleteID uuid.UUID
+		var count int
+		if err := rows.Scan(&athleteID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan feedback count: %w", err)
+		}
+		counts[athleteID] = count
+	}
+
+	return counts, nil
+}
+
+// GetReviewedVideoIDs returns which of the videos the coach has given
+// feedback on
+func (r *CoachRepository) GetReviewedVideoIDs(coachID uuid.UUID, videoIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	reviewed := make(map[uuid.UUID]bool)
+	if len(videoIDs) == 0 {
+		return reviewed, nil
+	}
+
+	ids := make([]string, len(videoIDs))
+	for i, id := range videoIDs {
+		ids[i] = id.String()
+	}
+
+	query := `
+		SELECT DISTINCT reference_id
+		FROM coach_feedback
+		WHERE coach_id = $1
+		  AND reference_type = 'video'
+		  AND reference_id = ANY($2::uuid[])`
+
+	rows, err := r.db.Query(query, coachID, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviewed videos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var videoID uuid.UUID
+		if err := rows.Scan(&videoID); err != nil {
+			return nil, fmt.Errorf("failed to scan reviewed video: %w", err)
+		}
+		reviewed[videoID] = true
+	}
+
+	return reviewed, nil
+}
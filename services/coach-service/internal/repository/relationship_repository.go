@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/powerlifting-coach-app/coach-service/internal/models"
+)
+
+// scopeArray reads and writes relationship scopes as a Postgres text array
+type scopeArray []models.AccessScope
+
+func (a *scopeArray) Scan(src interface{}) error {
+	var values pq.StringArray
+	if err := values.Scan(src); err != nil {
+		return err
+	}
+
+	scopes := make(scopeArray, len(values))
+	for i, value := range values {
+		scopes[i] = models.AccessScope(value)
+	}
+	*a = scopes
+	return nil
+}
+
+func (a scopeArray) Value() (driver.Value, error) {
+	values := make(pq.StringArray, len(a))
+	for i, scope := range a {
+		values[i] = string(scope)
+	}
+	return values.Value()
+}
+
+// UpdateRelationshipScopes replaces what the coach may do on a relationship
+// that hasn't been terminated
+func (r *CoachRepository) UpdateRelationshipScopes(relationshipID uuid.UUID, scopes []models.AccessScope) error {
+	query := `
+		UPDATE coach_athlete_relationships SET
+			scopes = $2,
+			updated_at = NOW()
+		WHERE id = $1 AND status <> $3`
+
+	result, err := r.db.Exec(query, relationshipID, scopeArray(scopes), models.StatusTerminated)
+	if err != nil {
+		return fmt.Errorf("failed to update relationship scopes: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("relationship not found or terminated")
+	}
+
+	return nil
+}
+
+// ActivateAccessCodeRelationship starts, or accepts, the relationship for a
+// coach who redeemed the athlete's access code. A terminated relationship only
+// comes back with a code other than the one it was started with and once any
+// cooldown has passed. It returns the relationship ID, or nil when nothing
+// changed because the pair is already active or may not be reactivated yet.
+func (r *CoachRepository) ActivateAccessCodeRelationship(coachID, athleteID uuid.UUID, accessCode string, grantedAt time.Time) (*uuid.UUID, error) {
+	query := `
+		INSERT INTO coach_athlete_relationships (coach_id, athlete_id, status, requested_at,
+		                                         accepted_at, source, access_code)
+		VALUES ($1, $2, $3, $5, $5, $6, $4)
+		ON CONFLICT (coach_id, athlete_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			accepted_at = EXCLUDED.accepted_at,
+			terminated_at = NULL,
+			terminated_by = NULL,
+			termination_reason = NULL,
+			cooldown_until = NULL,
+			source = EXCLUDED.source,
+			access_code = EXCLUDED.access_code,
+			updated_at = NOW()
+		WHERE coach_athlete_relationships.status = $7
+		   OR (coach_athlete_relationships.status = $8
+		       AND coach_athlete_relationships.access_code IS DISTINCT FROM EXCLUDED.access_code
+		       AND (coach_athlete_relationships.cooldown_until IS NULL
+		            OR coach_athlete_relationships.cooldown_until <= NOW()))
+		RETURNING id`
+
+	var relationshipID uuid.UUID
+	err := r.db.QueryRow(query,
+		coachID, athleteID, models.StatusActive, accessCode, grantedAt, models.SourceAccessCode,
+		models.StatusPending, models.StatusTerminated,
+	).Scan(&relationshipID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to activate access code relationship: %w", err)
+	}
+
+	return &relationshipID, nil
+}
+
+// CheckAccessCodeEligibility applies the same rules as
+// ActivateAccessCodeRelationship without changing anything, so a coach can be
+// told up front why a code won't be applied
+func (r *CoachRepository) CheckAccessCodeEligibility(coachID, athleteID uuid.UUID, accessCode string) (*models.AccessCodeEligibility, error) {
+	query := `
+		SELECT status, access_code, cooldown_until
+		FROM coach_athlete_relationships
+		WHERE coach_id = $1 AND athlete_id = $2`
+
+	var status models.RelationshipStatus
+	var currentCode sql.NullString
+	var cooldownUntil *time.Time
+	err := r.db.QueryRow(query, coachID, athleteID).Scan(&status, &currentCode, &cooldownUntil)
+	if err == sql.ErrNoRows {
+		return &models.AccessCodeEligibility{Eligible: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access code eligibility: %w", err)
+	}
+
+	switch {
+	case status == models.StatusPending:
+		return &models.AccessCodeEligibility{Eligible: true}, nil
+	case status == models.StatusActive:
+		return &models.AccessCodeEligibility{Reason: models.AccessCodeAlreadyActive}, nil
+	case currentCode.Valid && currentCode.String == accessCode:
+		return &models.AccessCodeEligibility{Reason: models.AccessCodeAlreadyUsed}, nil
+	case cooldownUntil != nil && cooldownUntil.After(time.Now()):
+		return &models.AccessCodeEligibility{Reason: models.AccessCodeCooldown, CooldownUntil: cooldownUntil}, nil
+	}
+
+	return &models.AccessCodeEligibility{Eligible: true}, nil
+}
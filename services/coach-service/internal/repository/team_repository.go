@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/powerlifting-coach-app/coach-service/internal/models"
+)
+
+// ErrDuplicateTeamName is returned when the coach already has a team by that name
+var ErrDuplicateTeamName = errors.New("a team with that name already exists")
+
+func (r *CoachRepository) CreateTeam(team *models.CoachTeam) error {
+	query := `
+		INSERT INTO coach_teams (coach_id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRow(query, team.CoachID, team.Name, team.Description).
+		Scan(&team.ID, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateTeamName
+		}
+		return fmt.Errorf("failed to create team: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CoachRepository) GetTeamByID(teamID uuid.UUID) (*models.CoachTeam, error) {
+	query := `
+		SELECT t.id, t.coach_id, t.name, t.description, t.created_at, t.updated_at,
+		       (SELECT COUNT(*) FROM coach_team_members m WHERE m.team_id = t.id)
+		FROM coach_teams t
+		WHERE t.id = $1`
+
+	var team models.CoachTeam
+	err := r.db.QueryRow(query, teamID).Scan(
+		&team.ID, &team.CoachID, &team.Name, &team.Description,
+		&team.CreatedAt, &team.UpdatedAt, &team.MemberCount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("team not found")
+		}
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	return &team, nil
+}
+
+func (r *CoachRepository) GetTeamsByCoachID(coachID uuid.UUID) ([]models.CoachTeam, error) {
+	query := `
+		SELECT t.id, t.coach_id, t.name, t.description, t.created_at, t.updated_at,
+		       (SELECT COUNT(*) FROM coach_team_members m WHERE m.team_id = t.id)
+		FROM coach_teams t
+		WHERE t.coach_id = $1
+		ORDER BY t.name`
+
+	rows, err := r.db.Query(query, coachID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get teams: %w", err)
+	}
+	defer rows.Close()
+
+	teams := []models.CoachTeam{}
+	for rows.Next() {
+		var team models.CoachTeam
+		err := rows.Scan(
+			&team.ID, &team.CoachID, &team.Name, &team.Description,
+			&team.CreatedAt, &team.UpdatedAt, &team.MemberCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+func (r *CoachRepository) UpdateTeam(team *models.CoachTeam) error {
+	query := `
+		UPDATE coach_teams SET name = $2, description = $3
+		WHERE id = $1
+		RETURNING updated_at`
+
+	if err := r.db.QueryRow(query, team.ID, team.Name, team.Description).Scan(&team.UpdatedAt); err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateTeamName
+		}
+		return fmt.Errorf("failed to update team: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTeam removes the team with its memberships and announcements
+func (r *CoachRepository) DeleteTeam(teamID uuid.UUID) error {
+	if _, err := r.db.Exec(`DELETE FROM coach_teams WHERE id = $1`, teamID); err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+	return nil
+}
+
+func (r *CoachRepository) GetTeamMembers(teamID uuid.UUID) ([]models.TeamMember, error) {
+	query := `
+		SELECT athlete_id, added_at
+		FROM coach_team_members
+		WHERE team_id = $1
+		ORDER BY added_at`
+
+	rows, err := r.db.Query(query, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []models.TeamMember{}
+	for rows.Next() {
+		var member models.TeamMember
+		if err := rows.Scan(&member.AthleteID, &member.AddedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan team member: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// AddTeamMembers adds the athletes to the team, leaving existing members as
+// they are
+func (r *CoachRepository) AddTeamMembers(teamID uuid.UUID, athleteIDs []uuid.UUID) error {
+	ids := make([]string, len(athleteIDs))
+	for i, id := range athleteIDs {
+		ids[i] = id.String()
+	}
+
+	query := `
+		INSERT INTO coach_team_members (team_id, athlete_id)
+		SELECT $1, athlete_id FROM unnest($2::uuid[]) AS athlete_id
+		ON CONFLICT (team_id, athlete_id) DO NOTHING`
+
+	if _, err := r.db.Exec(query, teamID, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to add team members: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CoachRepository) RemoveTeamMember(teamID, athleteID uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM coach_team_members WHERE team_id = $1 AND athlete_id = $2`, teamID, athleteID)
+	if err != nil {
+		return fmt.Errorf("failed to remove team member: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("athlete is not on this team")
+	}
+
+	return nil
+}
+
+func (r *CoachRepository) CreateTeamAnnouncement(announcement *models.TeamAnnouncement) error {
+	query := `
+		INSERT INTO team_announcements (team_id, coach_id, title, content)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(query,
+		announcement.TeamID, announcement.CoachID, announcement.Title, announcement.Content,
+	).Scan(&announcement.ID, &announcement.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create announcement: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CoachRepository) GetTeamAnnouncements(teamID uuid.UUID, limit int) ([]models.TeamAnnouncement, error) {
+	query := `
+		SELECT a.id, a.team_id, t.name, a.coach_id, a.title, a.content, a.created_at
+		FROM team_announcements a
+		JOIN coach_teams t ON t.id = a.team_id
+		WHERE a.team_id = $1
+		ORDER BY a.created_at DESC
+		LIMIT $2`
+
+	return r.queryAnnouncements(query, teamID, limit)
+}
+
+// GetAnnouncementsForAthlete returns announcements from every team the athlete
+// is on, newest first, while they're still coached by the team's coach
+func (r *CoachRepository) GetAnnouncementsForAthlete(athleteID uuid.UUID, limit int) ([]models.TeamAnnouncement, error) {
+	query := `
+		SELECT a.id, a.team_id, t.name, a.coach_id, a.title, a.content, a.created_at
+		FROM team_announcements a
+		JOIN coach_teams t ON t.id = a.team_id
+		JOIN coach_team_members m ON m.team_id = a.team_id
+		JOIN coach_athlete_relationships r
+		  ON r.coach_id = t.coach_id AND r.athlete_id = m.athlete_id AND r.status = 'active'
+		WHERE m.athlete_id = $1
+		ORDER BY a.created_at DESC
+		LIMIT $2`
+
+	return r.queryAnnouncements(query, athleteID, limit)
+}
+
+func (r *CoachRepository) queryAnnouncements(query string, args ...interface{}) ([]models.TeamAnnouncement, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcements: %w", err)
+	}
+	defer rows.Close()
+
+	announcements := []models.TeamAnnouncement{}
+	for rows.Next() {
+		var a models.TeamAnnouncement
+		err := rows.Scan(&a.ID, &a.TeamID, &a.TeamName, &a.CoachID, &a.Title, &a.Content, &a.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan announcement: %w", err)
+		}
+		announcements = append(announcements, a)
+	}
+
+	return announcements, nil
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
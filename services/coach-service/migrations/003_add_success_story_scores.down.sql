@@ -0,0 +1,8 @@
+ALTER TABLE coach_success_stories
+    DROP COLUMN IF EXISTS ipf_gl_points,
+    DROP COLUMN IF EXISTS wilks_2020,
+    DROP COLUMN IF EXISTS wilks,
+    DROP COLUMN IF EXISTS dots,
+    DROP COLUMN IF EXISTS equipment,
+    DROP COLUMN IF EXISTS sex,
+    DROP COLUMN IF EXISTS body_weight_kg;
@@ -0,0 +1,10 @@
+-- Store relative-strength scores alongside success story totals
+
+ALTER TABLE coach_success_stories
+    ADD COLUMN IF NOT EXISTS body_weight_kg DECIMAL(5,2),
+    ADD COLUMN IF NOT EXISTS sex VARCHAR(10) CHECK (sex IN ('male', 'female')),
+    ADD COLUMN IF NOT EXISTS equipment VARCHAR(20) DEFAULT 'classic' CHECK (equipment IN ('classic', 'equipped')),
+    ADD COLUMN IF NOT EXISTS dots DECIMAL(6,2),
+    ADD COLUMN IF NOT EXISTS wilks DECIMAL(6,2),
+    ADD COLUMN IF NOT EXISTS wilks_2020 DECIMAL(6,2),
+    ADD COLUMN IF NOT EXISTS ipf_gl_points DECIMAL(6,2);
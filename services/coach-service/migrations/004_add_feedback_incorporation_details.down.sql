@@ -0,0 +1,6 @@
+ALTER TABLE coach_feedback
+    DROP COLUMN IF EXISTS incorporated_conversation_id,
+    DROP COLUMN IF EXISTS incorporated_program_version,
+    DROP COLUMN IF EXISTS incorporated_program_name,
+    DROP COLUMN IF EXISTS incorporated_program_id,
+    DROP COLUMN IF EXISTS incorporated_source;
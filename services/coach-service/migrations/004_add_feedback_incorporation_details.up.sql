@@ -0,0 +1,8 @@
+-- Record where the AI applied a piece of feedback so the coach can see it
+
+ALTER TABLE coach_feedback
+    ADD COLUMN IF NOT EXISTS incorporated_source VARCHAR(20) CHECK (incorporated_source IN ('generate', 'chat')),
+    ADD COLUMN IF NOT EXISTS incorporated_program_id UUID,
+    ADD COLUMN IF NOT EXISTS incorporated_program_name VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS incorporated_program_version INTEGER,
+    ADD COLUMN IF NOT EXISTS incorporated_conversation_id UUID;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS update_coach_teams_updated_at ON coach_teams;
+
+DROP INDEX IF EXISTS idx_team_announcements_team;
+DROP INDEX IF EXISTS idx_coach_team_members_athlete;
+DROP INDEX IF EXISTS idx_coach_teams_coach;
+
+DROP TABLE IF EXISTS team_announcements;
+DROP TABLE IF EXISTS coach_team_members;
+DROP TABLE IF EXISTS coach_teams;
@@ -0,0 +1,35 @@
+-- Coach-owned teams for managing athletes as a group
+
+CREATE TABLE IF NOT EXISTS coach_teams (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    coach_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(coach_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS coach_team_members (
+    team_id UUID NOT NULL REFERENCES coach_teams(id) ON DELETE CASCADE,
+    athlete_id UUID NOT NULL,
+    added_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (team_id, athlete_id)
+);
+
+CREATE TABLE IF NOT EXISTS team_announcements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL REFERENCES coach_teams(id) ON DELETE CASCADE,
+    coach_id UUID NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_coach_teams_coach ON coach_teams(coach_id);
+CREATE INDEX IF NOT EXISTS idx_coach_team_members_athlete ON coach_team_members(athlete_id);
+CREATE INDEX IF NOT EXISTS idx_team_announcements_team ON team_announcements(team_id, created_at DESC);
+
+CREATE TRIGGER update_coach_teams_updated_at
+    BEFORE UPDATE ON coach_teams
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_permission_log_logged_at;
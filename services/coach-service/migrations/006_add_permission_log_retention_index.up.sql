@@ -0,0 +1,3 @@
+-- Lets the retention purge and athlete access history scan by date
+
+CREATE INDEX IF NOT EXISTS idx_permission_log_logged_at ON relationship_permission_log(logged_at);
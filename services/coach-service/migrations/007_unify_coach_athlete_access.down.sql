@@ -0,0 +1,5 @@
+-- Relationships carried over from access codes are left in place
+ALTER TABLE coach_athlete_relationships
+    DROP COLUMN IF EXISTS access_code,
+    DROP COLUMN IF EXISTS source,
+    DROP COLUMN IF EXISTS scopes;
@@ -0,0 +1,27 @@
+-- Make coach_athlete_relationships the single source of coach access. Scopes
+-- limit what an active coach may do, and access codes become another way to
+-- start a relationship.
+
+ALTER TABLE coach_athlete_relationships
+    ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL
+        DEFAULT ARRAY['view_history', 'edit_program', 'view_videos', 'direct_message'],
+    ADD COLUMN IF NOT EXISTS source VARCHAR(20) NOT NULL DEFAULT 'request'
+        CHECK (source IN ('request', 'access_code')),
+    ADD COLUMN IF NOT EXISTS access_code VARCHAR(6);
+
+-- Carry over access granted with codes in user-service. Pairs that already
+-- have a relationship keep it, so earlier terminations still stand. If
+-- user-service hasn't migrated yet its migration 003 makes this copy instead.
+DO $$
+BEGIN
+    IF to_regclass('coach_athlete_access') IS NOT NULL THEN
+        INSERT INTO coach_athlete_relationships (coach_id, athlete_id, status, requested_at,
+                                                 accepted_at, source, access_code)
+        SELECT coach_id, athlete_id, 'active', COALESCE(granted_at, NOW()), COALESCE(granted_at, NOW()),
+               'access_code', access_code
+        FROM coach_athlete_access
+        WHERE is_active = TRUE AND access_code IS NOT NULL
+          AND (expires_at IS NULL OR expires_at > NOW())
+        ON CONFLICT (coach_id, athlete_id) DO NOTHING;
+    END IF;
+END $$;
@@ -50,9 +50,13 @@ func main() {
 	eventConsumer.RegisterHandler("dm.message.sent", dmEventHandlers.HandleDMMessageSent)
 	eventConsumer.RegisterHandler("dm.pin.attempts", dmEventHandlers.HandleDMPinAttempts)
 
+	relationshipEventHandlers := handlers.NewRelationshipEventHandlers(db.DB)
+	eventConsumer.RegisterHandler("coach.relationship.changed", relationshipEventHandlers.HandleRelationshipChanged)
+
 	routingKeys := []string{
 		"dm.message.sent",
 		"dm.pin.attempts",
+		"coach.relationship.changed",
 	}
 
 	if err := eventConsumer.StartConsuming("dm-service.events", routingKeys); err != nil {
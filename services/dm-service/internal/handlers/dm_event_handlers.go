@@ -58,6 +58,20 @@ func (h *DMEventHandlers) HandleDMMessageSent(ctx context.Context, payload []byt
 		return fmt.Errorf("invalid recipient_id: %w", err)
 	}
 
+	allowed, err := directMessageAllowed(ctx, h.db, senderID, recipientID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		// Dropped rather than retried: redelivery can't change the outcome
+		log.Warn().
+			Str("sender_id", senderID.String()).
+			Str("recipient_id", recipientID.String()).
+			Msg("DM rejected: direct messaging not granted for this coaching relationship")
+		h.publishMessageRejected(event, "direct messaging is not enabled for this coaching relationship")
+		return nil
+	}
+
 	messageID := uuid.New()
 
 	attachmentsJSON, err := json.Marshal(event.Data.Attachments)
@@ -157,6 +171,31 @@ func (h *DMEventHandlers) HandleDMMessageSent(ctx context.Context, payload []byt
 	return nil
 }
 
+// publishMessageRejected tells the sender's clients a message was not delivered
+func (h *DMEventHandlers) publishMessageRejected(event DMMessageSentEvent, reason string) {
+	if h.publisher == nil {
+		return
+	}
+
+	rejectedEvent := map[string]interface{}{
+		"schema_version":      "1.0.0",
+		"event_type":          "dm.message.rejected",
+		"client_generated_id": event.ClientGeneratedID,
+		"user_id":             event.UserID,
+		"timestamp":           event.Timestamp,
+		"source_service":      "dm-service",
+		"data": map[string]interface{}{
+			"conversation_id": event.Data.ConversationID,
+			"sender_id":       event.Data.SenderID,
+			"recipient_id":    event.Data.RecipientID,
+			"reason":          reason,
+		},
+	}
+	if err := h.publisher.PublishEvent("dm.message.rejected", rejectedEvent); err != nil {
+		log.Error().Err(err).Msg("Failed to publish dm.message.rejected event")
+	}
+}
+
 type DMPinAttemptsEvent struct {
 	SchemaVersion     string `json:"schema_version"`
 	EventType         string `json:"event_type"`
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// scopeDirectMessage is the relationship scope that lets a coach and athlete
+// message each other
+const scopeDirectMessage = "direct_message"
+
+// RelationshipChangedEvent is published by coach-service whenever a coaching
+// relationship starts, ends or has its scopes changed
+type RelationshipChangedEvent struct {
+	EventType     string `json:"event_type"`
+	SourceService string `json:"source_service"`
+	Data          struct {
+		RelationshipID uuid.UUID `json:"relationship_id"`
+		CoachID        uuid.UUID `json:"coach_id"`
+		AthleteID      uuid.UUID `json:"athlete_id"`
+		Status         string    `json:"status"`
+		Scopes         []string  `json:"scopes"`
+	} `json:"data"`
+}
+
+type RelationshipEventHandlers struct {
+	db *sql.DB
+}
+
+func NewRelationshipEventHandlers(db *sql.DB) *RelationshipEventHandlers {
+	return &RelationshipEventHandlers{
+		db: db,
+	}
+}
+
+// HandleRelationshipChanged keeps coach_relationships in step with coach-service
+func (h *RelationshipEventHandlers) HandleRelationshipChanged(ctx context.Context, payload []byte) error {
+	var event RelationshipChangedEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	data := event.Data
+	if data.CoachID == uuid.Nil || data.AthleteID == uuid.Nil || data.Status == "" {
+		return fmt.Errorf("coach_id, athlete_id and status are required")
+	}
+
+	scopes := data.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	query := `
+	INSERT INTO coach_relationships (coach_id, athlete_id, status, scopes, updated_at)
+	VALUES ($1, $2, $3, $4, NOW())
+	ON CONFLICT (coach_id, athlete_id)
+	DO UPDATE SET status = EXCLUDED.status, scopes = EXCLUDED.scopes, updated_at = NOW()
+	`
+	if _, err := h.db.ExecContext(ctx, query, data.CoachID, data.AthleteID, data.Status, pq.StringArray(scopes)); err != nil {
+		return fmt.Errorf("failed to sync coach relationship: %w", err)
+	}
+
+	return nil
+}
+
+// directMessageAllowed reports whether two users may message each other. A
+// coach and athlete need an active relationship with the direct_message scope;
+// users with no coaching relationship, such as the AI assistant, are not
+// governed by a scope.
+func directMessageAllowed(ctx context.Context, db *sql.DB, senderID, recipientID uuid.UUID) (bool, error) {
+	query := `
+	SELECT bool_or(status = 'active' AND $3 = ANY(scopes))
+	FROM coach_relationships
+	WHERE (coach_id = $1 AND athlete_id = $2) OR (coach_id = $2 AND athlete_id = $1)
+	`
+
+	var allowed sql.NullBool
+	if err := db.QueryRowContext(ctx, query, senderID, recipientID, scopeDirectMessage).Scan(&allowed); err != nil {
+		return false, fmt.Errorf("failed to check coach relationship: %w", err)
+	}
+
+	return !allowed.Valid || allowed.Bool, nil
+}
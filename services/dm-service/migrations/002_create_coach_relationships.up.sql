@@ -0,0 +1,27 @@
+-- Mirror of coach-service relationships, kept up to date from
+-- coach.relationship.changed, so direct messages can be checked against the
+-- direct_message scope
+CREATE TABLE IF NOT EXISTS coach_relationships (
+    coach_id UUID NOT NULL,
+    athlete_id UUID NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (coach_id, athlete_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_coach_relationships_athlete_id ON coach_relationships(athlete_id);
+
+-- Bring the mirror in line with relationships that already exist
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'coach_athlete_relationships' AND column_name = 'scopes') THEN
+        INSERT INTO coach_relationships (coach_id, athlete_id, status, scopes)
+        SELECT coach_id, athlete_id, status, scopes
+        FROM coach_athlete_relationships
+        ON CONFLICT (coach_id, athlete_id) DO UPDATE SET
+            status = EXCLUDED.status,
+            scopes = EXCLUDED.scopes;
+    END IF;
+END $$;
@@ -16,9 +16,11 @@ import (
 	"github.com/powerlifting-coach-app/program-service/internal/database"
 	"github.com/powerlifting-coach-app/program-service/internal/excel"
 	"github.com/powerlifting-coach-app/program-service/internal/handlers"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
 	"github.com/powerlifting-coach-app/program-service/internal/repository"
 	"github.com/powerlifting-coach-app/program-service/internal/services"
 	"github.com/powerlifting-coach-app/program-service/internal/queue"
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/ai/prompts"
 	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -32,7 +34,10 @@ func main() {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	}
 
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load config")
+	}
 	log.Info().Str("port", cfg.Port).Msg("Starting program service")
 
 	db, err := database.New(cfg.DatabaseURL)
@@ -70,21 +75,73 @@ func main() {
 	}
 
 	programRepo := repository.NewProgramRepository(db.DB)
-	aiClient := ai.NewLiteLLMClient(cfg)
+	promptRegistry, err := prompts.LoadDefault()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load prompt templates")
+	}
+	aiProvider, err := ai.NewProvider(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create AI provider")
+	}
+	aiClient := ai.NewClient(aiProvider, ai.ModelRoutingFromConfig(cfg), promptRegistry)
+	aiClient.SetUsageRecorder(programRepo)
+	usageService := services.NewAIUsageService(programRepo, cfg.AIDailyTokenQuota, cfg.AIMonthlyTokenQuota)
 	excelExporter := excel.NewExcelExporter()
 	workoutGenerator := services.NewWorkoutGenerator(programRepo)
 	settingsClient := clients.NewSettingsClient(cfg.SettingsService)
 	coachClient := clients.NewCoachClient(cfg.CoachService)
 
-	programHandlers := handlers.NewProgramHandlers(programRepo, aiClient, excelExporter, workoutGenerator, settingsClient, coachClient)
-	openaiHandlers := handlers.NewOpenAICompatHandlers(cfg)
+	programHandlers := handlers.NewProgramHandlers(programRepo, aiClient, excelExporter, workoutGenerator, settingsClient, coachClient, usageService, eventConsumer)
+	trashRetention := time.Duration(cfg.SessionTrashRetentionDays) * 24 * time.Hour
+	programHandlers.SetSessionTrashRetention(trashRetention)
+	openaiHandlers := handlers.NewOpenAICompatHandlers(cfg, programRepo, aiClient, settingsClient)
+
+	// Form analysis takes tens of seconds per video, so it gets its own queue and
+	// consumer instead of holding up program events
+	if cfg.FormAnalysisEnabled {
+		formConsumer, err := queue.NewEventConsumer(cfg.RabbitMQURL)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create form analysis consumer")
+		}
+		defer formConsumer.Close()
+
+		frameExtractor := services.NewFrameExtractor(cfg.FFmpegPath, cfg.FFprobePath)
+		formAnalysisHandlers := handlers.NewFormAnalysisHandlers(programRepo, aiClient, frameExtractor, usageService, formConsumer, cfg.FormAnalysisFrameCount)
+		formConsumer.RegisterHandler("media.processed", formAnalysisHandlers.HandleMediaProcessed)
+
+		if err := formConsumer.StartConsuming("program-service.form-analysis", []string{"media.processed"}); err != nil {
+			log.Fatal().Err(err).Msg("Failed to start form analysis consumer")
+		}
+	}
+
+	// Sessions left in the trash past the retention window are purged for good;
+	// a retention of zero keeps them until they are restored
+	if trashRetention > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				purged, err := programRepo.PurgeDeletedSessions(trashRetention)
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to purge deleted sessions")
+					continue
+				}
+				if purged > 0 {
+					log.Info().Int64("sessions", purged).Msg("Purged deleted sessions")
+				}
+			}
+		}()
+	} else {
+		log.Info().Msg("Session trash retention disabled, deleted sessions are kept until restored")
+	}
 
 	router := gin.Default()
 
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Coach-Context")
 		
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -94,6 +151,9 @@ func main() {
 		c.Next()
 	})
 
+	// Coach access to athlete data is published once the request has succeeded
+	router.Use(programHandlers.PublishCoachDataAccess)
+
 	authConfig := middleware.AuthConfig{
 		AuthService:  cfg.AuthService,
 		JWTSecret:    cfg.JWTSecret,
@@ -109,8 +169,9 @@ func main() {
 			programs.Use(middleware.AuthMiddleware(authConfig))
 			{
 				programs.POST("/", programHandlers.CreateProgram)
-				programs.POST("/generate", programHandlers.GenerateProgram)
-				programs.POST("/from-chat", programHandlers.CreateProgramFromChat)
+				programs.POST("/generate", programHandlers.RequireAIQuota(models.AIFeatureGenerate), programHandlers.GenerateProgram)
+				programs.POST("/generate/stream", programHandlers.RequireAIQuota(models.AIFeatureGenerate), programHandlers.StreamGenerateProgram)
+				programs.POST("/from-chat", programHandlers.RequireAIQuota(models.AIFeatureGenerate), programHandlers.CreateProgramFromChat)
 				programs.GET("/", programHandlers.GetMyPrograms)
 				programs.GET("/active", programHandlers.GetActiveProgram)
 				programs.GET("/pending", programHandlers.GetPendingProgram)
@@ -118,8 +179,11 @@ func main() {
 				programs.POST("/:id/approve", programHandlers.ApproveProgram)
 				programs.POST("/:id/reject", programHandlers.RejectProgram)
 				programs.GET("/:id/changes/pending", programHandlers.GetPendingChanges)
+				programs.GET("/:id/adherence", programHandlers.GetProgramAdherence)
+				programs.POST("/assign", programHandlers.AssignProgram)
 				programs.POST("/export", programHandlers.ExportProgram)
-				programs.POST("/chat", programHandlers.ChatWithAI)
+				programs.POST("/chat", programHandlers.RequireAIQuota(models.AIFeatureChat), programHandlers.ChatWithAI)
+				programs.POST("/chat/stream", programHandlers.RequireAIQuota(models.AIFeatureChat), programHandlers.StreamChatWithAI)
 				programs.GET("/chat/conversation", programHandlers.GetAIConversation)
 				programs.POST("/log-workout", programHandlers.LogWorkout)
 
@@ -130,6 +194,20 @@ func main() {
 			}
 		}
 
+		// AI conversation threads
+		conversations := v1.Group("/conversations")
+		conversations.Use(middleware.AuthMiddleware(authConfig))
+		{
+			conversations.POST("/", programHandlers.CreateConversation)
+			conversations.GET("/", programHandlers.ListConversations)
+			conversations.GET("/search", programHandlers.SearchConversations)
+			conversations.GET("/:conversationId", programHandlers.GetConversation)
+			conversations.PATCH("/:conversationId", programHandlers.UpdateConversation)
+			conversations.DELETE("/:conversationId", programHandlers.DeleteConversation)
+			conversations.PUT("/:conversationId/program", programHandlers.AttachConversationProgram)
+			conversations.DELETE("/:conversationId/program", programHandlers.DetachConversationProgram)
+		}
+
 		// Exercise library endpoints
 		exercises := v1.Group("/exercises")
 		exercises.Use(middleware.AuthMiddleware(authConfig))
@@ -154,6 +232,28 @@ func main() {
 		{
 			analytics.POST("/volume", programHandlers.GetVolumeData)
 			analytics.POST("/e1rm", programHandlers.GetE1RMData)
+			analytics.POST("/velocity", programHandlers.GetVelocityTrends)
+			analytics.POST("/velocity/profile", programHandlers.GetLoadVelocityProfiles)
+			analytics.POST("/score", programHandlers.ScoreTotal)
+			analytics.GET("/score/athlete", programHandlers.ScoreAthlete)
+			analytics.GET("/goals", programHandlers.GetGoals)
+			analytics.GET("/compliance", programHandlers.GetAthleteCompliance)
+		}
+
+		// Pain and injury log
+		pain := v1.Group("/pain")
+		pain.Use(middleware.AuthMiddleware(authConfig))
+		{
+			pain.POST("/", programHandlers.CreatePainEpisode)
+			pain.GET("/", programHandlers.GetPainEpisodes)
+			pain.GET("/trends", programHandlers.GetPainTrends)
+			pain.GET("/flags", programHandlers.GetPainFlags)
+			pain.GET("/:episodeId", programHandlers.GetPainEpisode)
+			pain.PUT("/:episodeId", programHandlers.UpdatePainEpisode)
+			pain.DELETE("/:episodeId", programHandlers.DeletePainEpisode)
+			pain.POST("/:episodeId/reports", programHandlers.AddPainReport)
+			pain.POST("/:episodeId/resolve", programHandlers.ResolvePainEpisode)
+			pain.POST("/:episodeId/reopen", programHandlers.ReopenPainEpisode)
 		}
 
 		// Session history endpoints
@@ -161,14 +261,53 @@ func main() {
 		sessions.Use(middleware.AuthMiddleware(authConfig))
 		{
 			sessions.GET("/history", programHandlers.GetSessionHistory)
+			sessions.POST("/sync", programHandlers.SyncWorkouts)
 			sessions.DELETE("/:sessionId", programHandlers.DeleteSession)
+
+			// Trash
+			sessions.GET("/trash", programHandlers.GetSessionTrash)
+			sessions.POST("/:sessionId/restore", programHandlers.RestoreSession)
+
+			// Live session mode
+			sessions.GET("/:sessionId/live", programHandlers.GetLiveSession)
+			sessions.POST("/:sessionId/start", programHandlers.StartLiveSession)
+			sessions.POST("/:sessionId/sets", programHandlers.LogLiveSet)
+			sessions.POST("/:sessionId/rest/start", programHandlers.StartRestTimer)
+			sessions.POST("/:sessionId/rest/stop", programHandlers.StopRestTimer)
+			sessions.POST("/:sessionId/finish", programHandlers.FinishLiveSession)
+
+			// Set corrections
+			sessions.PUT("/:sessionId/sets/:setId", programHandlers.UpdateSet)
+			sessions.DELETE("/:sessionId/sets/:setId", programHandlers.DeleteSet)
+			sessions.POST("/:sessionId/sets/:setId/undo", programHandlers.UndoSetChange)
+			sessions.GET("/:sessionId/corrections", programHandlers.GetSetCorrections)
+
+			// Bar speed from velocity tracker exports
+			sessions.POST("/:sessionId/velocity/import", programHandlers.ImportVelocityCSV)
+		}
+
+		// AI usage endpoints
+		aiUsage := v1.Group("/ai")
+		aiUsage.Use(middleware.AuthMiddleware(authConfig))
+		{
+			aiUsage.GET("/usage", programHandlers.GetMyAIUsage)
+		}
+
+		adminAuthConfig := authConfig
+		adminAuthConfig.RequiredRole = "admin"
+
+		admin := v1.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(adminAuthConfig))
+		{
+			admin.GET("/ai/usage", programHandlers.GetAIUsageReport)
 		}
 	}
 
 	// OpenAI-compatible endpoints for chat UI libraries
 	openai := router.Group("/v1")
+	openai.Use(middleware.AuthMiddleware(authConfig))
 	{
-		openai.POST("/chat/completions", openaiHandlers.ChatCompletions)
+		openai.POST("/chat/completions", openaiHandlers.RateLimit, programHandlers.RequireAIQuota(models.AIFeatureOpenAICompat), openaiHandlers.ChatCompletions)
 		openai.GET("/models", openaiHandlers.ListModels)
 	}
 
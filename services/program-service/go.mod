@@ -66,3 +66,8 @@ require (
 	golang.org/x/tools v0.34.0 // indirect
 	google.golang.org/protobuf v1.36.9 // indirect
 )
+
+// The in-repo shared module is newer than its last tagged release, so the
+// service builds against it directly. Images are built from the repository
+// root; see the Dockerfile.
+replace github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared => ../../shared
@@ -0,0 +1,278 @@
+// Package adherence compares a program's prescription with what the athlete
+// logged against it.
+package adherence
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/powerlifting-coach-app/program-service/internal/numeric"
+)
+
+// "5", "3-5", "8–10" or "5+"; anything else, such as AMRAP, has no rep target
+var targetRepsPattern = regexp.MustCompile(`^\s*(\d+)\s*(?:[-–]\s*(\d+)|\+)?\s*$`)
+
+// Build reports adherence for every prescribed session of a program. A session
+// is on time when completed within graceDays of its scheduled date and missed
+// once that has passed without it. maxes turn percentage targets into loads.
+func Build(program *models.Program, sessions []models.TrainingSession, maxes *models.BestLifts, graceDays int, now time.Time) models.AdherenceReport {
+	report := models.AdherenceReport{
+		ProgramID:   program.ID,
+		ProgramName: program.Name,
+		GraceDays:   graceDays,
+		Weeks:       []models.WeekAdherence{},
+	}
+
+	byWeek := make(map[int][]models.SessionAdherence)
+	for _, session := range sessions {
+		// Ad hoc workouts are logged against week 0 and weren't prescribed
+		if session.WeekNumber == 0 {
+			continue
+		}
+		byWeek[session.WeekNumber] = append(byWeek[session.WeekNumber], sessionAdherence(session, maxes, graceDays, now))
+	}
+
+	weekNumbers := make([]int, 0, len(byWeek))
+	for week := range byWeek {
+		weekNumbers = append(weekNumbers, week)
+	}
+	sort.Ints(weekNumbers)
+
+	var overall totals
+	for _, weekNumber := range weekNumbers {
+		var week totals
+		for _, session := range byWeek[weekNumber] {
+			week.add(session)
+			overall.add(session)
+		}
+		report.Weeks = append(report.Weeks, models.WeekAdherence{
+			WeekNumber: weekNumber,
+			Summary:    week.summary(),
+			Sessions:   byWeek[weekNumber],
+		})
+	}
+	report.Summary = overall.summary()
+
+	return report
+}
+
+// Window totals the prescribed sessions scheduled between from and now, from
+// any program. Sessions still inside their grace days aren't due yet and don't
+// count. Loads aren't compared since that needs the maxes for each program.
+func Window(sessions []models.TrainingSession, graceDays int, from, now time.Time) models.AdherenceTotals {
+	var window totals
+	for _, session := range sessions {
+		if session.WeekNumber == 0 || session.ScheduledDate == nil {
+			continue
+		}
+		if session.ScheduledDate.Before(from) || session.ScheduledDate.After(now) {
+			continue
+		}
+		window.add(sessionAdherence(session, nil, graceDays, now))
+	}
+	return window.summary()
+}
+
+// CompletionPct is the share of due sessions that were completed, on time or
+// late, or nil when none were due
+func CompletionPct(summary models.AdherenceTotals) *float64 {
+	if summary.SessionsPrescribed == 0 {
+		return nil
+	}
+	completed := summary.SessionsOnTime + summary.SessionsLate
+	return numeric.Ptr(numeric.Round(float64(completed)/float64(summary.SessionsPrescribed)*100, 1))
+}
+
+func sessionAdherence(session models.TrainingSession, maxes *models.BestLifts, graceDays int, now time.Time) models.SessionAdherence {
+	adherence := models.SessionAdherence{
+		SessionID:     session.ID,
+		SessionName:   session.SessionName,
+		WeekNumber:    session.WeekNumber,
+		DayNumber:     session.DayNumber,
+		ScheduledDate: session.ScheduledDate,
+		CompletedAt:   session.CompletedAt,
+		Exercises:     make([]models.ExerciseAdherence, 0, len(session.Exercises)),
+	}
+
+	switch {
+	case session.CompletedAt != nil && session.ScheduledDate != nil:
+		late := daysBetween(*session.ScheduledDate, *session.CompletedAt)
+		if late > graceDays {
+			adherence.Status = models.AdherenceLate
+			adherence.DaysLate = &late
+		} else {
+			adherence.Status = models.AdherenceOnTime
+		}
+	case session.CompletedAt != nil:
+		adherence.Status = models.AdherenceOnTime
+	case session.ScheduledDate != nil && daysBetween(*session.ScheduledDate, now) > graceDays:
+		adherence.Status = models.AdherenceMissed
+	default:
+		adherence.Status = models.AdherenceUpcoming
+	}
+
+	for _, exercise := range session.Exercises {
+		adherence.Exercises = append(adherence.Exercises, exerciseAdherence(exercise, maxes))
+	}
+
+	return adherence
+}
+
+func exerciseAdherence(exercise models.Exercise, maxes *models.BestLifts) models.ExerciseAdherence {
+	adherence := models.ExerciseAdherence{
+		ExerciseID:     exercise.ID,
+		ExerciseName:   exercise.ExerciseName,
+		LiftType:       exercise.LiftType,
+		SetsPrescribed: exercise.TargetSets,
+		TargetReps:     exercise.TargetReps,
+		TargetLoadKg:   targetLoad(exercise, maxes),
+		TargetRPE:      exercise.TargetRPE,
+	}
+	if minReps, ok := parseTargetReps(exercise.TargetReps); ok {
+		prescribed := minReps * exercise.TargetSets
+		adherence.RepsPrescribed = &prescribed
+	}
+
+	var loadTotal, rpeTotal float64
+	var rpeCount int
+	for _, set := range exercise.CompletedSets {
+		if set.SetType == models.SetTypeWarmUp {
+			continue
+		}
+		adherence.SetsPerformed++
+		adherence.RepsPerformed += set.RepsCompleted
+		loadTotal += set.WeightKg
+		if set.RPEActual != nil {
+			rpeTotal += *set.RPEActual
+			rpeCount++
+		}
+	}
+
+	if adherence.SetsPerformed > 0 {
+		average := loadTotal / float64(adherence.SetsPerformed)
+		adherence.AverageLoadKg = numeric.Ptr(numeric.Round(average, 1))
+		if adherence.TargetLoadKg != nil && *adherence.TargetLoadKg > 0 {
+			adherence.LoadDeviationKg = numeric.Ptr(numeric.Round(average-*adherence.TargetLoadKg, 1))
+			adherence.LoadDeviationPct = numeric.Ptr(numeric.Round((average-*adherence.TargetLoadKg) / *adherence.TargetLoadKg * 100, 1))
+		}
+	}
+	if rpeCount > 0 {
+		average := rpeTotal / float64(rpeCount)
+		adherence.AverageRPE = numeric.Ptr(numeric.Round(average, 1))
+		if exercise.TargetRPE != nil {
+			adherence.RPEDeviation = numeric.Ptr(numeric.Round(average-*exercise.TargetRPE, 1))
+		}
+	}
+
+	return adherence
+}
+
+// targetLoad is the prescribed weight, or the target percentage of the
+// athlete's best competition lift
+func targetLoad(exercise models.Exercise, maxes *models.BestLifts) *float64 {
+	if exercise.TargetWeightKg != nil {
+		return exercise.TargetWeightKg
+	}
+	if exercise.TargetPercentage == nil || maxes == nil {
+		return nil
+	}
+
+	var best float64
+	switch exercise.LiftType {
+	case models.LiftTypeSquat:
+		best = maxes.SquatKg
+	case models.LiftTypeBench:
+		best = maxes.BenchKg
+	case models.LiftTypeDeadlift:
+		best = maxes.DeadliftKg
+	}
+	if best <= 0 {
+		return nil
+	}
+	return numeric.Ptr(numeric.Round(best**exercise.TargetPercentage/100, 1))
+}
+
+// parseTargetReps returns the fewest reps a set is prescribed
+func parseTargetReps(target string) (int, bool) {
+	match := targetRepsPattern.FindStringSubmatch(target)
+	if match == nil {
+		return 0, false
+	}
+	reps, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return reps, true
+}
+
+// totals accumulates AdherenceTotals; deviations are averaged per exercise
+type totals struct {
+	models.AdherenceTotals
+	loadDeviation, rpeDeviation float64
+	loadCount, rpeCount         int
+	repsComparable              int
+	repsComparablePrescribed    int
+}
+
+func (t *totals) add(session models.SessionAdherence) {
+	if session.Status == models.AdherenceUpcoming {
+		return
+	}
+
+	t.SessionsPrescribed++
+	switch session.Status {
+	case models.AdherenceOnTime:
+		t.SessionsOnTime++
+	case models.AdherenceLate:
+		t.SessionsLate++
+	case models.AdherenceMissed:
+		t.SessionsMissed++
+	}
+
+	for _, exercise := range session.Exercises {
+		t.SetsPrescribed += exercise.SetsPrescribed
+		t.SetsPerformed += exercise.SetsPerformed
+		t.RepsPerformed += exercise.RepsPerformed
+		if exercise.RepsPrescribed != nil {
+			t.RepsPrescribed += *exercise.RepsPrescribed
+			t.repsComparablePrescribed += *exercise.RepsPrescribed
+			t.repsComparable += exercise.RepsPerformed
+		}
+		if exercise.LoadDeviationPct != nil {
+			t.loadDeviation += *exercise.LoadDeviationPct
+			t.loadCount++
+		}
+		if exercise.RPEDeviation != nil {
+			t.rpeDeviation += *exercise.RPEDeviation
+			t.rpeCount++
+		}
+	}
+}
+
+func (t *totals) summary() models.AdherenceTotals {
+	summary := t.AdherenceTotals
+	if t.SetsPrescribed > 0 {
+		summary.SetCompletionPct = numeric.Ptr(numeric.Round(float64(t.SetsPerformed)/float64(t.SetsPrescribed)*100, 1))
+	}
+	if t.repsComparablePrescribed > 0 {
+		summary.RepCompletionPct = numeric.Ptr(numeric.Round(float64(t.repsComparable)/float64(t.repsComparablePrescribed)*100, 1))
+	}
+	if t.loadCount > 0 {
+		summary.LoadDeviationPct = numeric.Ptr(numeric.Round(t.loadDeviation/float64(t.loadCount), 1))
+	}
+	if t.rpeCount > 0 {
+		summary.RPEDeviation = numeric.Ptr(numeric.Round(t.rpeDeviation/float64(t.rpeCount), 2))
+	}
+	return summary
+}
+
+// daysBetween counts calendar days from one date to another
+func daysBetween(from, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(math.Round(toDay.Sub(fromDay).Hours() / 24))
+}
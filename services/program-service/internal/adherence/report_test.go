@@ -0,0 +1,260 @@
+package adherence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// now is midday on 10 March; tests use one grace day unless they say otherwise
+var now = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func day(month time.Month, day, hour int) *time.Time {
+	t := time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func float(v float64) *float64 { return &v }
+
+func integer(v int) *int { return &v }
+
+func TestParseTargetReps(t *testing.T) {
+	tests := []struct {
+		target string
+		want   int
+		wantOK bool
+	}{
+		{target: "5", want: 5, wantOK: true},
+		{target: " 6 ", want: 6, wantOK: true},
+		{target: "3-5", want: 3, wantOK: true},
+		{target: "8 – 10", want: 8, wantOK: true},
+		{target: "5+", want: 5, wantOK: true},
+		{target: "AMRAP"},
+		{target: "3x5"},
+		{target: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, ok := parseTargetReps(tt.target)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseTargetReps(%q) = %d, %v, want %d, %v", tt.target, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSessionStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		scheduled    *time.Time
+		completed    *time.Time
+		want         string
+		wantDaysLate *int
+	}{
+		{name: "completed on the day", scheduled: day(3, 2, 0), completed: day(3, 2, 23), want: models.AdherenceOnTime},
+		{name: "completed within the grace day", scheduled: day(3, 2, 0), completed: day(3, 3, 8), want: models.AdherenceOnTime},
+		{name: "completed early", scheduled: day(3, 4, 0), completed: day(3, 2, 8), want: models.AdherenceOnTime},
+		{name: "completed late", scheduled: day(3, 2, 0), completed: day(3, 5, 1), want: models.AdherenceLate, wantDaysLate: integer(3)},
+		{name: "completed without a schedule", completed: day(3, 5, 1), want: models.AdherenceOnTime},
+		{name: "missed once the grace day has passed", scheduled: day(3, 8, 0), want: models.AdherenceMissed},
+		{name: "still inside the grace day", scheduled: day(3, 9, 0), want: models.AdherenceUpcoming},
+		{name: "scheduled later", scheduled: day(3, 14, 0), want: models.AdherenceUpcoming},
+		{name: "never scheduled", want: models.AdherenceUpcoming},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := models.TrainingSession{WeekNumber: 1, ScheduledDate: tt.scheduled, CompletedAt: tt.completed}
+			got := sessionAdherence(session, nil, 1, now)
+			if got.Status != tt.want || !reflect.DeepEqual(got.DaysLate, tt.wantDaysLate) {
+				t.Errorf("status = %s (%v days late), want %s (%v)", got.Status, got.DaysLate, tt.want, tt.wantDaysLate)
+			}
+		})
+	}
+}
+
+func TestExerciseAdherence(t *testing.T) {
+	maxes := &models.BestLifts{SquatKg: 200, BenchKg: 0}
+	working := []models.CompletedSet{
+		{SetType: models.SetTypeWarmUp, RepsCompleted: 5, WeightKg: 100},
+		{SetType: models.SetTypeWorking, RepsCompleted: 5, WeightKg: 160, RPEActual: float(8.5)},
+		{SetType: models.SetTypeWorking, RepsCompleted: 4, WeightKg: 170, RPEActual: float(9)},
+		{SetType: models.SetTypeBackoff, RepsCompleted: 5, WeightKg: 165},
+	}
+
+	tests := []struct {
+		name     string
+		exercise models.Exercise
+		maxes    *models.BestLifts
+		want     models.ExerciseAdherence
+	}{
+		{
+			// 80% of 200 is 160; the working sets average 165kg, 5kg or 3.1% heavy,
+			// and RPE 8.75 against 8
+			name: "percentage target",
+			exercise: models.Exercise{
+				LiftType: models.LiftTypeSquat, TargetSets: 3, TargetReps: "3-5",
+				TargetPercentage: float(80), TargetRPE: float(8), CompletedSets: working,
+			},
+			maxes: maxes,
+			want: models.ExerciseAdherence{
+				LiftType: models.LiftTypeSquat, SetsPrescribed: 3, SetsPerformed: 3,
+				TargetReps: "3-5", RepsPrescribed: integer(9), RepsPerformed: 14,
+				TargetLoadKg: float(160), AverageLoadKg: float(165),
+				LoadDeviationKg: float(5), LoadDeviationPct: float(3.1),
+				TargetRPE: float(8), AverageRPE: float(8.8), RPEDeviation: float(0.8),
+			},
+		},
+		{
+			name: "fixed weight wins over the percentage",
+			exercise: models.Exercise{
+				LiftType: models.LiftTypeSquat, TargetSets: 3, TargetReps: "5",
+				TargetWeightKg: float(175), TargetPercentage: float(80), CompletedSets: working,
+			},
+			maxes: maxes,
+			want: models.ExerciseAdherence{
+				LiftType: models.LiftTypeSquat, SetsPrescribed: 3, SetsPerformed: 3,
+				TargetReps: "5", RepsPrescribed: integer(15), RepsPerformed: 14,
+				TargetLoadKg: float(175), AverageLoadKg: float(165),
+				LoadDeviationKg: float(-10), LoadDeviationPct: float(-5.7),
+				AverageRPE: float(8.8),
+			},
+		},
+		{
+			name: "percentage without a best lift",
+			exercise: models.Exercise{
+				LiftType: models.LiftTypeBench, TargetSets: 2, TargetReps: "AMRAP",
+				TargetPercentage: float(70), CompletedSets: working[3:],
+			},
+			maxes: maxes,
+			want: models.ExerciseAdherence{
+				LiftType: models.LiftTypeBench, SetsPrescribed: 2, SetsPerformed: 1,
+				TargetReps: "AMRAP", RepsPerformed: 5, AverageLoadKg: float(165),
+			},
+		},
+		{
+			name: "nothing logged",
+			exercise: models.Exercise{
+				LiftType: models.LiftTypeSquat, TargetSets: 3, TargetReps: "5+",
+				TargetPercentage: float(75), TargetRPE: float(7),
+			},
+			maxes: maxes,
+			want: models.ExerciseAdherence{
+				LiftType: models.LiftTypeSquat, SetsPrescribed: 3,
+				TargetReps: "5+", RepsPrescribed: integer(15),
+				TargetLoadKg: float(150), TargetRPE: float(7),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := exerciseAdherence(tt.exercise, tt.maxes)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("exerciseAdherence = %+v, want %+v", describe(got), describe(tt.want))
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	sessions := []models.TrainingSession{
+		// Ad hoc, unscheduled, before the window and after now: not counted
+		{WeekNumber: 0, ScheduledDate: day(3, 4, 0)},
+		{WeekNumber: 1},
+		{WeekNumber: 1, ScheduledDate: day(2, 27, 0)},
+		{WeekNumber: 2, ScheduledDate: day(3, 12, 0)},
+		// The window includes its start
+		{WeekNumber: 1, ScheduledDate: day(3, 1, 0), CompletedAt: day(3, 1, 18)},
+		{WeekNumber: 1, ScheduledDate: day(3, 3, 0), CompletedAt: day(3, 6, 18)},
+		{WeekNumber: 1, ScheduledDate: day(3, 5, 0)},
+		// Not due until the grace day has passed
+		{WeekNumber: 2, ScheduledDate: day(3, 10, 0)},
+	}
+
+	got := Window(sessions, 1, from, now)
+	want := models.AdherenceTotals{SessionsPrescribed: 3, SessionsOnTime: 1, SessionsLate: 1, SessionsMissed: 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Window = %+v, want %+v", got, want)
+	}
+
+	if pct := CompletionPct(got); pct == nil || *pct != 66.7 {
+		t.Errorf("CompletionPct = %v, want 66.7", pct)
+	}
+	if pct := CompletionPct(models.AdherenceTotals{}); pct != nil {
+		t.Errorf("CompletionPct with nothing due = %v, want nil", *pct)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	program := &models.Program{ID: uuid.New(), Name: "Meet prep"}
+	squat := func(weight, rpe float64) models.Exercise {
+		return models.Exercise{
+			LiftType: models.LiftTypeSquat, TargetSets: 2, TargetReps: "5",
+			TargetWeightKg: float(150), TargetRPE: float(8),
+			CompletedSets: []models.CompletedSet{
+				{SetType: models.SetTypeWorking, RepsCompleted: 5, WeightKg: weight, RPEActual: float(rpe)},
+			},
+		}
+	}
+	sessions := []models.TrainingSession{
+		{WeekNumber: 2, ScheduledDate: day(3, 9, 0)},
+		{WeekNumber: 1, ScheduledDate: day(3, 2, 0), CompletedAt: day(3, 2, 18), Exercises: []models.Exercise{squat(150, 8)}},
+		{WeekNumber: 1, ScheduledDate: day(3, 4, 0), CompletedAt: day(3, 4, 18), Exercises: []models.Exercise{squat(135, 9)}},
+		{WeekNumber: 0, CompletedAt: day(3, 5, 18), Exercises: []models.Exercise{squat(200, 10)}},
+	}
+
+	report := Build(program, sessions, nil, 1, now)
+
+	if len(report.Weeks) != 2 || report.Weeks[0].WeekNumber != 1 || report.Weeks[1].WeekNumber != 2 {
+		t.Fatalf("Weeks = %+v, want weeks 1 and 2 in order", report.Weeks)
+	}
+	// Two sessions of one 5-rep set against 2x5 each; 0% and -10% off the load
+	// and 0 and +1 RPE
+	want := models.AdherenceTotals{
+		SessionsPrescribed: 2, SessionsOnTime: 2,
+		SetsPrescribed: 4, SetsPerformed: 2, RepsPrescribed: 20, RepsPerformed: 10,
+		SetCompletionPct: float(50), RepCompletionPct: float(50),
+		LoadDeviationPct: float(-5), RPEDeviation: float(0.5),
+	}
+	if !reflect.DeepEqual(report.Weeks[0].Summary, want) {
+		t.Errorf("week 1 summary = %+v, want %+v", report.Weeks[0].Summary, want)
+	}
+	// Week 2's only session is still inside its grace day
+	if !reflect.DeepEqual(report.Weeks[1].Summary, models.AdherenceTotals{}) {
+		t.Errorf("week 2 summary = %+v, want nothing due", report.Weeks[1].Summary)
+	}
+	if !reflect.DeepEqual(report.Summary, want) {
+		t.Errorf("summary = %+v, want %+v", report.Summary, want)
+	}
+}
+
+// describe dereferences the optional fields so failures are readable
+func describe(e models.ExerciseAdherence) map[string]interface{} {
+	value := func(v *float64) interface{} {
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	var repsPrescribed interface{}
+	if e.RepsPrescribed != nil {
+		repsPrescribed = *e.RepsPrescribed
+	}
+	return map[string]interface{}{
+		"sets":          [2]int{e.SetsPerformed, e.SetsPrescribed},
+		"reps":          [2]interface{}{e.RepsPerformed, repsPrescribed},
+		"target_load":   value(e.TargetLoadKg),
+		"average_load":  value(e.AverageLoadKg),
+		"deviation_kg":  value(e.LoadDeviationKg),
+		"deviation_pct": value(e.LoadDeviationPct),
+		"target_rpe":    value(e.TargetRPE),
+		"average_rpe":   value(e.AverageRPE),
+		"rpe_deviation": value(e.RPEDeviation),
+	}
+}
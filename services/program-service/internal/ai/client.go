@@ -0,0 +1,496 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/ai/prompts"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// Client runs the AI features (program generation, chat, form analysis) on top
+// of a Provider, picking the model for each feature from its ModelRouting
+type Client struct {
+	provider Provider
+	models   ModelRouting
+	prompts  *prompts.Registry
+	usage    UsageRecorder
+}
+
+// Reply is a model response together with the prompt templates that produced it
+type Reply struct {
+	Content         string
+	Model           string
+	PromptTemplates []string
+}
+
+type ChatCompletionRequest struct {
+	// Feature is not sent upstream; it lets providers such as FakeProvider tell
+	// the AI features apart
+	Feature     models.AIFeature `json:"-"`
+	Model       string           `json:"model"`
+	Messages    []Message        `json:"messages"`
+	Temperature float32          `json:"temperature,omitempty"`
+	MaxTokens   int              `json:"max_tokens,omitempty"`
+	Stream      bool             `json:"stream,omitempty"`
+	// StreamOptions asks for a final usage chunk on streamed completions
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	Tools         []Tool         `json:"tools,omitempty"`
+	// ResponseFormat constrains the output to JSON (optionally a JSON schema)
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// Extra holds request fields this package doesn't model, such as top_p or
+	// seed, so requests relayed by the OpenAI-compatible API keep them. Callers
+	// decide which fields are safe to pass on; fields set above take precedence.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// MarshalJSON adds Extra to the modelled fields
+func (r ChatCompletionRequest) MarshalJSON() ([]byte, error) {
+	type request ChatCompletionRequest
+	data, err := json.Marshal(request(r))
+	if err != nil || len(r.Extra) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range r.Extra {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	// Parts replaces Content with a list of text and image parts, for vision models
+	Parts []ContentPart `json:"-"`
+}
+
+// ContentPart is an OpenAI multimodal content part, either text or an image
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: "text", Text: text}
+}
+
+// JPEGPart inlines a JPEG image as a base64 data URL
+func JPEGPart(image []byte) ContentPart {
+	return ContentPart{
+		Type:     "image_url",
+		ImageURL: &ImageURL{URL: "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(image)},
+	}
+}
+
+// MarshalJSON sends Parts as the content array when set, and Content otherwise
+func (m Message) MarshalJSON() ([]byte, error) {
+	type message Message
+	if len(m.Parts) == 0 {
+		return json.Marshal(message(m))
+	}
+
+	return json.Marshal(struct {
+		message
+		Content []ContentPart `json:"content"`
+	}{message(m), m.Parts})
+}
+
+// UnmarshalJSON accepts content either as a string or as a list of parts
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type message Message
+	var raw struct {
+		message
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = Message(raw.message)
+	content := bytes.TrimSpace(raw.Content)
+	switch {
+	case len(content) == 0 || string(content) == "null":
+		return nil
+	case content[0] == '[':
+		return json.Unmarshal(content, &m.Parts)
+	default:
+		return json.Unmarshal(content, &m.Content)
+	}
+}
+
+// Text returns the message's text, including the text of its parts
+func (m Message) Text() string {
+	if len(m.Parts) == 0 {
+		return m.Content
+	}
+
+	var texts []string
+	for _, part := range m.Parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+type ChatCompletionResponse struct {
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Created int64    `json:"created"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+	// Raw is the response as the backend sent it, when the provider has one
+	Raw json.RawMessage `json:"-"`
+}
+
+type Choice struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// ChatCompletionChunk is a single server-sent event from a streaming completion
+type ChatCompletionChunk struct {
+	ID      string        `json:"id"`
+	Object  string        `json:"object"`
+	Created int64         `json:"created"`
+	Model   string        `json:"model"`
+	Choices []ChunkChoice `json:"choices"`
+	// Usage is only set on the final chunk when include_usage is requested
+	Usage *Usage `json:"usage,omitempty"`
+	// Raw is the chunk as the backend sent it, when the provider has one
+	Raw json.RawMessage `json:"-"`
+}
+
+type ChunkChoice struct {
+	Index        int          `json:"index"`
+	Delta        MessageDelta `json:"delta"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+type MessageDelta struct {
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func NewClient(provider Provider, routing ModelRouting, promptRegistry *prompts.Registry) *Client {
+	return &Client{
+		provider: provider,
+		models:   routing,
+		prompts:  promptRegistry,
+	}
+}
+
+// StreamGenerateProgram generates a program with each content delta passed to
+// onDelta as it arrives. The full response is returned once the stream completes.
+func (c *Client) StreamGenerateProgram(ctx context.Context, req models.GenerateProgramRequest, athleteProfile string, coachFeedback string, subjectKey string, onDelta func(string)) (*Reply, error) {
+	messages, templateIDs, err := c.buildProgramGenerationMessages(req, athleteProfile, coachFeedback, subjectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	response, model, err := c.chatCompletionStream(ctx, models.AIFeatureGenerate, messages, c.models.Generation, onDelta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate program: %w", err)
+	}
+
+	return &Reply{Content: response, Model: model, PromptTemplates: templateIDs}, nil
+}
+
+func (c *Client) buildProgramGenerationMessages(req models.GenerateProgramRequest, athleteProfile string, coachFeedback string, subjectKey string) ([]Message, []string, error) {
+	coachFeedbackSection := ""
+	if coachFeedback != "" {
+		coachFeedbackSection = "\n\nCoach Feedback to Incorporate:\n" + coachFeedback
+	}
+
+	system, err := c.prompts.Render("program_generation_system", subjectKey, map[string]string{
+		"athlete_profile":        athleteProfile,
+		"coach_feedback_section": coachFeedbackSection,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	injuriesSection := ""
+	if req.Injuries != nil && *req.Injuries != "" {
+		injuriesSection = fmt.Sprintf("\n\nInjuries/Limitations: %s", *req.Injuries)
+	}
+
+	preferencesSection := ""
+	if req.Preferences != nil && *req.Preferences != "" {
+		preferencesSection = fmt.Sprintf("\n\nPreferences: %s", *req.Preferences)
+	}
+
+	user, err := c.prompts.Render("program_generation_user", subjectKey, map[string]string{
+		"weeks":               strconv.Itoa(req.WeeksDuration),
+		"experience_level":    req.ExperienceLevel,
+		"training_days":       strconv.Itoa(req.TrainingDays),
+		"goals":               req.Goals,
+		"squat_kg":            fmt.Sprintf("%.1f", getFloatValue(req.CurrentMaxes.SquatKg)),
+		"bench_kg":            fmt.Sprintf("%.1f", getFloatValue(req.CurrentMaxes.BenchKg)),
+		"deadlift_kg":         fmt.Sprintf("%.1f", getFloatValue(req.CurrentMaxes.DeadliftKg)),
+		"injuries_section":    injuriesSection,
+		"preferences_section": preferencesSection,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	messages := []Message{
+		{Role: "system", Content: system.Text},
+		{Role: "user", Content: user.Text},
+	}
+
+	return messages, []string{system.TemplateID, user.TemplateID}, nil
+}
+
+func (c *Client) ChatWithAI(ctx context.Context, conversation models.AIConversation, newMessage string, athleteProfile string, coachFeedback string) (*Reply, error) {
+	messages, templateIDs, err := c.buildChatMessages(conversation, newMessage, athleteProfile, coachFeedback)
+	if err != nil {
+		return nil, err
+	}
+
+	message, model, err := c.chatCompletion(ctx, models.AIFeatureChat, messages, c.models.Chat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI response: %w", err)
+	}
+
+	return &Reply{Content: message.Content, Model: model, PromptTemplates: templateIDs}, nil
+}
+
+// StreamChatWithAI is ChatWithAI with each content delta passed to onDelta as it
+// arrives. The full response is returned once the stream completes.
+func (c *Client) StreamChatWithAI(ctx context.Context, conversation models.AIConversation, newMessage string, athleteProfile string, coachFeedback string, onDelta func(string)) (*Reply, error) {
+	messages, templateIDs, err := c.buildChatMessages(conversation, newMessage, athleteProfile, coachFeedback)
+	if err != nil {
+		return nil, err
+	}
+
+	response, model, err := c.chatCompletionStream(ctx, models.AIFeatureChat, messages, c.models.Chat, onDelta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI response: %w", err)
+	}
+
+	return &Reply{Content: response, Model: model, PromptTemplates: templateIDs}, nil
+}
+
+// CoachSystemPrompt renders the coach persona used by chat, for callers such as
+// the OpenAI-compatible proxy that build their own message list
+func (c *Client) CoachSystemPrompt(subjectKey string, athleteProfile string, coachFeedback string) (prompts.Rendered, error) {
+	coachFeedbackSection := ""
+	if coachFeedback != "" {
+		coachFeedbackSection = "\n\nRecent Coach Feedback:\n" + coachFeedback
+	}
+
+	system, err := c.prompts.Render("program_chat_system", subjectKey, map[string]string{
+		"athlete_profile":        athleteProfile,
+		"coach_feedback_section": coachFeedbackSection,
+	})
+	if err != nil {
+		return prompts.Rendered{}, fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	return system, nil
+}
+
+// GenerateConversationTitle names a conversation after its first exchange
+func (c *Client) GenerateConversationTitle(ctx context.Context, subjectKey string, userMessage string, assistantMessage string) (string, error) {
+	// The opening exchange is plenty to name a thread; don't pay for long replies
+	// Cut by runes so a multi-byte character isn't split into invalid UTF-8
+	if runes := []rune(assistantMessage); len(runes) > 1000 {
+		assistantMessage = string(runes[:1000])
+	}
+
+	prompt, err := c.prompts.Render("conversation_title", subjectKey, map[string]string{
+		"user_message":      userMessage,
+		"assistant_message": assistantMessage,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	message, _, err := c.createChatCompletion(ctx, ChatCompletionRequest{
+		Feature:     models.AIFeatureChat,
+		Model:       c.models.Chat,
+		Messages:    []Message{{Role: "user", Content: prompt.Text}},
+		Temperature: 0.3,
+		MaxTokens:   20,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate title: %w", err)
+	}
+
+	title := strings.Trim(strings.TrimSpace(message.Content), "\"'.")
+	if title == "" {
+		return "", fmt.Errorf("model returned an empty title")
+	}
+
+	return title, nil
+}
+
+func (c *Client) buildChatMessages(conversation models.AIConversation, newMessage string, athleteProfile string, coachFeedback string) ([]Message, []string, error) {
+	system, err := c.CoachSystemPrompt(conversation.AthleteID.String(), athleteProfile, coachFeedback)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	messages := []Message{
+		{Role: "system", Content: system.Text},
+	}
+
+	// Add conversation history
+	for _, msg := range conversation.Messages {
+		messages = append(messages, Message{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+
+	// Add new user message
+	messages = append(messages, Message{
+		Role:    "user",
+		Content: newMessage,
+	})
+
+	return messages, []string{system.TemplateID}, nil
+}
+
+func (c *Client) chatCompletion(ctx context.Context, feature models.AIFeature, messages []Message, model string) (*Message, string, error) {
+	return c.createChatCompletion(ctx, ChatCompletionRequest{
+		Feature:     feature,
+		Model:       model,
+		Messages:    messages,
+		Temperature: 0.7,
+		MaxTokens:   2000,
+	})
+}
+
+// createChatCompletion sends a non-streaming request, records its usage and
+// returns the first choice's message, including any tool calls, along with the
+// model that answered, which may differ from the one requested
+func (c *Client) createChatCompletion(ctx context.Context, req ChatCompletionRequest) (*Message, string, error) {
+	response, err := c.provider.ChatCompletion(ctx, req)
+	if err != nil {
+		return nil, req.Model, err
+	}
+
+	model := response.Model
+	if model == "" {
+		model = req.Model
+	}
+	c.recordUsage(ctx, model, response.Usage)
+
+	if len(response.Choices) == 0 {
+		return nil, model, fmt.Errorf("no choices in response")
+	}
+
+	return &response.Choices[0].Message, model, nil
+}
+
+// chatCompletionStream requests a streamed completion and calls onDelta with each
+// content fragment. It returns the concatenated content and the model that
+// served it once the stream completes.
+func (c *Client) chatCompletionStream(ctx context.Context, feature models.AIFeature, messages []Message, model string, onDelta func(string)) (string, string, error) {
+	message, usedModel, err := c.createChatCompletionStream(ctx, ChatCompletionRequest{
+		Feature:     feature,
+		Model:       model,
+		Messages:    messages,
+		Temperature: 0.7,
+		MaxTokens:   2000,
+	}, onDelta)
+	if message == nil {
+		return "", usedModel, err
+	}
+	return message.Content, usedModel, err
+}
+
+// createChatCompletionStream is createChatCompletion over a stream. Content
+// fragments are passed to onDelta as they arrive and tool call fragments are
+// assembled, so the returned message matches what the non-streaming call gives.
+func (c *Client) createChatCompletionStream(ctx context.Context, req ChatCompletionRequest, onDelta func(string)) (*Message, string, error) {
+	req.Stream = true
+	req.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	var full strings.Builder
+	var toolCalls []ToolCall
+	var usage *Usage
+	usedModel := req.Model
+
+	err := c.provider.StreamChatCompletion(ctx, req, func(chunk ChatCompletionChunk) {
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if chunk.Model != "" {
+			usedModel = chunk.Model
+		}
+
+		for _, choice := range chunk.Choices {
+			toolCalls = appendToolCallDeltas(toolCalls, choice.Delta.ToolCalls)
+
+			if choice.Delta.Content == "" {
+				continue
+			}
+			full.WriteString(choice.Delta.Content)
+			if onDelta != nil {
+				onDelta(choice.Delta.Content)
+			}
+		}
+	})
+
+	// Record before checking for errors so partially streamed answers still count
+	if usage != nil {
+		c.recordUsage(ctx, usedModel, *usage)
+	}
+
+	message := &Message{Role: "assistant", Content: full.String(), ToolCalls: toolCalls}
+	if err != nil {
+		return message, usedModel, err
+	}
+
+	// An empty stream means the provider failed without reporting an error
+	if full.Len() == 0 && len(toolCalls) == 0 {
+		return nil, usedModel, fmt.Errorf("stream ended without content")
+	}
+
+	return message, usedModel, nil
+}
+
+func getFloatValue(ptr *float64) float64 {
+	if ptr == nil {
+		return 0
+	}
+	return *ptr
+}
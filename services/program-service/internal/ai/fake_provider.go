@@ -0,0 +1,256 @@
+package ai
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+//go:embed fixtures/*.json
+var defaultFixtures embed.FS
+
+// FakeResponse is one scripted completion. Responses are tried in file order and
+// the first unused one whose Match fits the request is returned; a response is
+// used once unless Repeat is set, so a sequence such as tool call then answer can
+// be scripted with two entries.
+type FakeResponse struct {
+	Name      string     `json:"name"`
+	Match     FakeMatch  `json:"match"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// Error makes the provider fail the call, e.g. to exercise fallbacks
+	Error  string `json:"error,omitempty"`
+	Repeat bool   `json:"repeat,omitempty"`
+	// Usage defaults to an estimate of roughly four characters per token
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// FakeMatch selects requests; empty fields match anything
+type FakeMatch struct {
+	Feature models.AIFeature `json:"feature,omitempty"`
+	Model   string           `json:"model,omitempty"`
+	// Contains is matched against the content of the last message
+	Contains string `json:"contains,omitempty"`
+}
+
+type fakeFixtures struct {
+	Responses []FakeResponse `json:"responses"`
+}
+
+// FakeProvider replays canned responses from fixtures so the AI features can run
+// without a model backend. It is deterministic: the same requests in the same
+// order always get the same responses.
+type FakeProvider struct {
+	mu        sync.Mutex
+	responses []FakeResponse
+	used      []bool
+}
+
+func NewFakeProvider(responses []FakeResponse) *FakeProvider {
+	return &FakeProvider{
+		responses: responses,
+		used:      make([]bool, len(responses)),
+	}
+}
+
+// NewDefaultFakeProvider uses the fixtures embedded from internal/ai/fixtures
+func NewDefaultFakeProvider() (*FakeProvider, error) {
+	entries, err := defaultFixtures.ReadDir("fixtures")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var responses []FakeResponse
+	for _, entry := range entries {
+		data, err := defaultFixtures.ReadFile("fixtures/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", entry.Name(), err)
+		}
+		parsed, err := parseFixtures(entry.Name(), data)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, parsed...)
+	}
+
+	return NewFakeProvider(responses), nil
+}
+
+// NewFakeProviderFromPath loads fixtures from a JSON file, or from every *.json
+// file in a directory in name order
+func NewFakeProviderFromPath(path string) (*FakeProvider, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list fixtures: %w", err)
+		}
+		sort.Strings(files)
+	}
+
+	var responses []FakeResponse
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", file, err)
+		}
+		parsed, err := parseFixtures(file, data)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, parsed...)
+	}
+
+	return NewFakeProvider(responses), nil
+}
+
+func parseFixtures(name string, data []byte) ([]FakeResponse, error) {
+	var fixtures fakeFixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", name, err)
+	}
+	return fixtures.Responses, nil
+}
+
+func (p *FakeProvider) ChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	scripted, err := p.next(req)
+	if err != nil {
+		return nil, err
+	}
+
+	finishReason := "stop"
+	if len(scripted.ToolCalls) > 0 {
+		finishReason = "tool_calls"
+	}
+
+	return &ChatCompletionResponse{
+		ID:     "fake-" + req.Model,
+		Object: "chat.completion",
+		Model:  req.Model,
+		Choices: []Choice{
+			{
+				Message: Message{
+					Role:      "assistant",
+					Content:   scripted.Content,
+					ToolCalls: scripted.ToolCalls,
+				},
+				FinishReason: finishReason,
+			},
+		},
+		Usage: fakeUsage(req, scripted),
+	}, nil
+}
+
+// StreamChatCompletion streams the scripted content a word at a time, followed
+// by any scripted tool calls with their arguments split across two chunks
+func (p *FakeProvider) StreamChatCompletion(ctx context.Context, req ChatCompletionRequest, onChunk func(ChatCompletionChunk)) error {
+	scripted, err := p.next(req)
+	if err != nil {
+		return err
+	}
+
+	for _, word := range strings.SplitAfter(scripted.Content, " ") {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		onChunk(ChatCompletionChunk{
+			Object:  "chat.completion.chunk",
+			Model:   req.Model,
+			Choices: []ChunkChoice{{Delta: MessageDelta{Content: word}}},
+		})
+	}
+
+	for i, call := range scripted.ToolCalls {
+		head := ToolCallDelta{Index: i, ID: call.ID, Type: call.Type}
+		head.Function.Name = call.Function.Name
+		head.Function.Arguments = call.Function.Arguments[:len(call.Function.Arguments)/2]
+		tail := ToolCallDelta{Index: i}
+		tail.Function.Arguments = call.Function.Arguments[len(call.Function.Arguments)/2:]
+
+		for _, delta := range []ToolCallDelta{head, tail} {
+			onChunk(ChatCompletionChunk{
+				Object:  "chat.completion.chunk",
+				Model:   req.Model,
+				Choices: []ChunkChoice{{Delta: MessageDelta{ToolCalls: []ToolCallDelta{delta}}}},
+			})
+		}
+	}
+
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		usage := fakeUsage(req, scripted)
+		onChunk(ChatCompletionChunk{
+			Object: "chat.completion.chunk",
+			Model:  req.Model,
+			Usage:  &usage,
+		})
+	}
+
+	return nil
+}
+
+func (p *FakeProvider) next(req ChatCompletionRequest) (*FakeResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	last := ""
+	if len(req.Messages) > 0 {
+		last = req.Messages[len(req.Messages)-1].Text()
+	}
+
+	for i := range p.responses {
+		r := &p.responses[i]
+		if p.used[i] || !r.Match.matches(req, last) {
+			continue
+		}
+		if !r.Repeat {
+			p.used[i] = true
+		}
+		if r.Error != "" {
+			return nil, fmt.Errorf("fake provider: %s", r.Error)
+		}
+		return r, nil
+	}
+
+	return nil, fmt.Errorf("fake provider: no fixture matches %s request for model %q", req.Feature, req.Model)
+}
+
+func (m FakeMatch) matches(req ChatCompletionRequest, lastMessage string) bool {
+	if m.Feature != "" && m.Feature != req.Feature {
+		return false
+	}
+	if m.Model != "" && m.Model != req.Model {
+		return false
+	}
+	return m.Contains == "" || strings.Contains(lastMessage, m.Contains)
+}
+
+func fakeUsage(req ChatCompletionRequest, scripted *FakeResponse) Usage {
+	if scripted.Usage != nil {
+		return *scripted.Usage
+	}
+
+	promptChars := 0
+	for _, msg := range req.Messages {
+		promptChars += len(msg.Text())
+	}
+
+	usage := Usage{
+		PromptTokens:     promptChars / 4,
+		CompletionTokens: len(scripted.Content) / 4,
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage
+}
@@ -0,0 +1,349 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/ai/prompts"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+var testRouting = ModelRouting{
+	Chat:           "chat-model",
+	Generation:     "generation-model",
+	Vision:         "vision-model",
+	VisionFallback: "vision-fallback-model",
+}
+
+func newTestClient(t *testing.T, provider Provider) *Client {
+	t.Helper()
+	registry, err := prompts.LoadDefault()
+	if err != nil {
+		t.Fatalf("LoadDefault: %v", err)
+	}
+	return NewClient(provider, testRouting, registry)
+}
+
+func newDefaultTestClient(t *testing.T) *Client {
+	t.Helper()
+	provider, err := NewDefaultFakeProvider()
+	if err != nil {
+		t.Fatalf("NewDefaultFakeProvider: %v", err)
+	}
+	return newTestClient(t, provider)
+}
+
+// defaultFixture returns the content of the embedded fixture with the given name
+func defaultFixture(t *testing.T, name string) string {
+	t.Helper()
+	provider, err := NewDefaultFakeProvider()
+	if err != nil {
+		t.Fatalf("NewDefaultFakeProvider: %v", err)
+	}
+	for _, r := range provider.responses {
+		if r.Name == name {
+			return r.Content
+		}
+	}
+	t.Fatalf("no fixture named %q", name)
+	return ""
+}
+
+type recordedUsage struct {
+	usages []*models.AIUsage
+}
+
+func (r *recordedUsage) RecordAIUsage(usage *models.AIUsage) error {
+	r.usages = append(r.usages, usage)
+	return nil
+}
+
+func TestGenerateProgramStructured(t *testing.T) {
+	client := newDefaultTestClient(t)
+
+	result, err := client.GenerateProgramStructured(context.Background(), models.GenerateProgramRequest{
+		Goals:           "Peak for a meet",
+		ExperienceLevel: "intermediate",
+		TrainingDays:    3,
+		WeeksDuration:   4,
+	}, "Athlete profile", "", uuid.New().String())
+	if err != nil {
+		t.Fatalf("GenerateProgramStructured: %v", err)
+	}
+
+	if result.Program == nil {
+		t.Fatal("expected a program")
+	}
+	if result.Model != testRouting.Generation {
+		t.Errorf("Model = %q, want %q", result.Model, testRouting.Generation)
+	}
+	if len(result.Attempts) != 1 || !result.Attempts[0].Succeeded {
+		t.Errorf("Attempts = %+v, want one successful attempt", result.Attempts)
+	}
+	if len(result.PromptTemplates) != 2 {
+		t.Errorf("PromptTemplates = %v, want the system and user templates", result.PromptTemplates)
+	}
+}
+
+func TestGenerateProgramStructuredRepairsInvalidProgram(t *testing.T) {
+	client := newTestClient(t, NewFakeProvider([]FakeResponse{
+		{Name: "invalid", Match: FakeMatch{Feature: models.AIFeatureGenerate, Contains: "Generate a 4-week"}, Content: `{"phases": []}`},
+		{Name: "repaired", Match: FakeMatch{Feature: models.AIFeatureGenerate, Contains: "failed validation"}, Content: defaultFixture(t, "program repair")},
+	}))
+
+	result, err := client.GenerateProgramStructured(context.Background(), models.GenerateProgramRequest{
+		Goals:           "Build strength",
+		ExperienceLevel: "novice",
+		TrainingDays:    3,
+		WeeksDuration:   4,
+	}, "Athlete profile", "", uuid.New().String())
+	if err != nil {
+		t.Fatalf("GenerateProgramStructured: %v", err)
+	}
+
+	if len(result.Attempts) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(result.Attempts))
+	}
+	if result.Attempts[0].Succeeded || len(result.Attempts[0].ValidationErrors) == 0 {
+		t.Errorf("first attempt = %+v, want validation errors", result.Attempts[0])
+	}
+	if !result.Attempts[1].Succeeded {
+		t.Errorf("second attempt failed: %v", result.Attempts[1].ValidationErrors)
+	}
+}
+
+func TestGenerateProgramStructuredGivesUp(t *testing.T) {
+	client := newTestClient(t, NewFakeProvider([]FakeResponse{
+		{Name: "always invalid", Match: FakeMatch{Feature: models.AIFeatureGenerate}, Content: "not json", Repeat: true},
+	}))
+
+	result, err := client.GenerateProgramStructured(context.Background(), models.GenerateProgramRequest{
+		Goals:           "Build strength",
+		ExperienceLevel: "novice",
+		TrainingDays:    3,
+		WeeksDuration:   4,
+	}, "Athlete profile", "", uuid.New().String())
+	if err != ErrInvalidProgram {
+		t.Fatalf("err = %v, want ErrInvalidProgram", err)
+	}
+	if len(result.Attempts) != maxGenerationAttempts {
+		t.Errorf("got %d attempts, want %d", len(result.Attempts), maxGenerationAttempts)
+	}
+}
+
+func TestChatWithAI(t *testing.T) {
+	client := newDefaultTestClient(t)
+	recorder := &recordedUsage{}
+	client.SetUsageRecorder(recorder)
+
+	userID := uuid.New()
+	ctx := WithUsage(context.Background(), userID, models.AIFeatureChat)
+	reply, err := client.ChatWithAI(ctx, models.AIConversation{AthleteID: userID}, "How heavy should I squat?", "Athlete profile", "")
+	if err != nil {
+		t.Fatalf("ChatWithAI: %v", err)
+	}
+
+	if want := defaultFixture(t, "chat"); reply.Content != want {
+		t.Errorf("Content = %q, want %q", reply.Content, want)
+	}
+	if reply.Model != testRouting.Chat {
+		t.Errorf("Model = %q, want %q", reply.Model, testRouting.Chat)
+	}
+	if len(recorder.usages) != 1 {
+		t.Fatalf("recorded %d usages, want 1", len(recorder.usages))
+	}
+	if usage := recorder.usages[0]; usage.UserID != userID || usage.Model != testRouting.Chat || usage.TotalTokens == 0 {
+		t.Errorf("usage = %+v, want tokens recorded for %s on %s", usage, userID, testRouting.Chat)
+	}
+}
+
+func TestGenerateConversationTitle(t *testing.T) {
+	client := newDefaultTestClient(t)
+
+	title, err := client.GenerateConversationTitle(context.Background(), uuid.New().String(), "How heavy should I squat?", "Keep it at RPE 7-8.")
+	if err != nil {
+		t.Fatalf("GenerateConversationTitle: %v", err)
+	}
+	if title != "Squat Programming Check-In" {
+		t.Errorf("title = %q, want the fixture title", title)
+	}
+}
+
+type stubExecutor struct {
+	calls []string
+}
+
+func (e *stubExecutor) Tools() []Tool {
+	return []Tool{NewFunctionTool("get_recent_sessions", "Recent training sessions", map[string]interface{}{
+		"limit": map[string]interface{}{"type": "integer"},
+	})}
+}
+
+func (e *stubExecutor) Execute(ctx context.Context, name string, arguments string) (string, error) {
+	e.calls = append(e.calls, name+" "+arguments)
+	if name != "get_recent_sessions" {
+		return "", fmt.Errorf("unknown tool %s", name)
+	}
+	return `{"sessions": [{"exercise": "Back Squat", "top_set_kg": 180}]}`, nil
+}
+
+func TestChatWithTools(t *testing.T) {
+	client := newTestClient(t, NewFakeProvider([]FakeResponse{
+		{
+			Name:  "tool call",
+			Match: FakeMatch{Feature: models.AIFeatureChat, Contains: "last squat"},
+			ToolCalls: []ToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: FunctionCall{Name: "get_recent_sessions", Arguments: `{"limit": 1}`},
+			}},
+		},
+		{
+			Name:    "answer",
+			Match:   FakeMatch{Feature: models.AIFeatureChat, Contains: "top_set_kg"},
+			Content: "Your last top squat was 180kg.",
+		},
+	}))
+	executor := &stubExecutor{}
+
+	reply, err := client.ChatWithTools(context.Background(), models.AIConversation{AthleteID: uuid.New()}, "What was my last squat?", "Athlete profile", "", executor)
+	if err != nil {
+		t.Fatalf("ChatWithTools: %v", err)
+	}
+
+	if reply.Content != "Your last top squat was 180kg." {
+		t.Errorf("Content = %q, want the answer after the tool call", reply.Content)
+	}
+	if reply.Model != testRouting.Chat {
+		t.Errorf("Model = %q, want %q", reply.Model, testRouting.Chat)
+	}
+	if len(executor.calls) != 1 || executor.calls[0] != `get_recent_sessions {"limit": 1}` {
+		t.Errorf("tool calls = %v, want one get_recent_sessions call", executor.calls)
+	}
+}
+
+func TestStreamChatWithTools(t *testing.T) {
+	client := newTestClient(t, NewFakeProvider([]FakeResponse{
+		{
+			Name:  "tool call",
+			Match: FakeMatch{Feature: models.AIFeatureChat, Contains: "last squat"},
+			ToolCalls: []ToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: FunctionCall{Name: "get_recent_sessions", Arguments: `{"limit": 1}`},
+			}},
+		},
+		{
+			Name:    "answer",
+			Match:   FakeMatch{Feature: models.AIFeatureChat, Contains: "top_set_kg"},
+			Content: "Your last top squat was 180kg.",
+		},
+	}))
+	recorder := &recordedUsage{}
+	client.SetUsageRecorder(recorder)
+	executor := &stubExecutor{}
+
+	userID := uuid.New()
+	ctx := WithUsage(context.Background(), userID, models.AIFeatureChat)
+	var streamed string
+	reply, err := client.StreamChatWithTools(ctx, models.AIConversation{AthleteID: userID}, "What was my last squat?", "Athlete profile", "", executor, func(delta string) {
+		streamed += delta
+	})
+	if err != nil {
+		t.Fatalf("StreamChatWithTools: %v", err)
+	}
+
+	if reply.Content != "Your last top squat was 180kg." || streamed != reply.Content {
+		t.Errorf("Content = %q, streamed %q, want the answer after the tool call", reply.Content, streamed)
+	}
+	if len(executor.calls) != 1 || executor.calls[0] != `get_recent_sessions {"limit": 1}` {
+		t.Errorf("tool calls = %v, want the streamed call reassembled", executor.calls)
+	}
+	if len(recorder.usages) != 2 {
+		t.Errorf("recorded %d usages, want one per round", len(recorder.usages))
+	}
+}
+
+func TestChatWithToolsStopsAfterMaxRounds(t *testing.T) {
+	client := newTestClient(t, NewFakeProvider([]FakeResponse{
+		{
+			Name:  "endless tool calls",
+			Match: FakeMatch{Feature: models.AIFeatureChat},
+			ToolCalls: []ToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: FunctionCall{Name: "get_recent_sessions", Arguments: `{}`},
+			}},
+			Repeat: true,
+		},
+	}))
+	executor := &stubExecutor{}
+
+	_, err := client.ChatWithTools(context.Background(), models.AIConversation{AthleteID: uuid.New()}, "What was my last squat?", "Athlete profile", "", executor)
+	if err == nil || !strings.Contains(err.Error(), "tool rounds") {
+		t.Fatalf("err = %v, want the tool round limit", err)
+	}
+	if len(executor.calls) != maxToolRounds+1 {
+		t.Errorf("got %d tool calls, want %d", len(executor.calls), maxToolRounds+1)
+	}
+}
+
+func testFormInput() FormAnalysisInput {
+	return FormAnalysisInput{
+		Exercise:        "Back Squat",
+		DurationSeconds: 4,
+		Frames: []Frame{
+			{TimestampSeconds: 0.5, JPEG: []byte{0xff, 0xd8}},
+			{TimestampSeconds: 2, JPEG: []byte{0xff, 0xd8}},
+		},
+	}
+}
+
+func TestAnalyzeForm(t *testing.T) {
+	client := newDefaultTestClient(t)
+
+	result, err := client.AnalyzeForm(context.Background(), testFormInput(), uuid.New().String())
+	if err != nil {
+		t.Fatalf("AnalyzeForm: %v", err)
+	}
+
+	if result.Score != 7.5 || result.Confidence != 0.7 {
+		t.Errorf("score/confidence = %v/%v, want 7.5/0.7", result.Score, result.Confidence)
+	}
+	if result.Model != testRouting.Vision {
+		t.Errorf("Model = %q, want %q", result.Model, testRouting.Vision)
+	}
+	if len(result.KeyFrames) != len(FormPhases) {
+		t.Errorf("KeyFrames = %+v, want one per phase", result.KeyFrames)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Type != "depth" || result.Issues[0].Severity == nil || *result.Issues[0].Severity != 0.6 {
+		t.Errorf("Issues = %+v, want one medium depth issue", result.Issues)
+	}
+}
+
+func TestAnalyzeFormFallsBackToVisionFallback(t *testing.T) {
+	client := newTestClient(t, NewFakeProvider([]FakeResponse{
+		{Name: "vision down", Match: FakeMatch{Feature: models.AIFeatureFormAnalysis, Model: testRouting.Vision}, Error: "model unavailable"},
+		{Name: "fallback", Match: FakeMatch{Feature: models.AIFeatureFormAnalysis, Model: testRouting.VisionFallback}, Content: defaultFixture(t, "form analysis")},
+	}))
+
+	result, err := client.AnalyzeForm(context.Background(), testFormInput(), uuid.New().String())
+	if err != nil {
+		t.Fatalf("AnalyzeForm: %v", err)
+	}
+	if result.Model != testRouting.VisionFallback {
+		t.Errorf("Model = %q, want %q", result.Model, testRouting.VisionFallback)
+	}
+}
+
+func TestFakeProviderReportsUnmatchedRequests(t *testing.T) {
+	provider := NewFakeProvider(nil)
+
+	_, err := provider.ChatCompletion(context.Background(), ChatCompletionRequest{Feature: models.AIFeatureChat, Model: "chat-model"})
+	if err == nil || !strings.Contains(err.Error(), "no fixture matches") {
+		t.Fatalf("err = %v, want no fixture matches", err)
+	}
+}
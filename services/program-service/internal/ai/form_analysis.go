@@ -0,0 +1,198 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// FormPhases are the key positions the model locates in a lift
+var FormPhases = []string{"setup", "bottom", "lockout"}
+
+// issueSeverity maps the model's severity labels onto FormIssue's 0-1 scale
+var issueSeverity = map[string]float64{
+	"low":    0.3,
+	"medium": 0.6,
+	"high":   0.9,
+}
+
+// Frame is a still from a lift video
+type Frame struct {
+	TimestampSeconds float64
+	JPEG             []byte
+}
+
+// FormAnalysisInput is what the vision model sees: frames sampled across the lift
+// plus what the athlete said they were lifting
+type FormAnalysisInput struct {
+	Exercise        string
+	Load            *float64
+	RPE             *float64
+	DurationSeconds float64
+	Frames          []Frame
+}
+
+// FormAnalysisResult is the parsed model output
+type FormAnalysisResult struct {
+	Score           float64
+	Confidence      float64
+	Summary         string
+	KeyFrames       []models.FormKeyFrame
+	Issues          []models.FormIssue
+	Model           string
+	PromptTemplates []string
+}
+
+// formAnalysisOutput is the JSON the form_analysis prompts ask for
+type formAnalysisOutput struct {
+	Score      float64            `json:"score"`
+	Confidence float64            `json:"confidence"`
+	Summary    string             `json:"summary"`
+	KeyFrames  map[string]float64 `json:"key_frames"`
+	Issues     []struct {
+		Type             string   `json:"type"`
+		Description      string   `json:"description"`
+		TimestampSeconds *float64 `json:"timestamp_seconds"`
+		Severity         string   `json:"severity"`
+	} `json:"issues"`
+}
+
+// AnalyzeForm sends the frames to the vision model and parses its review into
+// timestamped issues. VisionFallback is tried when the vision model fails.
+func (c *Client) AnalyzeForm(ctx context.Context, input FormAnalysisInput, subjectKey string) (*FormAnalysisResult, error) {
+	if len(input.Frames) == 0 {
+		return nil, fmt.Errorf("no frames to analyze")
+	}
+
+	system, err := c.prompts.Render("form_analysis_system", subjectKey, map[string]string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	user, err := c.prompts.Render("form_analysis_user", subjectKey, map[string]string{
+		"exercise_name": input.Exercise,
+		"load":          formatOptional(input.Load, "not given"),
+		"rpe":           formatOptional(input.RPE, "not given"),
+		"duration":      fmt.Sprintf("%.1f", input.DurationSeconds),
+		"frame_count":   fmt.Sprintf("%d", len(input.Frames)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	parts := []ContentPart{TextPart(user.Text)}
+	for i, frame := range input.Frames {
+		parts = append(parts,
+			TextPart(fmt.Sprintf("Frame %d at %.2fs:", i+1, frame.TimestampSeconds)),
+			JPEGPart(frame.JPEG),
+		)
+	}
+
+	messages := []Message{
+		{Role: "system", Content: system.Text},
+		{Role: "user", Parts: parts},
+	}
+
+	model := c.models.Vision
+	reply, answeredBy, err := c.formAnalysisCompletion(ctx, messages, model)
+	if err != nil && c.models.VisionFallback != "" && c.models.VisionFallback != model {
+		log.Warn().Err(err).Str("model", model).Msg("Vision model failed, retrying with fallback model")
+		model = c.models.VisionFallback
+		reply, answeredBy, err = c.formAnalysisCompletion(ctx, messages, model)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze form: %w", err)
+	}
+
+	result, err := ParseFormAnalysis(reply.Content, input.DurationSeconds)
+	if err != nil {
+		return nil, err
+	}
+	result.Model = answeredBy
+	result.PromptTemplates = []string{system.TemplateID, user.TemplateID}
+	return result, nil
+}
+
+func (c *Client) formAnalysisCompletion(ctx context.Context, messages []Message, model string) (*Message, string, error) {
+	return c.createChatCompletion(ctx, ChatCompletionRequest{
+		Feature:        models.AIFeatureFormAnalysis,
+		Model:          model,
+		Messages:       messages,
+		Temperature:    0.2,
+		MaxTokens:      1500,
+		ResponseFormat: &ResponseFormat{Type: "json_object"},
+	})
+}
+
+// ParseFormAnalysis validates the model's JSON, clamping scores into range and
+// dropping timestamps that fall outside the video
+func ParseFormAnalysis(raw string, durationSeconds float64) (*FormAnalysisResult, error) {
+	var out formAnalysisOutput
+	if err := json.Unmarshal([]byte(extractJSONObject(raw)), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse form analysis: %w", err)
+	}
+	if strings.TrimSpace(out.Summary) == "" {
+		return nil, fmt.Errorf("failed to parse form analysis: summary is empty")
+	}
+
+	result := &FormAnalysisResult{
+		Score:      math.Max(0, math.Min(10, out.Score)),
+		Confidence: math.Max(0, math.Min(1, out.Confidence)),
+		Summary:    strings.TrimSpace(out.Summary),
+		KeyFrames:  []models.FormKeyFrame{},
+		Issues:     []models.FormIssue{},
+	}
+
+	inVideo := func(ts float64) bool {
+		return ts >= 0 && (durationSeconds <= 0 || ts <= durationSeconds)
+	}
+
+	for _, phase := range FormPhases {
+		if ts, ok := out.KeyFrames[phase]; ok && inVideo(ts) {
+			result.KeyFrames = append(result.KeyFrames, models.FormKeyFrame{Phase: phase, TimestampSeconds: ts})
+		}
+	}
+
+	for _, issue := range out.Issues {
+		if strings.TrimSpace(issue.Description) == "" {
+			continue
+		}
+
+		formIssue := models.FormIssue{
+			Type:        strings.ToLower(strings.TrimSpace(issue.Type)),
+			Description: strings.TrimSpace(issue.Description),
+		}
+		if issue.TimestampSeconds != nil && inVideo(*issue.TimestampSeconds) {
+			ts := *issue.TimestampSeconds
+			formIssue.TimestampSeconds = &ts
+		}
+		if severity, ok := issueSeverity[strings.ToLower(issue.Severity)]; ok {
+			formIssue.Severity = &severity
+		}
+		result.Issues = append(result.Issues, formIssue)
+	}
+
+	return result, nil
+}
+
+// extractJSONObject strips any prose or code fences around the JSON object
+func extractJSONObject(raw string) string {
+	start := strings.Index(raw, "{")
+	end := strings.LastIndex(raw, "}")
+	if start == -1 || end < start {
+		return raw
+	}
+	return raw[start : end+1]
+}
+
+func formatOptional(value *float64, missing string) string {
+	if value == nil {
+		return missing
+	}
+	return fmt.Sprintf("%g", *value)
+}
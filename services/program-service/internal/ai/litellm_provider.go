@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// APIError is a non-200 response from LiteLLM. Body is truncated and meant for
+// logs, not for clients.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d", e.StatusCode)
+}
+
+// LiteLLMProvider talks to a LiteLLM proxy over its OpenAI-compatible API
+type LiteLLMProvider struct {
+	baseURL    string
+	httpClient *http.Client
+	// streamClient has no overall timeout; streams are bounded by the caller's context
+	streamClient *http.Client
+}
+
+func NewLiteLLMProvider(baseURL string) *LiteLLMProvider {
+	return &LiteLLMProvider{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		streamClient: &http.Client{},
+	}
+}
+
+func (p *LiteLLMProvider) ChatCompletion(ctx context.Context, reqBody ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	reqBody.Stream = false
+	reqBody.StreamOptions = nil
+
+	resp, err := p.post(ctx, p.httpClient, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var response ChatCompletionResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	response.Raw = body
+
+	return &response, nil
+}
+
+func (p *LiteLLMProvider) StreamChatCompletion(ctx context.Context, reqBody ChatCompletionRequest, onChunk func(ChatCompletionChunk)) error {
+	reqBody.Stream = true
+
+	resp, err := p.post(ctx, p.streamClient, reqBody)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return nil
+		}
+
+		var chunk ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			log.Warn().Err(err).Msg("Skipping malformed stream chunk")
+			continue
+		}
+		chunk.Raw = json.RawMessage(data)
+
+		onChunk(chunk)
+	}
+
+	// Some providers close the connection without sending [DONE]
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return nil
+}
+
+func (p *LiteLLMProvider) post(ctx context.Context, client *http.Client, reqBody ChatCompletionRequest) (*http.Response, error) {
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/chat/completions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if reqBody.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(detail)}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"context"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// Models returns the per-feature model routing
+func (c *Client) Models() ModelRouting {
+	return c.models
+}
+
+// ProxyChatCompletion sends a request from the OpenAI-compatible API through the
+// provider and records its usage. The caller picks the model.
+func (c *Client) ProxyChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	req.Feature = models.AIFeatureOpenAICompat
+
+	response, err := c.provider.ChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	model := response.Model
+	if model == "" {
+		model = req.Model
+	}
+	c.recordUsage(ctx, model, response.Usage)
+
+	return response, nil
+}
+
+// ProxyStreamChatCompletion is ProxyChatCompletion for streamed requests. Each
+// chunk is passed to onChunk as it arrives, including the final usage chunk.
+func (c *Client) ProxyStreamChatCompletion(ctx context.Context, req ChatCompletionRequest, onChunk func(ChatCompletionChunk)) error {
+	req.Feature = models.AIFeatureOpenAICompat
+	req.Stream = true
+	// Ask for a final usage chunk so streamed calls are accounted too
+	req.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	var usage *Usage
+	usedModel := req.Model
+
+	err := c.provider.StreamChatCompletion(ctx, req, func(chunk ChatCompletionChunk) {
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if chunk.Model != "" {
+			usedModel = chunk.Model
+		}
+		onChunk(chunk)
+	})
+
+	// Record before checking for errors so partially streamed answers still count
+	if usage != nil {
+		c.recordUsage(ctx, usedModel, *usage)
+	}
+
+	return err
+}
@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+func TestProxyChatCompletion(t *testing.T) {
+	client := newDefaultTestClient(t)
+	recorder := &recordedUsage{}
+	client.SetUsageRecorder(recorder)
+
+	ctx := WithUsage(context.Background(), uuid.New(), models.AIFeatureOpenAICompat)
+	response, err := client.ProxyChatCompletion(ctx, ChatCompletionRequest{
+		Model:    "gpt-4o-mini",
+		Messages: []Message{{Role: "user", Content: "Hi coach"}},
+	})
+	if err != nil {
+		t.Fatalf("ProxyChatCompletion: %v", err)
+	}
+
+	if want := defaultFixture(t, "openai-compatible proxy"); response.Choices[0].Message.Content != want {
+		t.Errorf("Content = %q, want %q", response.Choices[0].Message.Content, want)
+	}
+	if len(recorder.usages) != 1 || recorder.usages[0].Feature != models.AIFeatureOpenAICompat || recorder.usages[0].Model != "gpt-4o-mini" {
+		t.Errorf("usages = %+v, want one openai_compat record for gpt-4o-mini", recorder.usages)
+	}
+}
+
+func TestProxyStreamChatCompletion(t *testing.T) {
+	client := newDefaultTestClient(t)
+	recorder := &recordedUsage{}
+	client.SetUsageRecorder(recorder)
+
+	var content string
+	sawUsage := false
+	ctx := WithUsage(context.Background(), uuid.New(), models.AIFeatureOpenAICompat)
+	err := client.ProxyStreamChatCompletion(ctx, ChatCompletionRequest{
+		Model:    "gpt-4o-mini",
+		Messages: []Message{{Role: "user", Content: "Hi coach"}},
+	}, func(chunk ChatCompletionChunk) {
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+		}
+		if chunk.Usage != nil {
+			sawUsage = true
+		}
+	})
+	if err != nil {
+		t.Fatalf("ProxyStreamChatCompletion: %v", err)
+	}
+
+	if want := defaultFixture(t, "openai-compatible proxy"); content != want {
+		t.Errorf("streamed %q, want %q", content, want)
+	}
+	if !sawUsage {
+		t.Error("expected the usage chunk to be relayed")
+	}
+	if len(recorder.usages) != 1 {
+		t.Errorf("recorded %d usages, want 1", len(recorder.usages))
+	}
+}
+
+func TestChatCompletionRequestKeepsExtraFields(t *testing.T) {
+	var req ChatCompletionRequest
+	body := []byte(`{"model": "gpt-4o", "temperature": 0.3, "top_p": 0.9, "messages": [
+		{"role": "user", "content": [{"type": "text", "text": "Rate my squat"}, {"type": "image_url", "image_url": {"url": "data:image/jpeg;base64,AA=="}}]}
+	]}`)
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if err := json.Unmarshal(body, &req.Extra); err != nil {
+		t.Fatalf("Unmarshal extra: %v", err)
+	}
+	delete(req.Extra, "messages")
+	req.Model = "gpt-4o-mini"
+
+	if parts := req.Messages[0].Parts; len(parts) != 2 || parts[1].ImageURL == nil {
+		t.Fatalf("Parts = %+v, want text and image parts", parts)
+	}
+	if req.Messages[0].Text() != "Rate my squat" {
+		t.Errorf("Text() = %q, want the text part", req.Messages[0].Text())
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var sent map[string]interface{}
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("Unmarshal sent: %v", err)
+	}
+	if sent["model"] != "gpt-4o-mini" {
+		t.Errorf("model = %v, want the modelled field to win", sent["model"])
+	}
+	if sent["top_p"] != 0.9 {
+		t.Errorf("top_p = %v, want it forwarded", sent["top_p"])
+	}
+	if messages, _ := sent["messages"].([]interface{}); len(messages) != 1 {
+		t.Errorf("messages = %v, want one message", sent["messages"])
+	}
+}
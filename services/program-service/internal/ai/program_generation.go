@@ -0,0 +1,181 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// maxGenerationAttempts bounds the initial attempt plus repair attempts
+const maxGenerationAttempts = 3
+
+// ErrInvalidProgram is returned when the model could not produce a program that
+// passes validation within maxGenerationAttempts
+var ErrInvalidProgram = errors.New("generated program failed validation")
+
+// ResponseFormat is the OpenAI response_format parameter
+type ResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+type JSONSchemaFormat struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict"`
+}
+
+// GenerationResult is the outcome of a schema-constrained generation. Attempts is
+// populated even when an error is returned so failures can be recorded.
+type GenerationResult struct {
+	Program     map[string]interface{}
+	RawResponse string
+	Model       string
+	Attempts    []models.ProgramGenerationAttempt
+	// PromptTemplates identifies the template versions the prompt was built from
+	PromptTemplates []string
+}
+
+// responseFormatFor uses strict JSON-schema output on models that support it and
+// falls back to plain JSON mode elsewhere. Strict mode gets the schema without
+// the bounds it can't express; ValidateProgram still enforces them.
+func responseFormatFor(model string) *ResponseFormat {
+	for _, prefix := range []string{"gpt-4o", "gpt-4.1", "o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return &ResponseFormat{
+				Type: "json_schema",
+				JSONSchema: &JSONSchemaFormat{
+					Name:   "powerlifting_program",
+					Schema: strictProgramSchema,
+					Strict: true,
+				},
+			}
+		}
+	}
+	return &ResponseFormat{Type: "json_object"}
+}
+
+// GenerateProgramStructured generates a program under the program schema,
+// feeding validation errors back to the model until it passes or attempts run out
+func (c *Client) GenerateProgramStructured(ctx context.Context, req models.GenerateProgramRequest, athleteProfile string, coachFeedback string, subjectKey string) (*GenerationResult, error) {
+	messages, templateIDs, err := c.buildProgramGenerationMessages(req, athleteProfile, coachFeedback, subjectKey)
+	if err != nil {
+		return nil, err
+	}
+	messages[len(messages)-1].Content += "\n\n" + schemaInstructions()
+
+	expect := ProgramExpectations{Weeks: req.WeeksDuration, DaysPerWeek: req.TrainingDays}
+	result, err := c.generateValidProgram(ctx, messages, expect)
+	result.PromptTemplates = templateIDs
+	return result, err
+}
+
+// RepairProgram asks the model to fix a program that failed validation, e.g. one
+// extracted from a chat conversation or a stream
+func (c *Client) RepairProgram(ctx context.Context, rawProgram string, validationErrors []string, expect ProgramExpectations, subjectKey string) (*GenerationResult, error) {
+	system, err := c.prompts.Render("program_repair_system", subjectKey, map[string]string{
+		"schema_instructions": schemaInstructions(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	messages := []Message{
+		{Role: "system", Content: system.Text},
+		{Role: "user", Content: repairPrompt(rawProgram, validationErrors)},
+	}
+
+	result, err := c.generateValidProgram(ctx, messages, expect)
+	result.PromptTemplates = []string{system.TemplateID}
+	return result, err
+}
+
+func (c *Client) generateValidProgram(ctx context.Context, messages []Message, expect ProgramExpectations) (*GenerationResult, error) {
+	model := c.models.Generation
+	result := &GenerationResult{Model: model}
+
+	for attempt := 1; attempt <= maxGenerationAttempts; attempt++ {
+		started := time.Now()
+		record := models.ProgramGenerationAttempt{
+			AttemptNumber: attempt,
+			Model:         model,
+			CreatedAt:     started,
+		}
+
+		reply, answeredBy, err := c.createChatCompletion(ctx, ChatCompletionRequest{
+			Feature:        models.AIFeatureGenerate,
+			Model:          model,
+			Messages:       messages,
+			Temperature:    0.4,
+			MaxTokens:      4000,
+			ResponseFormat: responseFormatFor(model),
+		})
+		record.DurationMs = time.Since(started).Milliseconds()
+		record.Model = answeredBy
+		result.Model = answeredBy
+
+		if err != nil {
+			errMsg := err.Error()
+			record.Error = &errMsg
+			result.Attempts = append(result.Attempts, record)
+			return result, fmt.Errorf("failed to generate program: %w", err)
+		}
+
+		record.RawResponse = reply.Content
+		result.RawResponse = reply.Content
+
+		program, parseErr := ParseProgram(reply.Content)
+		if parseErr != nil {
+			record.ValidationErrors = []string{parseErr.Error()}
+		} else {
+			record.ValidationErrors = ValidateProgram(program, expect)
+		}
+		record.Succeeded = len(record.ValidationErrors) == 0
+		result.Attempts = append(result.Attempts, record)
+
+		if record.Succeeded {
+			result.Program = program
+			return result, nil
+		}
+
+		log.Warn().
+			Int("attempt", attempt).
+			Strs("validation_errors", record.ValidationErrors).
+			Msg("Generated program failed validation")
+
+		messages = append(messages,
+			Message{Role: "assistant", Content: reply.Content},
+			Message{Role: "user", Content: repairPrompt("", record.ValidationErrors)},
+		)
+	}
+
+	return result, ErrInvalidProgram
+}
+
+func schemaInstructions() string {
+	schema, _ := json.MarshalIndent(ProgramSchema, "", "  ")
+	return "Return the program as a single JSON object matching this JSON schema:\n" + string(schema)
+}
+
+func repairPrompt(rawProgram string, validationErrors []string) string {
+	var b strings.Builder
+	if rawProgram != "" {
+		b.WriteString("Program JSON:\n")
+		b.WriteString(rawProgram)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("The program failed validation with these errors:\n")
+	for _, e := range validationErrors {
+		b.WriteString("- ")
+		b.WriteString(e)
+		b.WriteString("\n")
+	}
+	b.WriteString("\nReturn the complete corrected JSON object only.")
+	return b.String()
+}
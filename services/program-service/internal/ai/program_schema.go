@@ -0,0 +1,477 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProgramSchema is the JSON schema every AI-generated program must satisfy. It
+// mirrors the structure described in the chat system prompt.
+var ProgramSchema = map[string]interface{}{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"phases", "weeklyWorkouts", "summary", AppliedFeedbackField},
+	"properties": map[string]interface{}{
+		"phases": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{"name", "weeks", "focus", "characteristics"},
+				"properties": map[string]interface{}{
+					"name":            map[string]interface{}{"type": "string"},
+					"weeks":           map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+					"focus":           map[string]interface{}{"type": "string"},
+					"characteristics": map[string]interface{}{"type": "string"},
+				},
+			},
+		},
+		"weeklyWorkouts": map[string]interface{}{
+			"type":     "array",
+			"minItems": 1,
+			"items": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{"week", "workouts"},
+				"properties": map[string]interface{}{
+					"week": map[string]interface{}{"type": "integer", "minimum": 1},
+					"workouts": map[string]interface{}{
+						"type":     "array",
+						"minItems": 1,
+						"items": map[string]interface{}{
+							"type":                 "object",
+							"additionalProperties": false,
+							"required":             []string{"day", "name", "groups", "exercises"},
+							"properties": map[string]interface{}{
+								"day":  map[string]interface{}{"type": "integer", "minimum": 1},
+								"name": map[string]interface{}{"type": "string"},
+								"groups": map[string]interface{}{
+									"type":        "array",
+									"description": "Supersets, circuits, EMOMs and clusters in the workout; empty when every exercise is done on its own",
+									"items": map[string]interface{}{
+										"type":                 "object",
+										"additionalProperties": false,
+										"required":             []string{"label", "type", "rounds", "restSeconds", "intervalSeconds", "notes"},
+										"properties": map[string]interface{}{
+											"label":           map[string]interface{}{"type": "string", "description": "Short label such as A; exercises reference it in their group field"},
+											"type":            map[string]interface{}{"type": "string", "enum": validGroupTypes},
+											"rounds":          map[string]interface{}{"type": "integer", "minimum": 1},
+											"restSeconds":     map[string]interface{}{"type": []string{"integer", "null"}, "description": "Rest after each round"},
+											"intervalSeconds": map[string]interface{}{"type": []string{"integer", "null"}, "description": "EMOM interval, or rest between the mini-sets of a cluster"},
+											"notes":           map[string]interface{}{"type": "string"},
+										},
+									},
+								},
+								"exercises": map[string]interface{}{
+									"type":     "array",
+									"minItems": 1,
+									"items": map[string]interface{}{
+										"type":                 "object",
+										"additionalProperties": false,
+										"required":             []string{"name", "liftType", "sets", "reps", "intensity", "rpe", "notes", "group", "velocityLossCutoff"},
+										"properties": map[string]interface{}{
+											"name":      map[string]interface{}{"type": "string"},
+											"liftType":  map[string]interface{}{"type": "string", "enum": validLiftTypes},
+											"sets":      map[string]interface{}{"type": "integer", "minimum": 1},
+											"reps":      map[string]interface{}{"type": "string"},
+											"intensity": map[string]interface{}{"type": "string"},
+											"rpe":       map[string]interface{}{"type": []string{"number", "null"}},
+											"notes":     map[string]interface{}{"type": "string"},
+											"group": map[string]interface{}{
+												"type":        []string{"string", "null"},
+												"description": "Label of the group the exercise belongs to, or null. For grouped exercises sets counts the sets per round.",
+											},
+											"velocityLossCutoff": map[string]interface{}{
+												"type":        []string{"number", "null"},
+												"description": "End each set once bar speed drops this many percent below the set's fastest rep, for athletes with a velocity tracker, or null.",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"summary": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []string{"totalWeeks", "trainingDaysPerWeek", "peakWeek", "competitionWeek"},
+			"properties": map[string]interface{}{
+				"totalWeeks":          map[string]interface{}{"type": "integer", "minimum": 1},
+				"trainingDaysPerWeek": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 7},
+				"peakWeek":            map[string]interface{}{"type": []string{"integer", "null"}},
+				"competitionWeek":     map[string]interface{}{"type": []string{"integer", "null"}},
+			},
+		},
+		AppliedFeedbackField: map[string]interface{}{
+			"type":        "array",
+			"description": "References such as [F1] of the coach feedback items the program acts on; empty when there are none",
+			"items":       map[string]interface{}{"type": "string"},
+		},
+	},
+}
+
+// strictUnsupported are the schema keywords OpenAI's strict structured outputs
+// reject. ValidateProgram checks the constraints they carry instead.
+var strictUnsupported = []string{
+	"minItems", "maxItems", "uniqueItems", "contains",
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "pattern", "format",
+	"minProperties", "maxProperties", "patternProperties",
+}
+
+// strictProgramSchema is ProgramSchema in the subset strict mode accepts
+var strictProgramSchema = StrictSchema(ProgramSchema)
+
+// StrictSchema returns a copy of a JSON schema that strict structured outputs
+// accept: unsupported keywords are dropped, every object is closed with
+// additionalProperties false and lists all its properties as required, and a
+// property that was optional becomes nullable instead. The schema passed in is
+// left untouched.
+func StrictSchema(schema map[string]interface{}) map[string]interface{} {
+	strict := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		strict[key] = value
+	}
+	for _, keyword := range strictUnsupported {
+		delete(strict, keyword)
+	}
+
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		strict["items"] = StrictSchema(items)
+	}
+
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return strict
+	}
+
+	required := make(map[string]bool)
+	if names, ok := schema["required"].([]string); ok {
+		for _, name := range names {
+			required[name] = true
+		}
+	}
+
+	strictProperties := make(map[string]interface{}, len(properties))
+	names := make([]string, 0, len(properties))
+	for name, value := range properties {
+		property, ok := value.(map[string]interface{})
+		if !ok {
+			strictProperties[name] = value
+			names = append(names, name)
+			continue
+		}
+		property = StrictSchema(property)
+		if !required[name] {
+			property["type"] = nullable(property["type"])
+		}
+		strictProperties[name] = property
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	strict["properties"] = strictProperties
+	strict["required"] = names
+	strict["additionalProperties"] = false
+	return strict
+}
+
+// nullable adds "null" to a schema type
+func nullable(schemaType interface{}) interface{} {
+	switch t := schemaType.(type) {
+	case string:
+		if t == "null" {
+			return t
+		}
+		return []string{t, "null"}
+	case []string:
+		for _, v := range t {
+			if v == "null" {
+				return t
+			}
+		}
+		return append(append([]string{}, t...), "null")
+	}
+	return schemaType
+}
+
+// AppliedFeedbackField lists the coach feedback a generated program cites. It is
+// removed before the program is saved.
+const AppliedFeedbackField = "appliedFeedback"
+
+var validLiftTypes = []string{"squat", "bench", "deadlift", "accessory"}
+
+var validGroupTypes = []string{"superset", "circuit", "emom", "cluster"}
+
+// ProgramExpectations are the request parameters a generated program is checked against
+type ProgramExpectations struct {
+	Weeks       int
+	DaysPerWeek int
+}
+
+// ValidateProgram checks that a program has a usable weeklyWorkouts tree. It
+// returns one human-readable message per problem so they can be fed back to the
+// model; an empty slice means the program is valid.
+func ValidateProgram(program map[string]interface{}, expect ProgramExpectations) []string {
+	var errs []string
+	addf := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	if _, ok := program["phases"].([]interface{}); !ok {
+		addf("phases must be an array")
+	}
+
+	summary, ok := program["summary"].(map[string]interface{})
+	if !ok {
+		addf("summary must be an object")
+	} else {
+		if total, ok := asInt(summary["totalWeeks"]); !ok || total < 1 {
+			addf("summary.totalWeeks must be a positive integer")
+		}
+		if raw, present := summary["trainingDaysPerWeek"]; present {
+			if days, ok := asInt(raw); !ok || days < 1 || days > 7 {
+				addf("summary.trainingDaysPerWeek must be an integer from 1 to 7")
+			}
+		}
+	}
+
+	weeks, ok := program["weeklyWorkouts"].([]interface{})
+	if !ok || len(weeks) == 0 {
+		addf("weeklyWorkouts must be a non-empty array")
+		return errs
+	}
+
+	if expect.Weeks > 0 && len(weeks) != expect.Weeks {
+		addf("weeklyWorkouts has %d weeks, expected %d", len(weeks), expect.Weeks)
+	}
+
+	seenWeeks := make(map[int]bool)
+	for i, w := range weeks {
+		path := fmt.Sprintf("weeklyWorkouts[%d]", i)
+		week, ok := w.(map[string]interface{})
+		if !ok {
+			addf("%s must be an object", path)
+			continue
+		}
+
+		weekNum, ok := asInt(week["week"])
+		if !ok || weekNum < 1 {
+			addf("%s.week must be an integer starting at 1", path)
+		} else if seenWeeks[weekNum] {
+			addf("%s.week %d is duplicated", path, weekNum)
+		} else {
+			seenWeeks[weekNum] = true
+		}
+
+		workouts, ok := week["workouts"].([]interface{})
+		if !ok || len(workouts) == 0 {
+			addf("%s.workouts must be a non-empty array", path)
+			continue
+		}
+		if expect.DaysPerWeek > 0 && len(workouts) > expect.DaysPerWeek {
+			addf("%s has %d workouts, at most %d training days allowed", path, len(workouts), expect.DaysPerWeek)
+		}
+
+		for j, wo := range workouts {
+			woPath := fmt.Sprintf("%s.workouts[%d]", path, j)
+			workout, ok := wo.(map[string]interface{})
+			if !ok {
+				addf("%s must be an object", woPath)
+				continue
+			}
+
+			if day, ok := asInt(workout["day"]); !ok || day < 1 || day > 7 {
+				addf("%s.day must be an integer from 1 to 7", woPath)
+			}
+
+			exercises, ok := workout["exercises"].([]interface{})
+			if !ok || len(exercises) == 0 {
+				addf("%s.exercises must be a non-empty array", woPath)
+				continue
+			}
+
+			groupTypes := validateGroups(workout["groups"], woPath, addf)
+			groupSizes := make(map[string]int)
+
+			for k, ex := range exercises {
+				exPath := fmt.Sprintf("%s.exercises[%d]", woPath, k)
+				exercise, ok := ex.(map[string]interface{})
+				if !ok {
+					addf("%s must be an object", exPath)
+					continue
+				}
+				if name, _ := exercise["name"].(string); strings.TrimSpace(name) == "" {
+					addf("%s.name is required", exPath)
+				}
+				if lt, _ := exercise["liftType"].(string); !isValidLiftType(lt) {
+					addf("%s.liftType must be one of %s", exPath, strings.Join(validLiftTypes, ", "))
+				}
+				if sets, ok := asInt(exercise["sets"]); !ok || sets < 1 {
+					addf("%s.sets must be a positive integer", exPath)
+				}
+				if !hasReps(exercise["reps"]) {
+					addf("%s.reps is required", exPath)
+				}
+				if cutoff, ok := exercise["velocityLossCutoff"].(float64); ok && (cutoff <= 0 || cutoff >= 100) {
+					addf("%s.velocityLossCutoff must be a percentage between 0 and 100", exPath)
+				}
+				if label, _ := exercise["group"].(string); label != "" {
+					if _, ok := groupTypes[label]; !ok {
+						addf("%s.group %q is not one of the workout's groups", exPath, label)
+					}
+					groupSizes[label]++
+				}
+			}
+
+			for label, groupType := range groupTypes {
+				size := groupSizes[label]
+				if size == 0 {
+					addf("%s group %q has no exercises", woPath, label)
+				} else if size < 2 && (groupType == "superset" || groupType == "circuit") {
+					addf("%s group %q is a %s and needs at least two exercises", woPath, label, groupType)
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateGroups checks a workout's groups and returns their types by label.
+// Groups are optional so programs saved before they existed stay valid.
+func validateGroups(raw interface{}, woPath string, addf func(string, ...interface{})) map[string]string {
+	types := make(map[string]string)
+	if raw == nil {
+		return types
+	}
+
+	groups, ok := raw.([]interface{})
+	if !ok {
+		addf("%s.groups must be an array", woPath)
+		return types
+	}
+
+	for i, g := range groups {
+		gPath := fmt.Sprintf("%s.groups[%d]", woPath, i)
+		group, ok := g.(map[string]interface{})
+		if !ok {
+			addf("%s must be an object", gPath)
+			continue
+		}
+
+		label, _ := group["label"].(string)
+		groupType, _ := group["type"].(string)
+		if strings.TrimSpace(label) == "" {
+			addf("%s.label is required", gPath)
+		} else if _, dup := types[label]; dup {
+			addf("%s.label %q is duplicated", gPath, label)
+		}
+		if !isValidGroupType(groupType) {
+			addf("%s.type must be one of %s", gPath, strings.Join(validGroupTypes, ", "))
+		}
+		if rounds, ok := asInt(group["rounds"]); !ok || rounds < 1 {
+			addf("%s.rounds must be a positive integer", gPath)
+		}
+		if groupType == "emom" {
+			if interval, ok := asInt(group["intervalSeconds"]); !ok || interval < 1 {
+				addf("%s.intervalSeconds is required for an emom", gPath)
+			}
+		}
+
+		if label != "" {
+			types[label] = groupType
+		}
+	}
+
+	return types
+}
+
+// ParseProgram extracts and decodes the program JSON from a model response,
+// tolerating code fences and surrounding prose. AppliedFeedbackField is dropped;
+// callers read citations from the raw response.
+func ParseProgram(response string) (map[string]interface{}, error) {
+	raw := strings.TrimSpace(response)
+
+	var program map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &program); err == nil {
+		delete(program, AppliedFeedbackField)
+		return program, nil
+	}
+
+	extracted := ExtractJSON(raw)
+	if extracted == "" {
+		return nil, fmt.Errorf("response does not contain a JSON object")
+	}
+	if err := json.Unmarshal([]byte(extracted), &program); err != nil {
+		return nil, fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	delete(program, AppliedFeedbackField)
+
+	return program, nil
+}
+
+// ExtractJSON pulls a program JSON object out of free text
+func ExtractJSON(response string) string {
+	// Try to find JSON in code blocks first (```json ... ```)
+	codeBlockPattern := regexp.MustCompile("(?s)```(?:json)?\\s*\\n?({[^`]+})\\s*\\n?```")
+	matches := codeBlockPattern.FindStringSubmatch(response)
+	if len(matches) > 1 {
+		return strings.TrimSpace(matches[1])
+	}
+
+	// Try to find standalone JSON object
+	jsonPattern := regexp.MustCompile(`(?s)\{[\s\S]*"phases"[\s\S]*"weeklyWorkouts"[\s\S]*"summary"[\s\S]*\}`)
+	match := jsonPattern.FindString(response)
+	if match != "" {
+		// Validate it's actual JSON
+		var test map[string]interface{}
+		if json.Unmarshal([]byte(match), &test) == nil {
+			return match
+		}
+	}
+
+	return ""
+}
+
+func isValidLiftType(lt string) bool {
+	for _, v := range validLiftTypes {
+		if lt == v {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidGroupType(gt string) bool {
+	for _, v := range validGroupTypes {
+		if gt == v {
+			return true
+		}
+	}
+	return false
+}
+
+func hasReps(v interface{}) bool {
+	switch r := v.(type) {
+	case string:
+		return strings.TrimSpace(r) != ""
+	case float64:
+		return r > 0
+	}
+	return false
+}
+
+func asInt(v interface{}) (int, bool) {
+	f, ok := v.(float64)
+	if !ok || f != float64(int(f)) {
+		return 0, false
+	}
+	return int(f), true
+}
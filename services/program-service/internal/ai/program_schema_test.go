@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestStrictSchema(t *testing.T) {
+	var walk func(path string, schema map[string]interface{})
+	walk = func(path string, schema map[string]interface{}) {
+		for _, keyword := range strictUnsupported {
+			if _, ok := schema[keyword]; ok {
+				t.Errorf("%s keeps %s", path, keyword)
+			}
+		}
+
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			walk(path+"[]", items)
+		}
+
+		properties, ok := schema["properties"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		if schema["additionalProperties"] != false {
+			t.Errorf("%s allows additional properties", path)
+		}
+		var names []string
+		for name, property := range properties {
+			names = append(names, name)
+			walk(path+"."+name, property.(map[string]interface{}))
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(schema["required"], names) {
+			t.Errorf("%s requires %v, want every property %v", path, schema["required"], names)
+		}
+	}
+	walk("program", strictProgramSchema)
+
+	// The full schema, used in prompts, keeps its bounds
+	weeklyWorkouts := ProgramSchema["properties"].(map[string]interface{})["weeklyWorkouts"].(map[string]interface{})
+	if weeklyWorkouts["minItems"] != 1 {
+		t.Errorf("ProgramSchema lost weeklyWorkouts.minItems")
+	}
+}
+
+func TestStrictSchemaMakesOptionalPropertiesNullable(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"name"},
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "minLength": 1},
+			"notes": map[string]interface{}{"type": "string"},
+			"rpe":   map[string]interface{}{"type": []string{"number", "null"}, "maximum": 10},
+		},
+	}
+
+	strict := StrictSchema(schema)
+	properties := strict["properties"].(map[string]interface{})
+
+	if !reflect.DeepEqual(strict["required"], []string{"name", "notes", "rpe"}) {
+		t.Errorf("required = %v, want every property", strict["required"])
+	}
+	if got := properties["name"].(map[string]interface{})["type"]; got != "string" {
+		t.Errorf("name type = %v, want string", got)
+	}
+	if got := properties["notes"].(map[string]interface{})["type"]; !reflect.DeepEqual(got, []string{"string", "null"}) {
+		t.Errorf("notes type = %v, want nullable", got)
+	}
+	if got := properties["rpe"].(map[string]interface{})["type"]; !reflect.DeepEqual(got, []string{"number", "null"}) {
+		t.Errorf("rpe type = %v, want it unchanged", got)
+	}
+	if _, ok := schema["properties"].(map[string]interface{})["name"].(map[string]interface{})["minLength"]; !ok {
+		t.Error("StrictSchema modified the schema passed in")
+	}
+}
+
+func TestValidateProgramChecksTrainingDays(t *testing.T) {
+	program, err := ParseProgram(defaultFixture(t, "program generation (4 weeks, 3 days)"))
+	if err != nil {
+		t.Fatalf("ParseProgram: %v", err)
+	}
+	if errs := ValidateProgram(program, ProgramExpectations{}); len(errs) != 0 {
+		t.Fatalf("fixture program failed validation: %v", errs)
+	}
+
+	program["summary"].(map[string]interface{})["trainingDaysPerWeek"] = float64(8)
+	errs := ValidateProgram(program, ProgramExpectations{})
+	if len(errs) != 1 || !strings.Contains(errs[0], "trainingDaysPerWeek") {
+		t.Errorf("errs = %v, want the training days bound", errs)
+	}
+}
@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/powerlifting-coach-app/program-service/internal/config"
+)
+
+// Provider sends chat completions to an LLM backend. Chat, program generation and
+// vision all go through it, so swapping the provider swaps the backend for every
+// AI feature.
+type Provider interface {
+	ChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error)
+	// StreamChatCompletion calls onChunk for each chunk until the stream ends
+	StreamChatCompletion(ctx context.Context, req ChatCompletionRequest, onChunk func(ChatCompletionChunk)) error
+}
+
+// ModelRouting picks the model used for each AI feature
+type ModelRouting struct {
+	Chat       string
+	Generation string
+	// Vision analyses form video frames; VisionFallback, when set, must also accept
+	// images and is tried when the vision model fails
+	Vision         string
+	VisionFallback string
+}
+
+// ModelRoutingFromConfig reads the per-feature models from config
+func ModelRoutingFromConfig(cfg *config.Config) ModelRouting {
+	return ModelRouting{
+		Chat:           cfg.AIChatModel,
+		Generation:     cfg.AIGenerationModel,
+		Vision:         cfg.AIVisionModel,
+		VisionFallback: cfg.AIVisionFallbackModel,
+	}
+}
+
+// NewProvider builds the provider selected by AI_PROVIDER: "litellm" (default)
+// or "fake", which replays fixtures and needs no network access
+func NewProvider(cfg *config.Config) (Provider, error) {
+	switch cfg.AIProvider {
+	case "", "litellm":
+		return NewLiteLLMProvider(cfg.LiteLLMEndpoint), nil
+	case "fake":
+		if cfg.AIFakeFixtures == "" {
+			return NewDefaultFakeProvider()
+		}
+		return NewFakeProviderFromPath(cfg.AIFakeFixtures)
+	default:
+		return nil, fmt.Errorf("unknown AI provider %q", cfg.AIProvider)
+	}
+}
@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// maxToolRounds caps how many times the model may call tools before it has to answer
+const maxToolRounds = 5
+
+// Tool is an OpenAI-style function definition offered to the model
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+type FunctionDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ToolCall is a function invocation requested by the model
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCallDelta is a fragment of a tool call in a streamed response. The first
+// fragment for an index carries the ID and name; later ones add to the arguments.
+type ToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+	} `json:"function"`
+}
+
+// appendToolCallDeltas merges streamed tool call fragments into calls
+func appendToolCallDeltas(calls []ToolCall, deltas []ToolCallDelta) []ToolCall {
+	for _, delta := range deltas {
+		if delta.Index < 0 {
+			continue
+		}
+		for len(calls) <= delta.Index {
+			calls = append(calls, ToolCall{Type: "function"})
+		}
+
+		call := &calls[delta.Index]
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Type != "" {
+			call.Type = delta.Type
+		}
+		call.Function.Name += delta.Function.Name
+		call.Function.Arguments += delta.Function.Arguments
+	}
+	return calls
+}
+
+// ToolExecutor runs tools on behalf of the model. Implementations are bound to a
+// single caller so every lookup runs with that caller's authorization scope.
+type ToolExecutor interface {
+	Tools() []Tool
+	Execute(ctx context.Context, name string, arguments string) (string, error)
+}
+
+// ChatWithTools is ChatWithAI with function calling. The model may call the
+// executor's tools for up to maxToolRounds rounds before giving its final answer.
+func (c *Client) ChatWithTools(ctx context.Context, conversation models.AIConversation, newMessage string, athleteProfile string, coachFeedback string, executor ToolExecutor) (*Reply, error) {
+	return c.chatWithTools(ctx, conversation, newMessage, athleteProfile, coachFeedback, executor, c.createChatCompletion)
+}
+
+// StreamChatWithTools is ChatWithTools with each round streamed, so content
+// deltas reach onDelta as they arrive while tool calls are run between rounds
+func (c *Client) StreamChatWithTools(ctx context.Context, conversation models.AIConversation, newMessage string, athleteProfile string, coachFeedback string, executor ToolExecutor, onDelta func(string)) (*Reply, error) {
+	return c.chatWithTools(ctx, conversation, newMessage, athleteProfile, coachFeedback, executor, func(ctx context.Context, req ChatCompletionRequest) (*Message, string, error) {
+		return c.createChatCompletionStream(ctx, req, onDelta)
+	})
+}
+
+// chatWithTools runs the tool rounds, sending each request through complete
+func (c *Client) chatWithTools(ctx context.Context, conversation models.AIConversation, newMessage string, athleteProfile string, coachFeedback string, executor ToolExecutor, complete func(context.Context, ChatCompletionRequest) (*Message, string, error)) (*Reply, error) {
+	messages, templateIDs, err := c.buildChatMessages(conversation, newMessage, athleteProfile, coachFeedback)
+	if err != nil {
+		return nil, err
+	}
+
+	guidance, err := c.prompts.Render("program_chat_tools", conversation.AthleteID.String(), map[string]string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render prompt: %w", err)
+	}
+	messages[0].Content += guidance.Text
+	templateIDs = append(templateIDs, guidance.TemplateID)
+
+	for round := 0; round <= maxToolRounds; round++ {
+		req := ChatCompletionRequest{
+			Feature:     models.AIFeatureChat,
+			Model:       c.models.Chat,
+			Messages:    messages,
+			Temperature: 0.7,
+			MaxTokens:   2000,
+		}
+		// On the last round withhold the tools so the model has to answer
+		if round < maxToolRounds {
+			req.Tools = executor.Tools()
+		}
+
+		reply, model, err := complete(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get AI response: %w", err)
+		}
+
+		if len(reply.ToolCalls) == 0 {
+			return &Reply{Content: reply.Content, Model: model, PromptTemplates: templateIDs}, nil
+		}
+
+		messages = append(messages, *reply)
+		for _, call := range reply.ToolCalls {
+			result, err := executor.Execute(ctx, call.Function.Name, call.Function.Arguments)
+			if err != nil {
+				log.Warn().Err(err).Str("tool", call.Function.Name).Msg("AI tool call failed")
+				result = fmt.Sprintf(`{"error": %q}`, err.Error())
+			}
+
+			messages = append(messages, Message{
+				Role:       "tool",
+				ToolCallID: call.ID,
+				Name:       call.Function.Name,
+				Content:    result,
+			})
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get AI response: exceeded %d tool rounds", maxToolRounds)
+}
+
+// NewFunctionTool builds a Tool with a JSON-schema object for its parameters
+func NewFunctionTool(name, description string, properties map[string]interface{}, required ...string) Tool {
+	params := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		params["required"] = required
+	}
+
+	return Tool{
+		Type: "function",
+		Function: FunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters:  params,
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// UsageRecorder persists the token usage of each model call
+type UsageRecorder interface {
+	RecordAIUsage(usage *models.AIUsage) error
+}
+
+type usageContextKey struct{}
+
+type usageTag struct {
+	userID  uuid.UUID
+	feature models.AIFeature
+}
+
+// WithUsage attributes every model call made with ctx to a user and feature.
+// Calls made with an untagged context are not recorded.
+func WithUsage(ctx context.Context, userID uuid.UUID, feature models.AIFeature) context.Context {
+	return context.WithValue(ctx, usageContextKey{}, usageTag{userID: userID, feature: feature})
+}
+
+// modelPrice is the USD price per 1K prompt and completion tokens
+type modelPrice struct {
+	prompt     float64
+	completion float64
+}
+
+// modelPricing is matched by longest prefix so dated model names such as
+// gpt-4o-2024-08-06 pick up the base model's price
+var modelPricing = map[string]modelPrice{
+	"gpt-3.5-turbo": {prompt: 0.0005, completion: 0.0015},
+	"gpt-4":         {prompt: 0.03, completion: 0.06},
+	"gpt-4-turbo":   {prompt: 0.01, completion: 0.03},
+	"gpt-4-vision":  {prompt: 0.01, completion: 0.03},
+	"gpt-4o":        {prompt: 0.0025, completion: 0.01},
+	"gpt-4o-mini":   {prompt: 0.00015, completion: 0.0006},
+	"gpt-4.1":       {prompt: 0.002, completion: 0.008},
+	"gpt-4.1-mini":  {prompt: 0.0004, completion: 0.0016},
+}
+
+// EstimateCost prices a call from the static pricing table. Unknown models are
+// reported as zero cost rather than guessed.
+func EstimateCost(model string, promptTokens, completionTokens int) float64 {
+	var price modelPrice
+	matched := ""
+	for prefix, p := range modelPricing {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			matched = prefix
+			price = p
+		}
+	}
+
+	return float64(promptTokens)/1000*price.prompt + float64(completionTokens)/1000*price.completion
+}
+
+// NewUsageRecord builds the usage row for one model call
+func NewUsageRecord(userID uuid.UUID, feature models.AIFeature, model string, usage Usage) *models.AIUsage {
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+
+	return &models.AIUsage{
+		ID:               uuid.New(),
+		UserID:           userID,
+		Feature:          feature,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      total,
+		EstimatedCostUSD: EstimateCost(model, usage.PromptTokens, usage.CompletionTokens),
+		CreatedAt:        time.Now(),
+	}
+}
+
+// SetUsageRecorder enables token accounting for calls made with a WithUsage context
+func (c *Client) SetUsageRecorder(recorder UsageRecorder) {
+	c.usage = recorder
+}
+
+func (c *Client) recordUsage(ctx context.Context, model string, usage Usage) {
+	if c.usage == nil {
+		return
+	}
+
+	tag, ok := ctx.Value(usageContextKey{}).(usageTag)
+	if !ok {
+		return
+	}
+
+	if err := c.usage.RecordAIUsage(NewUsageRecord(tag.userID, tag.feature, model, usage)); err != nil {
+		// Accounting must never fail the user's request
+		log.Error().Err(err).Str("user_id", tag.userID.String()).Msg("Failed to record AI usage")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,7 +25,7 @@ type CoachFeedback struct {
 	Priority      string    `json:"priority"`
 	Title         string    `json:"title"`
 	Content       string    `json:"content"`
-	IsIncorporated bool     `json:"is_incorporated"`
+	IsIncorporated bool     `json:"incorporated_by_ai"`
 	CreatedAt     time.Time `json:"created_at"`
 }
 
@@ -34,14 +36,34 @@ type FeedbackListResponse struct {
 	TotalCount int             `json:"total_count"`
 }
 
+// Scopes an athlete can grant a coach; see CoachingAssignment.HasScope
+const (
+	ScopeViewHistory = "view_history"
+	ScopeEditProgram = "edit_program"
+)
+
 type CoachingAssignment struct {
 	ID         uuid.UUID `json:"id"`
 	CoachID    uuid.UUID `json:"coach_id"`
 	AthleteID  uuid.UUID `json:"athlete_id"`
 	Status     string    `json:"status"`
+	Scopes     []string  `json:"scopes"`
 	StartDate  time.Time `json:"start_date"`
 }
 
+// HasScope reports whether the assignment is active and the athlete granted scope
+func (a CoachingAssignment) HasScope(scope string) bool {
+	if a.Status != "active" {
+		return false
+	}
+	for _, granted := range a.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
 type CoachingListResponse struct {
 	Assignments []CoachingAssignment `json:"assignments"`
 }
@@ -109,14 +131,55 @@ func (c *CoachClient) GetCoachAthletes(ctx context.Context, authToken string) ([
 	return assignmentsResp.Assignments, nil
 }
 
-func (c *CoachClient) HasCoachAccess(ctx context.Context, authToken string, coachID, athleteID uuid.UUID) (bool, error) {
+// RecordedBodyweight is a bodyweight a coach tracked for the athlete
+type RecordedBodyweight struct {
+	BodyweightKg float64 `json:"bodyweight_kg"`
+	RecordedOn   string  `json:"recorded_on"`
+}
+
+// GetBodyweightNear returns the caller's bodyweight recorded nearest to date,
+// or nil when none was ever recorded
+func (c *CoachClient) GetBodyweightNear(ctx context.Context, authToken string, date time.Time) (*RecordedBodyweight, error) {
+	url := fmt.Sprintf("%s/api/v1/athletes/bodyweight?date=%s", c.baseURL, date.Format("2006-01-02"))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", authToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coach service returned status %d", resp.StatusCode)
+	}
+
+	var bodyweight RecordedBodyweight
+	if err := json.NewDecoder(resp.Body).Decode(&bodyweight); err != nil {
+		return nil, err
+	}
+
+	return &bodyweight, nil
+}
+
+// HasCoachAccess reports whether the caller actively coaches the athlete with
+// the given scope granted
+func (c *CoachClient) HasCoachAccess(ctx context.Context, authToken string, coachID, athleteID uuid.UUID, scope string) (bool, error) {
 	assignments, err := c.GetCoachAthletes(ctx, authToken)
 	if err != nil {
 		return false, err
 	}
 
 	for _, assignment := range assignments {
-		if assignment.AthleteID == athleteID && assignment.Status == "active" {
+		if assignment.AthleteID == athleteID && assignment.HasScope(scope) {
 			return true, nil
 		}
 	}
@@ -124,20 +187,38 @@ func (c *CoachClient) HasCoachAccess(ctx context.Context, authToken string, coac
 	return false, nil
 }
 
-func FormatCoachFeedback(feedback []CoachFeedback) string {
-	if len(feedback) == 0 {
-		return ""
-	}
+// maxPromptFeedback caps how many pending feedback items go into a prompt
+const maxPromptFeedback = 5
 
-	result := "## Recent Coach Feedback\n\n"
-	for i, fb := range feedback {
-		if i >= 5 {
+// feedbackRefPattern matches the [F1], [F2]... references the model cites
+var feedbackRefPattern = regexp.MustCompile(`\[F(\d+)\]`)
+
+// CoachFeedbackPrompt is coach feedback formatted for a prompt. Items[i] is
+// labelled [F<i+1>] so the model can cite the items it acts on.
+type CoachFeedbackPrompt struct {
+	Text  string
+	Items []CoachFeedback
+}
+
+// FormatCoachFeedback formats the most recent feedback not yet incorporated by the AI
+func FormatCoachFeedback(feedback []CoachFeedback) CoachFeedbackPrompt {
+	var prompt CoachFeedbackPrompt
+	for _, fb := range feedback {
+		if len(prompt.Items) >= maxPromptFeedback {
 			break
 		}
-		if fb.IsIncorporated {
-			continue
+		if !fb.IsIncorporated {
+			prompt.Items = append(prompt.Items, fb)
 		}
+	}
+
+	if len(prompt.Items) == 0 {
+		return prompt
+	}
 
+	result := "## Recent Coach Feedback\n\n"
+	result += "Each item has a reference such as [F1]. Whenever your answer acts on an item, cite its reference.\n\n"
+	for i, fb := range prompt.Items {
 		priorityEmoji := ""
 		switch fb.Priority {
 		case "urgent":
@@ -150,10 +231,35 @@ func FormatCoachFeedback(feedback []CoachFeedback) string {
 			priorityEmoji = "💡"
 		}
 
-		result += fmt.Sprintf("### %s %s (%s)\n", priorityEmoji, fb.Title, fb.FeedbackType)
+		result += fmt.Sprintf("### [F%d] %s %s (%s)\n", i+1, priorityEmoji, fb.Title, fb.FeedbackType)
 		result += fb.Content + "\n"
 		result += fmt.Sprintf("*Priority: %s | Date: %s*\n\n", fb.Priority, fb.CreatedAt.Format("Jan 2, 2006"))
 	}
 
-	return result
+	prompt.Text = result
+	return prompt
+}
+
+// IncludedIDs returns the IDs of the feedback items in the prompt
+func (p CoachFeedbackPrompt) IncludedIDs() []string {
+	ids := make([]string, 0, len(p.Items))
+	for _, fb := range p.Items {
+		ids = append(ids, fb.ID.String())
+	}
+	return ids
+}
+
+// Cited returns the IDs of the items a model response cites, in citation order
+func (p CoachFeedbackPrompt) Cited(response string) []string {
+	var ids []string
+	seen := make(map[int]bool)
+	for _, match := range feedbackRefPattern.FindAllStringSubmatch(response, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n < 1 || n > len(p.Items) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		ids = append(ids, p.Items[n-1].ID.String())
+	}
+	return ids
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 	"strconv"
 
+	"github.com/google/uuid"
 	"github.com/powerlifting-coach-app/program-service/internal/models"
 	"github.com/tealeg/xlsx/v3"
 )
@@ -164,7 +165,7 @@ func (e *ExcelExporter) addSessionToSheet(sheet *xlsx.Sheet, session models.Trai
 
 	// Exercise headers
 	row = sheet.AddRow()
-	headers := []string{"Exercise", "Sets", "Reps", "Weight (kg)", "RPE", "Rest (sec)", "Notes"}
+	headers := []string{"Group", "Exercise", "Sets", "Reps", "Weight (kg)", "RPE", "Rest (sec)", "Notes"}
 	for _, header := range headers {
 		cell := row.AddCell()
 		cell.Value = header
@@ -177,6 +178,13 @@ func (e *ExcelExporter) addSessionToSheet(sheet *xlsx.Sheet, session models.Trai
 	for _, exercise := range session.Exercises {
 		row := sheet.AddRow()
 		
+		groupCell := row.AddCell()
+		if group := findGroup(session, exercise.GroupID); group != nil {
+			groupCell.Value = group.Label
+			if exercise.GroupPosition != nil {
+				groupCell.Value += strconv.Itoa(*exercise.GroupPosition)
+			}
+		}
 		row.AddCell().Value = exercise.ExerciseName
 		row.AddCell().Value = strconv.Itoa(exercise.TargetSets)
 		row.AddCell().Value = exercise.TargetReps
@@ -204,6 +212,15 @@ func (e *ExcelExporter) addSessionToSheet(sheet *xlsx.Sheet, session models.Trai
 		}
 	}
 
+	// Describe how each group is run
+	for _, group := range session.Groups {
+		row := sheet.AddRow()
+		labelCell := row.AddCell()
+		labelCell.Value = group.Label
+		labelCell.GetStyle().Font.Bold = true
+		row.AddCell().Value = describeGroup(group)
+	}
+
 	return nil
 }
 
@@ -273,4 +290,50 @@ func (e *ExcelExporter) getSessionsFromProgramData(program models.Program) []mod
 	// and create TrainingSession objects
 	
 	return sessions
-}
\ No newline at end of file
+}
+
+func findGroup(session models.TrainingSession, groupID *uuid.UUID) *models.ExerciseGroup {
+	if groupID == nil {
+		return nil
+	}
+	for i := range session.Groups {
+		if session.Groups[i].ID == *groupID {
+			return &session.Groups[i]
+		}
+	}
+	return nil
+}
+
+// describeGroup spells out how a group is run, e.g. "Superset, 3 rounds, 90s rest between rounds"
+func describeGroup(group models.ExerciseGroup) string {
+	var description string
+	switch group.GroupType {
+	case models.GroupTypeSuperset:
+		description = "Superset"
+	case models.GroupTypeCircuit:
+		description = "Circuit"
+	case models.GroupTypeEMOM:
+		description = "EMOM"
+	case models.GroupTypeCluster:
+		description = "Cluster"
+	default:
+		description = string(group.GroupType)
+	}
+
+	description += fmt.Sprintf(", %d rounds", group.Rounds)
+	if group.IntervalSeconds != nil {
+		if group.GroupType == models.GroupTypeCluster {
+			description += fmt.Sprintf(", %ds between mini-sets", *group.IntervalSeconds)
+		} else {
+			description += fmt.Sprintf(", every %ds", *group.IntervalSeconds)
+		}
+	}
+	if group.RestSeconds != nil {
+		description += fmt.Sprintf(", %ds rest between rounds", *group.RestSeconds)
+	}
+	if group.Notes != nil && *group.Notes != "" {
+		description += ". " + *group.Notes
+	}
+
+	return description
+}
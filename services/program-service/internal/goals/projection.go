@@ -0,0 +1,228 @@
+// Package goals tracks an athlete's estimated 1RM against their lift and total
+// goals and projects when the trend gets there.
+package goals
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/powerlifting-coach-app/program-service/internal/numeric"
+)
+
+const (
+	// Weeks of data a trend needs before it is projected
+	minWeeks = 3
+	// Projections further out than this are left without a date
+	horizonWeeks = 104
+	// The current e1RM is the best of this many recent weeks, so a deload or a
+	// missed week doesn't read as lost strength
+	recentWeeks = 4
+	// Quantiles of the pairwise slopes that bound the projection, an 80% band
+	lowerQuantile = 0.1
+	upperQuantile = 0.9
+)
+
+const week = 7 * 24 * time.Hour
+
+// Trend is a Theil-Sen line through weekly bests: its slope is the median of
+// the slopes between every pair of weeks, so one great or terrible week can't
+// drag it the way it would a least-squares fit. Low and High are the slower
+// and faster ends of those pairwise slopes.
+type Trend struct {
+	Start     time.Time
+	Intercept float64
+	Slope     float64
+	Low       float64
+	High      float64
+}
+
+// Fit fits a trend in kg per week. It returns false with fewer than minWeeks
+// weeks of data.
+func Fit(history []models.WeeklyE1RM) (Trend, bool) {
+	if len(history) < minWeeks {
+		return Trend{}, false
+	}
+
+	start := history[0].WeekStart
+	xs := make([]float64, len(history))
+	for i, point := range history {
+		xs[i] = float64(point.WeekStart.Sub(start)) / float64(week)
+	}
+
+	var slopes []float64
+	for i := range history {
+		for j := i + 1; j < len(history); j++ {
+			if xs[j] == xs[i] {
+				continue
+			}
+			slopes = append(slopes, (history[j].Estimated1RMKg-history[i].Estimated1RMKg)/(xs[j]-xs[i]))
+		}
+	}
+	if len(slopes) == 0 {
+		return Trend{}, false
+	}
+	sort.Float64s(slopes)
+	slope := quantile(slopes, 0.5)
+
+	offsets := make([]float64, len(history))
+	for i, point := range history {
+		offsets[i] = point.Estimated1RMKg - slope*xs[i]
+	}
+	sort.Float64s(offsets)
+
+	return Trend{
+		Start:     start,
+		Intercept: quantile(offsets, 0.5),
+		Slope:     slope,
+		Low:       quantile(slopes, lowerQuantile),
+		High:      quantile(slopes, upperQuantile),
+	}, true
+}
+
+// At is the trend's e1RM on a date
+func (t Trend) At(date time.Time) float64 {
+	return t.Intercept + t.Slope*float64(date.Sub(t.Start))/float64(week)
+}
+
+// Progress reports how far the athlete is from goalKg as of now and, given
+// enough history, when the trend reaches it and whether that is before the
+// competition
+func Progress(lift string, goalKg float64, history []models.WeeklyE1RM, competition *time.Time, now time.Time) models.GoalProgress {
+	progress := models.GoalProgress{
+		Lift:    lift,
+		GoalKg:  numeric.Round(goalKg, 1),
+		Status:  models.GoalStatusInsufficientData,
+		History: history,
+	}
+	if progress.History == nil {
+		progress.History = []models.WeeklyE1RM{}
+	}
+	if len(history) == 0 {
+		return progress
+	}
+
+	current := currentE1RM(history)
+	progress.CurrentE1RMKg = numeric.Ptr(numeric.Round(current, 1))
+	progress.RemainingKg = numeric.Ptr(numeric.Round(math.Max(goalKg-current, 0), 1))
+	if goalKg > 0 {
+		progress.PercentComplete = numeric.Ptr(numeric.Round(math.Min(current/goalKg, 1)*100, 1))
+	}
+	if current >= goalKg {
+		progress.Status = models.GoalStatusAchieved
+		return progress
+	}
+
+	trend, ok := Fit(history)
+	if !ok {
+		return progress
+	}
+
+	level := trend.At(now)
+	projection := &models.GoalProjection{
+		Date:         reachDate(goalKg, level, trend.Slope, now),
+		Earliest:     reachDate(goalKg, level, trend.High, now),
+		Latest:       reachDate(goalKg, level, trend.Low, now),
+		WeeklyGainKg: numeric.Round(trend.Slope, 2),
+	}
+	progress.Projection = projection
+
+	if competition == nil || competition.Before(now) {
+		progress.Status = models.GoalStatusNoCompetition
+		return progress
+	}
+
+	progress.ProjectedAtCompetitionKg = numeric.Ptr(numeric.Round(trend.At(*competition), 1))
+	switch {
+	case projection.Latest != nil && !projection.Latest.After(*competition):
+		progress.Status = models.GoalStatusOnTrack
+	case projection.Date != nil && !projection.Date.After(*competition):
+		progress.Status = models.GoalStatusPossible
+	default:
+		progress.Status = models.GoalStatusUnlikely
+	}
+
+	return progress
+}
+
+// Totals sums the three lifts' weekly bests into a weekly total. A lift not
+// trained in a week carries its last best forward, and weeks before all three
+// have been trained are left out.
+func Totals(history []models.WeeklyE1RM) []models.WeeklyE1RM {
+	latest := make(map[models.LiftType]float64)
+	totals := []models.WeeklyE1RM{}
+
+	for i := 0; i < len(history); {
+		weekStart := history[i].WeekStart
+		for ; i < len(history) && history[i].WeekStart.Equal(weekStart); i++ {
+			latest[history[i].LiftType] = history[i].Estimated1RMKg
+		}
+		if len(latest) < 3 {
+			continue
+		}
+
+		var total float64
+		for _, e1rm := range latest {
+			total += e1rm
+		}
+		totals = append(totals, models.WeeklyE1RM{
+			WeekStart:      weekStart,
+			Estimated1RMKg: numeric.Round(total, 1),
+		})
+	}
+
+	return totals
+}
+
+// Lift picks one lift's weeks out of the weekly bests of all three
+func Lift(history []models.WeeklyE1RM, liftType models.LiftType) []models.WeeklyE1RM {
+	weeks := []models.WeeklyE1RM{}
+	for _, point := range history {
+		if point.LiftType == liftType {
+			point.Estimated1RMKg = numeric.Round(point.Estimated1RMKg, 1)
+			weeks = append(weeks, point)
+		}
+	}
+	return weeks
+}
+
+func currentE1RM(history []models.WeeklyE1RM) float64 {
+	last := history[len(history)-1].WeekStart
+	var best float64
+	for _, point := range history {
+		if last.Sub(point.WeekStart) < recentWeeks*week {
+			best = math.Max(best, point.Estimated1RMKg)
+		}
+	}
+	return best
+}
+
+// reachDate is when a line at level today rising by slope kg a week reaches
+// goal, or nil if it doesn't within the horizon
+func reachDate(goal, level, slope float64, now time.Time) *time.Time {
+	if level >= goal {
+		return datePtr(now)
+	}
+	if slope <= 0 {
+		return nil
+	}
+	weeks := (goal - level) / slope
+	if weeks > horizonWeeks {
+		return nil
+	}
+	return datePtr(now.Add(time.Duration(weeks * float64(week))))
+}
+
+// quantile interpolates the q-th quantile of sorted values
+func quantile(sorted []float64, q float64) float64 {
+	position := q * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
+
+func datePtr(t time.Time) *time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return &day
+}
@@ -0,0 +1,280 @@
+package goals
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// weekOne is a Monday; history points are weekly from it
+var weekOne = time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+func weeks(e1rms ...float64) []models.WeeklyE1RM {
+	history := make([]models.WeeklyE1RM, len(e1rms))
+	for i, e1rm := range e1rms {
+		history[i] = models.WeeklyE1RM{WeekStart: weekOne.Add(time.Duration(i) * week), Estimated1RMKg: e1rm}
+	}
+	return history
+}
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func float(v float64) *float64 { return &v }
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []models.WeeklyE1RM
+		want    Trend
+		wantOK  bool
+	}{
+		{
+			// Pairwise slopes sorted: -1, 0.5, 1, 4/3, 1.5, 1.5, 5/3, 2, 2.5, 4.
+			// Median 1.5; 10th percentile -1 + 0.9*1.5 = 0.35; 90th 2.5 + 0.1*1.5
+			// = 2.65. Offsets 100, 100.5, 98, 100.5, 100 have median 100.
+			name:    "median slope with a bad week",
+			history: weeks(100, 102, 101, 105, 106),
+			want:    Trend{Start: weekOne, Intercept: 100, Slope: 1.5, Low: 0.35, High: 2.65},
+			wantOK:  true,
+		},
+		{
+			name:    "flat",
+			history: weeks(150, 150, 150),
+			want:    Trend{Start: weekOne, Intercept: 150},
+			wantOK:  true,
+		},
+		{
+			name:    "too few weeks",
+			history: weeks(100, 102),
+		},
+		{
+			name: "every point in one week",
+			history: []models.WeeklyE1RM{
+				{WeekStart: weekOne, Estimated1RMKg: 100},
+				{WeekStart: weekOne, Estimated1RMKg: 101},
+				{WeekStart: weekOne, Estimated1RMKg: 102},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Fit(tt.history)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !got.Start.Equal(tt.want.Start) ||
+				!approx(got.Intercept, tt.want.Intercept) || !approx(got.Slope, tt.want.Slope) ||
+				!approx(got.Low, tt.want.Low) || !approx(got.High, tt.want.High) {
+				t.Errorf("Fit = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrendAt(t *testing.T) {
+	trend := Trend{Start: weekOne, Intercept: 100, Slope: 1.5}
+
+	if got := trend.At(weekOne.Add(2*week + 12*time.Hour)); !approx(got, 100+1.5*(2+0.5/7)) {
+		t.Errorf("At = %v, want %v", got, 100+1.5*(2+0.5/7))
+	}
+}
+
+func TestProgress(t *testing.T) {
+	// Trend of 100 + 1.5 kg/week (band 0.35 to 2.65) from weekOne. At noon in the
+	// last week it sits at 106 + 1.5/14; the current e1RM is the best of the last
+	// four weeks, 106.
+	history := weeks(100, 102, 101, 105, 106)
+	now := weekOne.Add(4*week + 12*time.Hour)
+	// The 116kg goal is 9.89kg above the trend: 6.6 weeks at 1.5 kg/week, 3.7
+	// at 2.65 and 28.3 at 0.35
+	projection := &models.GoalProjection{
+		Date:         date(2026, 3, 20),
+		Earliest:     date(2026, 2, 28),
+		Latest:       date(2026, 8, 19),
+		WeeklyGainKg: 1.5,
+	}
+
+	tests := []struct {
+		name        string
+		goalKg      float64
+		history     []models.WeeklyE1RM
+		competition *time.Time
+		want        models.GoalProgress
+	}{
+		{
+			name:    "no history",
+			goalKg:  200,
+			history: nil,
+			want:    models.GoalProgress{GoalKg: 200, Status: models.GoalStatusInsufficientData},
+		},
+		{
+			name:    "too few weeks to project",
+			goalKg:  120,
+			history: history[:2],
+			want: models.GoalProgress{
+				GoalKg: 120, CurrentE1RMKg: float(102), RemainingKg: float(18), PercentComplete: float(85),
+				Status: models.GoalStatusInsufficientData,
+			},
+		},
+		{
+			name:    "achieved",
+			goalKg:  105,
+			history: history,
+			want: models.GoalProgress{
+				GoalKg: 105, CurrentE1RMKg: float(106), RemainingKg: float(0), PercentComplete: float(100),
+				Status: models.GoalStatusAchieved,
+			},
+		},
+		{
+			name:    "no competition",
+			goalKg:  116,
+			history: history,
+			want: models.GoalProgress{
+				GoalKg: 116, CurrentE1RMKg: float(106), RemainingKg: float(10), PercentComplete: float(91.4),
+				Projection: projection, Status: models.GoalStatusNoCompetition,
+			},
+		},
+		{
+			name:        "competition already past",
+			goalKg:      116,
+			history:     history,
+			competition: date(2026, 1, 31),
+			want: models.GoalProgress{
+				GoalKg: 116, CurrentE1RMKg: float(106), RemainingKg: float(10), PercentComplete: float(91.4),
+				Projection: projection, Status: models.GoalStatusNoCompetition,
+			},
+		},
+		{
+			name:        "on track when even the slow trend gets there",
+			goalKg:      116,
+			history:     history,
+			competition: date(2026, 9, 1),
+			want: models.GoalProgress{
+				GoalKg: 116, CurrentE1RMKg: float(106), RemainingKg: float(10), PercentComplete: float(91.4),
+				Projection: projection, ProjectedAtCompetitionKg: float(151.2), Status: models.GoalStatusOnTrack,
+			},
+		},
+		{
+			name:        "possible when the median trend gets there",
+			goalKg:      116,
+			history:     history,
+			competition: date(2026, 4, 1),
+			want: models.GoalProgress{
+				GoalKg: 116, CurrentE1RMKg: float(106), RemainingKg: float(10), PercentComplete: float(91.4),
+				Projection: projection, ProjectedAtCompetitionKg: float(118.4), Status: models.GoalStatusPossible,
+			},
+		},
+		{
+			name:        "unlikely when only the fast trend gets there",
+			goalKg:      116,
+			history:     history,
+			competition: date(2026, 3, 1),
+			want: models.GoalProgress{
+				GoalKg: 116, CurrentE1RMKg: float(106), RemainingKg: float(10), PercentComplete: float(91.4),
+				Projection: projection, ProjectedAtCompetitionKg: float(111.8), Status: models.GoalStatusUnlikely,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Lift = "squat"
+			tt.want.History = tt.history
+			if tt.want.History == nil {
+				tt.want.History = []models.WeeklyE1RM{}
+			}
+
+			got := Progress("squat", tt.goalKg, tt.history, tt.competition, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Progress = %s, want %s", describe(got), describe(tt.want))
+			}
+		})
+	}
+}
+
+func TestReachDate(t *testing.T) {
+	now := time.Date(2026, 2, 2, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		goal  float64
+		level float64
+		slope float64
+		want  *time.Time
+	}{
+		{name: "already there", goal: 100, level: 100, slope: 1, want: date(2026, 2, 2)},
+		{name: "not rising", goal: 110, level: 100, slope: 0},
+		{name: "falling", goal: 110, level: 100, slope: -0.5},
+		{name: "ten weeks out", goal: 110, level: 100, slope: 1, want: date(2026, 4, 13)},
+		{name: "at the horizon", goal: 204, level: 100, slope: 1, want: date(2028, 1, 31)},
+		{name: "past the horizon", goal: 204.5, level: 100, slope: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reachDate(tt.goal, tt.level, tt.slope, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reachDate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotals(t *testing.T) {
+	history := []models.WeeklyE1RM{
+		{WeekStart: weekOne, LiftType: models.LiftTypeSquat, Estimated1RMKg: 200},
+		{WeekStart: weekOne, LiftType: models.LiftTypeBench, Estimated1RMKg: 120},
+		{WeekStart: weekOne.Add(week), LiftType: models.LiftTypeDeadlift, Estimated1RMKg: 250},
+		{WeekStart: weekOne.Add(2 * week), LiftType: models.LiftTypeSquat, Estimated1RMKg: 205},
+		{WeekStart: weekOne.Add(3 * week), LiftType: models.LiftTypeBench, Estimated1RMKg: 122.46},
+		{WeekStart: weekOne.Add(3 * week), LiftType: models.LiftTypeDeadlift, Estimated1RMKg: 251.02},
+	}
+
+	// The first week has no deadlift so is left out; later weeks carry forward
+	// whichever lifts weren't trained
+	want := []models.WeeklyE1RM{
+		{WeekStart: weekOne.Add(week), Estimated1RMKg: 570},
+		{WeekStart: weekOne.Add(2 * week), Estimated1RMKg: 575},
+		{WeekStart: weekOne.Add(3 * week), Estimated1RMKg: 578.5},
+	}
+
+	if got := Totals(history); !reflect.DeepEqual(got, want) {
+		t.Errorf("Totals = %+v, want %+v", got, want)
+	}
+
+	wantBench := []models.WeeklyE1RM{
+		{WeekStart: weekOne, LiftType: models.LiftTypeBench, Estimated1RMKg: 120},
+		{WeekStart: weekOne.Add(3 * week), LiftType: models.LiftTypeBench, Estimated1RMKg: 122.5},
+	}
+	if got := Lift(history, models.LiftTypeBench); !reflect.DeepEqual(got, wantBench) {
+		t.Errorf("Lift = %+v, want %+v", got, wantBench)
+	}
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func describe(progress models.GoalProgress) string {
+	value := func(v *float64) interface{} {
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	s := fmt.Sprintf("{status %s current %v remaining %v percent %v at competition %v",
+		progress.Status, value(progress.CurrentE1RMKg), value(progress.RemainingKg),
+		value(progress.PercentComplete), value(progress.ProjectedAtCompetitionKg))
+	if p := progress.Projection; p != nil {
+		s += fmt.Sprintf(" projection %v/%v/%v gain %v", p.Earliest, p.Date, p.Latest, p.WeeklyGainKg)
+	}
+	return s + "}"
+}
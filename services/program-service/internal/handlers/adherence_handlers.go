@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/adherence"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultAdherenceGraceDays = 1
+
+// GetProgramAdherence compares a program's prescription with what was logged,
+// for the whole program and week by week down to each exercise. week limits
+// the weeks listed; grace_days is how late a session can be and still count as
+// on time (1 by default).
+func (h *ProgramHandlers) GetProgramAdherence(c *gin.Context) {
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	graceDays := defaultAdherenceGraceDays
+	if raw := c.Query("grace_days"); raw != "" {
+		graceDays, err = strconv.Atoi(raw)
+		if err != nil || graceDays < 0 || graceDays > 14 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "grace_days must be between 0 and 14"})
+			return
+		}
+	}
+
+	var week *int
+	if raw := c.Query("week"); raw != "" {
+		weekNumber, err := strconv.Atoi(raw)
+		if err != nil || weekNumber < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "week must be a positive integer"})
+			return
+		}
+		week = &weekNumber
+	}
+
+	userID := middleware.GetUserID(c)
+	program, err := h.programRepo.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+	if !h.hasAccessToProgram(c, userID, program) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	sessions, err := h.programRepo.GetSessionsByProgramID(programID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get training sessions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get training sessions"})
+		return
+	}
+
+	maxes, err := h.programRepo.GetBestLiftsAsOf(program.AthleteID, program.StartDate)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get best lifts for adherence targets")
+		maxes = nil
+	}
+
+	report := adherence.Build(program, sessions, maxes, graceDays, time.Now())
+	if week != nil {
+		weeks := []models.WeekAdherence{}
+		for _, w := range report.Weeks {
+			if w.WeekNumber == *week {
+				weeks = append(weeks, w)
+			}
+		}
+		report.Weeks = weeks
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/ai"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// maxToolResultRows keeps tool results small enough to fit in the model's context
+const maxToolResultRows = 50
+
+// athleteTools exposes the caller's own training data to the AI. Every lookup is
+// keyed on the authenticated athlete and program changes go through the same
+// access check as the REST API, so the model can never reach beyond the caller.
+type athleteTools struct {
+	h         *ProgramHandlers
+	c         *gin.Context
+	userID    string
+	athleteID uuid.UUID
+}
+
+func (h *ProgramHandlers) newAthleteTools(c *gin.Context, userID string, athleteID uuid.UUID) *athleteTools {
+	return &athleteTools{h: h, c: c, userID: userID, athleteID: athleteID}
+}
+
+var liftTypeParam = map[string]interface{}{
+	"type":        "string",
+	"enum":        []string{"squat", "bench", "deadlift", "accessory"},
+	"description": "Restrict results to one lift type",
+}
+
+func (t *athleteTools) Tools() []ai.Tool {
+	return []ai.Tool{
+		ai.NewFunctionTool("get_e1rm_trend",
+			"Estimated 1RM (Epley) per working set over a recent window, newest first.",
+			map[string]interface{}{
+				"lift_type": liftTypeParam,
+				"days":      map[string]interface{}{"type": "integer", "description": "How many days back to look (default 90)"},
+			}),
+		ai.NewFunctionTool("get_recent_sessions",
+			"The athlete's most recently completed training sessions with their exercises.",
+			map[string]interface{}{
+				"limit": map[string]interface{}{"type": "integer", "description": "Number of sessions (default 5, max 20)"},
+			}),
+		ai.NewFunctionTool("get_upcoming_sessions",
+			"Scheduled sessions from today onward that have not been completed yet.",
+			map[string]interface{}{
+				"limit": map[string]interface{}{"type": "integer", "description": "Number of sessions (default 7, max 20)"},
+			}),
+		ai.NewFunctionTool("get_prs",
+			"Best estimated 1RM set per exercise across the athlete's history.",
+			map[string]interface{}{
+				"lift_type": liftTypeParam,
+			}),
+		ai.NewFunctionTool("propose_program_change",
+			"File a change to the athlete's program for them or their coach to review. Nothing is applied until approved.",
+			map[string]interface{}{
+				"program_id":         map[string]interface{}{"type": "string", "description": "Program to change; defaults to the active program"},
+				"change_description": map[string]interface{}{"type": "string", "description": "Short human-readable summary of the change and why"},
+				"proposed_changes":   map[string]interface{}{"type": "object", "description": "The changes to apply, in the program's JSON structure"},
+			},
+			"change_description", "proposed_changes"),
+	}
+}
+
+func (t *athleteTools) Execute(ctx context.Context, name string, arguments string) (string, error) {
+	var args struct {
+		LiftType          *models.LiftType       `json:"lift_type"`
+		Days              int                    `json:"days"`
+		Limit             int                    `json:"limit"`
+		ProgramID         string                 `json:"program_id"`
+		ChangeDescription string                 `json:"change_description"`
+		ProposedChanges   map[string]interface{} `json:"proposed_changes"`
+	}
+	if arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments for %s: %w", name, err)
+		}
+	}
+
+	var result interface{}
+	var err error
+
+	switch name {
+	case "get_e1rm_trend":
+		result, err = t.e1rmTrend(args.LiftType, args.Days)
+	case "get_recent_sessions":
+		result, err = t.recentSessions(clampLimit(args.Limit, 5))
+	case "get_upcoming_sessions":
+		result, err = t.h.programRepo.GetUpcomingSessions(t.athleteID, clampLimit(args.Limit, 7))
+	case "get_prs":
+		result, err = t.h.programRepo.GetPersonalRecords(t.athleteID, args.LiftType)
+	case "propose_program_change":
+		result, err = t.proposeChange(args.ProgramID, args.ChangeDescription, args.ProposedChanges)
+	default:
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	out, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s result: %w", name, err)
+	}
+
+	return string(out), nil
+}
+
+func (t *athleteTools) e1rmTrend(liftType *models.LiftType, days int) ([]models.E1RMData, error) {
+	if days <= 0 {
+		days = 90
+	}
+
+	data, err := t.h.programRepo.GetE1RMData(t.athleteID, time.Now().AddDate(0, 0, -days), time.Now(), liftType)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxToolResultRows {
+		data = data[:maxToolResultRows]
+	}
+
+	return data, nil
+}
+
+func (t *athleteTools) recentSessions(limit int) ([]models.TrainingSession, error) {
+	sessions, err := t.h.programRepo.GetSessionHistory(t.athleteID, time.Now().AddDate(-1, 0, 0), time.Now(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		exercises, err := t.h.programRepo.GetExercisesBySessionID(sessions[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		sessions[i].Exercises = exercises
+	}
+
+	return sessions, nil
+}
+
+func (t *athleteTools) proposeChange(programIDStr, description string, proposed map[string]interface{}) (*models.ProgramChange, error) {
+	if len(proposed) == 0 {
+		return nil, fmt.Errorf("proposed_changes is required")
+	}
+
+	var program *models.Program
+	var err error
+	if programIDStr != "" {
+		programID, parseErr := uuid.Parse(programIDStr)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid program_id")
+		}
+		program, err = t.h.programRepo.GetProgramByID(programID)
+	} else {
+		program, err = t.h.programRepo.GetActiveApprovedProgramByAthleteID(t.athleteID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if program == nil {
+		return nil, fmt.Errorf("athlete has no active program")
+	}
+
+	if !t.h.hasAccessToProgram(t.c, t.userID, program) {
+		return nil, fmt.Errorf("not authorized to modify this program")
+	}
+
+	change := &models.ProgramChange{
+		ProgramID:         program.ID,
+		ChangeType:        "propose",
+		ProposedChanges:   proposed,
+		ChangeDescription: &description,
+		ProposedBy:        "ai",
+		Status:            "pending",
+	}
+
+	if err := t.h.programRepo.ProposeChange(change); err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func clampLimit(limit, def int) int {
+	if limit <= 0 {
+		return def
+	}
+	if limit > 20 {
+		return 20
+	}
+	return limit
+}
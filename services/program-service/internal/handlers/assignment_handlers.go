@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/clients"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// AssignProgram copies a program template, or a program the coach can see, to
+// each of the given athletes, e.g. a whole team at once. Every athlete gets
+// their own approved program whose percentage targets become loads from that
+// athlete's best lifts. Athletes the caller doesn't coach, or who haven't let
+// them edit their program, are skipped, and the response lists the outcome for
+// each athlete.
+func (h *ProgramHandlers) AssignProgram(c *gin.Context) {
+	coachID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	if middleware.GetUserType(c) != "coach" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only coaches can assign programs"})
+		return
+	}
+
+	var req models.AssignProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.TemplateID == nil) == (req.SourceProgramID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either template_id or source_program_id"})
+		return
+	}
+
+	var source *models.Program
+	if req.TemplateID != nil {
+		template, err := h.programRepo.GetProgramTemplateByID(*req.TemplateID)
+		if err != nil || (!template.IsPublic && (template.CreatedBy == nil || *template.CreatedBy != coachID)) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		source = &models.Program{
+			Name:        template.Name,
+			Description: template.Description,
+			Phase:       template.Phase,
+			WeeksTotal:  template.WeeksDuration,
+			DaysPerWeek: template.DaysPerWeek,
+			ProgramData: template.TemplateData,
+		}
+	} else {
+		source, err = h.programRepo.GetProgramByID(*req.SourceProgramID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+			return
+		}
+		// The source program is only copied, not changed
+		c.Set(auditViewOnlyKey, true)
+		if !h.hasAccessToProgram(c, coachID.String(), source) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+	}
+
+	if _, ok := source.ProgramData["weeklyWorkouts"].([]interface{}); !ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Program has no weekly workouts to assign"})
+		return
+	}
+	if req.Name != nil && *req.Name != "" {
+		source.Name = *req.Name
+	}
+
+	coached := h.coachedAthletes(c, coachID, req.AthleteIDs, clients.ScopeEditProgram)
+	now := time.Now()
+
+	assignments := []models.ProgramAssignment{}
+	seen := make(map[uuid.UUID]bool)
+	for _, athleteID := range req.AthleteIDs {
+		if seen[athleteID] {
+			continue
+		}
+		seen[athleteID] = true
+
+		if !coached[athleteID] {
+			assignments = append(assignments, models.ProgramAssignment{
+				AthleteID: athleteID,
+				Status:    models.AssignmentSkipped,
+				Error:     "Not one of your athletes, or they haven't let you edit their program",
+			})
+			continue
+		}
+		assignments = append(assignments, h.assignProgram(c, coachID, athleteID, source, req.StartDate, now))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"assignments": assignments})
+}
+
+// assignProgram creates the athlete's copy of the source program and its sessions
+func (h *ProgramHandlers) assignProgram(c *gin.Context, coachID, athleteID uuid.UUID, source *models.Program, startDate, now time.Time) models.ProgramAssignment {
+	assignment := models.ProgramAssignment{AthleteID: athleteID}
+
+	maxes, err := h.programRepo.GetBestLiftsAsOf(athleteID, now)
+	if err != nil {
+		log.Warn().Err(err).Str("athlete_id", athleteID.String()).Msg("Failed to get best lifts for program assignment")
+		maxes = nil
+	}
+	if maxes != nil && maxes.TotalKg() > 0 {
+		assignment.Maxes = maxes
+	}
+
+	program := &models.Program{
+		AthleteID:     athleteID,
+		CoachID:       &coachID,
+		Name:          source.Name,
+		Description:   source.Description,
+		Phase:         source.Phase,
+		StartDate:     startDate,
+		EndDate:       startDate.AddDate(0, 0, source.WeeksTotal*7),
+		WeeksTotal:    source.WeeksTotal,
+		DaysPerWeek:   source.DaysPerWeek,
+		ProgramData:   source.ProgramData,
+		ProgramStatus: models.ProgramStatusApproved,
+		IsActive:      true,
+	}
+
+	if err := h.programRepo.CreateProgram(program); err != nil {
+		log.Error().Err(err).Str("athlete_id", athleteID.String()).Msg("Failed to create assigned program")
+		assignment.Status = models.AssignmentFailed
+		assignment.Error = "Failed to create program"
+		return assignment
+	}
+	assignment.ProgramID = &program.ID
+	h.publishCoachDataAccessed(c, coachDataAccess{
+		coachID:      coachID,
+		athleteID:    athleteID,
+		action:       "program_modified",
+		resourceType: "program",
+		resourceID:   program.ID,
+	})
+
+	if err := h.workoutGenerator.GenerateWorkoutsWithMaxes(program, maxes); err != nil {
+		log.Error().Err(err).Str("program_id", program.ID.String()).Msg("Failed to generate workouts for assigned program")
+		assignment.Status = models.AssignmentFailed
+		assignment.Error = "Program created but its sessions could not be generated"
+		return assignment
+	}
+
+	assignment.Status = models.AssignmentCreated
+	return assignment
+}
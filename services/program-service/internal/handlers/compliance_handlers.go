@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/adherence"
+	"github.com/powerlifting-coach-app/program-service/internal/clients"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// maxComplianceAthletes caps how many athletes one compliance request covers
+const maxComplianceAthletes = 100
+
+// GetAthleteCompliance reports 7- and 28-day compliance, missed sessions and
+// RPE drift for each athlete_id (repeated or comma separated), defaulting to
+// the caller. Coaches get the athletes they actively coach; other IDs are left
+// out rather than failing the whole request, so a roster can be fetched in one
+// call, and each athlete returned is logged in their access history. grace_days
+// works as it does for program adherence.
+func (h *ProgramHandlers) GetAthleteCompliance(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	graceDays := defaultAdherenceGraceDays
+	if raw := c.Query("grace_days"); raw != "" {
+		graceDays, err = strconv.Atoi(raw)
+		if err != nil || graceDays < 0 || graceDays > 14 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "grace_days must be between 0 and 14"})
+			return
+		}
+	}
+
+	var athleteIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, raw := range c.QueryArray("athlete_id") {
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			athleteID, err := uuid.Parse(part)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid athlete ID: " + part})
+				return
+			}
+			if !seen[athleteID] {
+				seen[athleteID] = true
+				athleteIDs = append(athleteIDs, athleteID)
+			}
+		}
+	}
+	if len(athleteIDs) == 0 {
+		athleteIDs = []uuid.UUID{userID}
+	}
+	if len(athleteIDs) > maxComplianceAthletes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many athletes requested"})
+		return
+	}
+
+	accessible := h.coachedAthletes(c, userID, athleteIDs, clients.ScopeViewHistory)
+
+	now := time.Now()
+	results := []models.AthleteCompliance{}
+	for _, athleteID := range athleteIDs {
+		if athleteID != userID && !accessible[athleteID] {
+			continue
+		}
+
+		compliance, err := h.athleteCompliance(athleteID, graceDays, now)
+		if err != nil {
+			log.Error().Err(err).Str("athlete_id", athleteID.String()).Msg("Failed to get athlete compliance")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get athlete compliance"})
+			return
+		}
+		results = append(results, *compliance)
+
+		if athleteID != userID {
+			recordCoachDataAccess(c, coachDataAccess{
+				coachID:      userID,
+				athleteID:    athleteID,
+				action:       "data_viewed",
+				resourceType: "compliance",
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"athletes": results})
+}
+
+// coachedAthletes returns which of the athletes the user actively coaches with
+// scope granted, asking coach-service once for the whole list
+func (h *ProgramHandlers) coachedAthletes(c *gin.Context, userID uuid.UUID, athleteIDs []uuid.UUID, scope string) map[uuid.UUID]bool {
+	coached := make(map[uuid.UUID]bool)
+	if h.coachClient == nil {
+		return coached
+	}
+	if len(athleteIDs) == 1 && athleteIDs[0] == userID {
+		return coached
+	}
+
+	authToken := c.GetHeader("Authorization")
+	if authToken == "" {
+		return coached
+	}
+
+	assignments, err := h.coachClient.GetCoachAthletes(c.Request.Context(), authToken)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get coached athletes for compliance")
+		return coached
+	}
+	for _, assignment := range assignments {
+		if assignment.HasScope(scope) {
+			coached[assignment.AthleteID] = true
+		}
+	}
+
+	return coached
+}
+
+func (h *ProgramHandlers) athleteCompliance(athleteID uuid.UUID, graceDays int, now time.Time) (*models.AthleteCompliance, error) {
+	compliance := &models.AthleteCompliance{AthleteID: athleteID}
+
+	programs, err := h.programRepo.GetProgramsByAthleteID(athleteID)
+	if err != nil {
+		return nil, err
+	}
+	for _, program := range programs {
+		if program.IsActive {
+			compliance.ActivePrograms++
+		}
+	}
+
+	compliance.LastActiveAt, err = h.programRepo.GetLastCompletedAt(athleteID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := h.programRepo.GetScheduledSessions(athleteID, now.AddDate(0, 0, -28), now)
+	if err != nil {
+		return nil, err
+	}
+
+	week := adherence.Window(sessions, graceDays, now.AddDate(0, 0, -7), now)
+	month := adherence.Window(sessions, graceDays, now.AddDate(0, 0, -28), now)
+	compliance.Compliance7DayPct = adherence.CompletionPct(week)
+	compliance.Compliance28DayPct = adherence.CompletionPct(month)
+	compliance.SessionsMissed7Day = week.SessionsMissed
+	compliance.SessionsMissed28Day = month.SessionsMissed
+	compliance.RPEDrift = month.RPEDeviation
+
+	records, err := h.programRepo.GetPersonalRecords(athleteID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if !record.AchievedAt.Before(now.AddDate(0, 0, -28)) {
+			compliance.PersonalRecords28Day++
+		}
+	}
+
+	return compliance, nil
+}
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// chatHistoryLimit caps how many earlier messages are sent to the model
+	chatHistoryLimit = 50
+	maxTitleLength   = 100
+)
+
+var errConversationNotFound = errors.New("conversation not found")
+
+// conversationTitleFromText is the placeholder title used until the model has
+// named the conversation: the start of the text, cut at a word boundary
+func conversationTitleFromText(text string) string {
+	title := strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(title) <= 60 {
+		if title == "" {
+			return models.DefaultConversationTitle
+		}
+		return title
+	}
+
+	runes := []rune(title)[:60]
+	if i := strings.LastIndex(string(runes), " "); i > 20 {
+		return string(runes)[:i] + "…"
+	}
+	return string(runes) + "…"
+}
+
+// generateConversationTitle replaces the placeholder title with one written by
+// the model. It runs after the response has been sent, so it is detached from
+// the request context.
+func (h *ProgramHandlers) generateConversationTitle(ctx context.Context, conversation models.AIConversation, userMessage, assistantMessage string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	title, err := h.aiClient.GenerateConversationTitle(ctx, conversation.AthleteID.String(), userMessage, assistantMessage)
+	if err != nil {
+		log.Warn().Err(err).Str("conversation_id", conversation.ID.String()).Msg("Failed to generate conversation title")
+		return
+	}
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		title = string([]rune(title)[:maxTitleLength])
+	}
+
+	if err := h.programRepo.SetAutoAIConversationTitle(conversation.ID, conversation.Title, title); err != nil {
+		log.Warn().Err(err).Msg("Failed to save conversation title")
+	}
+}
+
+// getOwnedConversation loads a conversation belonging to the caller, responding
+// with an error and returning nil otherwise
+func (h *ProgramHandlers) getOwnedConversation(c *gin.Context, historyLimit int) *models.AIConversation {
+	userUUID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil
+	}
+
+	conversationID, err := uuid.Parse(c.Param("conversationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return nil
+	}
+
+	conversation, err := h.programRepo.GetAIConversationByID(conversationID, historyLimit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get AI conversation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get conversation"})
+		return nil
+	}
+
+	// Other athletes' conversations are reported as missing rather than forbidden
+	if conversation == nil || conversation.AthleteID != userUUID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return nil
+	}
+
+	return conversation
+}
+
+func (h *ProgramHandlers) CreateConversation(c *gin.Context) {
+	userUUID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversation := &models.AIConversation{
+		AthleteID:           userUUID,
+		ConversationType:    "chat",
+		Title:               models.DefaultConversationTitle,
+		CoachContextEnabled: req.CoachContextEnable,
+	}
+
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" || utf8.RuneCountInString(title) > maxTitleLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "title must be 1-100 characters"})
+			return
+		}
+		conversation.Title = title
+	}
+
+	if req.ProgramID != nil {
+		if !h.canAttachProgram(c, *req.ProgramID) {
+			return
+		}
+		conversation.ProgramID = req.ProgramID
+	}
+
+	if err := h.programRepo.CreateAIConversation(conversation); err != nil {
+		log.Error().Err(err).Msg("Failed to create AI conversation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create conversation"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"conversation": conversation})
+}
+
+func (h *ProgramHandlers) ListConversations(c *gin.Context) {
+	userUUID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	includeArchived := c.Query("include_archived") == "true"
+
+	var programID *uuid.UUID
+	if p := c.Query("program_id"); p != "" {
+		parsed, err := uuid.Parse(p)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program_id"})
+			return
+		}
+		programID = &parsed
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 {
+		limit = 20
+	} else if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	conversations, err := h.programRepo.ListAIConversations(userUUID, includeArchived, programID, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list AI conversations")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"conversations": conversations})
+}
+
+// SearchConversations runs a full-text search over the caller's messages. The
+// query supports web-search syntax: quoted phrases, OR and -excluded words.
+func (h *ProgramHandlers) SearchConversations(c *gin.Context) {
+	userUUID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 {
+		limit = 20
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	results, err := h.programRepo.SearchAIMessages(userUUID, query, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search AI conversations")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+func (h *ProgramHandlers) GetConversation(c *gin.Context) {
+	conversation := h.getOwnedConversation(c, 0)
+	if conversation == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"conversation": conversation})
+}
+
+// UpdateConversation renames and/or archives a conversation
+func (h *ProgramHandlers) UpdateConversation(c *gin.Context) {
+	conversation := h.getOwnedConversation(c, 0)
+	if conversation == nil {
+		return
+	}
+
+	var req models.UpdateConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" || utf8.RuneCountInString(title) > maxTitleLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "title must be 1-100 characters"})
+			return
+		}
+		if err := h.programRepo.RenameAIConversation(conversation.ID, title); err != nil {
+			log.Error().Err(err).Msg("Failed to rename AI conversation")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update conversation"})
+			return
+		}
+	}
+
+	if req.Archived != nil {
+		if err := h.programRepo.SetAIConversationArchived(conversation.ID, *req.Archived); err != nil {
+			log.Error().Err(err).Msg("Failed to archive AI conversation")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update conversation"})
+			return
+		}
+	}
+
+	updated, err := h.programRepo.GetAIConversationByID(conversation.ID, 0)
+	if err != nil || updated == nil {
+		log.Error().Err(err).Msg("Failed to reload AI conversation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get conversation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"conversation": updated})
+}
+
+func (h *ProgramHandlers) AttachConversationProgram(c *gin.Context) {
+	conversation := h.getOwnedConversation(c, 0)
+	if conversation == nil {
+		return
+	}
+
+	var req models.AttachConversationProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.canAttachProgram(c, req.ProgramID) {
+		return
+	}
+
+	if err := h.programRepo.SetAIConversationProgram(conversation.ID, &req.ProgramID); err != nil {
+		log.Error().Err(err).Msg("Failed to attach program to AI conversation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update conversation"})
+		return
+	}
+
+	conversation.ProgramID = &req.ProgramID
+	c.JSON(http.StatusOK, gin.H{"conversation": conversation})
+}
+
+func (h *ProgramHandlers) DetachConversationProgram(c *gin.Context) {
+	conversation := h.getOwnedConversation(c, 0)
+	if conversation == nil {
+		return
+	}
+
+	if err := h.programRepo.SetAIConversationProgram(conversation.ID, nil); err != nil {
+		log.Error().Err(err).Msg("Failed to detach program from AI conversation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update conversation"})
+		return
+	}
+
+	conversation.ProgramID = nil
+	c.JSON(http.StatusOK, gin.H{"conversation": conversation})
+}
+
+// DeleteConversation permanently deletes a conversation and its messages
+func (h *ProgramHandlers) DeleteConversation(c *gin.Context) {
+	conversation := h.getOwnedConversation(c, 1)
+	if conversation == nil {
+		return
+	}
+
+	if err := h.programRepo.DeleteAIConversation(conversation.ID); err != nil {
+		log.Error().Err(err).Msg("Failed to delete AI conversation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete conversation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation deleted"})
+}
+
+// canAttachProgram checks the caller may see the program, responding with an
+// error otherwise
+func (h *ProgramHandlers) canAttachProgram(c *gin.Context, programID uuid.UUID) bool {
+	program, err := h.programRepo.GetProgramByID(programID)
+	if err != nil || program == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return false
+	}
+
+	// Attaching a program to a conversation only reads it
+	c.Set(auditViewOnlyKey, true)
+	if !h.hasAccessToProgram(c, middleware.GetUserID(c), program) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return false
+	}
+
+	return true
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -395,32 +397,30 @@ func (h *ProgramHandlers) GetSessionHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"sessions": sessions, "total": len(sessions)})
 }
 
+// DeleteSession moves one of the caller's sessions to the trash, where it can
+// be restored until it is purged
 func (h *ProgramHandlers) DeleteSession(c *gin.Context) {
-	_, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	session, ok := h.ownSession(c)
+	if !ok {
 		return
 	}
 
-	sessionID, err := uuid.Parse(c.Param("sessionId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+	var req models.DeleteSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get reason from request body
-	var req struct {
-		Reason string `json:"reason"`
-	}
-	c.ShouldBindJSON(&req)
-
-	// TODO: Verify ownership before deleting
-
-	if err := h.programRepo.SoftDeleteSession(sessionID, req.Reason); err != nil {
+	if err := h.programRepo.SoftDeleteSession(session.ID, req.Reason); err != nil {
 		log.Error().Err(err).Msg("Failed to delete session")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
 		return
 	}
 
+	h.publishSessionEvent(eventWorkoutSessionDeleted, session, map[string]interface{}{
+		"reason":   req.Reason,
+		"purge_at": time.Now().Add(h.trashRetention).UTC().Format(time.RFC3339),
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Session deleted successfully"})
 }
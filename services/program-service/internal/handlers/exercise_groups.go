@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+func findSessionGroup(session *models.TrainingSession, groupID *uuid.UUID) *models.ExerciseGroup {
+	if groupID == nil {
+		return nil
+	}
+	for i := range session.Groups {
+		if session.Groups[i].ID == *groupID {
+			return &session.Groups[i]
+		}
+	}
+	return nil
+}
+
+// groupExercises returns the group's exercises in the order they are done each round
+func groupExercises(session *models.TrainingSession, group *models.ExerciseGroup) []*models.Exercise {
+	var exercises []*models.Exercise
+	for i := range session.Exercises {
+		if id := session.Exercises[i].GroupID; id != nil && *id == group.ID {
+			exercises = append(exercises, &session.Exercises[i])
+		}
+	}
+
+	sort.SliceStable(exercises, func(i, j int) bool {
+		return groupPosition(exercises[i]) < groupPosition(exercises[j])
+	})
+	return exercises
+}
+
+func groupPosition(exercise *models.Exercise) int {
+	if exercise.GroupPosition == nil {
+		return exercise.ExerciseOrder
+	}
+	return *exercise.GroupPosition
+}
+
+// groupLabel is the exercise's place in its group, e.g. A2
+func groupLabel(group *models.ExerciseGroup, exercise *models.Exercise) string {
+	return fmt.Sprintf("%s%d", group.Label, groupPosition(exercise))
+}
+
+// setRound is the round a logged set counts towards: the round it was logged
+// in, or for sets logged without one, the round its number falls in
+func setRound(group *models.ExerciseGroup, exercise *models.Exercise, set models.CompletedSet) int {
+	if set.RoundNumber != nil {
+		return *set.RoundNumber
+	}
+	return group.RoundOfSet(*exercise, set.SetNumber)
+}
+
+func groupProgress(session *models.TrainingSession, group *models.ExerciseGroup) models.GroupProgress {
+	progress := models.GroupProgress{Group: *group}
+
+	exercises := groupExercises(session, group)
+	if len(exercises) == 0 {
+		return progress
+	}
+
+	done := make([]map[int]int, len(exercises))
+	for i, exercise := range exercises {
+		done[i] = make(map[int]int)
+		for _, set := range exercise.CompletedSets {
+			done[i][setRound(group, exercise, set)]++
+		}
+	}
+
+	for round := 1; round <= group.Rounds; round++ {
+		complete := true
+		for i, exercise := range exercises {
+			if done[i][round] < group.SetsPerRound(*exercise) {
+				complete = false
+				if progress.NextExerciseID == nil {
+					progress.NextExerciseID = &exercise.ID
+				}
+			}
+		}
+		if complete {
+			progress.RoundsCompleted++
+		}
+	}
+	progress.Completed = progress.RoundsCompleted == group.Rounds
+
+	return progress
+}
+
+// restAfterSet is the rest due after the exercise's set with this number. Within
+// a superset or circuit the athlete moves straight on until the round ends; a
+// cluster rests its interval between mini-sets; an EMOM waits out the interval.
+func restAfterSet(session *models.TrainingSession, exercise *models.Exercise, setNumber int) *int {
+	group := findSessionGroup(session, exercise.GroupID)
+	if group == nil {
+		return exercise.RestSeconds
+	}
+
+	lastOfRound := setNumber%group.SetsPerRound(*exercise) == 0
+	switch group.GroupType {
+	case models.GroupTypeEMOM:
+		return group.IntervalSeconds
+	case models.GroupTypeCluster:
+		if !lastOfRound {
+			return group.IntervalSeconds
+		}
+		return group.RestSeconds
+	default:
+		exercises := groupExercises(session, group)
+		if lastOfRound && exercises[len(exercises)-1].ID == exercise.ID {
+			return group.RestSeconds
+		}
+		none := 0
+		return &none
+	}
+}
+
+func groupsProgress(session *models.TrainingSession) []models.GroupProgress {
+	progress := make([]models.GroupProgress, 0, len(session.Groups))
+	for i := range session.Groups {
+		progress = append(progress, groupProgress(session, &session.Groups[i]))
+	}
+	return progress
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/ai"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/powerlifting-coach-app/program-service/internal/repository"
+	"github.com/powerlifting-coach-app/program-service/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// formAnalysisTimeout bounds the download, frame extraction and model call for one video
+const formAnalysisTimeout = 5 * time.Minute
+
+// analyzableLifts are the movement labels the form analysis prompts cover
+var analyzableLifts = map[string]bool{
+	"squat":    true,
+	"bench":    true,
+	"deadlift": true,
+}
+
+type MediaProcessedEvent struct {
+	SchemaVersion     string `json:"schema_version"`
+	EventType         string `json:"event_type"`
+	ClientGeneratedID string `json:"client_generated_id"`
+	UserID            string `json:"user_id"`
+	Timestamp         string `json:"timestamp"`
+	SourceService     string `json:"source_service"`
+	Data              struct {
+		VideoID       string   `json:"video_id"`
+		MediaURL      string   `json:"media_url"`
+		ThumbnailURL  string   `json:"thumbnail_url"`
+		MovementLabel string   `json:"movement_label"`
+		Weight        *float64 `json:"weight"`
+		RPE           *float64 `json:"rpe"`
+	} `json:"data"`
+}
+
+// FormAnalyzedEvent is published flat rather than with a data object because
+// notification-service reads the analysis fields at the top level. The envelope
+// fields are included so EventConsumers can still route it by event_type.
+type FormAnalyzedEvent struct {
+	SchemaVersion     string                `json:"schema_version"`
+	EventType         string                `json:"event_type"`
+	ClientGeneratedID string                `json:"client_generated_id"`
+	UserID            string                `json:"user_id"`
+	Timestamp         string                `json:"timestamp"`
+	SourceService     string                `json:"source_service"`
+	AnalysisID        uuid.UUID             `json:"analysis_id"`
+	VideoID           uuid.UUID             `json:"video_id"`
+	AthleteID         uuid.UUID             `json:"athlete_id"`
+	Exercise          string                `json:"exercise"`
+	Score             float64               `json:"score"`
+	Confidence        float64               `json:"confidence"`
+	Feedback          string                `json:"feedback"`
+	KeyFrames         []models.FormKeyFrame `json:"key_frames"`
+	Issues            []models.FormIssue    `json:"issues"`
+	AIModel           string                `json:"ai_model"`
+	CreatedAt         time.Time             `json:"created_at"`
+}
+
+type FormAnalysisHandlers struct {
+	programRepo    *repository.ProgramRepository
+	aiClient       *ai.Client
+	frameExtractor *services.FrameExtractor
+	usageService   *services.AIUsageService
+	publisher      EventPublisher
+	frameCount     int
+}
+
+func NewFormAnalysisHandlers(
+	programRepo *repository.ProgramRepository,
+	aiClient *ai.Client,
+	frameExtractor *services.FrameExtractor,
+	usageService *services.AIUsageService,
+	publisher EventPublisher,
+	frameCount int,
+) *FormAnalysisHandlers {
+	return &FormAnalysisHandlers{
+		programRepo:    programRepo,
+		aiClient:       aiClient,
+		frameExtractor: frameExtractor,
+		usageService:   usageService,
+		publisher:      publisher,
+		frameCount:     frameCount,
+	}
+}
+
+// HandleMediaProcessed analyzes the form in a newly processed lift video and
+// publishes form.analyzed. Errors are returned so the consumer retries the video.
+func (h *FormAnalysisHandlers) HandleMediaProcessed(ctx context.Context, payload []byte) error {
+	var event MediaProcessedEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	athleteID, err := uuid.Parse(event.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid user_id: %w", err)
+	}
+
+	videoID, err := uuid.Parse(event.Data.VideoID)
+	if err != nil {
+		return fmt.Errorf("invalid video_id: %w", err)
+	}
+
+	exercise := strings.ToLower(event.Data.MovementLabel)
+	if !analyzableLifts[exercise] {
+		log.Debug().Str("video_id", videoID.String()).Str("movement_label", exercise).Msg("Skipping form analysis for unsupported lift")
+		return nil
+	}
+	if event.Data.MediaURL == "" {
+		return fmt.Errorf("media.processed event for video %s has no media_url", videoID)
+	}
+
+	existing, err := h.programRepo.GetFormAnalysisByVideoID(videoID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Status == models.FormAnalysisCompleted {
+		// Already analyzed; publish again in case the previous attempt failed to.
+		// Consumers de-duplicate on analysis_id.
+		return h.publishFormAnalyzed(existing)
+	}
+
+	exceeded, err := h.usageService.CheckQuota(athleteID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check AI quota")
+	} else if exceeded != nil {
+		log.Info().
+			Str("athlete_id", athleteID.String()).
+			Str("period", exceeded.Period).
+			Msg("Skipping form analysis, AI token quota exceeded")
+		return nil
+	}
+
+	analysis := &models.FormAnalysis{
+		VideoID:   videoID,
+		AthleteID: athleteID,
+		Exercise:  exercise,
+		Load:      event.Data.Weight,
+	}
+	if err := h.programRepo.StartFormAnalysis(analysis); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ai.WithUsage(ctx, athleteID, models.AIFeatureFormAnalysis), formAnalysisTimeout)
+	defer cancel()
+
+	frames, duration, err := h.frameExtractor.ExtractFrames(ctx, event.Data.MediaURL, h.frameCount)
+	if err != nil {
+		return h.failFormAnalysis(analysis, err)
+	}
+
+	result, err := h.aiClient.AnalyzeForm(ctx, ai.FormAnalysisInput{
+		Exercise:        exercise,
+		Load:            event.Data.Weight,
+		RPE:             event.Data.RPE,
+		DurationSeconds: duration,
+		Frames:          frames,
+	}, athleteID.String())
+	if err != nil {
+		return h.failFormAnalysis(analysis, err)
+	}
+
+	analysis.Score = &result.Score
+	analysis.Confidence = &result.Confidence
+	analysis.Summary = &result.Summary
+	analysis.KeyFrames = result.KeyFrames
+	analysis.Issues = result.Issues
+	analysis.AIModel = &result.Model
+
+	if err := h.programRepo.CompleteFormAnalysis(analysis); err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("video_id", videoID.String()).
+		Str("exercise", exercise).
+		Float64("score", result.Score).
+		Int("issues", len(result.Issues)).
+		Msg("Form analysis completed")
+
+	return h.publishFormAnalyzed(analysis)
+}
+
+func (h *FormAnalysisHandlers) failFormAnalysis(analysis *models.FormAnalysis, cause error) error {
+	if err := h.programRepo.FailFormAnalysis(analysis.ID, cause.Error()); err != nil {
+		log.Error().Err(err).Msg("Failed to record form analysis failure")
+	}
+	return cause
+}
+
+func (h *FormAnalysisHandlers) publishFormAnalyzed(analysis *models.FormAnalysis) error {
+	if h.publisher == nil {
+		return nil
+	}
+
+	event := FormAnalyzedEvent{
+		SchemaVersion:     "1.0.0",
+		EventType:         "form.analyzed",
+		ClientGeneratedID: uuid.New().String(),
+		UserID:            analysis.AthleteID.String(),
+		Timestamp:         time.Now().UTC().Format(time.RFC3339),
+		SourceService:     "program-service",
+		AnalysisID:        analysis.ID,
+		VideoID:           analysis.VideoID,
+		AthleteID:         analysis.AthleteID,
+		Exercise:          analysis.Exercise,
+		KeyFrames:         analysis.KeyFrames,
+		Issues:            analysis.Issues,
+		CreatedAt:         analysis.CreatedAt,
+	}
+	if analysis.Score != nil {
+		event.Score = *analysis.Score
+	}
+	if analysis.Confidence != nil {
+		event.Confidence = *analysis.Confidence
+	}
+	if analysis.Summary != nil {
+		event.Feedback = *analysis.Summary
+	}
+	if analysis.AIModel != nil {
+		event.AIModel = *analysis.AIModel
+	}
+
+	if err := h.publisher.PublishEvent("form.analyzed", event); err != nil {
+		return fmt.Errorf("failed to publish form.analyzed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/goals"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultGoalTrendWeeks = 16
+
+// GetGoals reports progress on the lift goals in the caller's settings, and on
+// their total when all three are set. Projections come from the weekly best
+// e1RM over the last `weeks` weeks (16 by default) and are judged against the
+// competition date in settings.
+func (h *ProgramHandlers) GetGoals(c *gin.Context) {
+	athleteID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	weeks := defaultGoalTrendWeeks
+	if raw := c.Query("weeks"); raw != "" {
+		weeks, err = strconv.Atoi(raw)
+		if err != nil || weeks < 3 || weeks > 104 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weeks must be between 3 and 104"})
+			return
+		}
+	}
+
+	if h.settingsClient == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Athlete goals are not available"})
+		return
+	}
+	settings, err := h.settingsClient.GetUserSettings(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch athlete goals from settings service")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to get athlete goals"})
+		return
+	}
+
+	now := time.Now()
+	history, err := h.programRepo.GetWeeklyBestE1RM(athleteID, now.AddDate(0, 0, -7*weeks))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get weekly e1RM")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get e1RM history"})
+		return
+	}
+
+	report := models.GoalsReport{
+		CompetitionDate: parseSettingsDate(settings.CompetitionDate),
+		Goals:           []models.GoalProgress{},
+	}
+
+	lifts := []struct {
+		liftType models.LiftType
+		goalKg   *float64
+	}{
+		{models.LiftTypeSquat, goalKg(settings.SquatGoalValue, settings.SquatGoalUnit)},
+		{models.LiftTypeBench, goalKg(settings.BenchGoalValue, settings.BenchGoalUnit)},
+		{models.LiftTypeDeadlift, goalKg(settings.DeadGoalValue, settings.DeadGoalUnit)},
+	}
+	var totalGoalKg float64
+	allSet := true
+	for _, lift := range lifts {
+		if lift.goalKg == nil {
+			allSet = false
+			continue
+		}
+		totalGoalKg += *lift.goalKg
+		report.Goals = append(report.Goals, goals.Progress(
+			string(lift.liftType), *lift.goalKg, goals.Lift(history, lift.liftType), report.CompetitionDate, now,
+		))
+	}
+	if allSet {
+		report.Goals = append(report.Goals, goals.Progress(
+			"total", totalGoalKg, goals.Totals(history), report.CompetitionDate, now,
+		))
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// goalKg is a goal from settings in kg, or nil when it isn't set
+func goalKg(value *float64, unit *string) *float64 {
+	if value == nil || *value <= 0 {
+		return nil
+	}
+	kg := *value
+	if unit != nil && strings.HasPrefix(strings.ToLower(*unit), "lb") {
+		kg *= kgPerLb
+	}
+	return &kg
+}
+
+// parseSettingsDate reads a date the settings service sends either as a plain
+// date or a timestamp
+func parseSettingsDate(value *string) *time.Time {
+	if value == nil || *value == "" {
+		return nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if parsed, err := time.Parse(layout, *value); err == nil {
+			return &parsed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,550 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/rs/zerolog/log"
+)
+
+// Live session events. workout.started and workout.completed are the same events
+// the frontend publishes, so consumers of those see live sessions too.
+const (
+	eventWorkoutStarted   = "workout.started"
+	eventWorkoutSetLogged = "workout.set.logged"
+	eventWorkoutSetEdited = "workout.set.updated"
+	eventWorkoutRestStart = "workout.rest.started"
+	eventWorkoutRestStop  = "workout.rest.stopped"
+	eventWorkoutCompleted = "workout.completed"
+)
+
+// GetLiveSession returns the session in progress with its running rest timer and
+// the next target for each exercise. Coaches with access to the athlete can
+// watch it too.
+func (h *ProgramHandlers) GetLiveSession(c *gin.Context) {
+	session, ok := h.viewableSession(c)
+	if !ok {
+		return
+	}
+
+	state, err := h.liveSessionState(session)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load live session")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// StartLiveSession starts the athlete's session; starting it again is a no-op
+// that returns the current state
+func (h *ProgramHandlers) StartLiveSession(c *gin.Context) {
+	session, ok := h.ownLiveSession(c)
+	if !ok {
+		return
+	}
+
+	current, err := h.programRepo.GetSessionStartedAt(session.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get session start")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+
+	startedAt, err := h.programRepo.StartSession(session.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to start session")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+
+	if current == nil {
+		h.publishSessionEvent(eventWorkoutStarted, session, map[string]interface{}{
+			"workout_id":      session.ID.String(),
+			"start_timestamp": startedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	state, err := h.liveSessionState(session)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load live session")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// LogLiveSet logs a single set as it is completed and returns the next target
+// for the exercise
+func (h *ProgramHandlers) LogLiveSet(c *gin.Context) {
+	session, ok := h.ownLiveSession(c)
+	if !ok {
+		return
+	}
+
+	var req models.LogLiveSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exercise := findSessionExercise(session, req.ExerciseID)
+	if exercise == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exercise is not part of this session"})
+		return
+	}
+
+	set := &models.CompletedSet{
+		ExerciseID:      req.ExerciseID,
+		RepsCompleted:   req.RepsCompleted,
+		WeightKg:        req.WeightKg,
+		RPEActual:       req.RPEActual,
+		VideoID:         req.VideoID,
+		Notes:           req.Notes,
+		SetType:         req.SetType,
+		MediaURLs:       req.MediaURLs,
+		ExerciseNotes:   req.ExerciseNotes,
+		MeanVelocityMps: req.MeanVelocityMps,
+		PeakVelocityMps: req.PeakVelocityMps,
+		VelocityLossPct: req.VelocityLossPct,
+		RepVelocities:   req.RepVelocities,
+	}
+	if req.SetNumber != nil {
+		set.SetNumber = *req.SetNumber
+	}
+	if set.SetType == "" {
+		set.SetType = models.SetTypeWorking
+	}
+
+	group := findSessionGroup(session, exercise.GroupID)
+	if group != nil {
+		round := req.RoundNumber
+		if round == nil {
+			setNumber := set.SetNumber
+			if setNumber == 0 {
+				setNumber = nextSetNumber(exercise)
+			}
+			r := group.RoundOfSet(*exercise, setNumber)
+			round = &r
+		}
+		set.RoundNumber = round
+	}
+
+	stoppedRest, err := h.programRepo.LogLiveSet(session.ID, set)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to log set")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log set"})
+		return
+	}
+
+	if stoppedRest != nil {
+		h.publishSessionEvent(eventWorkoutRestStop, session, map[string]interface{}{"rest": stoppedRest})
+	}
+
+	exercise.CompletedSets = append(exercise.CompletedSets, *set)
+	nextTarget := h.nextSetTarget(session, exercise)
+
+	data := map[string]interface{}{
+		"exercise_name": exercise.ExerciseName,
+		"set":           set,
+		"next_target":   nextTarget,
+	}
+	response := gin.H{
+		"set":         set,
+		"next_target": nextTarget,
+	}
+	if group != nil {
+		progress := groupProgress(session, group)
+		data["group_progress"] = progress
+		response["group_progress"] = progress
+	}
+	if exercise.VelocityLossCutoffPct != nil && set.VelocityLossPct != nil {
+		reached := *set.VelocityLossPct >= *exercise.VelocityLossCutoffPct
+		data["velocity_cutoff_reached"] = reached
+		response["velocity_cutoff_reached"] = reached
+	}
+
+	h.publishSessionEvent(eventWorkoutSetLogged, session, data)
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// StartRestTimer starts a rest timer, replacing any timer already running
+func (h *ProgramHandlers) StartRestTimer(c *gin.Context) {
+	session, ok := h.ownLiveSession(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req models.StartRestRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rest := &models.RestPeriod{
+		SessionID:     session.ID,
+		ExerciseID:    req.ExerciseID,
+		AfterSetID:    req.AfterSetID,
+		TargetSeconds: req.TargetSeconds,
+	}
+
+	if req.ExerciseID != nil {
+		exercise := findSessionExercise(session, *req.ExerciseID)
+		if exercise == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Exercise is not part of this session"})
+			return
+		}
+		if rest.TargetSeconds == nil {
+			rest.TargetSeconds = restAfterSet(session, exercise, nextSetNumber(exercise)-1)
+		}
+	}
+
+	stopped, err := h.programRepo.StartRest(rest)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to start rest timer")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start rest timer"})
+		return
+	}
+
+	if stopped != nil {
+		h.publishSessionEvent(eventWorkoutRestStop, session, map[string]interface{}{"rest": stopped})
+	}
+	h.publishSessionEvent(eventWorkoutRestStart, session, map[string]interface{}{"rest": rest})
+
+	c.JSON(http.StatusCreated, gin.H{"rest": rest})
+}
+
+// StopRestTimer stops the running rest timer
+func (h *ProgramHandlers) StopRestTimer(c *gin.Context) {
+	session, ok := h.ownLiveSession(c)
+	if !ok {
+		return
+	}
+
+	stopped, err := h.programRepo.StopRest(session.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to stop rest timer")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop rest timer"})
+		return
+	}
+
+	if stopped == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No rest timer running"})
+		return
+	}
+
+	h.publishSessionEvent(eventWorkoutRestStop, session, map[string]interface{}{"rest": stopped})
+
+	c.JSON(http.StatusOK, gin.H{"rest": stopped})
+}
+
+// FinishLiveSession completes the session, stopping any running rest timer
+func (h *ProgramHandlers) FinishLiveSession(c *gin.Context) {
+	session, ok := h.ownLiveSession(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req models.FinishSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	duration := req.DurationMins
+	if duration == nil {
+		startedAt, err := h.programRepo.GetSessionStartedAt(session.ID)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get session start")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to finish session"})
+			return
+		}
+		if startedAt != nil {
+			minutes := int(time.Since(*startedAt).Minutes())
+			duration = &minutes
+		}
+	}
+
+	stopped, err := h.programRepo.StopRest(session.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to stop rest timer")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to finish session"})
+		return
+	}
+	if stopped != nil {
+		h.publishSessionEvent(eventWorkoutRestStop, session, map[string]interface{}{"rest": stopped})
+	}
+
+	if err := h.programRepo.CompleteSession(session.ID, req.Notes, req.RPERating, duration); err != nil {
+		log.Error().Err(err).Msg("Failed to complete session")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to finish session"})
+		return
+	}
+
+	finished, err := h.programRepo.GetTrainingSessionByID(session.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load finished session")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load session"})
+		return
+	}
+
+	data := map[string]interface{}{
+		"workout_id":        finished.ID.String(),
+		"exercises_summary": exercisesSummary(finished),
+	}
+	if len(finished.Groups) > 0 {
+		data["groups"] = groupsProgress(finished)
+	}
+	if finished.DurationMins != nil {
+		data["duration_minutes"] = *finished.DurationMins
+	}
+	if finished.Notes != nil {
+		data["notes"] = *finished.Notes
+	}
+	h.publishSessionEvent(eventWorkoutCompleted, finished, data)
+
+	c.JSON(http.StatusOK, gin.H{"session": finished})
+}
+
+// ownSession loads the session in the path and checks it belongs to the caller,
+// writing the error response when it doesn't
+func (h *ProgramHandlers) ownSession(c *gin.Context) (*models.TrainingSession, bool) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return nil, false
+	}
+
+	session, err := h.programRepo.GetTrainingSessionByID(sessionID)
+	if err != nil || session.DeletedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+
+	if session.AthleteID.String() != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return session, true
+}
+
+// viewableSession is ownSession that also lets in coaches with access to the
+// athlete's program
+func (h *ProgramHandlers) viewableSession(c *gin.Context) (*models.TrainingSession, bool) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return nil, false
+	}
+
+	session, err := h.programRepo.GetTrainingSessionByID(sessionID)
+	if err != nil || session.DeletedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+
+	if session.AthleteID.String() != userID {
+		program, err := h.programRepo.GetProgramByID(session.ProgramID)
+		if err != nil || !h.hasAccessToProgram(c, userID, program) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return nil, false
+		}
+	}
+
+	return session, true
+}
+
+// ownLiveSession is ownSession for steps that need the session to be unfinished
+func (h *ProgramHandlers) ownLiveSession(c *gin.Context) (*models.TrainingSession, bool) {
+	session, ok := h.ownSession(c)
+	if !ok {
+		return nil, false
+	}
+
+	if session.CompletedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Session is already finished"})
+		return nil, false
+	}
+
+	return session, true
+}
+
+func (h *ProgramHandlers) liveSessionState(session *models.TrainingSession) (*models.LiveSessionState, error) {
+	startedAt, err := h.programRepo.GetSessionStartedAt(session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	activeRest, err := h.programRepo.GetActiveRest(session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &models.LiveSessionState{
+		Session:     *session,
+		StartedAt:   startedAt,
+		ActiveRest:  activeRest,
+		NextTargets: []models.LiveSetTarget{},
+		Groups:      groupsProgress(session),
+	}
+	for i := range session.Exercises {
+		if target := h.nextSetTarget(session, &session.Exercises[i]); target != nil {
+			state.NextTargets = append(state.NextTargets, *target)
+		}
+	}
+
+	return state, nil
+}
+
+// nextSetTarget returns the prescription for the exercise's next set, or nil once
+// all prescribed sets are done. The suggested weight is the prescribed weight,
+// else the last set logged today, else the same set from the previous session.
+// RestSeconds is the rest due after the set, which within a group depends on
+// where the set falls in the round.
+func (h *ProgramHandlers) nextSetTarget(session *models.TrainingSession, exercise *models.Exercise) *models.LiveSetTarget {
+	next := nextSetNumber(exercise)
+	var lastWeight *float64
+	if n := len(exercise.CompletedSets); n > 0 {
+		lastWeight = &exercise.CompletedSets[n-1].WeightKg
+	}
+	if next > exercise.TargetSets {
+		return nil
+	}
+
+	target := &models.LiveSetTarget{
+		ExerciseID:            exercise.ID,
+		ExerciseName:          exercise.ExerciseName,
+		SetNumber:             next,
+		TargetSets:            exercise.TargetSets,
+		TargetReps:            exercise.TargetReps,
+		TargetWeightKg:        exercise.TargetWeightKg,
+		TargetRPE:             exercise.TargetRPE,
+		RestSeconds:           restAfterSet(session, exercise, next),
+		VelocityLossCutoffPct: exercise.VelocityLossCutoffPct,
+	}
+
+	if group := findSessionGroup(session, exercise.GroupID); group != nil {
+		label := groupLabel(group, exercise)
+		round := group.RoundOfSet(*exercise, next)
+		target.GroupLabel = &label
+		target.RoundNumber = &round
+	}
+
+	previousSets, err := h.programRepo.GetPreviousSetsForExercise(session.AthleteID, exercise.ExerciseName, 1)
+	if err != nil {
+		log.Warn().Err(err).Str("exercise", exercise.ExerciseName).Msg("Failed to get previous sets for autofill")
+	}
+	for i, prev := range previousSets {
+		// Rows are newest session first; only the most recent session is used
+		if !prev.SessionDate.Equal(previousSets[0].SessionDate) {
+			break
+		}
+		if prev.SetNumber == next {
+			target.Previous = &previousSets[i]
+			break
+		}
+	}
+
+	switch {
+	case exercise.TargetWeightKg != nil:
+		target.SuggestedWeightKg = exercise.TargetWeightKg
+	case lastWeight != nil:
+		target.SuggestedWeightKg = lastWeight
+	case target.Previous != nil:
+		target.SuggestedWeightKg = &target.Previous.WeightKg
+	}
+
+	return target
+}
+
+// nextSetNumber is the number the exercise's next set will get
+func nextSetNumber(exercise *models.Exercise) int {
+	next := 1
+	for _, set := range exercise.CompletedSets {
+		if set.SetNumber >= next {
+			next = set.SetNumber + 1
+		}
+	}
+	return next
+}
+
+func findSessionExercise(session *models.TrainingSession, exerciseID uuid.UUID) *models.Exercise {
+	for i := range session.Exercises {
+		if session.Exercises[i].ID == exerciseID {
+			return &session.Exercises[i]
+		}
+	}
+	return nil
+}
+
+func exercisesSummary(session *models.TrainingSession) []map[string]interface{} {
+	summary := make([]map[string]interface{}, 0, len(session.Exercises))
+	for i, exercise := range session.Exercises {
+		var volume, top float64
+		for _, set := range exercise.CompletedSets {
+			volume += set.WeightKg * float64(set.RepsCompleted)
+			if set.WeightKg > top {
+				top = set.WeightKg
+			}
+		}
+		entry := map[string]interface{}{
+			"exercise_id":    exercise.ID.String(),
+			"exercise_name":  exercise.ExerciseName,
+			"sets_completed": len(exercise.CompletedSets),
+			"top_weight_kg":  top,
+			"volume_kg":      volume,
+		}
+		if group := findSessionGroup(session, exercise.GroupID); group != nil {
+			entry["group_label"] = groupLabel(group, &session.Exercises[i])
+		}
+		summary = append(summary, entry)
+	}
+	return summary
+}
+
+// publishSessionEvent publishes a live session step so coaches can follow along
+func (h *ProgramHandlers) publishSessionEvent(eventType string, session *models.TrainingSession, data map[string]interface{}) {
+	if h.publisher == nil {
+		return
+	}
+
+	data["session_id"] = session.ID.String()
+	data["program_id"] = session.ProgramID.String()
+
+	event := map[string]interface{}{
+		"schema_version":      "1.0.0",
+		"event_type":          eventType,
+		"client_generated_id": uuid.New().String(),
+		"user_id":             session.AthleteID.String(),
+		"timestamp":           time.Now().UTC().Format(time.RFC3339),
+		"source_service":      "program-service",
+		"data":                data,
+	}
+	if err := h.publisher.PublishEvent(eventType, event); err != nil {
+		log.Error().Err(err).Str("event_type", eventType).Msg("Failed to publish live session event")
+	}
+}
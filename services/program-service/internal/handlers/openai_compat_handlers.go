@@ -1,110 +1,328 @@
 package handlers
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/ai"
+	"github.com/powerlifting-coach-app/program-service/internal/clients"
 	"github.com/powerlifting-coach-app/program-service/internal/config"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/powerlifting-coach-app/program-service/internal/repository"
+	"github.com/powerlifting-coach-app/program-service/internal/services"
 	"github.com/rs/zerolog/log"
 )
 
+// coachContextHeader lets a client opt out of context injection with "false"
+const coachContextHeader = "X-Coach-Context"
+
+// proxiedParams are the OpenAI request fields passed through to the provider
+// besides those ChatCompletionRequest models
+var proxiedParams = []string{
+	"temperature",
+	"top_p",
+	"stop",
+	"seed",
+	"presence_penalty",
+	"frequency_penalty",
+	"logit_bias",
+	"tool_choice",
+	"parallel_tool_calls",
+}
+
 type OpenAICompatHandlers struct {
-	cfg *config.Config
+	cfg            *config.Config
+	programRepo    *repository.ProgramRepository
+	aiClient       *ai.Client
+	settingsClient *clients.SettingsClient
+	rateLimiter    *services.RateLimiter
+	allowedModels  map[string]bool
 }
 
-func NewOpenAICompatHandlers(cfg *config.Config) *OpenAICompatHandlers {
+func NewOpenAICompatHandlers(
+	cfg *config.Config,
+	programRepo *repository.ProgramRepository,
+	aiClient *ai.Client,
+	settingsClient *clients.SettingsClient,
+) *OpenAICompatHandlers {
+	allowed := make(map[string]bool)
+	for _, model := range cfg.AIProxyAllowedModels {
+		allowed[model] = true
+	}
+
 	return &OpenAICompatHandlers{
-		cfg: cfg,
+		cfg:            cfg,
+		programRepo:    programRepo,
+		aiClient:       aiClient,
+		settingsClient: settingsClient,
+		rateLimiter:    services.NewRateLimiter(cfg.AIProxyRateLimit, time.Minute),
+		allowedModels:  allowed,
+	}
+}
+
+// openAIError responds in the OpenAI error format so chat UI libraries can show it
+func openAIError(c *gin.Context, status int, errType string, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    errType,
+		},
+	})
+}
+
+// RateLimit limits each user to AIProxyRateLimit requests per minute
+func (h *OpenAICompatHandlers) RateLimit(c *gin.Context) {
+	allowed, retryAfter := h.rateLimiter.Allow(middleware.GetUserID(c))
+	if !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		openAIError(c, http.StatusTooManyRequests, "rate_limit_exceeded", "Too many requests, please slow down")
+		return
 	}
+	c.Next()
 }
 
 func (h *OpenAICompatHandlers) ChatCompletions(c *gin.Context) {
-	body, err := io.ReadAll(c.Request.Body)
+	userID, err := uuid.Parse(middleware.GetUserID(c))
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to read request body")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request"})
+		openAIError(c, http.StatusUnauthorized, "authentication_error", "User not authenticated")
 		return
 	}
-	defer c.Request.Body.Close()
 
-	litellmURL := fmt.Sprintf("%s/v1/chat/completions", h.cfg.LiteLLMEndpoint)
-	req, err := http.NewRequest(http.MethodPost, litellmURL, bytes.NewBuffer(body))
+	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to create proxy request")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create proxy request"})
+		openAIError(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
 		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	var req ai.ChatCompletionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		openAIError(c, http.StatusBadRequest, "invalid_request_error", "Request body must be a JSON object")
+		return
+	}
+	// Forward the sampling parameters this proxy doesn't model; anything else,
+	// such as api_base, user or n, never reaches the provider
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		openAIError(c, http.StatusBadRequest, "invalid_request_error", "Request body must be a JSON object")
+		return
+	}
+	req.Extra = make(map[string]json.RawMessage)
+	for _, field := range proxiedParams {
+		if value, ok := fields[field]; ok {
+			req.Extra[field] = value
+		}
+	}
+	// Each request gets a single choice of at most AIProxyMaxTokens tokens
+	if req.MaxTokens <= 0 || req.MaxTokens > h.cfg.AIProxyMaxTokens {
+		req.MaxTokens = h.cfg.AIProxyMaxTokens
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Error().Err(err).Str("litellm_url", litellmURL).Msg("Failed to forward to LiteLLM")
-		c.JSON(http.StatusBadGateway, gin.H{
-			"error":        "Failed to forward request to LiteLLM",
-			"error_detail": err.Error(),
-			"litellm_url":  litellmURL,
-		})
+	if req.Model == "" {
+		req.Model = h.aiClient.Models().Chat
+	}
+	if !h.allowedModels[req.Model] {
+		openAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Model %q is not available", req.Model))
 		return
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to read LiteLLM response")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read LiteLLM response"})
+	if len(req.Messages) == 0 {
+		openAIError(c, http.StatusBadRequest, "invalid_request_error", "messages must be a non-empty array")
 		return
 	}
 
-	for key, values := range resp.Header {
-		for _, value := range values {
-			c.Header(key, value)
+	if h.cfg.AIProxyInjectContext && c.GetHeader(coachContextHeader) != "false" {
+		if system, ok := h.coachContextMessage(c, userID); ok {
+			req.Messages = append([]ai.Message{system}, req.Messages...)
 		}
 	}
 
-	c.Data(resp.StatusCode, "application/json", respBody)
-}
+	ctx := ai.WithUsage(c.Request.Context(), userID, models.AIFeatureOpenAICompat)
 
-func (h *OpenAICompatHandlers) ListModels(c *gin.Context) {
-	litellmURL := fmt.Sprintf("%s/v1/models", h.cfg.LiteLLMEndpoint)
-	req, err := http.NewRequest(http.MethodGet, litellmURL, nil)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to create proxy request")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create proxy request"})
+	if req.Stream {
+		h.relayStream(c, ctx, req)
 		return
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	completion, err := h.aiClient.ProxyChatCompletion(ctx, req)
 	if err != nil {
-		log.Error().Err(err).Str("litellm_url", litellmURL).Msg("Failed to forward to LiteLLM")
-		c.JSON(http.StatusBadGateway, gin.H{
-			"error":        "Failed to forward request to LiteLLM",
-			"error_detail": err.Error(),
-			"litellm_url":  litellmURL,
-		})
+		h.upstreamError(c, err)
+		return
+	}
+
+	if completion.Raw != nil {
+		c.Data(http.StatusOK, "application/json", completion.Raw)
+		return
+	}
+	c.JSON(http.StatusOK, completion)
+}
+
+// relayStream sends each chunk to the client as a server-sent event. Errors
+// before the first chunk get a normal error response; after that the stream is
+// simply closed.
+func (h *OpenAICompatHandlers) relayStream(c *gin.Context, ctx context.Context, req ai.ChatCompletionRequest) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	started := false
+	err := h.aiClient.ProxyStreamChatCompletion(ctx, req, func(chunk ai.ChatCompletionChunk) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !started {
+			c.Writer.Header().Set("Content-Type", "text/event-stream")
+			c.Writer.Header().Set("Cache-Control", "no-cache")
+			c.Writer.Header().Set("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+			started = true
+		}
+
+		data := []byte(chunk.Raw)
+		if data == nil {
+			var err error
+			if data, err = json.Marshal(chunk); err != nil {
+				log.Warn().Err(err).Msg("Failed to encode stream chunk")
+				return
+			}
+		}
+
+		if _, err := c.Writer.WriteString("data: " + string(data) + "\n\n"); err != nil {
+			log.Info().Msg("Client disconnected from proxied stream")
+			cancel()
+			return
+		}
+		c.Writer.Flush()
+	})
+
+	if !started {
+		if err == nil {
+			err = fmt.Errorf("stream ended without content")
+		}
+		h.upstreamError(c, err)
 		return
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to read LiteLLM response")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read LiteLLM response"})
+		if ctx.Err() == nil {
+			log.Warn().Err(err).Msg("Proxied stream ended early")
+		}
 		return
 	}
 
-	var modelsResponse map[string]interface{}
-	if err := json.Unmarshal(respBody, &modelsResponse); err != nil {
-		log.Error().Err(err).Msg("Failed to parse models response")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse models response"})
+	c.Writer.WriteString("data: [DONE]\n\n")
+	c.Writer.Flush()
+}
+
+// upstreamError logs the provider's error and returns a generic one, so internal
+// URLs and provider details never reach the client
+func (h *OpenAICompatHandlers) upstreamError(c *gin.Context, err error) {
+	var apiErr *ai.APIError
+	if !errors.As(err, &apiErr) {
+		log.Error().Err(err).Msg("Failed to get a completion from the model service")
+		openAIError(c, http.StatusBadGateway, "api_error", "The model service is unavailable")
 		return
 	}
 
-	c.JSON(resp.StatusCode, modelsResponse)
+	log.Error().
+		Int("status", apiErr.StatusCode).
+		Str("body", apiErr.Body).
+		Msg("LiteLLM returned an error")
+
+	switch {
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		openAIError(c, http.StatusTooManyRequests, "rate_limit_exceeded", "The model service is busy, please retry shortly")
+	case apiErr.StatusCode >= 400 && apiErr.StatusCode < 500:
+		openAIError(c, http.StatusBadRequest, "invalid_request_error", "The model service rejected the request")
+	default:
+		openAIError(c, http.StatusBadGateway, "api_error", "The model service returned an error")
+	}
+}
+
+// coachContextMessage builds the same coach system prompt /programs/chat uses,
+// with the athlete's profile and active program
+func (h *OpenAICompatHandlers) coachContextMessage(c *gin.Context, userID uuid.UUID) (ai.Message, bool) {
+	profile := "Athlete profile not available"
+	if authToken := c.GetHeader("Authorization"); authToken != "" && h.settingsClient != nil {
+		settings, err := h.settingsClient.GetUserSettings(c.Request.Context(), authToken)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to fetch athlete profile from settings service")
+		} else {
+			profile = settings.FormatAthleteProfile()
+		}
+	}
+
+	if episodes, err := h.programRepo.GetPainEpisodes(userID, "active"); err != nil {
+		log.Warn().Err(err).Msg("Failed to get active pain episodes for proxy context")
+	} else {
+		profile += formatActivePain(episodes)
+	}
+
+	program, err := h.programRepo.GetActiveApprovedProgramByAthleteID(userID)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get active program for proxy context")
+	} else if program != nil {
+		profile += formatActiveProgram(program)
+	}
+
+	system, err := h.aiClient.CoachSystemPrompt(userID.String(), profile, "")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to render coach context")
+		return ai.Message{}, false
+	}
+
+	return ai.Message{Role: "system", Content: system.Text}, true
+}
+
+// formatActiveProgram summarises the active program and its current week
+func formatActiveProgram(program *models.Program) string {
+	week := int(time.Since(program.StartDate).Hours()/(24*7)) + 1
+	if week < 1 {
+		week = 1
+	}
+
+	summary := fmt.Sprintf("\n\n## Active Program\n\n**%s** (%s phase), week %d of %d, %d days per week\n",
+		program.Name, program.Phase, week, program.WeeksTotal, program.DaysPerWeek)
+
+	weeks, _ := program.ProgramData["weeklyWorkouts"].([]interface{})
+	for _, w := range weeks {
+		weekData, ok := w.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if n, _ := weekData["week"].(float64); int(n) == week {
+			if workouts, err := json.Marshal(weekData["workouts"]); err == nil {
+				summary += "\nThis week's workouts: " + string(workouts) + "\n"
+			}
+			break
+		}
+	}
+
+	return summary
+}
+
+// ListModels lists the models the proxy allows rather than everything LiteLLM
+// is configured with
+func (h *OpenAICompatHandlers) ListModels(c *gin.Context) {
+	data := make([]gin.H, 0, len(h.cfg.AIProxyAllowedModels))
+	for _, model := range h.cfg.AIProxyAllowedModels {
+		data = append(data, gin.H{
+			"id":       model,
+			"object":   "model",
+			"owned_by": "powerlifting-coach",
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"object": "list",
+		"data":   data,
+	})
 }
@@ -0,0 +1,550 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/clients"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultPainTrendWeeks = 12
+
+// CreatePainEpisode opens a pain episode for the caller with its first score
+func (h *ProgramHandlers) CreatePainEpisode(c *gin.Context) {
+	athleteID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CreatePainEpisodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sessionID, ok := h.painReportSession(c, athleteID, req.SessionID, req.SetID)
+	if !ok {
+		return
+	}
+
+	episode := &models.PainEpisode{
+		AthleteID:          athleteID,
+		BodyRegion:         req.BodyRegion,
+		Side:               req.Side,
+		TriggeringMovement: trimmedOrNil(req.TriggeringMovement),
+		LiftType:           req.LiftType,
+		StartedOn:          time.Now(),
+		Notes:              req.Notes,
+	}
+	if req.StartedOn != nil {
+		episode.StartedOn = *req.StartedOn
+	}
+	report := &models.PainReport{
+		PainScore: *req.PainScore,
+		SessionID: sessionID,
+		SetID:     req.SetID,
+		Notes:     req.Notes,
+	}
+
+	if err := h.programRepo.CreatePainEpisode(episode, report); err != nil {
+		log.Error().Err(err).Msg("Failed to create pain episode")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pain episode"})
+		return
+	}
+
+	created, err := h.programRepo.GetPainEpisodeByID(episode.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get pain episode")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pain episode"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// GetPainEpisodes lists the athlete's pain episodes, filtered by status=active
+// or status=resolved. Coaches pass athlete_id to read an athlete's log.
+func (h *ProgramHandlers) GetPainEpisodes(c *gin.Context) {
+	athleteID, ok := h.painLogAthlete(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && status != "active" && status != "resolved" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or resolved"})
+		return
+	}
+
+	episodes, err := h.programRepo.GetPainEpisodes(athleteID, status)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get pain episodes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pain episodes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"episodes": episodes, "total": len(episodes)})
+}
+
+// GetPainEpisode returns one episode with all of its reports
+func (h *ProgramHandlers) GetPainEpisode(c *gin.Context) {
+	episode, ok := h.painEpisode(c, false)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, episode)
+}
+
+func (h *ProgramHandlers) UpdatePainEpisode(c *gin.Context) {
+	episode, ok := h.painEpisode(c, true)
+	if !ok {
+		return
+	}
+
+	var req models.UpdatePainEpisodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.BodyRegion != nil {
+		episode.BodyRegion = *req.BodyRegion
+	}
+	if req.Side != nil {
+		episode.Side = req.Side
+	}
+	if req.TriggeringMovement != nil {
+		episode.TriggeringMovement = trimmedOrNil(req.TriggeringMovement)
+	}
+	if req.LiftType != nil {
+		episode.LiftType = req.LiftType
+	}
+	if req.StartedOn != nil {
+		if episode.ResolvedOn != nil && req.StartedOn.After(*episode.ResolvedOn) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "started_on can't be after resolved_on"})
+			return
+		}
+		episode.StartedOn = *req.StartedOn
+	}
+	if req.Notes != nil {
+		episode.Notes = req.Notes
+	}
+
+	if err := h.programRepo.UpdatePainEpisode(episode); err != nil {
+		log.Error().Err(err).Msg("Failed to update pain episode")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pain episode"})
+		return
+	}
+
+	h.respondPainEpisode(c, episode.ID)
+}
+
+func (h *ProgramHandlers) DeletePainEpisode(c *gin.Context) {
+	episode, ok := h.painEpisode(c, true)
+	if !ok {
+		return
+	}
+
+	if err := h.programRepo.DeletePainEpisode(episode.ID); err != nil {
+		log.Error().Err(err).Msg("Failed to delete pain episode")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete pain episode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pain episode deleted successfully"})
+}
+
+// AddPainReport logs another pain score for an episode, optionally tied to the
+// session or set it came up in
+func (h *ProgramHandlers) AddPainReport(c *gin.Context) {
+	episode, ok := h.painEpisode(c, true)
+	if !ok {
+		return
+	}
+
+	var req models.AddPainReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sessionID, ok := h.painReportSession(c, episode.AthleteID, req.SessionID, req.SetID)
+	if !ok {
+		return
+	}
+
+	report := &models.PainReport{
+		EpisodeID: episode.ID,
+		PainScore: *req.PainScore,
+		SessionID: sessionID,
+		SetID:     req.SetID,
+		Notes:     req.Notes,
+	}
+	if req.ReportedAt != nil {
+		report.ReportedAt = *req.ReportedAt
+	}
+
+	if err := h.programRepo.AddPainReport(report); err != nil {
+		log.Error().Err(err).Msg("Failed to add pain report")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add pain report"})
+		return
+	}
+
+	h.respondPainEpisode(c, episode.ID)
+}
+
+// ResolvePainEpisode marks an episode resolved, today unless a date is given
+func (h *ProgramHandlers) ResolvePainEpisode(c *gin.Context) {
+	episode, ok := h.painEpisode(c, true)
+	if !ok {
+		return
+	}
+
+	var req models.ResolvePainEpisodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resolvedOn := time.Now()
+	if req.ResolvedOn != nil {
+		resolvedOn = *req.ResolvedOn
+	}
+	if resolvedOn.Before(episode.StartedOn) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resolved_on can't be before started_on"})
+		return
+	}
+
+	if err := h.programRepo.SetPainEpisodeResolved(episode.ID, &resolvedOn); err != nil {
+		log.Error().Err(err).Msg("Failed to resolve pain episode")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve pain episode"})
+		return
+	}
+
+	h.respondPainEpisode(c, episode.ID)
+}
+
+// ReopenPainEpisode makes a resolved episode active again
+func (h *ProgramHandlers) ReopenPainEpisode(c *gin.Context) {
+	episode, ok := h.painEpisode(c, true)
+	if !ok {
+		return
+	}
+
+	if err := h.programRepo.SetPainEpisodeResolved(episode.ID, nil); err != nil {
+		log.Error().Err(err).Msg("Failed to reopen pain episode")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reopen pain episode"})
+		return
+	}
+
+	h.respondPainEpisode(c, episode.ID)
+}
+
+// GetPainTrends puts weekly pain scores next to weekly training load over the
+// last `weeks` weeks (12 by default), optionally for one body region
+func (h *ProgramHandlers) GetPainTrends(c *gin.Context) {
+	athleteID, ok := h.painLogAthlete(c)
+	if !ok {
+		return
+	}
+
+	weeks := defaultPainTrendWeeks
+	if raw := c.Query("weeks"); raw != "" {
+		var err error
+		weeks, err = strconv.Atoi(raw)
+		if err != nil || weeks < 1 || weeks > 104 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weeks must be between 1 and 104"})
+			return
+		}
+	}
+
+	var region *string
+	if raw := c.Query("region"); raw != "" {
+		region = &raw
+	}
+
+	trends, err := h.programRepo.GetPainTrends(athleteID, time.Now().AddDate(0, 0, -7*weeks), region)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get pain trends")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pain trends"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"weeks": trends})
+}
+
+// GetPainFlags flags exercises in the athlete's upcoming sessions that
+// aggravated a pain episode that is still active
+func (h *ProgramHandlers) GetPainFlags(c *gin.Context) {
+	athleteID, ok := h.painLogAthlete(c)
+	if !ok {
+		return
+	}
+
+	limit := 14
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+			return
+		}
+	}
+
+	episodes, err := h.programRepo.GetPainEpisodes(athleteID, "active")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get pain episodes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pain episodes"})
+		return
+	}
+
+	flags := []models.PainFlag{}
+	if len(episodes) > 0 {
+		sessions, err := h.programRepo.GetUpcomingSessions(athleteID, limit)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get upcoming sessions")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get upcoming sessions"})
+			return
+		}
+		for i := range sessions {
+			exercises, err := h.programRepo.GetExercisesBySessionID(sessions[i].ID)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to get exercises")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get upcoming sessions"})
+				return
+			}
+			sessions[i].Exercises = exercises
+		}
+		flags = painFlags(sessions, episodes)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"flags": flags, "active_episodes": len(episodes)})
+}
+
+// painFlags matches upcoming exercises against what aggravated each active
+// episode: the exercises its reports were tied to, its triggering movement and,
+// for a competition lift, the lift itself
+func painFlags(sessions []models.TrainingSession, episodes []models.PainEpisode) []models.PainFlag {
+	flags := []models.PainFlag{}
+	for _, session := range sessions {
+		for _, exercise := range session.Exercises {
+			for _, episode := range episodes {
+				reason := painFlagReason(exercise, episode)
+				if reason == "" {
+					continue
+				}
+				flags = append(flags, models.PainFlag{
+					SessionID:     session.ID,
+					SessionName:   session.SessionName,
+					ScheduledDate: session.ScheduledDate,
+					ExerciseID:    exercise.ID,
+					ExerciseName:  exercise.ExerciseName,
+					EpisodeID:     episode.ID,
+					BodyRegion:    episode.BodyRegion,
+					CurrentScore:  episode.CurrentScore,
+					Reason:        reason,
+				})
+			}
+		}
+	}
+	return flags
+}
+
+func painFlagReason(exercise models.Exercise, episode models.PainEpisode) string {
+	name := strings.ToLower(exercise.ExerciseName)
+	for _, aggravating := range episode.AggravatingExercises {
+		if strings.EqualFold(aggravating, exercise.ExerciseName) {
+			return fmt.Sprintf("Pain was reported during %s", aggravating)
+		}
+	}
+	if episode.TriggeringMovement != nil {
+		movement := strings.ToLower(*episode.TriggeringMovement)
+		if strings.Contains(name, movement) || strings.Contains(movement, name) {
+			return fmt.Sprintf("Matches the triggering movement %q", *episode.TriggeringMovement)
+		}
+	}
+	if episode.LiftType != nil && *episode.LiftType != models.LiftTypeAccessory && exercise.LiftType == *episode.LiftType {
+		return fmt.Sprintf("The %s aggravated this issue", *episode.LiftType)
+	}
+	return ""
+}
+
+// formatActivePain describes active pain episodes for the AI athlete profile
+func formatActivePain(episodes []models.PainEpisode) string {
+	if len(episodes) == 0 {
+		return ""
+	}
+
+	profile := "\n### Active Pain and Injuries\n"
+	for _, episode := range episodes {
+		region := strings.ReplaceAll(episode.BodyRegion, "_", " ")
+		if episode.Side != nil {
+			region = fmt.Sprintf("%s (%s)", region, *episode.Side)
+		}
+		line := fmt.Sprintf("- **%s**: since %s", region, episode.StartedOn.Format("2006-01-02"))
+		if episode.CurrentScore != nil {
+			line += fmt.Sprintf(", pain %d/10 now", *episode.CurrentScore)
+		}
+		if episode.PeakScore != nil {
+			line += fmt.Sprintf(", peak %d/10", *episode.PeakScore)
+		}
+		if episode.TriggeringMovement != nil {
+			line += fmt.Sprintf("; triggered by %s", *episode.TriggeringMovement)
+		}
+		if len(episode.AggravatingExercises) > 0 {
+			line += fmt.Sprintf("; aggravated by %s", strings.Join(episode.AggravatingExercises, ", "))
+		}
+		profile += line + "\n"
+	}
+	return profile
+}
+
+// activePainProfile is the athlete's active pain for the AI profile, or empty
+// when there is none or it can't be loaded
+func (h *ProgramHandlers) activePainProfile(athleteID uuid.UUID) string {
+	episodes, err := h.programRepo.GetPainEpisodes(athleteID, "active")
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get active pain episodes for athlete profile")
+		return ""
+	}
+	return formatActivePain(episodes)
+}
+
+// painLogAthlete is the athlete whose pain log a read is for: the caller, or
+// the athlete_id of an athlete they coach
+func (h *ProgramHandlers) painLogAthlete(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return uuid.Nil, false
+	}
+
+	raw := c.Query("athlete_id")
+	if raw == "" {
+		return userID, true
+	}
+
+	athleteID, err := uuid.Parse(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid athlete ID"})
+		return uuid.Nil, false
+	}
+	if !h.hasAccessToAthlete(c, userID, athleteID, clients.ScopeViewHistory) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return uuid.Nil, false
+	}
+	if athleteID != userID {
+		recordCoachDataAccess(c, coachDataAccess{
+			coachID:      userID,
+			athleteID:    athleteID,
+			action:       "data_viewed",
+			resourceType: "pain_log",
+		})
+	}
+
+	return athleteID, true
+}
+
+// painEpisode loads the :episodeId episode. Only the athlete can change it;
+// their coaches can read it.
+func (h *ProgramHandlers) painEpisode(c *gin.Context, write bool) (*models.PainEpisode, bool) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	episodeID, err := uuid.Parse(c.Param("episodeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		return nil, false
+	}
+
+	episode, err := h.programRepo.GetPainEpisodeByID(episodeID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pain episode not found"})
+		return nil, false
+	}
+
+	if episode.AthleteID != userID {
+		if write || !h.hasAccessToAthlete(c, userID, episode.AthleteID, clients.ScopeViewHistory) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return nil, false
+		}
+		recordCoachDataAccess(c, coachDataAccess{
+			coachID:      userID,
+			athleteID:    episode.AthleteID,
+			action:       "data_viewed",
+			resourceType: "pain_episode",
+			resourceID:   episode.ID,
+		})
+	}
+
+	return episode, true
+}
+
+// painReportSession checks that the session and set a report is tied to are
+// the athlete's and belong together, and fills in the session of a set
+func (h *ProgramHandlers) painReportSession(c *gin.Context, athleteID uuid.UUID, sessionID, setID *uuid.UUID) (*uuid.UUID, bool) {
+	if setID != nil {
+		_, setSessionID, err := h.programRepo.GetCompletedSetByID(*setID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Set not found"})
+			return nil, false
+		}
+		if sessionID != nil && *sessionID != setSessionID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Set is not part of this session"})
+			return nil, false
+		}
+		sessionID = &setSessionID
+	}
+	if sessionID == nil {
+		return nil, true
+	}
+
+	session, err := h.programRepo.GetTrainingSessionByID(*sessionID)
+	if err != nil || session.DeletedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+	if session.AthleteID != athleteID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return sessionID, true
+}
+
+func (h *ProgramHandlers) respondPainEpisode(c *gin.Context, episodeID uuid.UUID) {
+	episode, err := h.programRepo.GetPainEpisodeByID(episodeID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get pain episode")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pain episode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, episode)
+}
+
+func trimmedOrNil(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -298,15 +299,20 @@ func (h *ProgramEventHandlers) HandleWorkoutStarted(ctx context.Context, payload
 		return nil
 	}
 
+	sessionID, err := resolveWorkoutSession(ctx, tx, workoutID, userID, startTime)
+	if err != nil {
+		return err
+	}
+
 	query := `
-	INSERT INTO workout_sessions (workout_session_id, user_id, started_at, status)
-	VALUES ($1, $2, $3, 'in_progress')
-	ON CONFLICT (workout_session_id) DO NOTHING
+	UPDATE training_sessions
+	SET started_at = COALESCE(started_at, $1), modified_at = NOW()
+	WHERE id = $2
 	`
 
-	_, err = tx.ExecContext(ctx, query, workoutID, userID, startTime)
+	_, err = tx.ExecContext(ctx, query, startTime, sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to insert workout session: %w", err)
+		return fmt.Errorf("failed to start training session: %w", err)
 	}
 
 	idempotencyQuery := `INSERT INTO idempotency_keys (key, event_type, processed_at) VALUES ($1, $2, NOW())`
@@ -321,6 +327,7 @@ func (h *ProgramEventHandlers) HandleWorkoutStarted(ctx context.Context, payload
 
 	log.Info().
 		Str("workout_id", workoutID.String()).
+		Str("session_id", sessionID.String()).
 		Str("user_id", event.UserID).
 		Msg("Workout started")
 
@@ -335,10 +342,10 @@ type WorkoutCompletedEvent struct {
 	Timestamp         string `json:"timestamp"`
 	SourceService     string `json:"source_service"`
 	Data              struct {
-		WorkoutID        string      `json:"workout_id"`
-		DurationMinutes  int         `json:"duration_minutes"`
-		ExercisesSummary interface{} `json:"exercises_summary"`
-		Notes            string      `json:"notes"`
+		WorkoutID        string          `json:"workout_id"`
+		DurationMinutes  int             `json:"duration_minutes"`
+		ExercisesSummary json.RawMessage `json:"exercises_summary"`
+		Notes            string          `json:"notes"`
 	} `json:"data"`
 }
 
@@ -358,9 +365,22 @@ func (h *ProgramEventHandlers) HandleWorkoutCompleted(ctx context.Context, paylo
 		return fmt.Errorf("invalid workout_id: %w", err)
 	}
 
-	summaryJSON, err := json.Marshal(event.Data.ExercisesSummary)
-	if err != nil {
-		return fmt.Errorf("failed to marshal exercises summary: %w", err)
+	// Sessions run through the live session API are already recorded set by set
+	if event.SourceService == "program-service" {
+		return nil
+	}
+
+	var summary []WorkoutSummaryExercise
+	if len(event.Data.ExercisesSummary) > 0 && string(event.Data.ExercisesSummary) != "null" {
+		if err := json.Unmarshal(event.Data.ExercisesSummary, &summary); err != nil {
+			log.Warn().Err(err).Str("workout_id", workoutID.String()).Msg("Ignoring unreadable exercises summary")
+			summary = nil
+		}
+	}
+
+	completedAt := time.Now().UTC()
+	if ts, err := time.Parse(time.RFC3339, event.Timestamp); err == nil {
+		completedAt = ts
 	}
 
 	tx, err := h.db.BeginTx(ctx, nil)
@@ -380,31 +400,28 @@ func (h *ProgramEventHandlers) HandleWorkoutCompleted(ctx context.Context, paylo
 		return nil
 	}
 
-	query := `
-	UPDATE workout_sessions
-	SET completed_at = NOW(), duration_minutes = $1, exercises_summary = $2, notes = $3, status = 'completed'
-	WHERE workout_session_id = $4 AND user_id = $5
-	`
-
-	result, err := tx.ExecContext(ctx, query, event.Data.DurationMinutes, summaryJSON, event.Data.Notes, workoutID, userID)
+	sessionID, err := resolveWorkoutSession(ctx, tx, workoutID, userID, completedAt)
 	if err != nil {
-		return fmt.Errorf("failed to update workout session: %w", err)
+		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	setsRecorded, err := recordWorkoutSummary(ctx, tx, sessionID, summary, completedAt)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return err
 	}
 
-	if rowsAffected == 0 {
-		insertQuery := `
-		INSERT INTO workout_sessions (workout_session_id, user_id, completed_at, duration_minutes, exercises_summary, notes, status)
-		VALUES ($1, $2, NOW(), $3, $4, $5, 'completed')
-		`
-		_, err = tx.ExecContext(ctx, insertQuery, workoutID, userID, event.Data.DurationMinutes, summaryJSON, event.Data.Notes)
-		if err != nil {
-			return fmt.Errorf("failed to insert workout session: %w", err)
-		}
+	query := `
+	UPDATE training_sessions
+	SET completed_at = COALESCE(completed_at, $1),
+	    duration_minutes = COALESCE(duration_minutes, NULLIF($2, 0)),
+	    notes = COALESCE(notes, NULLIF($3, '')),
+	    modified_at = NOW()
+	WHERE id = $4
+	`
+
+	_, err = tx.ExecContext(ctx, query, completedAt, event.Data.DurationMinutes, event.Data.Notes, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to complete training session: %w", err)
 	}
 
 	idempotencyQuery := `INSERT INTO idempotency_keys (key, event_type, processed_at) VALUES ($1, $2, NOW())`
@@ -420,8 +437,162 @@ func (h *ProgramEventHandlers) HandleWorkoutCompleted(ctx context.Context, paylo
 	log.Info().
 		Str("workout_id", workoutID.String()).
 		Str("user_id", event.UserID).
+		Str("session_id", sessionID.String()).
 		Int("duration_minutes", event.Data.DurationMinutes).
+		Int("sets_recorded", setsRecorded).
 		Msg("Workout completed")
 
 	return nil
 }
+
+// WorkoutSummaryExercise is one exercise of a workout.completed summary as sent
+// by the workout screen
+type WorkoutSummaryExercise struct {
+	Name string `json:"name"`
+	Sets []struct {
+		Weight    float64  `json:"weight"`
+		Reps      int      `json:"reps"`
+		RPE       *float64 `json:"rpe"`
+		Completed *bool    `json:"completed"`
+	} `json:"sets"`
+}
+
+// resolveWorkoutSession finds the training session a workout event belongs to.
+// The workout id is normally the session id; otherwise the workout is linked to
+// the athlete's session scheduled that day, or recorded as an ad hoc session in
+// their current program. The link is kept in client_workout_id so later events
+// for the same workout land on the same session.
+func resolveWorkoutSession(ctx context.Context, tx *sql.Tx, workoutID, userID uuid.UUID, at time.Time) (uuid.UUID, error) {
+	var sessionID uuid.UUID
+	err := tx.QueryRowContext(ctx, `
+	SELECT id FROM training_sessions
+	WHERE (id = $1 OR client_workout_id = $1) AND athlete_id = $2 AND deleted_at IS NULL
+	FOR UPDATE
+	`, workoutID, userID).Scan(&sessionID)
+	if err == nil {
+		return sessionID, nil
+	}
+	if err != sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("failed to find training session: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+	SELECT ts.id FROM training_sessions ts
+	JOIN programs p ON p.id = ts.program_id
+	WHERE ts.athlete_id = $1 AND p.is_active = true AND ts.scheduled_date = $2::date
+	  AND ts.completed_at IS NULL AND ts.deleted_at IS NULL AND ts.client_workout_id IS NULL
+	ORDER BY ts.week_number, ts.day_number
+	LIMIT 1
+	FOR UPDATE OF ts
+	`, userID, at.Format("2006-01-02")).Scan(&sessionID)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `UPDATE training_sessions SET client_workout_id = $1, modified_at = NOW() WHERE id = $2`, workoutID, sessionID)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to link workout to training session: %w", err)
+		}
+		return sessionID, nil
+	}
+	if err != sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("failed to find scheduled session: %w", err)
+	}
+
+	var programID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+	SELECT id FROM programs
+	WHERE athlete_id = $1
+	ORDER BY is_active DESC, created_at DESC
+	LIMIT 1
+	`, userID).Scan(&programID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("no program to record workout in: user_id=%s", userID.String())
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to find program: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO training_sessions (id, program_id, athlete_id, week_number, day_number, session_name,
+	                               scheduled_date, is_adhoc, client_workout_id)
+	VALUES ($1, $2, $3, 0, 0, 'Ad hoc workout', $4::date, true, $1)
+	`, workoutID, programID, userID, at.Format("2006-01-02"))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create ad hoc training session: %w", err)
+	}
+
+	return workoutID, nil
+}
+
+// recordWorkoutSummary stores the summary's sets against the session's exercises,
+// matching them by name and adding any the session doesn't have. Exercises that
+// already have sets logged are left alone, so sets recorded through the API win
+// over the summary. It returns the number of sets stored.
+func recordWorkoutSummary(ctx context.Context, tx *sql.Tx, sessionID uuid.UUID, summary []WorkoutSummaryExercise, completedAt time.Time) (int, error) {
+	recorded := 0
+	for _, exercise := range summary {
+		name := strings.TrimSpace(exercise.Name)
+		if name == "" {
+			continue
+		}
+
+		var exerciseID uuid.UUID
+		var loggedSets int
+		err := tx.QueryRowContext(ctx, `
+		SELECT e.id, (SELECT COUNT(*) FROM completed_sets cs WHERE cs.exercise_id = e.id)
+		FROM exercises e
+		WHERE e.session_id = $1 AND LOWER(e.exercise_name) = LOWER($2)
+		ORDER BY e.exercise_order
+		LIMIT 1
+		`, sessionID, name).Scan(&exerciseID, &loggedSets)
+		if err == sql.ErrNoRows {
+			err = tx.QueryRowContext(ctx, `
+			INSERT INTO exercises (session_id, exercise_order, lift_type, exercise_name, target_sets)
+			SELECT $1, COALESCE(MAX(exercise_order), 0) + 1,
+			       COALESCE((SELECT lift_type FROM exercise_library WHERE LOWER(name) = LOWER($2) LIMIT 1), $3::lift_type),
+			       $2, $4
+			FROM exercises WHERE session_id = $1
+			RETURNING id
+			`, sessionID, name, liftTypeForExercise(name), len(exercise.Sets)).Scan(&exerciseID)
+			if err != nil {
+				return 0, fmt.Errorf("failed to add exercise %q: %w", name, err)
+			}
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to find exercise %q: %w", name, err)
+		}
+
+		if loggedSets > 0 {
+			continue
+		}
+
+		for i, set := range exercise.Sets {
+			if (set.Completed != nil && !*set.Completed) || set.Reps <= 0 {
+				continue
+			}
+
+			_, err := tx.ExecContext(ctx, `
+			INSERT INTO completed_sets (exercise_id, set_number, reps_completed, weight_kg, rpe_actual, completed_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			`, exerciseID, i+1, set.Reps, set.Weight, set.RPE, completedAt)
+			if err != nil {
+				return 0, fmt.Errorf("failed to record set for %q: %w", name, err)
+			}
+			recorded++
+		}
+	}
+
+	return recorded, nil
+}
+
+// liftTypeForExercise guesses the competition lift from an exercise name
+func liftTypeForExercise(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "squat"):
+		return "squat"
+	case strings.Contains(lower, "bench"):
+		return "bench"
+	case strings.Contains(lower, "deadlift"):
+		return "deadlift"
+	default:
+		return "accessory"
+	}
+}
@@ -4,10 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"regexp"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -24,20 +23,26 @@ import (
 
 type ProgramHandlers struct {
 	programRepo      *repository.ProgramRepository
-	aiClient         *ai.LiteLLMClient
+	aiClient         *ai.Client
 	excelExporter    *excel.ExcelExporter
 	workoutGenerator *services.WorkoutGenerator
 	settingsClient   *clients.SettingsClient
 	coachClient      *clients.CoachClient
+	usageService     *services.AIUsageService
+	publisher        EventPublisher
+	// trashRetention is how long deleted sessions can still be restored
+	trashRetention time.Duration
 }
 
 func NewProgramHandlers(
 	programRepo *repository.ProgramRepository,
-	aiClient *ai.LiteLLMClient,
+	aiClient *ai.Client,
 	excelExporter *excel.ExcelExporter,
 	workoutGenerator *services.WorkoutGenerator,
 	settingsClient *clients.SettingsClient,
 	coachClient *clients.CoachClient,
+	usageService *services.AIUsageService,
+	publisher EventPublisher,
 ) *ProgramHandlers {
 	return &ProgramHandlers{
 		programRepo:      programRepo,
@@ -46,9 +51,18 @@ func NewProgramHandlers(
 		workoutGenerator: workoutGenerator,
 		settingsClient:   settingsClient,
 		coachClient:      coachClient,
+		usageService:     usageService,
+		publisher:        publisher,
+		trashRetention:   30 * 24 * time.Hour,
 	}
 }
 
+// SetSessionTrashRetention sets how long deleted sessions stay restorable; zero
+// keeps them until they are restored
+func (h *ProgramHandlers) SetSessionTrashRetention(retention time.Duration) {
+	h.trashRetention = retention
+}
+
 func (h *ProgramHandlers) CreateProgram(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
@@ -110,28 +124,49 @@ func (h *ProgramHandlers) GenerateProgram(c *gin.Context) {
 
 	userUUID, _ := uuid.Parse(userID)
 
-	athleteProfile := h.getAthleteProfileString(c.Request.Context(), authToken)
+	athleteProfile := h.getAthleteProfileString(c, authToken)
 
-	var coachFeedback string
+	var coachFeedback clients.CoachFeedbackPrompt
 	if req.CoachContextEnable {
-		coachFeedback = h.getCoachFeedbackString(c.Request.Context(), authToken)
+		coachFeedback = h.getCoachFeedback(c, authToken)
 	}
 
-	programJSON, err := h.aiClient.GenerateProgram(c.Request.Context(), req, athleteProfile, coachFeedback)
+	result, err := h.aiClient.GenerateProgramStructured(c.Request.Context(), req, athleteProfile, coachFeedback.Text, userID)
 	if err != nil {
+		h.recordGenerationAttempts(userUUID, nil, "generate", result)
+		if errors.Is(err, ai.ErrInvalidProgram) {
+			log.Warn().Err(err).Msg("AI could not produce a valid program")
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":             "Generated program failed validation",
+				"validation_errors": lastValidationErrors(result),
+			})
+			return
+		}
 		log.Error().Err(err).Msg("Failed to generate program with AI")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate program"})
 		return
 	}
 
-	// Parse AI response and create program
-	var programData map[string]interface{}
-	if err := json.Unmarshal([]byte(programJSON), &programData); err != nil {
-		log.Error().Err(err).Msg("Failed to parse AI program response")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse generated program"})
+	programJSON := result.RawResponse
+	program, conversation, err := h.saveGeneratedProgram(userUUID, req, result, coachFeedback)
+	if err != nil {
+		h.recordGenerationAttempts(userUUID, nil, "generate", result)
+		log.Error().Err(err).Msg("Failed to save generated program")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save program"})
 		return
 	}
+	h.recordGenerationAttempts(userUUID, &program.ID, "generate", result)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"program":      program,
+		"ai_response":  programJSON,
+		"conversation": conversation,
+	})
+}
 
+// saveGeneratedProgram stores an AI-generated program together with the
+// conversation that produced it, and reports the coach feedback it applied
+func (h *ProgramHandlers) saveGeneratedProgram(userUUID uuid.UUID, req models.GenerateProgramRequest, result *ai.GenerationResult, coachFeedback clients.CoachFeedbackPrompt) (*models.Program, *models.AIConversation, error) {
 	// Determine program phase based on competition date
 	phase := h.determineProgramPhase(req.CompetitionDate)
 
@@ -147,24 +182,25 @@ func (h *ProgramHandlers) GenerateProgram(c *gin.Context) {
 		EndDate:      endDate,
 		WeeksTotal:   req.WeeksDuration,
 		DaysPerWeek:  req.TrainingDays,
-		ProgramData:  programData,
+		ProgramData:  result.Program,
 		AIGenerated:  true,
-		AIModel:      stringPtr("gpt-3.5-turbo"),
+		AIModel:      stringPtr(result.Model),
 		AIPrompt:     &req.Goals,
 		IsActive:     true,
 	}
 
 	if err := h.programRepo.CreateProgram(program); err != nil {
-		log.Error().Err(err).Msg("Failed to save generated program")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save program"})
-		return
+		return nil, nil, err
 	}
 
+	applied := coachFeedback.Cited(result.RawResponse)
+
 	// Create AI conversation record
 	conversation := &models.AIConversation{
 		AthleteID:           userUUID,
 		ProgramID:           &program.ID,
 		ConversationType:    "program_generation",
+		Title:               conversationTitleFromText(fmt.Sprintf("%d-week program: %s", req.WeeksDuration, req.Goals)),
 		Messages: []models.Message{
 			{
 				ID:        uuid.New().String(),
@@ -173,24 +209,30 @@ func (h *ProgramHandlers) GenerateProgram(c *gin.Context) {
 				Timestamp: time.Now(),
 			},
 			{
-				ID:        uuid.New().String(),
-				Role:      "assistant",
-				Content:   programJSON,
-				Timestamp: time.Now(),
+				ID:                    uuid.New().String(),
+				Role:                  "assistant",
+				Content:               result.RawResponse,
+				Timestamp:             time.Now(),
+				PromptTemplates:       result.PromptTemplates,
+				CoachFeedbackIncluded: coachFeedback.IncludedIDs(),
+				CoachFeedbackApplied:  applied,
 			},
 		},
 		CoachContextEnabled: req.CoachContextEnable,
+		CoachFeedbackIncorp: applied,
 	}
 
 	if err := h.programRepo.CreateAIConversation(conversation); err != nil {
 		log.Warn().Err(err).Msg("Failed to save AI conversation")
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"program":      program,
-		"ai_response":  programJSON,
-		"conversation": conversation,
-	})
+	var conversationID *uuid.UUID
+	if conversation.ID != uuid.Nil {
+		conversationID = &conversation.ID
+	}
+	h.publishFeedbackIncorporated(userUUID, applied, "generate", conversationID, program)
+
+	return program, conversation, nil
 }
 
 func (h *ProgramHandlers) GetMyPrograms(c *gin.Context) {
@@ -270,14 +312,67 @@ func (h *ProgramHandlers) ChatWithAI(c *gin.Context) {
 
 	userUUID, _ := uuid.Parse(userID)
 
-	// Get or create conversation
-	conversations, err := h.programRepo.GetAIConversationsByAthleteID(userUUID, 1)
+	conversation, err := h.loadChatConversation(userUUID, req)
+	if errors.Is(err, errConversationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get AI conversations")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get conversation"})
 		return
 	}
 
+	athleteProfile := h.getAthleteProfileString(c, authToken)
+	var coachFeedback clients.CoachFeedbackPrompt
+	if req.CoachContextEnable {
+		coachFeedback = h.getCoachFeedback(c, authToken)
+	}
+
+	tools := h.newAthleteTools(c, userID, userUUID)
+	reply, err := h.aiClient.ChatWithTools(c.Request.Context(), conversation, req.Message, athleteProfile, coachFeedback.Text, tools)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get AI response")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get AI response"})
+		return
+	}
+
+	programData := h.saveChatExchange(c.Request.Context(), &conversation, req.Message, reply, coachFeedback)
+
+	response := gin.H{
+		"message":      reply.Content,
+		"conversation": conversation,
+	}
+
+	// Include program data if extracted
+	if programData != nil {
+		response["program_proposal"] = programData
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// loadChatConversation continues the requested conversation. Without one it
+// continues the latest conversation when it belongs to the same program, otherwise
+// it starts a new one.
+func (h *ProgramHandlers) loadChatConversation(userUUID uuid.UUID, req models.ChatRequest) (models.AIConversation, error) {
+	if req.ConversationID != nil {
+		conversation, err := h.programRepo.GetAIConversationByID(*req.ConversationID, chatHistoryLimit)
+		if err != nil {
+			return models.AIConversation{}, err
+		}
+		if conversation == nil || conversation.AthleteID != userUUID {
+			return models.AIConversation{}, errConversationNotFound
+		}
+		return *conversation, nil
+	}
+
+	// Get or create conversation
+	conversations, err := h.programRepo.GetAIConversationsByAthleteID(userUUID, 1, chatHistoryLimit)
+	if err != nil {
+		return models.AIConversation{}, err
+	}
+
 	var conversation models.AIConversation
 	if len(conversations) > 0 && conversations[0].ProgramID != nil && req.ProgramID != nil && *conversations[0].ProgramID == *req.ProgramID {
 		conversation = conversations[0]
@@ -292,22 +387,17 @@ func (h *ProgramHandlers) ChatWithAI(c *gin.Context) {
 		}
 	}
 
-	athleteProfile := h.getAthleteProfileString(c.Request.Context(), authToken)
-	var coachFeedback string
-	if req.CoachContextEnable {
-		coachFeedback = h.getCoachFeedbackString(c.Request.Context(), authToken)
-	}
-
-	aiResponse, err := h.aiClient.ChatWithAI(c.Request.Context(), conversation, req.Message, athleteProfile, coachFeedback)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get AI response")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get AI response"})
-		return
-	}
+	return conversation, nil
+}
 
+// saveChatExchange appends the user/assistant turn to the conversation, persists it
+// and returns any program proposal found in the AI response. The first exchange of
+// an unnamed conversation also gets it titled, and coach feedback the response
+// cites is reported as incorporated.
+func (h *ProgramHandlers) saveChatExchange(ctx context.Context, conversation *models.AIConversation, userMessageText string, reply *ai.Reply, coachFeedback clients.CoachFeedbackPrompt) map[string]interface{} {
 	// Extract program JSON if present in AI response
 	var programData map[string]interface{}
-	extractedJSON := extractJSONFromResponse(aiResponse)
+	extractedJSON := extractJSONFromResponse(reply.Content)
 	if extractedJSON != "" {
 		if err := json.Unmarshal([]byte(extractedJSON), &programData); err != nil {
 			log.Warn().Err(err).Msg("Failed to parse extracted JSON from AI response")
@@ -318,41 +408,71 @@ func (h *ProgramHandlers) ChatWithAI(c *gin.Context) {
 	userMessage := models.Message{
 		ID:        uuid.New().String(),
 		Role:      "user",
-		Content:   req.Message,
+		Content:   userMessageText,
 		Timestamp: time.Now(),
 	}
 
+	applied := coachFeedback.Cited(reply.Content)
 	aiMessage := models.Message{
-		ID:        uuid.New().String(),
-		Role:      "assistant",
-		Content:   aiResponse,
-		Timestamp: time.Now(),
+		ID:                    uuid.New().String(),
+		Role:                  "assistant",
+		Content:               reply.Content,
+		Timestamp:             time.Now(),
+		PromptTemplates:       reply.PromptTemplates,
+		CoachFeedbackIncluded: coachFeedback.IncludedIDs(),
+		CoachFeedbackApplied:  applied,
 	}
 
-	conversation.Messages = append(conversation.Messages, userMessage, aiMessage)
+	exchange := []models.Message{userMessage, aiMessage}
+	untitled := conversation.MessageCount == 0 &&
+		(conversation.Title == "" || conversation.Title == models.DefaultConversationTitle)
 
 	// Save conversation
 	if conversation.ID == uuid.Nil {
-		if err := h.programRepo.CreateAIConversation(&conversation); err != nil {
+		conversation.Title = conversationTitleFromText(userMessageText)
+		conversation.Messages = exchange
+		if err := h.programRepo.CreateAIConversation(conversation); err != nil {
 			log.Error().Err(err).Msg("Failed to create AI conversation")
+			return programData
 		}
 	} else {
-		if err := h.programRepo.UpdateAIConversation(&conversation); err != nil {
+		if err := h.programRepo.AppendAIMessages(conversation.ID, exchange); err != nil {
 			log.Error().Err(err).Msg("Failed to update AI conversation")
+			return programData
+		}
+		conversation.Messages = append(conversation.Messages, exchange...)
+		conversation.MessageCount += len(exchange)
+		conversation.LastMessageAt = &aiMessage.Timestamp
+
+		if untitled {
+			placeholder := conversation.Title
+			conversation.Title = conversationTitleFromText(userMessageText)
+			if err := h.programRepo.SetAutoAIConversationTitle(conversation.ID, placeholder, conversation.Title); err != nil {
+				log.Warn().Err(err).Msg("Failed to title AI conversation")
+			}
 		}
 	}
 
-	response := gin.H{
-		"message":      aiResponse,
-		"conversation": conversation,
+	if untitled {
+		go h.generateConversationTitle(ctx, *conversation, userMessageText, reply.Content)
 	}
 
-	// Include program data if extracted
-	if programData != nil {
-		response["program_proposal"] = programData
+	if len(applied) > 0 {
+		if err := h.programRepo.AddAIConversationFeedback(conversation.ID, applied); err != nil {
+			log.Warn().Err(err).Msg("Failed to record incorporated coach feedback")
+		}
+		conversation.CoachFeedbackIncorp = appendMissing(conversation.CoachFeedbackIncorp, applied)
+
+		var program *models.Program
+		if conversation.ProgramID != nil {
+			if p, err := h.programRepo.GetProgramByID(*conversation.ProgramID); err == nil {
+				program = p
+			}
+		}
+		h.publishFeedbackIncorporated(conversation.AthleteID, applied, "chat", &conversation.ID, program)
 	}
 
-	c.JSON(http.StatusOK, response)
+	return programData
 }
 
 // GetAIConversation retrieves the current AI conversation for the user
@@ -366,7 +486,7 @@ func (h *ProgramHandlers) GetAIConversation(c *gin.Context) {
 	userUUID, _ := uuid.Parse(userID)
 
 	// Get the most recent conversation
-	conversations, err := h.programRepo.GetAIConversationsByAthleteID(userUUID, 1)
+	conversations, err := h.programRepo.GetAIConversationsByAthleteID(userUUID, 1, 0)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get AI conversation")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get conversation"})
@@ -426,13 +546,21 @@ func (h *ProgramHandlers) LogWorkout(c *gin.Context) {
 	for _, exercise := range req.Exercises {
 		for _, set := range exercise.Sets {
 			completedSet := &models.CompletedSet{
-				ExerciseID:    exercise.ExerciseID,
-				SetNumber:     set.SetNumber,
-				RepsCompleted: set.RepsCompleted,
-				WeightKg:      set.WeightKg,
-				RPEActual:     set.RPEActual,
-				VideoID:       set.VideoID,
-				Notes:         set.Notes,
+				ExerciseID:      exercise.ExerciseID,
+				SetNumber:       set.SetNumber,
+				RepsCompleted:   set.RepsCompleted,
+				WeightKg:        set.WeightKg,
+				RPEActual:       set.RPEActual,
+				VideoID:         set.VideoID,
+				Notes:           set.Notes,
+				SetType:         set.SetType,
+				MediaURLs:       set.MediaURLs,
+				ExerciseNotes:   set.ExerciseNotes,
+				RoundNumber:     set.RoundNumber,
+				MeanVelocityMps: set.MeanVelocityMps,
+				PeakVelocityMps: set.PeakVelocityMps,
+				VelocityLossPct: set.VelocityLossPct,
+				RepVelocities:   set.RepVelocities,
 			}
 
 			if err := h.programRepo.LogCompletedSet(completedSet); err != nil {
@@ -472,7 +600,8 @@ func (h *ProgramHandlers) ExportProgram(c *gin.Context) {
 		return
 	}
 
-	// Check access permissions
+	// An export only reads the program
+	c.Set(auditViewOnlyKey, true)
 	if !h.hasAccessToProgram(c, userID, program) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
@@ -691,6 +820,24 @@ func (h *ProgramHandlers) CreateProgramFromChat(c *gin.Context) {
 
 	userUUID, _ := uuid.Parse(userID)
 
+	// Programs extracted from chat are free-form; validate them and let the model
+	// repair anything unusable before it is stored
+	expect := ai.ProgramExpectations{Weeks: req.WeeksTotal, DaysPerWeek: req.DaysPerWeek}
+	if validationErrors := ai.ValidateProgram(req.ProgramData, expect); len(validationErrors) > 0 {
+		rawProgram, _ := json.Marshal(req.ProgramData)
+		result, err := h.aiClient.RepairProgram(c.Request.Context(), string(rawProgram), validationErrors, expect, userID)
+		h.recordGenerationAttempts(userUUID, nil, "from_chat", result)
+		if err != nil {
+			log.Warn().Err(err).Strs("validation_errors", validationErrors).Msg("Failed to repair program from chat")
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":             "Program failed validation",
+				"validation_errors": validationErrors,
+			})
+			return
+		}
+		req.ProgramData = result.Program
+	}
+
 	// Check if user already has a pending program
 	existingPending, _ := h.programRepo.GetPendingProgramByAthleteID(userUUID)
 	if existingPending != nil {
@@ -769,14 +916,127 @@ func (h *ProgramHandlers) hasAccessToProgram(c *gin.Context, userID string, prog
 		return true
 	}
 
-	if program.CoachID != nil && *program.CoachID == userUUID {
+	// Having written the program grants nothing by itself; the athlete's
+	// current relationship with the coach decides
+	action := programAccessAction(c)
+	scope := clients.ScopeViewHistory
+	if action == "program_modified" {
+		scope = clients.ScopeEditProgram
+	}
+	if h.hasAccessToAthlete(c, userUUID, program.AthleteID, scope) {
+		recordCoachDataAccess(c, coachDataAccess{
+			coachID:      userUUID,
+			athleteID:    program.AthleteID,
+			action:       action,
+			resourceType: "program",
+			resourceID:   program.ID,
+		})
+		return true
+	}
+
+	return false
+}
+
+// auditViewOnlyKey marks a request that only reads the programs it checks,
+// such as an export, so coach access is logged as a view whatever its method
+const auditViewOnlyKey = "audit_view_only"
+
+// programAccessAction is how a coach's access to a program is logged: reads
+// are views and anything else may change the program
+func programAccessAction(c *gin.Context) string {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.GetBool(auditViewOnlyKey) {
+		return "data_viewed"
+	}
+	return "program_modified"
+}
+
+// coachDataAccessKey holds the coach accesses a request has made so far
+const coachDataAccessKey = "coach_data_accesses"
+
+// coachDataAccess is a coach viewing or changing an athlete's data. resourceID
+// is uuid.Nil when the access covers a collection, such as the pain log.
+type coachDataAccess struct {
+	coachID      uuid.UUID
+	athleteID    uuid.UUID
+	action       string
+	resourceType string
+	resourceID   uuid.UUID
+}
+
+// recordCoachDataAccess notes an access for PublishCoachDataAccess to publish
+// once the request has been handled
+func recordCoachDataAccess(c *gin.Context, access coachDataAccess) {
+	value, _ := c.Get(coachDataAccessKey)
+	accesses, _ := value.([]coachDataAccess)
+	for _, recorded := range accesses {
+		if recorded == access {
+			return
+		}
+	}
+	c.Set(coachDataAccessKey, append(accesses, access))
+}
+
+// PublishCoachDataAccess publishes the coach accesses recorded while handling
+// the request. Nothing is published when the request failed, so a rejected
+// write doesn't show up as a change in the athlete's access history.
+func (h *ProgramHandlers) PublishCoachDataAccess(c *gin.Context) {
+	c.Next()
+
+	if c.Writer.Status() >= http.StatusBadRequest {
+		return
+	}
+
+	value, _ := c.Get(coachDataAccessKey)
+	accesses, _ := value.([]coachDataAccess)
+	for _, access := range accesses {
+		h.publishCoachDataAccessed(c, access)
+	}
+}
+
+// publishCoachDataAccessed tells coach-service a coach viewed or changed an
+// athlete's data so it shows in the athlete's access history
+func (h *ProgramHandlers) publishCoachDataAccessed(c *gin.Context, access coachDataAccess) {
+	if h.publisher == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"coach_id":      access.coachID.String(),
+		"athlete_id":    access.athleteID.String(),
+		"action":        access.action,
+		"resource_type": access.resourceType,
+		"ip_address":    c.ClientIP(),
+		"user_agent":    c.Request.UserAgent(),
+	}
+	if access.resourceID != uuid.Nil {
+		data["resource_id"] = access.resourceID.String()
+	}
+
+	event := map[string]interface{}{
+		"schema_version":      "1.0.0",
+		"event_type":          "coach.data.accessed",
+		"client_generated_id": uuid.New().String(),
+		"user_id":             access.coachID.String(),
+		"timestamp":           time.Now().UTC().Format(time.RFC3339),
+		"source_service":      "program-service",
+		"data":                data,
+	}
+	if err := h.publisher.PublishEvent("coach.data.accessed", event); err != nil {
+		log.Error().Err(err).Msg("Failed to publish coach.data.accessed event")
+	}
+}
+
+// hasAccessToAthlete reports whether the user is the athlete or one of their
+// coaches who was granted scope
+func (h *ProgramHandlers) hasAccessToAthlete(c *gin.Context, userID, athleteID uuid.UUID, scope string) bool {
+	if userID == athleteID {
 		return true
 	}
 
 	if h.coachClient != nil {
 		authToken := c.GetHeader("Authorization")
 		if authToken != "" {
-			hasAccess, err := h.coachClient.HasCoachAccess(c.Request.Context(), authToken, userUUID, program.AthleteID)
+			hasAccess, err := h.coachClient.HasCoachAccess(c.Request.Context(), authToken, userID, athleteID, scope)
 			if err == nil && hasAccess {
 				return true
 			}
@@ -786,46 +1046,129 @@ func (h *ProgramHandlers) hasAccessToProgram(c *gin.Context, userID string, prog
 	return false
 }
 
-func (h *ProgramHandlers) getAthleteProfileString(ctx context.Context, authToken string) string {
+// recordGenerationAttempts stores generation attempts for debugging. Failures are
+// logged rather than surfaced since they must not fail the request.
+func (h *ProgramHandlers) recordGenerationAttempts(athleteID uuid.UUID, programID *uuid.UUID, source string, result *ai.GenerationResult) {
+	if result == nil || len(result.Attempts) == 0 {
+		return
+	}
+
+	for i := range result.Attempts {
+		result.Attempts[i].AthleteID = athleteID
+		result.Attempts[i].ProgramID = programID
+		result.Attempts[i].Source = source
+	}
+
+	if err := h.programRepo.RecordGenerationAttempts(result.Attempts); err != nil {
+		log.Warn().Err(err).Msg("Failed to record program generation attempts")
+	}
+}
+
+func lastValidationErrors(result *ai.GenerationResult) []string {
+	if result == nil || len(result.Attempts) == 0 {
+		return nil
+	}
+	return result.Attempts[len(result.Attempts)-1].ValidationErrors
+}
+
+// getAthleteProfileString is the athlete's settings profile followed by their
+// active pain episodes
+func (h *ProgramHandlers) getAthleteProfileString(c *gin.Context, authToken string) string {
+	var activePain string
+	if athleteID, err := uuid.Parse(middleware.GetUserID(c)); err == nil {
+		activePain = h.activePainProfile(athleteID)
+	}
+
 	// Fetch athlete profile from settings service
 	if h.settingsClient == nil {
 		log.Warn().Msg("Settings client not configured, using minimal profile")
-		return "Athlete profile not available"
+		return "Athlete profile not available\n" + activePain
 	}
 
-	settings, err := h.settingsClient.GetUserSettings(ctx, authToken)
+	settings, err := h.settingsClient.GetUserSettings(c.Request.Context(), authToken)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to fetch athlete profile from settings service")
-		return "Athlete profile could not be retrieved"
+		return "Athlete profile could not be retrieved\n" + activePain
 	}
 
-	return settings.FormatAthleteProfile()
+	return settings.FormatAthleteProfile() + activePain
 }
 
-func (h *ProgramHandlers) getCoachFeedbackString(ctx context.Context, authToken string) string {
+// getCoachFeedback fetches the athlete's pending coach feedback, formatted for a
+// prompt with references the model cites
+func (h *ProgramHandlers) getCoachFeedback(c *gin.Context, authToken string) clients.CoachFeedbackPrompt {
 	if h.coachClient == nil {
-		return ""
+		return clients.CoachFeedbackPrompt{}
 	}
 
-	userIDStr := ctx.Value("user_id")
-	if userIDStr == nil {
-		return ""
-	}
-
-	athleteID, err := uuid.Parse(userIDStr.(string))
+	athleteID, err := uuid.Parse(middleware.GetUserID(c))
 	if err != nil {
-		return ""
+		return clients.CoachFeedbackPrompt{}
 	}
 
-	feedback, err := h.coachClient.GetAthleteFeedback(ctx, authToken, athleteID)
+	feedback, err := h.coachClient.GetAthleteFeedback(c.Request.Context(), authToken, athleteID)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to fetch coach feedback")
-		return ""
+		return clients.CoachFeedbackPrompt{}
 	}
 
 	return clients.FormatCoachFeedback(feedback)
 }
 
+// publishFeedbackIncorporated tells coach-service which feedback the AI acted on,
+// and in which program, so it can mark it incorporated and show the coach
+func (h *ProgramHandlers) publishFeedbackIncorporated(athleteID uuid.UUID, feedbackIDs []string, source string, conversationID *uuid.UUID, program *models.Program) {
+	if h.publisher == nil || len(feedbackIDs) == 0 {
+		return
+	}
+
+	data := map[string]interface{}{
+		"feedback_ids": feedbackIDs,
+		"source":       source,
+	}
+	if conversationID != nil {
+		data["conversation_id"] = conversationID.String()
+	}
+	if program != nil {
+		data["program_id"] = program.ID.String()
+		data["program_name"] = program.Name
+		if version, err := h.programRepo.GetAthleteProgramNumber(program); err != nil {
+			log.Warn().Err(err).Msg("Failed to number program")
+		} else {
+			data["program_version"] = version
+		}
+	}
+
+	event := map[string]interface{}{
+		"schema_version":      "1.0.0",
+		"event_type":          "coach.feedback.incorporated",
+		"client_generated_id": uuid.New().String(),
+		"user_id":             athleteID.String(),
+		"timestamp":           time.Now().UTC().Format(time.RFC3339),
+		"source_service":      "program-service",
+		"data":                data,
+	}
+	if err := h.publisher.PublishEvent("coach.feedback.incorporated", event); err != nil {
+		log.Error().Err(err).Msg("Failed to publish coach.feedback.incorporated event")
+	}
+}
+
+func appendMissing(values []string, extra []string) []string {
+	for _, v := range extra {
+		found := false
+		for _, existing := range values {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 func (h *ProgramHandlers) determineProgramPhase(competitionDate *time.Time) models.ProgramPhase {
 	if competitionDate == nil {
 		return models.PhaseStrength
@@ -848,23 +1191,5 @@ func stringPtr(s string) *string {
 
 // extractJSONFromResponse extracts JSON code blocks from AI response text
 func extractJSONFromResponse(response string) string {
-	// Try to find JSON in code blocks first (```json ... ```)
-	codeBlockPattern := regexp.MustCompile("(?s)```(?:json)?\\s*\\n?({[^`]+})\\s*\\n?```")
-	matches := codeBlockPattern.FindStringSubmatch(response)
-	if len(matches) > 1 {
-		return strings.TrimSpace(matches[1])
-	}
-
-	// Try to find standalone JSON object
-	jsonPattern := regexp.MustCompile(`(?s)\{[\s\S]*"phases"[\s\S]*"weeklyWorkouts"[\s\S]*"summary"[\s\S]*\}`)
-	match := jsonPattern.FindString(response)
-	if match != "" {
-		// Validate it's actual JSON
-		var test map[string]interface{}
-		if json.Unmarshal([]byte(match), &test) == nil {
-			return match
-		}
-	}
-
-	return ""
+	return ai.ExtractJSON(response)
 }
\ No newline at end of file
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/scoring"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/clients"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const kgPerLb = 0.45359237
+
+// ScoreTotal scores an arbitrary total (or bench-only result)
+func (h *ProgramHandlers) ScoreTotal(c *gin.Context) {
+	var req models.ScoreTotalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input, err := buildScoringInput(req.Sex, req.Equipment, req.Event)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.BodyweightKg = req.BodyweightKg
+	input.LiftedKg = req.TotalKg
+	input.Age = req.Age
+
+	scores, err := scoring.Calculate(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scores": scores})
+}
+
+// ScoreAthlete scores the caller's best squat, bench and deadlift as of a date.
+// Without bodyweight_kg, a past date uses the bodyweight recorded nearest to
+// it and today uses the athlete's settings. Age falls back to the settings,
+// less the years since the date.
+func (h *ProgramHandlers) ScoreAthlete(c *gin.Context) {
+	userIDStr := middleware.GetUserID(c)
+	athleteID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	now := time.Now()
+	asOf := now
+	historical := false
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be YYYY-MM-DD"})
+			return
+		}
+		// include everything completed on that day
+		asOf = parsed.Add(24*time.Hour - time.Nanosecond)
+		historical = asOf.Before(now)
+	}
+
+	input, err := buildScoringInput(c.Query("sex"), c.Query("equipment"), "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bodyweightSource := "request"
+	var bodyweightRecordedOn string
+	if bwStr := c.Query("bodyweight_kg"); bwStr != "" {
+		input.BodyweightKg, err = strconv.ParseFloat(bwStr, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bodyweight_kg"})
+			return
+		}
+	} else if historical {
+		// Today's bodyweight says nothing about lifts from back then
+		var recorded *clients.RecordedBodyweight
+		if h.coachClient != nil {
+			recorded, err = h.coachClient.GetBodyweightNear(c.Request.Context(), c.GetHeader("Authorization"), asOf)
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to fetch recorded bodyweight for scoring")
+			}
+		}
+		if recorded == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bodyweight_kg is required for a past date when no bodyweight was recorded"})
+			return
+		}
+		input.BodyweightKg = recorded.BodyweightKg
+		bodyweightSource = "recorded"
+		bodyweightRecordedOn = recorded.RecordedOn
+	}
+	if ageStr := c.Query("age"); ageStr != "" {
+		age, err := strconv.Atoi(ageStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid age"})
+			return
+		}
+		input.Age = &age
+	}
+
+	if (input.BodyweightKg == 0 || input.Age == nil) && h.settingsClient != nil {
+		settings, err := h.settingsClient.GetUserSettings(c.Request.Context(), c.GetHeader("Authorization"))
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to fetch athlete settings for scoring")
+		} else {
+			if input.BodyweightKg == 0 && settings.WeightValue != nil {
+				input.BodyweightKg = *settings.WeightValue
+				if settings.WeightUnit != nil && strings.HasPrefix(strings.ToLower(*settings.WeightUnit), "lb") {
+					input.BodyweightKg *= kgPerLb
+				}
+				bodyweightSource = "settings"
+			}
+			if input.Age == nil && settings.Age != nil {
+				age := *settings.Age - yearsBetween(asOf, now)
+				input.Age = &age
+			}
+		}
+	}
+
+	if input.BodyweightKg <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bodyweight_kg is required when no bodyweight is stored in settings"})
+		return
+	}
+
+	best, err := h.programRepo.GetBestLiftsAsOf(athleteID, asOf)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get best lifts")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get best lifts"})
+		return
+	}
+
+	if best.SquatKg == 0 || best.BenchKg == 0 || best.DeadliftKg == 0 {
+		c.JSON(http.StatusOK, gin.H{"best_lifts": best, "scores": nil})
+		return
+	}
+
+	input.LiftedKg = best.TotalKg()
+	scores, err := scoring.Calculate(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"best_lifts": best, "scores": scores, "bodyweight_source": bodyweightSource}
+	if bodyweightRecordedOn != "" {
+		response["bodyweight_recorded_on"] = bodyweightRecordedOn
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// yearsBetween counts the full years from then until now
+func yearsBetween(then, now time.Time) int {
+	years := now.Year() - then.Year()
+	if then.AddDate(years, 0, 0).After(now) {
+		years--
+	}
+	if years < 0 {
+		return 0
+	}
+	return years
+}
+
+func buildScoringInput(sex, equipment, event string) (scoring.Input, error) {
+	var input scoring.Input
+	var err error
+
+	if input.Sex, err = scoring.ParseSex(sex); err != nil {
+		return input, err
+	}
+	if input.Equipment, err = scoring.ParseEquipment(equipment); err != nil {
+		return input, err
+	}
+	if input.Event, err = scoring.ParseEvent(event); err != nil {
+		return input, err
+	}
+
+	return input, nil
+}
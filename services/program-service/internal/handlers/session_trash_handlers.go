@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/powerlifting-coach-app/program-service/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	eventWorkoutSessionDeleted  = "workout.session.deleted"
+	eventWorkoutSessionRestored = "workout.session.restored"
+)
+
+// GetSessionTrash lists the caller's deleted sessions with when each will be
+// purged for good
+func (h *ProgramHandlers) GetSessionTrash(c *gin.Context) {
+	athleteID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessions, err := h.programRepo.GetDeletedSessions(athleteID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get deleted sessions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get deleted sessions"})
+		return
+	}
+
+	trash := make([]models.TrashedSession, 0, len(sessions))
+	for _, session := range sessions {
+		entry := models.TrashedSession{TrainingSession: session}
+		if h.trashRetention > 0 && session.DeletedAt != nil {
+			purgeAt := session.DeletedAt.Add(h.trashRetention)
+			entry.PurgeAt = &purgeAt
+		}
+		trash = append(trash, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": trash, "total": len(trash)})
+}
+
+// RestoreSession takes one of the caller's sessions out of the trash. When its
+// program day has been taken or its program generated again, the conflicts
+// come back with a 409 unless the request sets force.
+func (h *ProgramHandlers) RestoreSession(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	var req models.RestoreSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.programRepo.GetTrainingSessionByID(sessionID)
+	if err != nil || session.DeletedAt == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted session not found"})
+		return
+	}
+	if session.AthleteID.String() != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	conflicts, err := h.programRepo.RestoreSession(sessionID, req.Force)
+	if errors.Is(err, repository.ErrSessionNotInTrash) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted session not found"})
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to restore session")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore session"})
+		return
+	}
+	if len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "Session conflicts with the current program; restore with force to keep both",
+			"conflicts": conflicts,
+		})
+		return
+	}
+
+	session.DeletedAt = nil
+	session.DeletedReason = nil
+
+	h.publishSessionEvent(eventWorkoutSessionRestored, session, map[string]interface{}{
+		"forced": req.Force,
+	})
+
+	c.JSON(http.StatusOK, session)
+}
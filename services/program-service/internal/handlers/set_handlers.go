@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/powerlifting-coach-app/program-service/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// Set correction events. Personal records and analytics are computed from the
+// logged sets, so consumers that cache them should refresh on these.
+const (
+	eventWorkoutSetDeleted  = "workout.set.deleted"
+	eventWorkoutSetReverted = "workout.set.reverted"
+)
+
+// UpdateSet edits a logged set in any of the athlete's sessions, finished or not.
+// The change is kept in the set's history so it can be undone.
+func (h *ProgramHandlers) UpdateSet(c *gin.Context) {
+	session, ok := h.ownSession(c)
+	if !ok {
+		return
+	}
+
+	setID, err := uuid.Parse(c.Param("setId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+		return
+	}
+
+	var req models.UpdateSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.SetType != nil && !req.SetType.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown set_type %q", *req.SetType)})
+		return
+	}
+
+	set, setSessionID, err := h.programRepo.GetCompletedSetByID(setID)
+	if err != nil || setSessionID != session.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Set not found"})
+		return
+	}
+
+	if req.SetNumber != nil {
+		set.SetNumber = *req.SetNumber
+	}
+	if req.RepsCompleted != nil {
+		set.RepsCompleted = *req.RepsCompleted
+	}
+	if req.WeightKg != nil {
+		set.WeightKg = *req.WeightKg
+	}
+	if req.RPEActual != nil {
+		set.RPEActual = req.RPEActual
+	}
+	if req.VideoID != nil {
+		set.VideoID = req.VideoID
+	}
+	if req.Notes != nil {
+		set.Notes = req.Notes
+	}
+	if req.SetType != nil {
+		set.SetType = *req.SetType
+	}
+	if req.MediaURLs != nil {
+		set.MediaURLs = *req.MediaURLs
+	}
+	if req.ExerciseNotes != nil {
+		set.ExerciseNotes = req.ExerciseNotes
+	}
+	if req.RepVelocities != nil {
+		// The summary is recomputed from the new readings unless given with them
+		set.RepVelocities = *req.RepVelocities
+		set.MeanVelocityMps, set.PeakVelocityMps, set.VelocityLossPct = nil, nil, nil
+	}
+	if req.MeanVelocityMps != nil {
+		set.MeanVelocityMps = req.MeanVelocityMps
+	}
+	if req.PeakVelocityMps != nil {
+		set.PeakVelocityMps = req.PeakVelocityMps
+	}
+	if req.VelocityLossPct != nil {
+		set.VelocityLossPct = req.VelocityLossPct
+	}
+
+	revision, err := h.programRepo.UpdateCompletedSet(set, session.AthleteID)
+	if err != nil {
+		log.Error().Err(err).Str("set_id", setID.String()).Msg("Failed to update set")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update set"})
+		return
+	}
+
+	response := h.setCorrectionResponse(session, set, revision)
+	h.publishSessionEvent(eventWorkoutSetEdited, session, setCorrectionEventData(response))
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteSet removes a logged set. It stays in the set's history and can be
+// brought back with UndoSetChange.
+func (h *ProgramHandlers) DeleteSet(c *gin.Context) {
+	session, ok := h.ownSession(c)
+	if !ok {
+		return
+	}
+
+	setID, err := uuid.Parse(c.Param("setId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+		return
+	}
+
+	if _, setSessionID, err := h.programRepo.GetCompletedSetByID(setID); err != nil || setSessionID != session.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Set not found"})
+		return
+	}
+
+	revision, err := h.programRepo.DeleteCompletedSet(setID, session.AthleteID)
+	if err != nil {
+		log.Error().Err(err).Str("set_id", setID.String()).Msg("Failed to delete set")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete set"})
+		return
+	}
+
+	response := h.setCorrectionResponse(session, nil, revision)
+	h.publishSessionEvent(eventWorkoutSetDeleted, session, setCorrectionEventData(response))
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UndoSetChange reverts the latest edit or delete of a set that hasn't been
+// undone yet
+func (h *ProgramHandlers) UndoSetChange(c *gin.Context) {
+	session, ok := h.ownSession(c)
+	if !ok {
+		return
+	}
+
+	setID, err := uuid.Parse(c.Param("setId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+		return
+	}
+
+	set, revision, err := h.programRepo.UndoSetChange(session.ID, setID, session.AthleteID)
+	if errors.Is(err, repository.ErrNothingToUndo) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Nothing to undo for this set"})
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("set_id", setID.String()).Msg("Failed to undo set change")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to undo set change"})
+		return
+	}
+
+	response := h.setCorrectionResponse(session, set, revision)
+	h.publishSessionEvent(eventWorkoutSetReverted, session, setCorrectionEventData(response))
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSetCorrections returns the session's correction history, optionally for one
+// set via ?set_id=. Coaches with access to the athlete can read it too.
+func (h *ProgramHandlers) GetSetCorrections(c *gin.Context) {
+	session, ok := h.viewableSession(c)
+	if !ok {
+		return
+	}
+
+	var setID *uuid.UUID
+	if raw := c.Query("set_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+			return
+		}
+		setID = &parsed
+	}
+
+	revisions, err := h.programRepo.GetSetRevisions(session.ID, setID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", session.ID.String()).Msg("Failed to get set corrections")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get set corrections"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"corrections": revisions})
+}
+
+// setCorrectionResponse pairs a correction with the athlete's personal records for
+// the corrected lift, recomputed now that the history has changed
+func (h *ProgramHandlers) setCorrectionResponse(session *models.TrainingSession, set *models.CompletedSet, revision *models.SetRevision) models.SetCorrectionResponse {
+	response := models.SetCorrectionResponse{
+		Set:             set,
+		Revision:        *revision,
+		PersonalRecords: []models.PersonalRecord{},
+	}
+
+	exercise := findSessionExercise(session, revision.ExerciseID)
+	if exercise == nil {
+		return response
+	}
+
+	records, err := h.programRepo.GetPersonalRecords(session.AthleteID, &exercise.LiftType)
+	if err != nil {
+		log.Error().Err(err).Str("athlete_id", session.AthleteID.String()).Msg("Failed to recompute personal records")
+		return response
+	}
+	if records != nil {
+		response.PersonalRecords = records
+	}
+
+	return response
+}
+
+func setCorrectionEventData(response models.SetCorrectionResponse) map[string]interface{} {
+	return map[string]interface{}{
+		"set_id":           response.Revision.SetID.String(),
+		"exercise_id":      response.Revision.ExerciseID.String(),
+		"set":              response.Set,
+		"revision":         response.Revision,
+		"personal_records": response.PersonalRecords,
+	}
+}
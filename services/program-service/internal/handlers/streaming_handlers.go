@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/ai"
+	"github.com/powerlifting-coach-app/program-service/internal/clients"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// streamTimeout bounds a single streamed completion. The upstream request is
+// detached from the client connection so a disconnect does not lose the answer.
+const streamTimeout = 5 * time.Minute
+
+// sseWriter relays events to the client until it disconnects, after which writes
+// are dropped so the upstream stream can still run to completion and be persisted
+type sseWriter struct {
+	c      *gin.Context
+	closed bool
+}
+
+func newSSEWriter(c *gin.Context) *sseWriter {
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	return &sseWriter{c: c}
+}
+
+func (w *sseWriter) send(event string, data interface{}) {
+	if w.closed {
+		return
+	}
+
+	select {
+	case <-w.c.Request.Context().Done():
+		w.closed = true
+		log.Info().Msg("Client disconnected from AI stream; continuing in background")
+		return
+	default:
+	}
+
+	w.c.SSEvent(event, data)
+	w.c.Writer.Flush()
+}
+
+func (w *sseWriter) delta(content string) {
+	w.send("delta", gin.H{"content": content})
+}
+
+// StreamChatWithAI is ChatWithAI over Server-Sent Events, with the same athlete
+// tools. It emits "delta" events with content fragments, then a single "done"
+// or "error" event.
+func (h *ProgramHandlers) StreamChatWithAI(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	authToken := c.GetHeader("Authorization")
+	if authToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		return
+	}
+
+	var req models.ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+
+	conversation, err := h.loadChatConversation(userUUID, req)
+	if errors.Is(err, errConversationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get AI conversations")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get conversation"})
+		return
+	}
+
+	athleteProfile := h.getAthleteProfileString(c, authToken)
+	var coachFeedback clients.CoachFeedbackPrompt
+	if req.CoachContextEnable {
+		coachFeedback = h.getCoachFeedback(c, authToken)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), streamTimeout)
+	defer cancel()
+
+	w := newSSEWriter(c)
+
+	tools := h.newAthleteTools(c, userID, userUUID)
+	reply, err := h.aiClient.StreamChatWithTools(ctx, conversation, req.Message, athleteProfile, coachFeedback.Text, tools, w.delta)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to stream AI response")
+		w.send("error", gin.H{"error": "Failed to get AI response"})
+		return
+	}
+
+	programData := h.saveChatExchange(ctx, &conversation, req.Message, reply, coachFeedback)
+
+	done := gin.H{
+		"message":      reply.Content,
+		"conversation": conversation,
+	}
+	if programData != nil {
+		done["program_proposal"] = programData
+	}
+
+	w.send("done", done)
+}
+
+// StreamGenerateProgram is GenerateProgram over Server-Sent Events. The program is
+// saved once the full response has arrived and returned in the "done" event.
+func (h *ProgramHandlers) StreamGenerateProgram(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	authToken := c.GetHeader("Authorization")
+	if authToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		return
+	}
+
+	var req models.GenerateProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+
+	athleteProfile := h.getAthleteProfileString(c, authToken)
+
+	var coachFeedback clients.CoachFeedbackPrompt
+	if req.CoachContextEnable {
+		coachFeedback = h.getCoachFeedback(c, authToken)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), streamTimeout)
+	defer cancel()
+
+	w := newSSEWriter(c)
+
+	started := time.Now()
+	reply, err := h.aiClient.StreamGenerateProgram(ctx, req, athleteProfile, coachFeedback.Text, userID, w.delta)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to stream generated program")
+		w.send("error", gin.H{"error": "Failed to generate program"})
+		return
+	}
+	programJSON := reply.Content
+
+	// Streamed output can't be schema-constrained, so validate it afterwards and
+	// fall back to the repair loop when it is unusable
+	streamed := models.ProgramGenerationAttempt{
+		AttemptNumber: 1,
+		Model:         reply.Model,
+		RawResponse:   programJSON,
+		DurationMs:    time.Since(started).Milliseconds(),
+		CreatedAt:     started,
+	}
+	expect := ai.ProgramExpectations{Weeks: req.WeeksDuration, DaysPerWeek: req.TrainingDays}
+
+	programData, parseErr := ai.ParseProgram(programJSON)
+	if parseErr != nil {
+		streamed.ValidationErrors = []string{parseErr.Error()}
+	} else {
+		streamed.ValidationErrors = ai.ValidateProgram(programData, expect)
+	}
+	streamed.Succeeded = len(streamed.ValidationErrors) == 0
+
+	result := &ai.GenerationResult{
+		Program:         programData,
+		RawResponse:     programJSON,
+		Model:           reply.Model,
+		Attempts:        []models.ProgramGenerationAttempt{streamed},
+		PromptTemplates: reply.PromptTemplates,
+	}
+
+	if !streamed.Succeeded {
+		w.send("repairing", gin.H{"validation_errors": streamed.ValidationErrors})
+
+		repaired, err := h.aiClient.RepairProgram(ctx, programJSON, streamed.ValidationErrors, expect, userID)
+		if repaired != nil {
+			for i := range repaired.Attempts {
+				repaired.Attempts[i].AttemptNumber += len(result.Attempts)
+			}
+			result.Attempts = append(result.Attempts, repaired.Attempts...)
+		}
+		if err != nil {
+			h.recordGenerationAttempts(userUUID, nil, "generate_stream", result)
+			log.Warn().Err(err).Msg("AI could not produce a valid program")
+			w.send("error", gin.H{
+				"error":             "Generated program failed validation",
+				"validation_errors": lastValidationErrors(result),
+			})
+			return
+		}
+		result.Program = repaired.Program
+		result.RawResponse = repaired.RawResponse
+		result.PromptTemplates = append(result.PromptTemplates, repaired.PromptTemplates...)
+	}
+
+	program, conversation, err := h.saveGeneratedProgram(userUUID, req, result, coachFeedback)
+	if err != nil {
+		h.recordGenerationAttempts(userUUID, nil, "generate_stream", result)
+		log.Error().Err(err).Msg("Failed to save generated program")
+		w.send("error", gin.H{"error": "Failed to save program"})
+		return
+	}
+	h.recordGenerationAttempts(userUUID, &program.ID, "generate_stream", result)
+
+	w.send("done", gin.H{
+		"program":      program,
+		"ai_response":  result.RawResponse,
+		"conversation": conversation,
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/rs/zerolog/log"
+)
+
+// SyncWorkouts applies a batch of sets and session changes queued while the
+// athlete was offline. The batch is applied in one transaction, so a failed
+// request can simply be retried; mutations that were already applied come back
+// as duplicates with their original result.
+func (h *ProgramHandlers) SyncWorkouts(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	athleteID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Mutations) > models.MaxSyncMutations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d mutations per sync", models.MaxSyncMutations)})
+		return
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.Mutations))
+	for _, mutation := range req.Mutations {
+		if seen[mutation.ID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Duplicate mutation id %s", mutation.ID)})
+			return
+		}
+		seen[mutation.ID] = true
+	}
+
+	results, sessionIDs, err := h.programRepo.ApplySyncMutations(athleteID, req.Mutations)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Int("mutations", len(req.Mutations)).Msg("Failed to sync workouts")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync workouts"})
+		return
+	}
+
+	sessions := make([]models.TrainingSession, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		session, err := h.programRepo.GetTrainingSessionByID(sessionID)
+		if err != nil {
+			log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to load synced session")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load synced sessions"})
+			return
+		}
+		sessions = append(sessions, *session)
+	}
+
+	c.JSON(http.StatusOK, models.SyncResponse{
+		Results:    results,
+		Sessions:   sessions,
+		ServerTime: time.Now().UTC(),
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/ai"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// RequireAIQuota rejects the request with 429 once the caller has used up a token
+// quota, and attributes the model calls made while handling it to feature
+func (h *ProgramHandlers) RequireAIQuota(feature models.AIFeature) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(middleware.GetUserID(c))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		exceeded, err := h.usageService.CheckQuota(userID)
+		if err != nil {
+			// Fail open: an accounting outage should not take AI features down
+			log.Error().Err(err).Msg("Failed to check AI quota")
+		} else if exceeded != nil {
+			retryAfter := int(time.Until(exceeded.ResetsAt).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":     fmt.Sprintf("%s AI token quota exceeded", exceeded.Period),
+				"period":    exceeded.Period,
+				"limit":     exceeded.Limit,
+				"used":      exceeded.Used,
+				"resets_at": exceeded.ResetsAt,
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(ai.WithUsage(c.Request.Context(), userID, feature))
+		c.Next()
+	}
+}
+
+// GetMyAIUsage returns the caller's quota position and usage by feature and model
+func (h *ProgramHandlers) GetMyAIUsage(c *gin.Context) {
+	userID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	from, to, err := parseUsageRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quotas, err := h.usageService.QuotaStatus(userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get AI quota status")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get AI usage"})
+		return
+	}
+
+	usage, err := h.programRepo.GetAIUsageSummary(&userID, from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get AI usage summary")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get AI usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":   from,
+		"to":     to,
+		"quotas": quotas,
+		"usage":  usage,
+		"totals": sumUsage(usage),
+	})
+}
+
+// GetAIUsageReport is the admin view of usage across all users: totals by feature
+// and model plus the heaviest users
+func (h *ProgramHandlers) GetAIUsageReport(c *gin.Context) {
+	from, to, err := parseUsageRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 50
+	if l := c.Query("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	usage, err := h.programRepo.GetAIUsageSummary(nil, from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get AI usage summary")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get AI usage"})
+		return
+	}
+
+	byUser, err := h.programRepo.GetAIUsageByUser(from, to, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get AI usage by user")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get AI usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":    from,
+		"to":      to,
+		"usage":   usage,
+		"by_user": byUser,
+		"totals":  sumUsage(usage),
+	})
+}
+
+// parseUsageRange reads from/to (YYYY-MM-DD, to inclusive), defaulting to the
+// current calendar month in UTC
+func parseUsageRange(c *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	if s := c.Query("from"); s != "" {
+		parsed, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return from, to, fmt.Errorf("from must be YYYY-MM-DD")
+		}
+		from = parsed
+	}
+	if s := c.Query("to"); s != "" {
+		parsed, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return from, to, fmt.Errorf("to must be YYYY-MM-DD")
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	if !to.After(from) {
+		return from, to, fmt.Errorf("to must not be before from")
+	}
+
+	return from, to, nil
+}
+
+func sumUsage(usage []models.AIUsageSummary) models.AIUsageSummary {
+	var total models.AIUsageSummary
+	for _, u := range usage {
+		total.Calls += u.Calls
+		total.PromptTokens += u.PromptTokens
+		total.CompletionTokens += u.CompletionTokens
+		total.TotalTokens += u.TotalTokens
+		total.EstimatedCostUSD += u.EstimatedCostUSD
+	}
+	return total
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/powerlifting-coach-app/program-service/internal/velocity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	eventWorkoutVelocityImported = "workout.velocity.imported"
+
+	maxVelocityExportBytes = 2 << 20
+)
+
+// GetVelocityTrends summarises the athlete's tracked sets per exercise per day
+func (h *ProgramHandlers) GetVelocityTrends(c *gin.Context) {
+	var req models.GetVelocityDataRequest
+	sets, ok := h.velocitySets(c, &req)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"velocity_trends": velocity.Trends(sets)})
+}
+
+// GetLoadVelocityProfiles fits a load-velocity profile for each exercise the
+// athlete has tracked, with the 1RM it estimates overall and day by day
+func (h *ProgramHandlers) GetLoadVelocityProfiles(c *gin.Context) {
+	var req models.GetVelocityDataRequest
+	sets, ok := h.velocitySets(c, &req)
+	if !ok {
+		return
+	}
+
+	var order []string
+	byExercise := make(map[string][]models.VelocitySetData)
+	for _, set := range sets {
+		key := strings.ToLower(set.ExerciseName)
+		if _, seen := byExercise[key]; !seen {
+			order = append(order, key)
+		}
+		byExercise[key] = append(byExercise[key], set)
+	}
+
+	profiles := make([]models.LoadVelocityProfile, 0, len(order))
+	for _, key := range order {
+		exerciseSets := byExercise[key]
+		points := make([]velocity.Point, 0, len(exerciseSets))
+		for _, set := range exerciseSets {
+			points = append(points, velocity.SetPoint(set))
+		}
+
+		first := exerciseSets[0]
+		minVelocity, source := velocity.MinVelocity(first.LiftType, points, req.MinVelocityMps)
+		profiles = append(profiles, velocity.FitProfile(first.ExerciseName, first.LiftType, points, minVelocity, source))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+// ImportVelocityCSV attaches bar speed from a velocity tracker's CSV export to
+// one exercise of a session. Sets are numbered from first_set_number in file
+// order; sets already logged under those numbers get the readings and the rest
+// are logged from the export's load and rep count.
+func (h *ProgramHandlers) ImportVelocityCSV(c *gin.Context) {
+	session, ok := h.ownSession(c)
+	if !ok {
+		return
+	}
+
+	exerciseID, err := uuid.Parse(c.PostForm("exercise_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exercise ID"})
+		return
+	}
+	exercise := findSessionExercise(session, exerciseID)
+	if exercise == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exercise is not part of this session"})
+		return
+	}
+
+	firstSetNumber := 1
+	if raw := c.PostForm("first_set_number"); raw != "" {
+		firstSetNumber, err = strconv.Atoi(raw)
+		if err != nil || firstSetNumber < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "first_set_number must be a positive integer"})
+			return
+		}
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is required"})
+		return
+	}
+	if fileHeader.Size > maxVelocityExportBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is too large"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read CSV file"})
+		return
+	}
+	defer file.Close()
+
+	imported, err := velocity.ParseCSV(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if name := strings.TrimSpace(c.PostForm("exercise")); name != "" {
+		var matching []velocity.ImportedSet
+		for _, set := range imported {
+			if strings.EqualFold(set.Exercise, name) {
+				matching = append(matching, set)
+			}
+		}
+		if len(matching) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("No sets of %q in the export", name)})
+			return
+		}
+		imported = matching
+	} else if names := velocity.Exercises(imported); len(names) > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "The export has several exercises; choose one with the exercise field",
+			"exercises": names,
+		})
+		return
+	}
+
+	logged := make(map[int]bool, len(exercise.CompletedSets))
+	for _, set := range exercise.CompletedSets {
+		logged[set.SetNumber] = true
+	}
+	group := findSessionGroup(session, exercise.GroupID)
+
+	sets := make([]models.CompletedSet, 0, len(imported))
+	for i, importedSet := range imported {
+		set := models.CompletedSet{
+			SetNumber:       firstSetNumber + i,
+			MeanVelocityMps: importedSet.MeanVelocityMps,
+			PeakVelocityMps: importedSet.PeakVelocityMps,
+			RepVelocities:   importedSet.Reps,
+			SetType:         models.SetTypeWorking,
+		}
+
+		if !logged[set.SetNumber] {
+			if importedSet.WeightKg == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Set %d has no load in the export and isn't logged yet", set.SetNumber)})
+				return
+			}
+			set.WeightKg = *importedSet.WeightKg
+			set.RepsCompleted = len(importedSet.Reps)
+			if importedSet.RepCount != nil {
+				set.RepsCompleted = *importedSet.RepCount
+			}
+			if group != nil {
+				round := group.RoundOfSet(*exercise, set.SetNumber)
+				set.RoundNumber = &round
+			}
+		}
+
+		sets = append(sets, set)
+	}
+
+	result, err := h.programRepo.ImportSetVelocities(exercise.ID, sets, session.AthleteID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", session.ID.String()).Msg("Failed to import velocity data")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import velocity data"})
+		return
+	}
+
+	h.publishSessionEvent(eventWorkoutVelocityImported, session, map[string]interface{}{
+		"exercise_id":   exercise.ID.String(),
+		"exercise_name": exercise.ExerciseName,
+		"sets":          result,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"sets": result})
+}
+
+// velocitySets binds the optional filters into req and loads the caller's
+// tracked sets that match
+func (h *ProgramHandlers) velocitySets(c *gin.Context, req *models.GetVelocityDataRequest) ([]models.VelocitySetData, bool) {
+	athleteID, err := uuid.Parse(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	// Default to last 90 days if not specified
+	if req.StartDate.IsZero() {
+		req.StartDate = time.Now().AddDate(0, 0, -90)
+	}
+	if req.EndDate.IsZero() {
+		req.EndDate = time.Now()
+	}
+
+	sets, err := h.programRepo.GetVelocitySets(athleteID, req.StartDate, req.EndDate, req.LiftType, req.ExerciseName)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get velocity data")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get velocity data"})
+		return nil, false
+	}
+
+	return sets, true
+}
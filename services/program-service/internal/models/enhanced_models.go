@@ -142,3 +142,652 @@ type GetE1RMDataRequest struct {
 	EndDate      time.Time `json:"end_date"`
 	LiftType     *LiftType `json:"lift_type"`
 }
+
+// BestLifts holds an athlete's heaviest completed squat, bench and deadlift
+type BestLifts struct {
+	AsOf       time.Time `json:"as_of"`
+	SquatKg    float64   `json:"squat_kg"`
+	BenchKg    float64   `json:"bench_kg"`
+	DeadliftKg float64   `json:"deadlift_kg"`
+}
+
+func (b BestLifts) TotalKg() float64 {
+	return b.SquatKg + b.BenchKg + b.DeadliftKg
+}
+
+type ScoreTotalRequest struct {
+	Sex          string  `json:"sex" binding:"required"`
+	BodyweightKg float64 `json:"bodyweight_kg" binding:"required,gt=0"`
+	TotalKg      float64 `json:"total_kg" binding:"required,gt=0"`
+	Equipment    string  `json:"equipment"` // classic (default) or equipped
+	Event        string  `json:"event"`     // SBD (default) or B
+	Age          *int    `json:"age"`
+}
+
+// PersonalRecord is an athlete's best result on an exercise
+type PersonalRecord struct {
+	ExerciseName string    `json:"exercise_name"`
+	LiftType     LiftType  `json:"lift_type"`
+	WeightKg     float64   `json:"weight_kg"`
+	Reps         int       `json:"reps"`
+	Estimated1RM float64   `json:"estimated_1rm"`
+	AchievedAt   time.Time `json:"achieved_at"`
+}
+
+// ProgramGenerationAttempt records one round trip to the model while generating
+// or repairing a program
+type ProgramGenerationAttempt struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	AthleteID        uuid.UUID  `json:"athlete_id" db:"athlete_id"`
+	ProgramID        *uuid.UUID `json:"program_id" db:"program_id"`
+	Source           string     `json:"source" db:"source"`
+	AttemptNumber    int        `json:"attempt_number" db:"attempt_number"`
+	Model            string     `json:"model" db:"model"`
+	RawResponse      string     `json:"raw_response" db:"raw_response"`
+	ValidationErrors []string   `json:"validation_errors" db:"validation_errors"`
+	Error            *string    `json:"error" db:"error"`
+	Succeeded        bool       `json:"succeeded" db:"succeeded"`
+	DurationMs       int64      `json:"duration_ms" db:"duration_ms"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
+
+// AIFeature identifies which part of the product spent AI tokens
+type AIFeature string
+
+const (
+	AIFeatureGenerate     AIFeature = "generate"
+	AIFeatureChat         AIFeature = "chat"
+	AIFeatureFormAnalysis AIFeature = "form_analysis"
+	AIFeatureOpenAICompat AIFeature = "openai_compat"
+)
+
+// AIUsage is the token usage of a single model call
+type AIUsage struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	UserID           uuid.UUID `json:"user_id" db:"user_id"`
+	Feature          AIFeature `json:"feature" db:"feature"`
+	Model            string    `json:"model" db:"model"`
+	PromptTokens     int       `json:"prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens" db:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens" db:"total_tokens"`
+	EstimatedCostUSD float64   `json:"estimated_cost_usd" db:"estimated_cost_usd"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// AIUsageSummary aggregates usage for one feature/model pair, optionally per user
+type AIUsageSummary struct {
+	UserID           *uuid.UUID `json:"user_id,omitempty"`
+	Feature          AIFeature  `json:"feature,omitempty"`
+	Model            string     `json:"model,omitempty"`
+	Calls            int        `json:"calls"`
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	TotalTokens      int        `json:"total_tokens"`
+	EstimatedCostUSD float64    `json:"estimated_cost_usd"`
+}
+
+// AIQuotaStatus reports a user's position against one quota window
+type AIQuotaStatus struct {
+	Period    string    `json:"period"` // daily or monthly
+	Limit     int       `json:"limit"` // 0 means unlimited
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+func (q AIQuotaStatus) Exceeded() bool {
+	return q.Limit > 0 && q.Used >= q.Limit
+}
+
+const (
+	FormAnalysisProcessing = "processing"
+	FormAnalysisCompleted  = "completed"
+	FormAnalysisFailed     = "failed"
+)
+
+// FormAnalysis is the AI review of one lift video, keyed by the video-service
+// video it was run on
+type FormAnalysis struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	VideoID     uuid.UUID      `json:"video_id" db:"video_id"`
+	AthleteID   uuid.UUID      `json:"athlete_id" db:"athlete_id"`
+	Exercise    string         `json:"exercise" db:"exercise"`
+	Load        *float64       `json:"load" db:"load"`
+	Status      string         `json:"status" db:"status"`
+	Score       *float64       `json:"score" db:"score"`           // 0-10
+	Confidence  *float64       `json:"confidence" db:"confidence"` // 0-1
+	Summary     *string        `json:"summary" db:"summary"`
+	KeyFrames   []FormKeyFrame `json:"key_frames" db:"key_frames"`
+	Issues      []FormIssue    `json:"issues" db:"issues"`
+	AIModel     *string        `json:"ai_model" db:"ai_model"`
+	Error       *string        `json:"error" db:"error"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time     `json:"completed_at" db:"completed_at"`
+}
+
+// FormKeyFrame marks where a phase of the lift (setup, bottom, lockout) happens
+type FormKeyFrame struct {
+	Phase            string  `json:"phase"`
+	TimestampSeconds float64 `json:"timestamp_seconds"`
+}
+
+// FormIssue matches video-service's FormIssue so it can be stored as-is
+type FormIssue struct {
+	Type             string   `json:"type"`
+	Description      string   `json:"description"`
+	TimestampSeconds *float64 `json:"timestamp_seconds"`
+	Severity         *float64 `json:"severity"` // 0-1 scale
+}
+
+// RestPeriod is a rest timer run between sets in a live session
+type RestPeriod struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	SessionID     uuid.UUID  `json:"session_id" db:"session_id"`
+	ExerciseID    *uuid.UUID `json:"exercise_id" db:"exercise_id"`
+	AfterSetID    *uuid.UUID `json:"after_set_id" db:"after_set_id"`
+	TargetSeconds *int       `json:"target_seconds" db:"target_seconds"`
+	StartedAt     time.Time  `json:"started_at" db:"started_at"`
+	StoppedAt     *time.Time `json:"stopped_at" db:"stopped_at"`
+}
+
+// LiveSetTarget is what the athlete should do on the next set of an exercise,
+// with their previous session's matching set for autofill
+type LiveSetTarget struct {
+	ExerciseID        uuid.UUID        `json:"exercise_id"`
+	ExerciseName      string           `json:"exercise_name"`
+	SetNumber         int              `json:"set_number"`
+	TargetSets        int              `json:"target_sets"`
+	TargetReps        string           `json:"target_reps"`
+	TargetWeightKg    *float64         `json:"target_weight_kg"`
+	TargetRPE         *float64         `json:"target_rpe"`
+	RestSeconds       *int             `json:"rest_seconds"`
+	SuggestedWeightKg *float64         `json:"suggested_weight_kg"`
+	Previous          *PreviousSetData `json:"previous"`
+	// GroupLabel is the exercise's place in its group, e.g. A2, and RoundNumber
+	// the round the set belongs to; both are unset for ungrouped exercises
+	GroupLabel  *string `json:"group_label,omitempty"`
+	RoundNumber *int    `json:"round_number,omitempty"`
+	// VelocityLossCutoffPct ends the set once a rep is this much slower than
+	// the set's fastest
+	VelocityLossCutoffPct *float64 `json:"velocity_loss_cutoff_pct,omitempty"`
+}
+
+// GroupProgress is how far a session has got through an exercise group. A round
+// is complete once every exercise in the group has done its sets for that round.
+type GroupProgress struct {
+	Group           ExerciseGroup `json:"group"`
+	RoundsCompleted int           `json:"rounds_completed"`
+	Completed       bool          `json:"completed"`
+	// NextExerciseID is the exercise up next in the current round
+	NextExerciseID *uuid.UUID `json:"next_exercise_id"`
+}
+
+// LiveSessionState is a session in progress as the athlete and their coach see it
+type LiveSessionState struct {
+	Session     TrainingSession `json:"session"`
+	StartedAt   *time.Time      `json:"started_at"`
+	ActiveRest  *RestPeriod     `json:"active_rest"`
+	NextTargets []LiveSetTarget `json:"next_targets"`
+	Groups      []GroupProgress `json:"groups"`
+}
+
+// LogLiveSetRequest logs one set as it happens; SetNumber defaults to the next
+// set of the exercise and, for grouped exercises, RoundNumber to that set's round
+type LogLiveSetRequest struct {
+	ExerciseID      uuid.UUID     `json:"exercise_id" binding:"required"`
+	SetNumber       *int          `json:"set_number" binding:"omitempty,min=1"`
+	RoundNumber     *int          `json:"round_number" binding:"omitempty,min=1"`
+	RepsCompleted   int           `json:"reps_completed" binding:"min=0"`
+	WeightKg        float64       `json:"weight_kg" binding:"min=0"`
+	RPEActual       *float64      `json:"rpe_actual" binding:"omitempty,min=1,max=10"`
+	VideoID         *uuid.UUID    `json:"video_id"`
+	Notes           *string       `json:"notes"`
+	SetType         SetType       `json:"set_type"`
+	MediaURLs       []string      `json:"media_urls"`
+	ExerciseNotes   *string       `json:"exercise_notes"`
+	MeanVelocityMps *float64      `json:"mean_velocity_mps" binding:"omitempty,min=0"`
+	PeakVelocityMps *float64      `json:"peak_velocity_mps" binding:"omitempty,min=0"`
+	VelocityLossPct *float64      `json:"velocity_loss_pct" binding:"omitempty,min=0,max=100"`
+	RepVelocities   []RepVelocity `json:"rep_velocities" binding:"omitempty,dive"`
+}
+
+// UpdateSetRequest edits a logged set; omitted fields are left unchanged
+type UpdateSetRequest struct {
+	SetNumber       *int       `json:"set_number" binding:"omitempty,min=1"`
+	RepsCompleted   *int       `json:"reps_completed" binding:"omitempty,min=0"`
+	WeightKg        *float64   `json:"weight_kg" binding:"omitempty,min=0"`
+	RPEActual       *float64   `json:"rpe_actual" binding:"omitempty,min=1,max=10"`
+	VideoID         *uuid.UUID `json:"video_id"`
+	Notes           *string    `json:"notes"`
+	SetType         *SetType   `json:"set_type"`
+	MediaURLs       *[]string  `json:"media_urls"`
+	ExerciseNotes   *string    `json:"exercise_notes"`
+	MeanVelocityMps *float64   `json:"mean_velocity_mps" binding:"omitempty,min=0"`
+	PeakVelocityMps *float64   `json:"peak_velocity_mps" binding:"omitempty,min=0"`
+	VelocityLossPct *float64   `json:"velocity_loss_pct" binding:"omitempty,min=0,max=100"`
+	// RepVelocities replaces the per-rep readings; the set's summary is
+	// recomputed from them unless given alongside
+	RepVelocities *[]RepVelocity `json:"rep_velocities" binding:"omitempty,dive"`
+}
+
+// Actions recorded in a set's correction history
+const (
+	SetRevisionUpdated  = "updated"
+	SetRevisionDeleted  = "deleted"
+	SetRevisionReverted = "reverted"
+)
+
+// SetRevision is one correction to a logged set: who made it and the set before
+// and after. Before is nil when an undo brought a deleted set back; After is nil
+// when the set was deleted. UndoneAt is set once the change has been undone.
+type SetRevision struct {
+	ID                uuid.UUID     `json:"id"`
+	SetID             uuid.UUID     `json:"set_id"`
+	SessionID         uuid.UUID     `json:"session_id"`
+	ExerciseID        uuid.UUID     `json:"exercise_id"`
+	Action            string        `json:"action"`
+	ChangedBy         uuid.UUID     `json:"changed_by"`
+	Before            *CompletedSet `json:"before"`
+	After             *CompletedSet `json:"after"`
+	RevertsRevisionID *uuid.UUID    `json:"reverts_revision_id,omitempty"`
+	UndoneAt          *time.Time    `json:"undone_at,omitempty"`
+	CreatedAt         time.Time     `json:"created_at"`
+}
+
+// SetCorrectionResponse is the result of editing, deleting or undoing a change to
+// a set, with the athlete's personal records for the lift recomputed from the
+// corrected history
+type SetCorrectionResponse struct {
+	Set             *CompletedSet    `json:"set"`
+	Revision        SetRevision      `json:"revision"`
+	PersonalRecords []PersonalRecord `json:"personal_records"`
+}
+
+// StartRestRequest starts a rest timer. TargetSeconds defaults to the exercise's
+// prescribed rest.
+type StartRestRequest struct {
+	ExerciseID    *uuid.UUID `json:"exercise_id"`
+	AfterSetID    *uuid.UUID `json:"after_set_id"`
+	TargetSeconds *int       `json:"target_seconds" binding:"omitempty,min=1"`
+}
+
+// FinishSessionRequest ends a live session. DurationMins defaults to the time
+// since the session was started.
+type FinishSessionRequest struct {
+	Notes        *string  `json:"notes"`
+	RPERating    *float64 `json:"rpe_rating" binding:"omitempty,min=1,max=10"`
+	DurationMins *int     `json:"duration_minutes" binding:"omitempty,min=0"`
+}
+
+// GetVelocityDataRequest filters tracked sets for velocity analytics; the window
+// defaults to the last 90 days
+type GetVelocityDataRequest struct {
+	StartDate    time.Time `json:"start_date"`
+	EndDate      time.Time `json:"end_date"`
+	LiftType     *LiftType `json:"lift_type"`
+	ExerciseName *string   `json:"exercise_name"`
+	// MinVelocityMps overrides the velocity a 1RM is expected to move at
+	MinVelocityMps *float64 `json:"min_velocity_mps" binding:"omitempty,gt=0"`
+}
+
+// VelocitySetData is a set logged with bar speed
+type VelocitySetData struct {
+	SetID           uuid.UUID     `json:"set_id"`
+	SessionID       uuid.UUID     `json:"session_id"`
+	Date            time.Time     `json:"date"`
+	ExerciseName    string        `json:"exercise_name"`
+	LiftType        LiftType      `json:"lift_type"`
+	SetType         SetType       `json:"set_type"`
+	WeightKg        float64       `json:"weight_kg"`
+	RepsCompleted   int           `json:"reps_completed"`
+	RPE             *float64      `json:"rpe"`
+	MeanVelocityMps float64       `json:"mean_velocity_mps"`
+	PeakVelocityMps *float64      `json:"peak_velocity_mps"`
+	VelocityLossPct *float64      `json:"velocity_loss_pct"`
+	RepVelocities   []RepVelocity `json:"rep_velocities"`
+}
+
+// VelocityTrendPoint is one day's tracked sets of an exercise
+type VelocityTrendPoint struct {
+	Date                   time.Time `json:"date"`
+	ExerciseName           string    `json:"exercise_name"`
+	LiftType               LiftType  `json:"lift_type"`
+	Sets                   int       `json:"sets"`
+	BestVelocityMps        float64   `json:"best_velocity_mps"`
+	AverageVelocityMps     float64   `json:"average_velocity_mps"`
+	AverageVelocityLossPct *float64  `json:"average_velocity_loss_pct"`
+	TopWeightKg            float64   `json:"top_weight_kg"`
+	VelocityAtTopWeightMps float64   `json:"velocity_at_top_weight_mps"`
+}
+
+// LoadVelocityProfile is the line bar speed follows as load goes up for one
+// exercise, and the 1RM it points to. The fit and estimates are unset when
+// there aren't enough sets at different loads to draw it.
+type LoadVelocityProfile struct {
+	ExerciseName   string   `json:"exercise_name"`
+	LiftType       LiftType `json:"lift_type"`
+	Sets           int      `json:"sets"`
+	SlopeMpsPerKg  *float64 `json:"slope_mps_per_kg"`
+	InterceptMps   *float64 `json:"intercept_mps"`
+	RSquared       *float64 `json:"r_squared"`
+	MinVelocityMps *float64 `json:"min_velocity_mps"`
+	// MinVelocitySource is where the threshold came from: request, athlete
+	// (their own max singles) or default
+	MinVelocitySource string                  `json:"min_velocity_source,omitempty"`
+	Estimated1RMKg    *float64                `json:"estimated_1rm_kg"`
+	Daily             []DailyVelocityEstimate `json:"daily"`
+}
+
+// DailyVelocityEstimate is the 1RM a day's submaximal sets point to
+type DailyVelocityEstimate struct {
+	Date           time.Time `json:"date"`
+	Sets           int       `json:"sets"`
+	HeaviestKg     float64   `json:"heaviest_kg"`
+	Estimated1RMKg float64   `json:"estimated_1rm_kg"`
+}
+
+// DeleteSessionRequest moves a session to the trash; the reason is shown there
+type DeleteSessionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// TrashedSession is a deleted session with when it will be purged for good;
+// PurgeAt is null when deleted sessions are kept until restored
+type TrashedSession struct {
+	TrainingSession
+	PurgeAt *time.Time `json:"purge_at"`
+}
+
+// Reasons a deleted session can't simply be put back
+const (
+	// RestoreConflictSlotTaken: another session now holds the same program day
+	RestoreConflictSlotTaken = "slot_taken"
+	// RestoreConflictProgramRegenerated: the program's sessions were generated
+	// again after the session was deleted
+	RestoreConflictProgramRegenerated = "program_regenerated"
+)
+
+type RestoreConflict struct {
+	Type      string     `json:"type"`
+	Message   string     `json:"message"`
+	SessionID *uuid.UUID `json:"session_id,omitempty"`
+}
+
+// RestoreSessionRequest brings a session back from the trash. Force restores it
+// despite conflicts, leaving both sessions in place.
+type RestoreSessionRequest struct {
+	Force bool `json:"force"`
+}
+
+// Where an athlete stands on a goal, judged against their next competition
+const (
+	GoalStatusAchieved         = "achieved"
+	GoalStatusOnTrack          = "on_track"
+	GoalStatusPossible         = "possible"
+	GoalStatusUnlikely         = "unlikely"
+	GoalStatusNoCompetition    = "no_competition"
+	GoalStatusInsufficientData = "insufficient_data"
+)
+
+// WeeklyE1RM is the best estimated 1RM of a week, which starts on Monday
+type WeeklyE1RM struct {
+	WeekStart      time.Time `json:"week_start"`
+	LiftType       LiftType  `json:"lift_type,omitempty"`
+	Estimated1RMKg float64   `json:"estimated_1rm_kg"`
+}
+
+// GoalProjection is when the trend reaches the goal. Earliest and Latest bound
+// it using the faster and slower ends of the trend's spread; a date is unset
+// when that trend doesn't get there within two years.
+type GoalProjection struct {
+	Date         *time.Time `json:"date"`
+	Earliest     *time.Time `json:"earliest"`
+	Latest       *time.Time `json:"latest"`
+	WeeklyGainKg float64    `json:"weekly_gain_kg"`
+}
+
+// GoalProgress is an athlete's progress toward one lift goal or their total goal
+type GoalProgress struct {
+	// Lift is squat, bench, deadlift or total
+	Lift            string          `json:"lift"`
+	GoalKg          float64         `json:"goal_kg"`
+	CurrentE1RMKg   *float64        `json:"current_e1rm_kg"`
+	RemainingKg     *float64        `json:"remaining_kg"`
+	PercentComplete *float64        `json:"percent_complete"`
+	Projection      *GoalProjection `json:"projection"`
+	// ProjectedAtCompetitionKg is where the trend puts the lift on meet day
+	ProjectedAtCompetitionKg *float64     `json:"projected_at_competition_kg,omitempty"`
+	Status                   string       `json:"status"`
+	History                  []WeeklyE1RM `json:"history"`
+}
+
+// GoalsReport is progress on every goal the athlete has set
+type GoalsReport struct {
+	CompetitionDate *time.Time     `json:"competition_date"`
+	Goals           []GoalProgress `json:"goals"`
+}
+
+// PainEpisode is one pain or injury issue in one body region. It is active
+// until it has a resolved_on date.
+type PainEpisode struct {
+	ID                 uuid.UUID  `json:"id"`
+	AthleteID          uuid.UUID  `json:"athlete_id"`
+	BodyRegion         string     `json:"body_region"`
+	Side               *string    `json:"side"`
+	TriggeringMovement *string    `json:"triggering_movement"`
+	LiftType           *LiftType  `json:"lift_type"`
+	StartedOn          time.Time  `json:"started_on"`
+	ResolvedOn         *time.Time `json:"resolved_on"`
+	Notes              *string    `json:"notes"`
+	// CurrentScore is the latest report's score and PeakScore the highest
+	CurrentScore *int `json:"current_score"`
+	PeakScore    *int `json:"peak_score"`
+	// AggravatingExercises are the exercises of the sets its reports are tied to
+	AggravatingExercises []string     `json:"aggravating_exercises"`
+	Reports              []PainReport `json:"reports,omitempty"`
+	CreatedAt            time.Time    `json:"created_at"`
+	UpdatedAt            time.Time    `json:"updated_at"`
+}
+
+// PainReport is a 0-10 pain score logged during an episode, optionally tied to
+// the session or set it came up in
+type PainReport struct {
+	ID           uuid.UUID  `json:"id"`
+	EpisodeID    uuid.UUID  `json:"episode_id"`
+	PainScore    int        `json:"pain_score"`
+	SessionID    *uuid.UUID `json:"session_id"`
+	SetID        *uuid.UUID `json:"set_id"`
+	ExerciseName *string    `json:"exercise_name,omitempty"`
+	Notes        *string    `json:"notes"`
+	ReportedAt   time.Time  `json:"reported_at"`
+}
+
+// CreatePainEpisodeRequest opens an episode with its first pain report
+type CreatePainEpisodeRequest struct {
+	BodyRegion         string     `json:"body_region" binding:"required,oneof=neck upper_back lower_back chest shoulder elbow wrist hand hip glute groin hamstring quad knee calf shin ankle foot other"`
+	Side               *string    `json:"side" binding:"omitempty,oneof=left right both"`
+	PainScore          *int       `json:"pain_score" binding:"required,min=0,max=10"`
+	TriggeringMovement *string    `json:"triggering_movement" binding:"omitempty,max=255"`
+	LiftType           *LiftType  `json:"lift_type" binding:"omitempty,oneof=squat bench deadlift accessory"`
+	StartedOn          *time.Time `json:"started_on"`
+	SessionID          *uuid.UUID `json:"session_id"`
+	SetID              *uuid.UUID `json:"set_id"`
+	Notes              *string    `json:"notes"`
+}
+
+// UpdatePainEpisodeRequest changes the details of an episode; unset fields are kept
+type UpdatePainEpisodeRequest struct {
+	BodyRegion         *string    `json:"body_region" binding:"omitempty,oneof=neck upper_back lower_back chest shoulder elbow wrist hand hip glute groin hamstring quad knee calf shin ankle foot other"`
+	Side               *string    `json:"side" binding:"omitempty,oneof=left right both"`
+	TriggeringMovement *string    `json:"triggering_movement" binding:"omitempty,max=255"`
+	LiftType           *LiftType  `json:"lift_type" binding:"omitempty,oneof=squat bench deadlift accessory"`
+	StartedOn          *time.Time `json:"started_on"`
+	Notes              *string    `json:"notes"`
+}
+
+// AddPainReportRequest logs a pain score for an episode
+type AddPainReportRequest struct {
+	PainScore  *int       `json:"pain_score" binding:"required,min=0,max=10"`
+	SessionID  *uuid.UUID `json:"session_id"`
+	SetID      *uuid.UUID `json:"set_id"`
+	Notes      *string    `json:"notes"`
+	ReportedAt *time.Time `json:"reported_at"`
+}
+
+// ResolvePainEpisodeRequest closes an episode, today unless a date is given
+type ResolvePainEpisodeRequest struct {
+	ResolvedOn *time.Time `json:"resolved_on"`
+}
+
+// PainTrendWeek puts a week's pain reports next to the training load of the
+// same week. The pain fields are unset in weeks without reports.
+type PainTrendWeek struct {
+	WeekStart        time.Time            `json:"week_start"`
+	Reports          int                  `json:"reports"`
+	MaxPainScore     *int                 `json:"max_pain_score"`
+	AveragePainScore *float64             `json:"average_pain_score"`
+	ActiveEpisodes   int                  `json:"active_episodes"`
+	WorkingSets      int                  `json:"working_sets"`
+	TonnageKg        float64              `json:"tonnage_kg"`
+	TonnageByLift    map[LiftType]float64 `json:"tonnage_by_lift"`
+}
+
+// PainFlag marks an exercise in an upcoming session that aggravated an active
+// pain episode before
+type PainFlag struct {
+	SessionID     uuid.UUID  `json:"session_id"`
+	SessionName   *string    `json:"session_name"`
+	ScheduledDate *time.Time `json:"scheduled_date"`
+	ExerciseID    uuid.UUID  `json:"exercise_id"`
+	ExerciseName  string     `json:"exercise_name"`
+	EpisodeID     uuid.UUID  `json:"episode_id"`
+	BodyRegion    string     `json:"body_region"`
+	CurrentScore  *int       `json:"current_score"`
+	// Reason names the movement or lift the exercise was matched on
+	Reason string `json:"reason"`
+}
+
+// How a prescribed session was done
+const (
+	AdherenceOnTime   = "on_time"
+	AdherenceLate     = "late"
+	AdherenceMissed   = "missed"
+	AdherenceUpcoming = "upcoming"
+)
+
+// AdherenceTotals compares what was prescribed with what was done across the
+// sessions that are due; sessions still upcoming aren't counted
+type AdherenceTotals struct {
+	SessionsPrescribed int      `json:"sessions_prescribed"`
+	SessionsOnTime     int      `json:"sessions_on_time"`
+	SessionsLate       int      `json:"sessions_late"`
+	SessionsMissed     int      `json:"sessions_missed"`
+	SetsPrescribed     int      `json:"sets_prescribed"`
+	SetsPerformed      int      `json:"sets_performed"`
+	RepsPrescribed     int      `json:"reps_prescribed"`
+	RepsPerformed      int      `json:"reps_performed"`
+	SetCompletionPct   *float64 `json:"set_completion_pct"`
+	RepCompletionPct   *float64 `json:"rep_completion_pct"`
+	// LoadDeviationPct averages how far each exercise's working load was from
+	// its target, as a percentage of the target; negative is lighter
+	LoadDeviationPct *float64 `json:"load_deviation_pct"`
+	// RPEDeviation averages actual minus target RPE; positive felt harder
+	RPEDeviation *float64 `json:"rpe_deviation"`
+}
+
+// ExerciseAdherence is one prescribed exercise against its performed sets.
+// Warm-up sets don't count. The rep fields are unset when the target reps
+// aren't a number or range, e.g. AMRAP.
+type ExerciseAdherence struct {
+	ExerciseID     uuid.UUID `json:"exercise_id"`
+	ExerciseName   string    `json:"exercise_name"`
+	LiftType       LiftType  `json:"lift_type"`
+	SetsPrescribed int       `json:"sets_prescribed"`
+	SetsPerformed  int       `json:"sets_performed"`
+	TargetReps     string    `json:"target_reps"`
+	RepsPrescribed *int      `json:"reps_prescribed"`
+	RepsPerformed  int       `json:"reps_performed"`
+	// TargetLoadKg is the prescribed weight, or the target percentage of the
+	// athlete's best lift when the program started
+	TargetLoadKg     *float64 `json:"target_load_kg"`
+	AverageLoadKg    *float64 `json:"average_load_kg"`
+	LoadDeviationKg  *float64 `json:"load_deviation_kg"`
+	LoadDeviationPct *float64 `json:"load_deviation_pct"`
+	TargetRPE        *float64 `json:"target_rpe"`
+	AverageRPE       *float64 `json:"average_rpe"`
+	RPEDeviation     *float64 `json:"rpe_deviation"`
+}
+
+// SessionAdherence is whether and when a prescribed session was done
+type SessionAdherence struct {
+	SessionID     uuid.UUID           `json:"session_id"`
+	SessionName   *string             `json:"session_name"`
+	WeekNumber    int                 `json:"week_number"`
+	DayNumber     int                 `json:"day_number"`
+	ScheduledDate *time.Time          `json:"scheduled_date"`
+	CompletedAt   *time.Time          `json:"completed_at"`
+	Status        string              `json:"status"`
+	DaysLate      *int                `json:"days_late,omitempty"`
+	Exercises     []ExerciseAdherence `json:"exercises"`
+}
+
+type WeekAdherence struct {
+	WeekNumber int                `json:"week_number"`
+	Summary    AdherenceTotals    `json:"summary"`
+	Sessions   []SessionAdherence `json:"sessions"`
+}
+
+// AdherenceReport is a program's prescription against what the athlete did,
+// overall and week by week
+type AdherenceReport struct {
+	ProgramID   uuid.UUID       `json:"program_id"`
+	ProgramName string          `json:"program_name"`
+	GraceDays   int             `json:"grace_days"`
+	Summary     AdherenceTotals `json:"summary"`
+	Weeks       []WeekAdherence `json:"weeks"`
+}
+
+// AthleteCompliance is how closely an athlete has kept to their prescribed
+// sessions lately, for a coach triaging their roster. Compliance is the share
+// of sessions due in the window that were completed, late or not.
+type AthleteCompliance struct {
+	AthleteID           uuid.UUID  `json:"athlete_id"`
+	ActivePrograms      int        `json:"active_programs_count"`
+	LastActiveAt        *time.Time `json:"last_active_at"`
+	Compliance7DayPct   *float64   `json:"compliance_7d_pct"`
+	Compliance28DayPct  *float64   `json:"compliance_28d_pct"`
+	SessionsMissed7Day  int        `json:"sessions_missed_7d"`
+	SessionsMissed28Day int        `json:"sessions_missed_28d"`
+	// RPEDrift averages actual minus target RPE over the last 28 days;
+	// positive means sessions are feeling harder than prescribed
+	RPEDrift *float64 `json:"rpe_drift"`
+	// PersonalRecords28Day counts exercises whose best e1RM was set in the
+	// last 28 days
+	PersonalRecords28Day int `json:"personal_records_28d"`
+}
+
+// AssignProgramRequest copies a template, or an existing program, to several
+// athletes at once. Percentage targets become loads from each athlete's best
+// lifts.
+type AssignProgramRequest struct {
+	TemplateID      *uuid.UUID  `json:"template_id"`
+	SourceProgramID *uuid.UUID  `json:"source_program_id"`
+	AthleteIDs      []uuid.UUID `json:"athlete_ids" binding:"required,min=1,max=100"`
+	StartDate       time.Time   `json:"start_date" binding:"required"`
+	Name            *string     `json:"name"`
+}
+
+const (
+	AssignmentCreated = "created"
+	AssignmentSkipped = "skipped"
+	AssignmentFailed  = "failed"
+)
+
+// ProgramAssignment is the outcome of assigning a program to one athlete
+type ProgramAssignment struct {
+	AthleteID uuid.UUID  `json:"athlete_id"`
+	Status    string     `json:"status"`
+	ProgramID *uuid.UUID `json:"program_id,omitempty"`
+	Maxes     *BestLifts `json:"maxes,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 	"github.com/google/uuid"
 )
@@ -68,7 +69,53 @@ type TrainingSession struct {
 	DurationMins  *int       `json:"duration_minutes" db:"duration_minutes"`
 	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	// DeletedAt is set while the session is in the athlete's trash
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeletedReason *string    `json:"deleted_reason,omitempty" db:"deleted_reason"`
 	Exercises     []Exercise `json:"exercises,omitempty"`
+	Groups        []ExerciseGroup `json:"groups,omitempty"`
+}
+
+type ExerciseGroupType string
+
+const (
+	GroupTypeSuperset ExerciseGroupType = "superset"
+	GroupTypeCircuit  ExerciseGroupType = "circuit"
+	GroupTypeEMOM     ExerciseGroupType = "emom"
+	GroupTypeCluster  ExerciseGroupType = "cluster"
+)
+
+// ExerciseGroup ties exercises of a session together, e.g. the A1/A2 of a
+// superset. Each round the exercises are done in GroupPosition order; the
+// exercise's TargetSets are spread evenly over the rounds. RestSeconds is the
+// rest after a round; IntervalSeconds is the EMOM interval or the rest between
+// the mini-sets of a cluster.
+type ExerciseGroup struct {
+	ID              uuid.UUID         `json:"id" db:"id"`
+	SessionID       uuid.UUID         `json:"session_id" db:"session_id"`
+	Label           string            `json:"label" db:"label"`
+	GroupType       ExerciseGroupType `json:"group_type" db:"group_type"`
+	Rounds          int               `json:"rounds" db:"rounds"`
+	RestSeconds     *int              `json:"rest_seconds" db:"rest_seconds"`
+	IntervalSeconds *int              `json:"interval_seconds" db:"interval_seconds"`
+	Notes           *string           `json:"notes" db:"notes"`
+	CreatedAt       time.Time         `json:"created_at" db:"created_at"`
+}
+
+// SetsPerRound is how many of the exercise's sets are done each round
+func (g ExerciseGroup) SetsPerRound(exercise Exercise) int {
+	if g.Rounds < 1 || exercise.TargetSets < g.Rounds {
+		return 1
+	}
+	return exercise.TargetSets / g.Rounds
+}
+
+// RoundOfSet is the round the exercise's set with this number falls in
+func (g ExerciseGroup) RoundOfSet(exercise Exercise, setNumber int) int {
+	if setNumber < 1 {
+		return 1
+	}
+	return (setNumber-1)/g.SetsPerRound(exercise) + 1
 }
 
 type Exercise struct {
@@ -85,6 +132,11 @@ type Exercise struct {
 	RestSeconds       *int      `json:"rest_seconds" db:"rest_seconds"`
 	Notes             *string   `json:"notes" db:"notes"`
 	Tempo             *string   `json:"tempo" db:"tempo"`
+	GroupID           *uuid.UUID `json:"group_id" db:"group_id"`
+	GroupPosition     *int      `json:"group_position" db:"group_position"`
+	// VelocityLossCutoffPct ends a set once bar speed drops this far below the
+	// set's fastest rep
+	VelocityLossCutoffPct *float64 `json:"velocity_loss_cutoff_pct" db:"velocity_loss_cutoff_pct"`
 	CreatedAt         time.Time `json:"created_at" db:"created_at"`
 	CompletedSets     []CompletedSet `json:"completed_sets,omitempty"`
 }
@@ -104,27 +156,93 @@ const (
 	SetTypeCustom   SetType = "custom"
 )
 
+// Valid reports whether t is one of the set types the database accepts
+func (t SetType) Valid() bool {
+	switch t {
+	case SetTypeWarmUp, SetTypeWorking, SetTypeBackoff, SetTypeAMRAP, SetTypeFailure,
+		SetTypeDropSet, SetTypeCluster, SetTypePause, SetTypeTempo, SetTypeCustom:
+		return true
+	}
+	return false
+}
+
 type CompletedSet struct {
-	ID            uuid.UUID       `json:"id" db:"id"`
-	ExerciseID    uuid.UUID       `json:"exercise_id" db:"exercise_id"`
-	SetNumber     int             `json:"set_number" db:"set_number"`
-	RepsCompleted int             `json:"reps_completed" db:"reps_completed"`
-	WeightKg      float64         `json:"weight_kg" db:"weight_kg"`
-	RPEActual     *float64        `json:"rpe_actual" db:"rpe_actual"`
-	VideoID       *uuid.UUID      `json:"video_id" db:"video_id"`
-	Notes         *string         `json:"notes" db:"notes"`
-	SetType       SetType         `json:"set_type" db:"set_type"`
-	MediaURLs     []string        `json:"media_urls" db:"media_urls"`
-	ExerciseNotes *string         `json:"exercise_notes" db:"exercise_notes"`
-	CompletedAt   time.Time       `json:"completed_at" db:"completed_at"`
+	ID            uuid.UUID  `json:"id" db:"id"`
+	ExerciseID    uuid.UUID  `json:"exercise_id" db:"exercise_id"`
+	SetNumber     int        `json:"set_number" db:"set_number"`
+	RepsCompleted int        `json:"reps_completed" db:"reps_completed"`
+	WeightKg      float64    `json:"weight_kg" db:"weight_kg"`
+	RPEActual     *float64   `json:"rpe_actual" db:"rpe_actual"`
+	VideoID       *uuid.UUID `json:"video_id" db:"video_id"`
+	Notes         *string    `json:"notes" db:"notes"`
+	SetType       SetType    `json:"set_type" db:"set_type"`
+	MediaURLs     []string   `json:"media_urls" db:"media_urls"`
+	ExerciseNotes *string    `json:"exercise_notes" db:"exercise_notes"`
+	RoundNumber   *int       `json:"round_number,omitempty" db:"round_number"`
+	// Bar speed from a velocity tracker, in m/s. MeanVelocityMps is the set's
+	// average rep and VelocityLossPct the drop from its fastest rep to its last.
+	MeanVelocityMps *float64      `json:"mean_velocity_mps,omitempty" db:"mean_velocity_mps"`
+	PeakVelocityMps *float64      `json:"peak_velocity_mps,omitempty" db:"peak_velocity_mps"`
+	VelocityLossPct *float64      `json:"velocity_loss_pct,omitempty" db:"velocity_loss_pct"`
+	RepVelocities   []RepVelocity `json:"rep_velocities,omitempty" db:"rep_velocities"`
+	CompletedAt     time.Time     `json:"completed_at" db:"completed_at"`
+}
+
+// RepVelocity is one rep's concentric bar speed
+type RepVelocity struct {
+	Rep          int      `json:"rep" binding:"min=1"`
+	MeanVelocity float64  `json:"mean_velocity_mps" binding:"min=0"`
+	PeakVelocity *float64 `json:"peak_velocity_mps" binding:"omitempty,min=0"`
+}
+
+// FillVelocity derives the set's velocity summary from its per-rep readings,
+// keeping any value that was given explicitly
+func (s *CompletedSet) FillVelocity() {
+	if len(s.RepVelocities) == 0 {
+		return
+	}
+
+	var total, fastest float64
+	var peak *float64
+	for _, rep := range s.RepVelocities {
+		total += rep.MeanVelocity
+		if rep.MeanVelocity > fastest {
+			fastest = rep.MeanVelocity
+		}
+		if rep.PeakVelocity != nil && (peak == nil || *rep.PeakVelocity > *peak) {
+			value := *rep.PeakVelocity
+			peak = &value
+		}
+	}
+
+	if s.MeanVelocityMps == nil {
+		mean := math.Round(total/float64(len(s.RepVelocities))*1000) / 1000
+		s.MeanVelocityMps = &mean
+	}
+	if s.PeakVelocityMps == nil {
+		s.PeakVelocityMps = peak
+	}
+	if s.VelocityLossPct == nil && fastest > 0 {
+		last := s.RepVelocities[len(s.RepVelocities)-1].MeanVelocity
+		loss := math.Round((fastest-last)/fastest*10000) / 100
+		s.VelocityLossPct = &loss
+	}
 }
 
+// DefaultConversationTitle marks a conversation that hasn't been named yet
+const DefaultConversationTitle = "New conversation"
+
 type AIConversation struct {
 	ID                      uuid.UUID              `json:"id" db:"id"`
 	AthleteID               uuid.UUID              `json:"athlete_id" db:"athlete_id"`
 	ProgramID               *uuid.UUID             `json:"program_id" db:"program_id"`
 	ConversationType        string                 `json:"conversation_type" db:"conversation_type"`
-	Messages                []Message              `json:"messages" db:"messages"`
+	Title                   string                 `json:"title" db:"title"`
+	ArchivedAt              *time.Time             `json:"archived_at" db:"archived_at"`
+	LastMessageAt           *time.Time             `json:"last_message_at" db:"last_message_at"`
+	// Messages are stored in ai_messages; list endpoints leave them empty
+	Messages                []Message              `json:"messages,omitempty"`
+	MessageCount            int                    `json:"message_count"`
 	CoachContextEnabled     bool                   `json:"coach_context_enabled" db:"coach_context_enabled"`
 	CoachFeedbackIncorp     []string               `json:"coach_feedback_incorporated" db:"coach_feedback_incorporated"`
 	CreatedAt               time.Time              `json:"created_at" db:"created_at"`
@@ -136,6 +254,12 @@ type Message struct {
 	Role      string    `json:"role"` // "user", "assistant", "system"
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
+	// PromptTemplates records which template versions produced an assistant message
+	PromptTemplates []string `json:"prompt_templates,omitempty"`
+	// CoachFeedbackIncluded lists the coach feedback IDs in the prompt for an
+	// assistant message; CoachFeedbackApplied the ones the response cited
+	CoachFeedbackIncluded []string `json:"coach_feedback_included,omitempty"`
+	CoachFeedbackApplied  []string `json:"coach_feedback_applied,omitempty"`
 }
 
 type ProgramTemplate struct {
@@ -187,6 +311,34 @@ type ChatRequest struct {
 	Message            string     `json:"message" binding:"required"`
 	ProgramID          *uuid.UUID `json:"program_id"`
 	CoachContextEnable bool       `json:"coach_context_enable"`
+	// ConversationID continues a specific thread instead of the latest one
+	ConversationID *uuid.UUID `json:"conversation_id"`
+}
+
+type CreateConversationRequest struct {
+	Title              *string    `json:"title"`
+	ProgramID          *uuid.UUID `json:"program_id"`
+	CoachContextEnable bool       `json:"coach_context_enable"`
+}
+
+type UpdateConversationRequest struct {
+	Title    *string `json:"title"`
+	Archived *bool   `json:"archived"`
+}
+
+type AttachConversationProgramRequest struct {
+	ProgramID uuid.UUID `json:"program_id" binding:"required"`
+}
+
+// ConversationSearchResult is a message matching a full-text search
+type ConversationSearchResult struct {
+	ConversationID    uuid.UUID `json:"conversation_id"`
+	ConversationTitle string    `json:"conversation_title"`
+	MessageID         uuid.UUID `json:"message_id"`
+	Role              string    `json:"role"`
+	Snippet           string    `json:"snippet"`
+	Rank              float64   `json:"rank"`
+	CreatedAt         time.Time `json:"created_at"`
 }
 
 type LogWorkoutRequest struct {
@@ -204,15 +356,98 @@ type LoggedExercise struct {
 }
 
 type LoggedSet struct {
-	SetNumber     int        `json:"set_number" binding:"required"`
-	RepsCompleted int        `json:"reps_completed" binding:"required"`
-	WeightKg      float64    `json:"weight_kg" binding:"required"`
-	RPEActual     *float64   `json:"rpe_actual"`
-	VideoID       *uuid.UUID `json:"video_id"`
-	Notes         *string    `json:"notes"`
-	SetType       SetType    `json:"set_type"`
-	MediaURLs     []string   `json:"media_urls"`
-	ExerciseNotes *string    `json:"exercise_notes"`
+	SetNumber       int           `json:"set_number" binding:"required"`
+	RepsCompleted   int           `json:"reps_completed" binding:"required"`
+	WeightKg        float64       `json:"weight_kg" binding:"required"`
+	RPEActual       *float64      `json:"rpe_actual"`
+	VideoID         *uuid.UUID    `json:"video_id"`
+	Notes           *string       `json:"notes"`
+	SetType         SetType       `json:"set_type"`
+	MediaURLs       []string      `json:"media_urls"`
+	ExerciseNotes   *string       `json:"exercise_notes"`
+	RoundNumber     *int          `json:"round_number" binding:"omitempty,min=1"`
+	MeanVelocityMps *float64      `json:"mean_velocity_mps" binding:"omitempty,min=0"`
+	PeakVelocityMps *float64      `json:"peak_velocity_mps" binding:"omitempty,min=0"`
+	VelocityLossPct *float64      `json:"velocity_loss_pct" binding:"omitempty,min=0,max=100"`
+	RepVelocities   []RepVelocity `json:"rep_velocities" binding:"omitempty,dive"`
+}
+
+// Offline sync mutation types
+const (
+	SyncSetUpsert       = "set.upsert"
+	SyncSetDelete       = "set.delete"
+	SyncSessionComplete = "session.complete"
+)
+
+// Sync mutation outcomes
+const (
+	SyncStatusApplied  = "applied"
+	SyncStatusConflict = "conflict"
+	SyncStatusRejected = "rejected"
+)
+
+// MaxSyncMutations bounds one sync batch; clients with a longer queue send it in chunks
+const MaxSyncMutations = 500
+
+type SyncRequest struct {
+	Mutations []SyncMutation `json:"mutations" binding:"required,dive"`
+}
+
+// SyncMutation is one change queued on the client while offline. ID is generated
+// by the client and makes a replayed batch safe; ClientTimestamp is when the
+// change was made on the device.
+type SyncMutation struct {
+	ID              uuid.UUID           `json:"id" binding:"required"`
+	Type            string              `json:"type" binding:"required,oneof=set.upsert set.delete session.complete"`
+	ClientTimestamp time.Time           `json:"client_timestamp" binding:"required"`
+	Set             *SyncSetPayload     `json:"set"`
+	Session         *SyncSessionPayload `json:"session"`
+}
+
+// SyncSetPayload carries a completed set. ID is client-generated so a set logged
+// offline can be edited or deleted before it ever reaches the server.
+type SyncSetPayload struct {
+	ID              uuid.UUID     `json:"id" binding:"required"`
+	ExerciseID      uuid.UUID     `json:"exercise_id"`
+	SetNumber       int           `json:"set_number"`
+	RepsCompleted   int           `json:"reps_completed"`
+	WeightKg        float64       `json:"weight_kg"`
+	RPEActual       *float64      `json:"rpe_actual"`
+	VideoID         *uuid.UUID    `json:"video_id"`
+	Notes           *string       `json:"notes"`
+	SetType         SetType       `json:"set_type"`
+	MediaURLs       []string      `json:"media_urls"`
+	ExerciseNotes   *string       `json:"exercise_notes"`
+	MeanVelocityMps *float64      `json:"mean_velocity_mps" binding:"omitempty,min=0"`
+	PeakVelocityMps *float64      `json:"peak_velocity_mps" binding:"omitempty,min=0"`
+	VelocityLossPct *float64      `json:"velocity_loss_pct" binding:"omitempty,min=0,max=100"`
+	RepVelocities   []RepVelocity `json:"rep_velocities" binding:"omitempty,dive"`
+}
+
+type SyncSessionPayload struct {
+	ID           uuid.UUID  `json:"id" binding:"required"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	Notes        *string    `json:"notes"`
+	RPERating    *float64   `json:"rpe_rating"`
+	DurationMins *int       `json:"duration_minutes"`
+}
+
+type SyncMutationResult struct {
+	MutationID uuid.UUID  `json:"mutation_id"`
+	Status     string     `json:"status"`
+	Reason     string     `json:"reason,omitempty"`
+	SessionID  *uuid.UUID `json:"session_id,omitempty"`
+	// Duplicate is set when the mutation was already processed by an earlier
+	// sync; Status is the original outcome
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// SyncResponse returns the server's copy of every session the batch touched,
+// which the client should replace its local state with
+type SyncResponse struct {
+	Results    []SyncMutationResult `json:"results"`
+	Sessions   []TrainingSession    `json:"sessions"`
+	ServerTime time.Time            `json:"server_time"`
 }
 
 type ProgramResponse struct {
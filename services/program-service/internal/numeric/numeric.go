@@ -0,0 +1,15 @@
+// Package numeric holds the rounding helpers shared by the analytics packages.
+package numeric
+
+import "math"
+
+// Round rounds value to the given number of decimal places
+func Round(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
+
+// Ptr returns a pointer to value, for the optional fields of response models
+func Ptr(value float64) *float64 {
+	return &value
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// RecordAIUsage stores the token usage of a single model call
+func (r *ProgramRepository) RecordAIUsage(usage *models.AIUsage) error {
+	query := `
+		INSERT INTO ai_usage (id, user_id, feature, model, prompt_tokens, completion_tokens,
+		                      total_tokens, estimated_cost_usd, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := r.db.Exec(query,
+		usage.ID, usage.UserID, usage.Feature, usage.Model, usage.PromptTokens,
+		usage.CompletionTokens, usage.TotalTokens, usage.EstimatedCostUSD, usage.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record AI usage: %w", err)
+	}
+
+	return nil
+}
+
+// GetAITokensUsedSince returns the total tokens a user has spent since a point in time
+func (r *ProgramRepository) GetAITokensUsedSince(userID uuid.UUID, since time.Time) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(total_tokens), 0)
+		FROM ai_usage
+		WHERE user_id = $1 AND created_at >= $2`
+
+	var total int
+	if err := r.db.QueryRow(query, userID, since).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to get AI token usage: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetAIUsageSummary aggregates usage by feature and model between from and to.
+// A nil userID aggregates across every user.
+func (r *ProgramRepository) GetAIUsageSummary(userID *uuid.UUID, from, to time.Time) ([]models.AIUsageSummary, error) {
+	query := `
+		SELECT feature, model, COUNT(*), SUM(prompt_tokens), SUM(completion_tokens),
+		       SUM(total_tokens), SUM(estimated_cost_usd)
+		FROM ai_usage
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+		  AND created_at >= $2 AND created_at < $3
+		GROUP BY feature, model
+		ORDER BY SUM(total_tokens) DESC`
+
+	rows, err := r.db.Query(query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI usage summary: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []models.AIUsageSummary
+	for rows.Next() {
+		var s models.AIUsageSummary
+		err := rows.Scan(
+			&s.Feature, &s.Model, &s.Calls, &s.PromptTokens,
+			&s.CompletionTokens, &s.TotalTokens, &s.EstimatedCostUSD,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan AI usage summary: %w", err)
+		}
+		summaries = append(summaries, s)
+	}
+
+	return summaries, nil
+}
+
+// GetAIUsageByUser returns per-user totals between from and to, heaviest users first
+func (r *ProgramRepository) GetAIUsageByUser(from, to time.Time, limit int) ([]models.AIUsageSummary, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT user_id, COUNT(*), SUM(prompt_tokens), SUM(completion_tokens),
+		       SUM(total_tokens), SUM(estimated_cost_usd)
+		FROM ai_usage
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY user_id
+		ORDER BY SUM(total_tokens) DESC
+		LIMIT $3`
+
+	rows, err := r.db.Query(query, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI usage by user: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []models.AIUsageSummary
+	for rows.Next() {
+		var s models.AIUsageSummary
+		var userID uuid.UUID
+		err := rows.Scan(
+			&userID, &s.Calls, &s.PromptTokens,
+			&s.CompletionTokens, &s.TotalTokens, &s.EstimatedCostUSD,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan AI usage by user: %w", err)
+		}
+		s.UserID = &userID
+		summaries = append(summaries, s)
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,389 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// conversationColumns are selected by every conversation query, in scan order
+const conversationColumns = `
+		c.id, c.athlete_id, c.program_id, c.conversation_type, c.title,
+		c.archived_at, c.last_message_at, c.coach_context_enabled,
+		c.coach_feedback_incorporated, c.created_at, c.updated_at,
+		(SELECT COUNT(*) FROM ai_messages m WHERE m.conversation_id = c.id)`
+
+func scanConversation(scanner interface{ Scan(...interface{}) error }) (*models.AIConversation, error) {
+	var conversation models.AIConversation
+	var feedbackJSON []byte
+
+	err := scanner.Scan(
+		&conversation.ID, &conversation.AthleteID, &conversation.ProgramID,
+		&conversation.ConversationType, &conversation.Title,
+		&conversation.ArchivedAt, &conversation.LastMessageAt, &conversation.CoachContextEnabled,
+		&feedbackJSON, &conversation.CreatedAt, &conversation.UpdatedAt,
+		&conversation.MessageCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(feedbackJSON) > 0 {
+		json.Unmarshal(feedbackJSON, &conversation.CoachFeedbackIncorp)
+	}
+
+	return &conversation, nil
+}
+
+// CreateAIConversation creates the conversation together with any initial messages
+func (r *ProgramRepository) CreateAIConversation(conversation *models.AIConversation) error {
+	feedbackJSON, _ := json.Marshal(conversation.CoachFeedbackIncorp)
+	if conversation.Title == "" {
+		conversation.Title = models.DefaultConversationTitle
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO ai_conversations (athlete_id, program_id, conversation_type, title,
+		                             coach_context_enabled, coach_feedback_incorporated)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(query,
+		conversation.AthleteID, conversation.ProgramID, conversation.ConversationType,
+		conversation.Title, conversation.CoachContextEnabled, feedbackJSON,
+	).Scan(&conversation.ID, &conversation.CreatedAt, &conversation.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create AI conversation: %w", err)
+	}
+
+	if err := insertAIMessages(tx, conversation.ID, conversation.Messages); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	conversation.MessageCount = len(conversation.Messages)
+	if n := len(conversation.Messages); n > 0 {
+		conversation.LastMessageAt = &conversation.Messages[n-1].Timestamp
+	}
+
+	return nil
+}
+
+// AppendAIMessages adds messages to the end of a conversation without rewriting
+// the earlier ones
+func (r *ProgramRepository) AppendAIMessages(conversationID uuid.UUID, messages []models.Message) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertAIMessages(tx, conversationID, messages); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func insertAIMessages(tx *sql.Tx, conversationID uuid.UUID, messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO ai_messages (id, conversation_id, role, content, prompt_templates,
+		                         coach_feedback_included, coach_feedback_applied, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	last := messages[0].Timestamp
+	for _, msg := range messages {
+		id, err := uuid.Parse(msg.ID)
+		if err != nil {
+			id = uuid.New()
+		}
+		templatesJSON := jsonStringArray(msg.PromptTemplates)
+		includedJSON := jsonStringArray(msg.CoachFeedbackIncluded)
+		appliedJSON := jsonStringArray(msg.CoachFeedbackApplied)
+
+		if _, err := tx.Exec(query, id, conversationID, msg.Role, msg.Content, templatesJSON,
+			includedJSON, appliedJSON, msg.Timestamp); err != nil {
+			return fmt.Errorf("failed to insert AI message: %w", err)
+		}
+		if msg.Timestamp.After(last) {
+			last = msg.Timestamp
+		}
+	}
+
+	_, err := tx.Exec(`UPDATE ai_conversations SET last_message_at = $2 WHERE id = $1`, conversationID, last)
+	if err != nil {
+		return fmt.Errorf("failed to update AI conversation: %w", err)
+	}
+
+	return nil
+}
+
+// jsonStringArray encodes a nil slice as [] rather than null
+func jsonStringArray(values []string) []byte {
+	if values == nil {
+		return []byte("[]")
+	}
+	data, _ := json.Marshal(values)
+	return data
+}
+
+// AddAIConversationFeedback adds coach feedback IDs to the ones the conversation
+// has incorporated, keeping each ID once
+func (r *ProgramRepository) AddAIConversationFeedback(conversationID uuid.UUID, feedbackIDs []string) error {
+	query := `
+		UPDATE ai_conversations
+		SET coach_feedback_incorporated = (
+			SELECT COALESCE(jsonb_agg(DISTINCT id), '[]'::jsonb)
+			FROM jsonb_array_elements_text(COALESCE(coach_feedback_incorporated, '[]'::jsonb) || $2::jsonb) AS id
+		)
+		WHERE id = $1`
+
+	if _, err := r.db.Exec(query, conversationID, jsonStringArray(feedbackIDs)); err != nil {
+		return fmt.Errorf("failed to update AI conversation feedback: %w", err)
+	}
+
+	return nil
+}
+
+// GetAIConversationsByAthleteID returns the most recently active conversations
+// with up to historyLimit of their latest messages each
+func (r *ProgramRepository) GetAIConversationsByAthleteID(athleteID uuid.UUID, limit int, historyLimit int) ([]models.AIConversation, error) {
+	query := `
+		SELECT` + conversationColumns + `
+		FROM ai_conversations c
+		WHERE c.athlete_id = $1 AND c.archived_at IS NULL
+		ORDER BY c.updated_at DESC
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, athleteID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var conversations []models.AIConversation
+	for rows.Next() {
+		conversation, err := scanConversation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan AI conversation: %w", err)
+		}
+		conversations = append(conversations, *conversation)
+	}
+	rows.Close()
+
+	for i := range conversations {
+		conversations[i].Messages, err = r.GetAIMessages(conversations[i].ID, historyLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return conversations, nil
+}
+
+// GetAIConversationByID returns a conversation with up to historyLimit of its
+// latest messages (0 for all), or nil if it does not exist
+func (r *ProgramRepository) GetAIConversationByID(conversationID uuid.UUID, historyLimit int) (*models.AIConversation, error) {
+	query := `
+		SELECT` + conversationColumns + `
+		FROM ai_conversations c
+		WHERE c.id = $1`
+
+	conversation, err := scanConversation(r.db.QueryRow(query, conversationID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI conversation: %w", err)
+	}
+
+	conversation.Messages, err = r.GetAIMessages(conversationID, historyLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return conversation, nil
+}
+
+// ListAIConversations lists an athlete's conversations without their messages
+func (r *ProgramRepository) ListAIConversations(athleteID uuid.UUID, includeArchived bool, programID *uuid.UUID, limit, offset int) ([]models.AIConversation, error) {
+	query := `
+		SELECT` + conversationColumns + `
+		FROM ai_conversations c
+		WHERE c.athlete_id = $1
+		  AND ($2 OR c.archived_at IS NULL)
+		  AND ($3::uuid IS NULL OR c.program_id = $3)
+		ORDER BY COALESCE(c.last_message_at, c.created_at) DESC
+		LIMIT $4 OFFSET $5`
+
+	rows, err := r.db.Query(query, athleteID, includeArchived, programID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AI conversations: %w", err)
+	}
+	defer rows.Close()
+
+	conversations := []models.AIConversation{}
+	for rows.Next() {
+		conversation, err := scanConversation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan AI conversation: %w", err)
+		}
+		conversations = append(conversations, *conversation)
+	}
+
+	return conversations, nil
+}
+
+// GetAIMessages returns the latest limit messages of a conversation in order, or
+// all of them when limit is 0
+func (r *ProgramRepository) GetAIMessages(conversationID uuid.UUID, limit int) ([]models.Message, error) {
+	query := `
+		SELECT id, role, content, prompt_templates, coach_feedback_included, coach_feedback_applied, created_at
+		FROM (
+			SELECT id, seq, role, content, prompt_templates, coach_feedback_included, coach_feedback_applied, created_at
+			FROM ai_messages
+			WHERE conversation_id = $1
+			ORDER BY seq DESC
+			LIMIT NULLIF($2, 0)
+		) latest
+		ORDER BY seq`
+
+	rows, err := r.db.Query(query, conversationID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []models.Message{}
+	for rows.Next() {
+		var msg models.Message
+		var id uuid.UUID
+		var templatesJSON, includedJSON, appliedJSON []byte
+
+		if err := rows.Scan(&id, &msg.Role, &msg.Content, &templatesJSON, &includedJSON, &appliedJSON, &msg.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan AI message: %w", err)
+		}
+		msg.ID = id.String()
+		if len(templatesJSON) > 0 {
+			json.Unmarshal(templatesJSON, &msg.PromptTemplates)
+		}
+		if len(includedJSON) > 0 {
+			json.Unmarshal(includedJSON, &msg.CoachFeedbackIncluded)
+		}
+		if len(appliedJSON) > 0 {
+			json.Unmarshal(appliedJSON, &msg.CoachFeedbackApplied)
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+func (r *ProgramRepository) RenameAIConversation(conversationID uuid.UUID, title string) error {
+	_, err := r.db.Exec(`UPDATE ai_conversations SET title = $2 WHERE id = $1`, conversationID, title)
+	if err != nil {
+		return fmt.Errorf("failed to rename AI conversation: %w", err)
+	}
+	return nil
+}
+
+// SetAutoAIConversationTitle replaces a generated placeholder title, leaving the
+// conversation alone if the athlete renamed it in the meantime
+func (r *ProgramRepository) SetAutoAIConversationTitle(conversationID uuid.UUID, placeholder, title string) error {
+	_, err := r.db.Exec(`UPDATE ai_conversations SET title = $3 WHERE id = $1 AND title = $2`, conversationID, placeholder, title)
+	if err != nil {
+		return fmt.Errorf("failed to set AI conversation title: %w", err)
+	}
+	return nil
+}
+
+func (r *ProgramRepository) SetAIConversationArchived(conversationID uuid.UUID, archived bool) error {
+	var archivedAt *time.Time
+	if archived {
+		now := time.Now()
+		archivedAt = &now
+	}
+
+	_, err := r.db.Exec(`UPDATE ai_conversations SET archived_at = $2 WHERE id = $1`, conversationID, archivedAt)
+	if err != nil {
+		return fmt.Errorf("failed to archive AI conversation: %w", err)
+	}
+	return nil
+}
+
+// SetAIConversationProgram attaches a conversation to a program, or detaches it
+// when programID is nil
+func (r *ProgramRepository) SetAIConversationProgram(conversationID uuid.UUID, programID *uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE ai_conversations SET program_id = $2 WHERE id = $1`, conversationID, programID)
+	if err != nil {
+		return fmt.Errorf("failed to set AI conversation program: %w", err)
+	}
+	return nil
+}
+
+func (r *ProgramRepository) DeleteAIConversation(conversationID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM ai_conversations WHERE id = $1`, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to delete AI conversation: %w", err)
+	}
+	return nil
+}
+
+// SearchAIMessages runs a full-text search over an athlete's messages, best
+// matches first
+func (r *ProgramRepository) SearchAIMessages(athleteID uuid.UUID, search string, limit int) ([]models.ConversationSearchResult, error) {
+	query := `
+		SELECT c.id, c.title, m.id, m.role,
+		       ts_headline('english', m.content, q, 'MaxFragments=2, MaxWords=20, MinWords=5'),
+		       ts_rank(m.search_vector, q) AS rank,
+		       m.created_at
+		FROM ai_messages m
+		JOIN ai_conversations c ON c.id = m.conversation_id,
+		     websearch_to_tsquery('english', $2) q
+		WHERE c.athlete_id = $1 AND m.search_vector @@ q
+		ORDER BY rank DESC, m.created_at DESC
+		LIMIT $3`
+
+	rows, err := r.db.Query(query, athleteID, search, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search AI messages: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.ConversationSearchResult{}
+	for rows.Next() {
+		var res models.ConversationSearchResult
+		err := rows.Scan(
+			&res.ConversationID, &res.ConversationTitle, &res.MessageID, &res.Role,
+			&res.Snippet, &res.Rank, &res.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan AI message search result: %w", err)
+		}
+		results = append(results, res)
+	}
+
+	return results, nil
+}
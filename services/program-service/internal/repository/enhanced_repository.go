@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -345,6 +346,45 @@ func (r *ProgramRepository) GetE1RMData(athleteID uuid.UUID, startDate, endDate
 	return e1rmData, nil
 }
 
+// GetWeeklyBestE1RM returns the best estimated 1RM of each week since startDate
+// for the squat, bench and deadlift, oldest first, using the same sets and
+// formula as GetE1RMData
+func (r *ProgramRepository) GetWeeklyBestE1RM(athleteID uuid.UUID, startDate time.Time) ([]models.WeeklyE1RM, error) {
+	query := `
+		SELECT
+			DATE_TRUNC('week', ts.completed_at) as week_start,
+			e.lift_type,
+			MAX(cs.weight_kg * (1 + cs.reps_completed::float / 30.0)) as estimated_1rm
+		FROM completed_sets cs
+		JOIN exercises e ON cs.exercise_id = e.id
+		JOIN training_sessions ts ON e.session_id = ts.id
+		WHERE ts.athlete_id = $1
+		  AND ts.completed_at >= $2
+		  AND ts.deleted_at IS NULL
+		  AND cs.reps_completed BETWEEN 1 AND 10
+		  AND cs.set_type IN ('working', 'amrap')
+		  AND e.lift_type IN ('squat', 'bench', 'deadlift')
+		GROUP BY week_start, e.lift_type
+		ORDER BY week_start, e.lift_type`
+
+	rows, err := r.db.Query(query, athleteID, startDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weekly e1RM: %w", err)
+	}
+	defer rows.Close()
+
+	weeks := []models.WeeklyE1RM{}
+	for rows.Next() {
+		var week models.WeeklyE1RM
+		if err := rows.Scan(&week.WeekStart, &week.LiftType, &week.Estimated1RMKg); err != nil {
+			return nil, fmt.Errorf("failed to scan weekly e1RM: %w", err)
+		}
+		weeks = append(weeks, week)
+	}
+
+	return weeks, nil
+}
+
 // Program Change Management (Git-like)
 
 func (r *ProgramRepository) ProposeChange(change *models.ProgramChange) error {
@@ -475,16 +515,231 @@ func (r *ProgramRepository) GetSessionHistory(athleteID uuid.UUID, startDate, en
 	return sessions, nil
 }
 
+// SoftDeleteSession moves a session to the trash. Bumping modified_at makes
+// offline edits queued before the delete lose to it when they sync.
 func (r *ProgramRepository) SoftDeleteSession(sessionID uuid.UUID, reason string) error {
 	query := `
 		UPDATE training_sessions
-		SET deleted_at = NOW(), deleted_reason = $1
-		WHERE id = $2`
+		SET deleted_at = NOW(), deleted_reason = $1, modified_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL`
 
-	_, err := r.db.Exec(query, reason, sessionID)
+	result, err := r.db.Exec(query, nullString(reason), sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to soft delete session: %w", err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
+// GetBestLiftsAsOf returns the heaviest working set completed for each competition
+// lift up to and including asOf
+func (r *ProgramRepository) GetBestLiftsAsOf(athleteID uuid.UUID, asOf time.Time) (*models.BestLifts, error) {
+	query := `
+		SELECT
+			COALESCE(MAX(cs.weight_kg) FILTER (WHERE e.lift_type = 'squat'), 0),
+			COALESCE(MAX(cs.weight_kg) FILTER (WHERE e.lift_type = 'bench'), 0),
+			COALESCE(MAX(cs.weight_kg) FILTER (WHERE e.lift_type = 'deadlift'), 0)
+		FROM completed_sets cs
+		JOIN exercises e ON cs.exercise_id = e.id
+		JOIN training_sessions ts ON e.session_id = ts.id
+		WHERE ts.athlete_id = $1
+		  AND ts.completed_at <= $2
+		  AND ts.deleted_at IS NULL
+		  AND cs.reps_completed >= 1
+		  AND (cs.set_type IS NULL OR cs.set_type != 'warm_up')`
+
+	best := &models.BestLifts{AsOf: asOf}
+	err := r.db.QueryRow(query, athleteID, asOf).Scan(&best.SquatKg, &best.BenchKg, &best.DeadliftKg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get best lifts: %w", err)
+	}
+
+	return best, nil
+}
+
+// GetUpcomingSessions returns scheduled, not yet completed sessions from today on
+func (r *ProgramRepository) GetUpcomingSessions(athleteID uuid.UUID, limit int) ([]models.TrainingSession, error) {
+	if limit == 0 {
+		limit = 7
+	}
+
+	query := `
+		SELECT id, program_id, athlete_id, week_number, day_number, session_name,
+		       scheduled_date, completed_at, notes, rpe_rating, duration_minutes,
+		       created_at, updated_at
+		FROM training_sessions
+		WHERE athlete_id = $1
+		  AND completed_at IS NULL
+		  AND deleted_at IS NULL
+		  AND scheduled_date >= CURRENT_DATE
+		ORDER BY scheduled_date ASC, day_number ASC
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, athleteID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upcoming sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.TrainingSession
+	for rows.Next() {
+		var session models.TrainingSession
+		err := rows.Scan(
+			&session.ID, &session.ProgramID, &session.AthleteID,
+			&session.WeekNumber, &session.DayNumber, &session.SessionName,
+			&session.ScheduledDate, &session.CompletedAt, &session.Notes,
+			&session.RPERating, &session.DurationMins,
+			&session.CreatedAt, &session.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// GetScheduledSessions returns an athlete's sessions scheduled between two
+// dates, from any program, with their exercises and logged sets
+func (r *ProgramRepository) GetScheduledSessions(athleteID uuid.UUID, startDate, endDate time.Time) ([]models.TrainingSession, error) {
+	query := `
+		SELECT id, program_id, athlete_id, week_number, day_number, session_name,
+		       scheduled_date, completed_at, notes, rpe_rating, duration_minutes,
+		       created_at, updated_at
+		FROM training_sessions
+		WHERE athlete_id = $1
+		  AND deleted_at IS NULL
+		  AND scheduled_date BETWEEN $2 AND $3
+		ORDER BY scheduled_date ASC, day_number ASC`
+
+	rows, err := r.db.Query(query, athleteID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.TrainingSession
+	for rows.Next() {
+		var session models.TrainingSession
+		err := rows.Scan(
+			&session.ID, &session.ProgramID, &session.AthleteID,
+			&session.WeekNumber, &session.DayNumber, &session.SessionName,
+			&session.ScheduledDate, &session.CompletedAt, &session.Notes,
+			&session.RPERating, &session.DurationMins,
+			&session.CreatedAt, &session.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	for i := range sessions {
+		exercises, err := r.GetExercisesBySessionID(sessions[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get exercises for session %s: %w", sessions[i].ID, err)
+		}
+		sessions[i].Exercises = exercises
+	}
+
+	return sessions, nil
+}
+
+// GetLastCompletedAt returns when the athlete last completed a session, or nil
+// if they never have
+func (r *ProgramRepository) GetLastCompletedAt(athleteID uuid.UUID) (*time.Time, error) {
+	query := `
+		SELECT MAX(completed_at)
+		FROM training_sessions
+		WHERE athlete_id = $1 AND deleted_at IS NULL`
+
+	var last sql.NullTime
+	if err := r.db.QueryRow(query, athleteID).Scan(&last); err != nil {
+		return nil, fmt.Errorf("failed to get last completed session: %w", err)
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+
+	return &last.Time, nil
+}
+
+// GetPersonalRecords returns the best estimated 1RM set per exercise, optionally
+// limited to one lift type
+func (r *ProgramRepository) GetPersonalRecords(athleteID uuid.UUID, liftType *models.LiftType) ([]models.PersonalRecord, error) {
+	query := `
+		SELECT DISTINCT ON (e.exercise_name)
+			e.exercise_name,
+			e.lift_type,
+			cs.weight_kg,
+			cs.reps_completed,
+			cs.weight_kg * (1 + cs.reps_completed::float / 30.0) as estimated_1rm,
+			ts.completed_at
+		FROM completed_sets cs
+		JOIN exercises e ON cs.exercise_id = e.id
+		JOIN training_sessions ts ON e.session_id = ts.id
+		WHERE ts.athlete_id = $1
+		  AND ts.completed_at IS NOT NULL
+		  AND ts.deleted_at IS NULL
+		  AND cs.reps_completed BETWEEN 1 AND 10
+		  AND (cs.set_type IS NULL OR cs.set_type != 'warm_up')
+		  AND ($2::lift_type IS NULL OR e.lift_type = $2)
+		ORDER BY e.exercise_name, estimated_1rm DESC, ts.completed_at ASC`
+
+	rows, err := r.db.Query(query, athleteID, liftType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get personal records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []models.PersonalRecord
+	for rows.Next() {
+		var pr models.PersonalRecord
+		err := rows.Scan(
+			&pr.ExerciseName, &pr.LiftType, &pr.WeightKg,
+			&pr.Reps, &pr.Estimated1RM, &pr.AchievedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal record: %w", err)
+		}
+		records = append(records, pr)
+	}
+
+	return records, nil
+}
+
+// RecordGenerationAttempts stores the attempts from one program generation
+func (r *ProgramRepository) RecordGenerationAttempts(attempts []models.ProgramGenerationAttempt) error {
+	query := `
+		INSERT INTO program_generation_attempts (athlete_id, program_id, source, attempt_number, model,
+		                                         raw_response, validation_errors, error, succeeded,
+		                                         duration_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	for _, a := range attempts {
+		errorsJSON, _ := json.Marshal(a.ValidationErrors)
+
+		_, err := r.db.Exec(query,
+			a.AthleteID, a.ProgramID, a.Source, a.AttemptNumber, a.Model,
+			a.RawResponse, errorsJSON, a.Error, a.Succeeded,
+			a.DurationMs, a.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record generation attempt: %w", err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+func (r *ProgramRepository) CreateExerciseGroup(group *models.ExerciseGroup) error {
+	query := `
+		INSERT INTO exercise_groups (session_id, label, group_type, rounds, rest_seconds,
+		                             interval_seconds, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(query,
+		group.SessionID, group.Label, group.GroupType, group.Rounds, group.RestSeconds,
+		group.IntervalSeconds, group.Notes,
+	).Scan(&group.ID, &group.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create exercise group: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ProgramRepository) GetExerciseGroupsBySessionID(sessionID uuid.UUID) ([]models.ExerciseGroup, error) {
+	query := `
+		SELECT id, session_id, label, group_type, rounds, rest_seconds, interval_seconds,
+		       notes, created_at
+		FROM exercise_groups
+		WHERE session_id = $1
+		ORDER BY label`
+
+	rows, err := r.db.Query(query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercise groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []models.ExerciseGroup
+	for rows.Next() {
+		var group models.ExerciseGroup
+		err := rows.Scan(
+			&group.ID, &group.SessionID, &group.Label, &group.GroupType, &group.Rounds,
+			&group.RestSeconds, &group.IntervalSeconds, &group.Notes, &group.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan exercise group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
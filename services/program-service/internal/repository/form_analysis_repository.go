@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+const formAnalysisColumns = `id, video_id, athlete_id, exercise, load, status, score, confidence,
+	summary, key_frames, issues, ai_model, error, created_at, completed_at`
+
+// GetFormAnalysisByVideoID returns nil when the video has not been analyzed
+func (r *ProgramRepository) GetFormAnalysisByVideoID(videoID uuid.UUID) (*models.FormAnalysis, error) {
+	query := `SELECT ` + formAnalysisColumns + ` FROM form_analyses WHERE video_id = $1`
+
+	var analysis models.FormAnalysis
+	var keyFramesJSON, issuesJSON []byte
+	err := r.db.QueryRow(query, videoID).Scan(
+		&analysis.ID, &analysis.VideoID, &analysis.AthleteID, &analysis.Exercise, &analysis.Load,
+		&analysis.Status, &analysis.Score, &analysis.Confidence, &analysis.Summary,
+		&keyFramesJSON, &issuesJSON, &analysis.AIModel, &analysis.Error,
+		&analysis.CreatedAt, &analysis.CompletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form analysis: %w", err)
+	}
+
+	if err := json.Unmarshal(keyFramesJSON, &analysis.KeyFrames); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal key frames: %w", err)
+	}
+	if err := json.Unmarshal(issuesJSON, &analysis.Issues); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal issues: %w", err)
+	}
+
+	return &analysis, nil
+}
+
+// StartFormAnalysis creates the processing row for a video, or resets a failed
+// or interrupted one so it can be retried
+func (r *ProgramRepository) StartFormAnalysis(analysis *models.FormAnalysis) error {
+	query := `
+		INSERT INTO form_analyses (id, video_id, athlete_id, exercise, load, status)
+		VALUES ($1, $2, $3, $4, $5, 'processing')
+		ON CONFLICT (video_id) DO UPDATE
+		SET athlete_id = EXCLUDED.athlete_id, exercise = EXCLUDED.exercise, load = EXCLUDED.load,
+		    status = 'processing', error = NULL
+		RETURNING id, status, created_at`
+
+	err := r.db.QueryRow(query,
+		uuid.New(), analysis.VideoID, analysis.AthleteID, analysis.Exercise, analysis.Load,
+	).Scan(&analysis.ID, &analysis.Status, &analysis.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to start form analysis: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteFormAnalysis stores the model's review
+func (r *ProgramRepository) CompleteFormAnalysis(analysis *models.FormAnalysis) error {
+	keyFramesJSON, err := json.Marshal(analysis.KeyFrames)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key frames: %w", err)
+	}
+	issuesJSON, err := json.Marshal(analysis.Issues)
+	if err != nil {
+		return fmt.Errorf("failed to marshal issues: %w", err)
+	}
+
+	query := `
+		UPDATE form_analyses
+		SET status = 'completed', score = $2, confidence = $3, summary = $4, key_frames = $5,
+		    issues = $6, ai_model = $7, error = NULL, completed_at = NOW()
+		WHERE id = $1
+		RETURNING status, completed_at`
+
+	err = r.db.QueryRow(query,
+		analysis.ID, analysis.Score, analysis.Confidence, analysis.Summary,
+		keyFramesJSON, issuesJSON, analysis.AIModel,
+	).Scan(&analysis.Status, &analysis.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete form analysis: %w", err)
+	}
+
+	return nil
+}
+
+// FailFormAnalysis records why an analysis could not be completed
+func (r *ProgramRepository) FailFormAnalysis(id uuid.UUID, reason string) error {
+	query := `UPDATE form_analyses SET status = 'failed', error = $2 WHERE id = $1`
+
+	if _, err := r.db.Exec(query, id, reason); err != nil {
+		return fmt.Errorf("failed to mark form analysis failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+const restPeriodColumns = `id, session_id, exercise_id, after_set_id, target_seconds, started_at, stopped_at`
+
+// StartSession marks the session as started. Starting again keeps the original
+// start time.
+func (r *ProgramRepository) StartSession(sessionID uuid.UUID) (time.Time, error) {
+	query := `
+		UPDATE training_sessions
+		SET started_at = COALESCE(started_at, NOW()), modified_at = NOW()
+		WHERE id = $1
+		RETURNING started_at`
+
+	var startedAt time.Time
+	if err := r.db.QueryRow(query, sessionID).Scan(&startedAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to start session: %w", err)
+	}
+
+	return startedAt, nil
+}
+
+func (r *ProgramRepository) GetSessionStartedAt(sessionID uuid.UUID) (*time.Time, error) {
+	var startedAt *time.Time
+	err := r.db.QueryRow(`SELECT started_at FROM training_sessions WHERE id = $1`, sessionID).Scan(&startedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session start: %w", err)
+	}
+
+	return startedAt, nil
+}
+
+// LogLiveSet records one set of a session in progress. When SetNumber is zero the
+// set is numbered after the exercise's last set. Any running rest timer is
+// stopped, since the athlete has started lifting again; it is returned so the
+// caller can report it.
+func (r *ProgramRepository) LogLiveSet(sessionID uuid.UUID, set *models.CompletedSet) (*models.RestPeriod, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if set.SetNumber == 0 {
+		// Lock the exercise so two quick logs don't take the same number
+		if _, err := tx.Exec(`SELECT id FROM exercises WHERE id = $1 FOR UPDATE`, set.ExerciseID); err != nil {
+			return nil, fmt.Errorf("failed to lock exercise: %w", err)
+		}
+
+		err := tx.QueryRow(`SELECT COALESCE(MAX(set_number), 0) + 1 FROM completed_sets WHERE exercise_id = $1`, set.ExerciseID).
+			Scan(&set.SetNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to number set: %w", err)
+		}
+	}
+
+	if err := insertCompletedSet(tx, set); err != nil {
+		return nil, err
+	}
+
+	stopped, err := stopRest(tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return stopped, nil
+}
+
+// GetCompletedSetByID returns the set and the session it belongs to
+func (r *ProgramRepository) GetCompletedSetByID(setID uuid.UUID) (*models.CompletedSet, uuid.UUID, error) {
+	query := `
+		SELECT cs.id, cs.exercise_id, cs.set_number, cs.reps_completed, cs.weight_kg,
+		       cs.rpe_actual, cs.video_id, cs.notes, cs.set_type, cs.media_urls,
+		       cs.exercise_notes, cs.round_number, cs.mean_velocity_mps, cs.peak_velocity_mps,
+		       cs.velocity_loss_pct, cs.rep_velocities, cs.completed_at, e.session_id
+		FROM completed_sets cs
+		JOIN exercises e ON e.id = cs.exercise_id
+		WHERE cs.id = $1`
+
+	var set models.CompletedSet
+	var mediaURLsJSON, repVelocitiesJSON []byte
+	var sessionID uuid.UUID
+	err := r.db.QueryRow(query, setID).Scan(
+		&set.ID, &set.ExerciseID, &set.SetNumber, &set.RepsCompleted, &set.WeightKg,
+		&set.RPEActual, &set.VideoID, &set.Notes, &set.SetType, &mediaURLsJSON,
+		&set.ExerciseNotes, &set.RoundNumber, &set.MeanVelocityMps, &set.PeakVelocityMps,
+		&set.VelocityLossPct, &repVelocitiesJSON, &set.CompletedAt, &sessionID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, uuid.Nil, fmt.Errorf("set not found")
+		}
+		return nil, uuid.Nil, fmt.Errorf("failed to get set: %w", err)
+	}
+
+	if len(mediaURLsJSON) > 0 {
+		json.Unmarshal(mediaURLsJSON, &set.MediaURLs)
+	}
+	if len(repVelocitiesJSON) > 0 {
+		json.Unmarshal(repVelocitiesJSON, &set.RepVelocities)
+	}
+
+	return &set, sessionID, nil
+}
+
+// StartRest starts a rest timer, stopping any timer already running. The stopped
+// timer is returned, or nil.
+func (r *ProgramRepository) StartRest(rest *models.RestPeriod) (*models.RestPeriod, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stopped, err := stopRest(tx, rest.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO session_rest_periods (session_id, exercise_id, after_set_id, target_seconds)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, started_at`,
+		rest.SessionID, rest.ExerciseID, rest.AfterSetID, rest.TargetSeconds,
+	).Scan(&rest.ID, &rest.StartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start rest: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return stopped, nil
+}
+
+// StopRest stops the session's running rest timer and returns it, or nil when
+// none is running
+func (r *ProgramRepository) StopRest(sessionID uuid.UUID) (*models.RestPeriod, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stopped, err := stopRest(tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return stopped, nil
+}
+
+func stopRest(tx *sql.Tx, sessionID uuid.UUID) (*models.RestPeriod, error) {
+	query := `
+		UPDATE session_rest_periods SET stopped_at = NOW()
+		WHERE session_id = $1 AND stopped_at IS NULL
+		RETURNING ` + restPeriodColumns
+
+	rest, err := scanRestPeriod(tx.QueryRow(query, sessionID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stop rest: %w", err)
+	}
+
+	return rest, nil
+}
+
+// GetActiveRest returns the session's running rest timer, or nil
+func (r *ProgramRepository) GetActiveRest(sessionID uuid.UUID) (*models.RestPeriod, error) {
+	query := `SELECT ` + restPeriodColumns + ` FROM session_rest_periods WHERE session_id = $1 AND stopped_at IS NULL`
+
+	rest, err := scanRestPeriod(r.db.QueryRow(query, sessionID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active rest: %w", err)
+	}
+
+	return rest, nil
+}
+
+func scanRestPeriod(row *sql.Row) (*models.RestPeriod, error) {
+	var rest models.RestPeriod
+	err := row.Scan(
+		&rest.ID, &rest.SessionID, &rest.ExerciseID, &rest.AfterSetID,
+		&rest.TargetSeconds, &rest.StartedAt, &rest.StoppedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rest, nil
+}
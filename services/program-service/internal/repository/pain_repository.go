@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// Episode columns with the latest and highest score and the exercises of the
+// sets its reports are tied to
+const painEpisodeColumns = `
+	pe.id, pe.athlete_id, pe.body_region, pe.side, pe.triggering_movement, pe.lift_type,
+	pe.started_on, pe.resolved_on, pe.notes, pe.created_at, pe.updated_at,
+	(SELECT pr.pain_score FROM pain_reports pr WHERE pr.episode_id = pe.id
+	 ORDER BY pr.reported_at DESC LIMIT 1),
+	(SELECT MAX(pr.pain_score) FROM pain_reports pr WHERE pr.episode_id = pe.id),
+	(SELECT COALESCE(json_agg(DISTINCT e.exercise_name), '[]')
+	 FROM pain_reports pr
+	 JOIN completed_sets cs ON cs.id = pr.set_id
+	 JOIN exercises e ON e.id = cs.exercise_id
+	 WHERE pr.episode_id = pe.id)`
+
+// CreatePainEpisode opens an episode together with its first report
+func (r *ProgramRepository) CreatePainEpisode(episode *models.PainEpisode, report *models.PainReport) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO pain_episodes (athlete_id, body_region, side, triggering_movement, lift_type, started_on, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at`,
+		episode.AthleteID, episode.BodyRegion, episode.Side, episode.TriggeringMovement,
+		episode.LiftType, episode.StartedOn, episode.Notes,
+	).Scan(&episode.ID, &episode.CreatedAt, &episode.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create pain episode: %w", err)
+	}
+
+	report.EpisodeID = episode.ID
+	if err := insertPainReport(tx, report); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPainEpisodes returns the athlete's episodes, most recent first. status is
+// "active", "resolved" or empty for both.
+func (r *ProgramRepository) GetPainEpisodes(athleteID uuid.UUID, status string) ([]models.PainEpisode, error) {
+	query := `
+		SELECT ` + painEpisodeColumns + `
+		FROM pain_episodes pe
+		WHERE pe.athlete_id = $1
+		  AND ($2 = '' OR ($2 = 'active') = (pe.resolved_on IS NULL))
+		ORDER BY pe.started_on DESC, pe.created_at DESC`
+
+	rows, err := r.db.Query(query, athleteID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pain episodes: %w", err)
+	}
+	defer rows.Close()
+
+	episodes := []models.PainEpisode{}
+	for rows.Next() {
+		episode, err := scanPainEpisode(rows)
+		if err != nil {
+			return nil, err
+		}
+		episodes = append(episodes, *episode)
+	}
+
+	return episodes, nil
+}
+
+// GetPainEpisodeByID returns the episode with its reports, oldest first
+func (r *ProgramRepository) GetPainEpisodeByID(episodeID uuid.UUID) (*models.PainEpisode, error) {
+	episode, err := scanPainEpisode(r.db.QueryRow(`
+		SELECT `+painEpisodeColumns+`
+		FROM pain_episodes pe
+		WHERE pe.id = $1`, episodeID))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT pr.id, pr.episode_id, pr.pain_score, pr.session_id, pr.set_id,
+		       e.exercise_name, pr.notes, pr.reported_at
+		FROM pain_reports pr
+		LEFT JOIN completed_sets cs ON cs.id = pr.set_id
+		LEFT JOIN exercises e ON e.id = cs.exercise_id
+		WHERE pr.episode_id = $1
+		ORDER BY pr.reported_at`, episodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pain reports: %w", err)
+	}
+	defer rows.Close()
+
+	episode.Reports = []models.PainReport{}
+	for rows.Next() {
+		var report models.PainReport
+		err := rows.Scan(
+			&report.ID, &report.EpisodeID, &report.PainScore, &report.SessionID, &report.SetID,
+			&report.ExerciseName, &report.Notes, &report.ReportedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pain report: %w", err)
+		}
+		episode.Reports = append(episode.Reports, report)
+	}
+
+	return episode, nil
+}
+
+func (r *ProgramRepository) UpdatePainEpisode(episode *models.PainEpisode) error {
+	_, err := r.db.Exec(`
+		UPDATE pain_episodes
+		SET body_region = $1, side = $2, triggering_movement = $3, lift_type = $4,
+		    started_on = $5, notes = $6
+		WHERE id = $7`,
+		episode.BodyRegion, episode.Side, episode.TriggeringMovement, episode.LiftType,
+		episode.StartedOn, episode.Notes, episode.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update pain episode: %w", err)
+	}
+
+	return nil
+}
+
+// SetPainEpisodeResolved resolves an episode on resolvedOn, or reopens it when
+// resolvedOn is nil
+func (r *ProgramRepository) SetPainEpisodeResolved(episodeID uuid.UUID, resolvedOn *time.Time) error {
+	_, err := r.db.Exec(`UPDATE pain_episodes SET resolved_on = $1 WHERE id = $2`, resolvedOn, episodeID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve pain episode: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ProgramRepository) DeletePainEpisode(episodeID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM pain_episodes WHERE id = $1`, episodeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete pain episode: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ProgramRepository) AddPainReport(report *models.PainReport) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertPainReport(tx, report); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPainTrends returns a week-by-week series from startDate of pain reports,
+// optionally for one body region, next to the training load of each week
+func (r *ProgramRepository) GetPainTrends(athleteID uuid.UUID, startDate time.Time, bodyRegion *string) ([]models.PainTrendWeek, error) {
+	weeks := make(map[time.Time]*models.PainTrendWeek)
+	week := func(start time.Time) *models.PainTrendWeek {
+		start = start.UTC()
+		if w, ok := weeks[start]; ok {
+			return w
+		}
+		w := &models.PainTrendWeek{WeekStart: start, TonnageByLift: map[models.LiftType]float64{}}
+		weeks[start] = w
+		return w
+	}
+
+	painRows, err := r.db.Query(`
+		SELECT DATE_TRUNC('week', pr.reported_at) as week_start,
+		       COUNT(*), MAX(pr.pain_score), AVG(pr.pain_score)
+		FROM pain_reports pr
+		JOIN pain_episodes pe ON pe.id = pr.episode_id
+		WHERE pe.athlete_id = $1
+		  AND pr.reported_at >= $2
+		  AND ($3::text IS NULL OR pe.body_region = $3)
+		GROUP BY week_start`, athleteID, startDate, bodyRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pain trends: %w", err)
+	}
+	defer painRows.Close()
+
+	for painRows.Next() {
+		var start time.Time
+		var reports, maxScore int
+		var average float64
+		if err := painRows.Scan(&start, &reports, &maxScore, &average); err != nil {
+			return nil, fmt.Errorf("failed to scan pain trend: %w", err)
+		}
+		w := week(start)
+		w.Reports = reports
+		w.MaxPainScore = &maxScore
+		average = math.Round(average*10) / 10
+		w.AveragePainScore = &average
+	}
+
+	loadRows, err := r.db.Query(`
+		SELECT DATE_TRUNC('week', ts.completed_at) as week_start, e.lift_type,
+		       COUNT(*), SUM(cs.weight_kg * cs.reps_completed)
+		FROM completed_sets cs
+		JOIN exercises e ON cs.exercise_id = e.id
+		JOIN training_sessions ts ON e.session_id = ts.id
+		WHERE ts.athlete_id = $1
+		  AND ts.completed_at >= $2
+		  AND ts.deleted_at IS NULL
+		  AND (cs.set_type IS NULL OR cs.set_type != 'warm_up')
+		GROUP BY week_start, e.lift_type`, athleteID, startDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get training load: %w", err)
+	}
+	defer loadRows.Close()
+
+	for loadRows.Next() {
+		var start time.Time
+		var liftType models.LiftType
+		var sets int
+		var tonnage float64
+		if err := loadRows.Scan(&start, &liftType, &sets, &tonnage); err != nil {
+			return nil, fmt.Errorf("failed to scan training load: %w", err)
+		}
+		w := week(start)
+		w.WorkingSets += sets
+		w.TonnageKg += tonnage
+		w.TonnageByLift[liftType] += tonnage
+	}
+
+	activeRows, err := r.db.Query(`
+		SELECT weeks.week_start, COUNT(pe.id)
+		FROM generate_series(DATE_TRUNC('week', $2::timestamptz), NOW(), INTERVAL '1 week') AS weeks(week_start)
+		LEFT JOIN pain_episodes pe
+		  ON pe.athlete_id = $1
+		 AND ($3::text IS NULL OR pe.body_region = $3)
+		 AND pe.started_on < weeks.week_start + INTERVAL '1 week'
+		 AND (pe.resolved_on IS NULL OR pe.resolved_on >= weeks.week_start)
+		GROUP BY weeks.week_start`, athleteID, startDate, bodyRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active pain episodes: %w", err)
+	}
+	defer activeRows.Close()
+
+	for activeRows.Next() {
+		var start time.Time
+		var active int
+		if err := activeRows.Scan(&start, &active); err != nil {
+			return nil, fmt.Errorf("failed to scan active pain episodes: %w", err)
+		}
+		week(start).ActiveEpisodes = active
+	}
+
+	trends := make([]models.PainTrendWeek, 0, len(weeks))
+	for _, w := range weeks {
+		trends = append(trends, *w)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		return trends[i].WeekStart.Before(trends[j].WeekStart)
+	})
+
+	return trends, nil
+}
+
+func insertPainReport(tx *sql.Tx, report *models.PainReport) error {
+	if report.ReportedAt.IsZero() {
+		report.ReportedAt = time.Now()
+	}
+
+	err := tx.QueryRow(`
+		INSERT INTO pain_reports (episode_id, pain_score, session_id, set_id, notes, reported_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		report.EpisodeID, report.PainScore, report.SessionID, report.SetID, report.Notes, report.ReportedAt,
+	).Scan(&report.ID)
+	if err != nil {
+		return fmt.Errorf("failed to add pain report: %w", err)
+	}
+
+	return nil
+}
+
+func scanPainEpisode(scanner interface{ Scan(...interface{}) error }) (*models.PainEpisode, error) {
+	var episode models.PainEpisode
+	var exercisesJSON []byte
+	err := scanner.Scan(
+		&episode.ID, &episode.AthleteID, &episode.BodyRegion, &episode.Side,
+		&episode.TriggeringMovement, &episode.LiftType, &episode.StartedOn, &episode.ResolvedOn,
+		&episode.Notes, &episode.CreatedAt, &episode.UpdatedAt,
+		&episode.CurrentScore, &episode.PeakScore, &exercisesJSON,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("pain episode not found")
+		}
+		return nil, fmt.Errorf("failed to scan pain episode: %w", err)
+	}
+
+	episode.AggravatingExercises = []string{}
+	if len(exercisesJSON) > 0 {
+		json.Unmarshal(exercisesJSON, &episode.AggravatingExercises)
+	}
+
+	return &episode, nil
+}
@@ -79,6 +79,19 @@ func (r *ProgramRepository) GetProgramByID(id uuid.UUID) (*models.Program, error
 	return program, nil
 }
 
+// GetAthleteProgramNumber returns the program's position among the athlete's
+// programs, oldest first, so it can be shown as "v3"
+func (r *ProgramRepository) GetAthleteProgramNumber(program *models.Program) (int, error) {
+	query := `SELECT COUNT(*) FROM programs WHERE athlete_id = $1 AND created_at <= $2`
+
+	var number int
+	if err := r.db.QueryRow(query, program.AthleteID, program.CreatedAt).Scan(&number); err != nil {
+		return 0, fmt.Errorf("failed to count programs: %w", err)
+	}
+
+	return number, nil
+}
+
 func (r *ProgramRepository) GetProgramsByAthleteID(athleteID uuid.UUID) ([]models.Program, error) {
 	query := `
 		SELECT id, athlete_id, coach_id, name, description, phase, start_date, end_date,
@@ -168,7 +181,7 @@ func (r *ProgramRepository) GetSessionsByProgramID(programID uuid.UUID) ([]model
 		       scheduled_date, completed_at, notes, rpe_rating, duration_minutes,
 		       created_at, updated_at
 		FROM training_sessions 
-		WHERE program_id = $1 
+		WHERE program_id = $1 AND deleted_at IS NULL
 		ORDER BY week_number, day_number`
 
 	rows, err := r.db.Query(query, programID)
@@ -199,6 +212,12 @@ func (r *ProgramRepository) GetSessionsByProgramID(programID uuid.UUID) ([]model
 		}
 		session.Exercises = exercises
 
+		groups, err := r.GetExerciseGroupsBySessionID(session.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get exercise groups for session %s: %w", session.ID, err)
+		}
+		session.Groups = groups
+
 		sessions = append(sessions, session)
 	}
 
@@ -209,8 +228,9 @@ func (r *ProgramRepository) CreateExercise(exercise *models.Exercise) error {
 	query := `
 		INSERT INTO exercises (session_id, exercise_order, lift_type, exercise_name,
 		                      target_sets, target_reps, target_weight_kg, target_rpe,
-		                      target_percentage, rest_seconds, notes, tempo)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		                      target_percentage, rest_seconds, notes, tempo, group_id, group_position,
+		                      velocity_loss_cutoff_pct)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING id, created_at`
 
 	err := r.db.QueryRow(query,
@@ -218,6 +238,7 @@ func (r *ProgramRepository) CreateExercise(exercise *models.Exercise) error {
 		exercise.ExerciseName, exercise.TargetSets, exercise.TargetReps,
 		exercise.TargetWeightKg, exercise.TargetRPE, exercise.TargetPercentage,
 		exercise.RestSeconds, exercise.Notes, exercise.Tempo,
+		exercise.GroupID, exercise.GroupPosition, exercise.VelocityLossCutoffPct,
 	).Scan(&exercise.ID, &exercise.CreatedAt)
 
 	if err != nil {
@@ -231,7 +252,8 @@ func (r *ProgramRepository) GetExercisesBySessionID(sessionID uuid.UUID) ([]mode
 	query := `
 		SELECT id, session_id, exercise_order, lift_type, exercise_name,
 		       target_sets, target_reps, target_weight_kg, target_rpe,
-		       target_percentage, rest_seconds, notes, tempo, created_at
+		       target_percentage, rest_seconds, notes, tempo, group_id, group_position,
+		       velocity_loss_cutoff_pct, created_at
 		FROM exercises 
 		WHERE session_id = $1 
 		ORDER BY exercise_order`
@@ -251,7 +273,8 @@ func (r *ProgramRepository) GetExercisesBySessionID(sessionID uuid.UUID) ([]mode
 			&exercise.LiftType, &exercise.ExerciseName, &exercise.TargetSets,
 			&exercise.TargetReps, &exercise.TargetWeightKg, &exercise.TargetRPE,
 			&exercise.TargetPercentage, &exercise.RestSeconds, &exercise.Notes,
-			&exercise.Tempo, &exercise.CreatedAt,
+			&exercise.Tempo, &exercise.GroupID, &exercise.GroupPosition,
+			&exercise.VelocityLossCutoffPct, &exercise.CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan exercise: %w", err)
@@ -271,18 +294,28 @@ func (r *ProgramRepository) GetExercisesBySessionID(sessionID uuid.UUID) ([]mode
 }
 
 func (r *ProgramRepository) LogCompletedSet(set *models.CompletedSet) error {
+	if set.SetType == "" {
+		set.SetType = models.SetTypeWorking
+	}
+	set.FillVelocity()
+
 	// Convert MediaURLs to JSON
 	mediaURLsJSON, _ := json.Marshal(set.MediaURLs)
+	repVelocitiesJSON := marshalRepVelocities(set.RepVelocities)
 
 	query := `
 		INSERT INTO completed_sets (exercise_id, set_number, reps_completed, weight_kg,
-		                           rpe_actual, video_id, notes, set_type, media_urls, exercise_notes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		                           rpe_actual, video_id, notes, set_type, media_urls, exercise_notes,
+		                           round_number, mean_velocity_mps, peak_velocity_mps, velocity_loss_pct,
+		                           rep_velocities)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING id, completed_at`
 
 	err := r.db.QueryRow(query,
 		set.ExerciseID, set.SetNumber, set.RepsCompleted, set.WeightKg,
 		set.RPEActual, set.VideoID, set.Notes, set.SetType, mediaURLsJSON, set.ExerciseNotes,
+		set.RoundNumber, set.MeanVelocityMps, set.PeakVelocityMps, set.VelocityLossPct,
+		repVelocitiesJSON,
 	).Scan(&set.ID, &set.CompletedAt)
 
 	if err != nil {
@@ -295,7 +328,8 @@ func (r *ProgramRepository) LogCompletedSet(set *models.CompletedSet) error {
 func (r *ProgramRepository) GetCompletedSetsByExerciseID(exerciseID uuid.UUID) ([]models.CompletedSet, error) {
 	query := `
 		SELECT id, exercise_id, set_number, reps_completed, weight_kg,
-		       rpe_actual, video_id, notes, set_type, media_urls, exercise_notes, completed_at
+		       rpe_actual, video_id, notes, set_type, media_urls, exercise_notes, round_number,
+		       mean_velocity_mps, peak_velocity_mps, velocity_loss_pct, rep_velocities, completed_at
 		FROM completed_sets
 		WHERE exercise_id = $1
 		ORDER BY set_number`
@@ -309,12 +343,14 @@ func (r *ProgramRepository) GetCompletedSetsByExerciseID(exerciseID uuid.UUID) (
 	var sets []models.CompletedSet
 	for rows.Next() {
 		var set models.CompletedSet
-		var mediaURLsJSON []byte
+		var mediaURLsJSON, repVelocitiesJSON []byte
 
 		err := rows.Scan(
 			&set.ID, &set.ExerciseID, &set.SetNumber, &set.RepsCompleted,
 			&set.WeightKg, &set.RPEActual, &set.VideoID, &set.Notes,
-			&set.SetType, &mediaURLsJSON, &set.ExerciseNotes, &set.CompletedAt,
+			&set.SetType, &mediaURLsJSON, &set.ExerciseNotes, &set.RoundNumber,
+			&set.MeanVelocityMps, &set.PeakVelocityMps, &set.VelocityLossPct, &repVelocitiesJSON,
+			&set.CompletedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan completed set: %w", err)
@@ -324,6 +360,9 @@ func (r *ProgramRepository) GetCompletedSetsByExerciseID(exerciseID uuid.UUID) (
 		if len(mediaURLsJSON) > 0 {
 			json.Unmarshal(mediaURLsJSON, &set.MediaURLs)
 		}
+		if len(repVelocitiesJSON) > 0 {
+			json.Unmarshal(repVelocitiesJSON, &set.RepVelocities)
+		}
 
 		sets = append(sets, set)
 	}
@@ -337,7 +376,8 @@ func (r *ProgramRepository) CompleteSession(sessionID uuid.UUID, notes *string,
 			completed_at = NOW(),
 			notes = COALESCE($2, notes),
 			rpe_rating = COALESCE($3, rpe_rating),
-			duration_minutes = COALESCE($4, duration_minutes)
+			duration_minutes = COALESCE($4, duration_minutes),
+			modified_at = NOW()
 		WHERE id = $1`
 
 	_, err := r.db.Exec(query, sessionID, notes, rpeRating, durationMins)
@@ -348,90 +388,6 @@ func (r *ProgramRepository) CompleteSession(sessionID uuid.UUID, notes *string,
 	return nil
 }
 
-func (r *ProgramRepository) CreateAIConversation(conversation *models.AIConversation) error {
-	messagesJSON, _ := json.Marshal(conversation.Messages)
-	feedbackJSON, _ := json.Marshal(conversation.CoachFeedbackIncorp)
-
-	query := `
-		INSERT INTO ai_conversations (athlete_id, program_id, conversation_type, messages,
-		                             coach_context_enabled, coach_feedback_incorporated)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at`
-
-	err := r.db.QueryRow(query,
-		conversation.AthleteID, conversation.ProgramID, conversation.ConversationType,
-		messagesJSON, conversation.CoachContextEnabled, feedbackJSON,
-	).Scan(&conversation.ID, &conversation.CreatedAt, &conversation.UpdatedAt)
-
-	if err != nil {
-		return fmt.Errorf("failed to create AI conversation: %w", err)
-	}
-
-	return nil
-}
-
-func (r *ProgramRepository) UpdateAIConversation(conversation *models.AIConversation) error {
-	messagesJSON, _ := json.Marshal(conversation.Messages)
-	feedbackJSON, _ := json.Marshal(conversation.CoachFeedbackIncorp)
-
-	query := `
-		UPDATE ai_conversations SET
-			messages = $2,
-			coach_feedback_incorporated = $3
-		WHERE id = $1`
-
-	_, err := r.db.Exec(query, conversation.ID, messagesJSON, feedbackJSON)
-	if err != nil {
-		return fmt.Errorf("failed to update AI conversation: %w", err)
-	}
-
-	return nil
-}
-
-func (r *ProgramRepository) GetAIConversationsByAthleteID(athleteID uuid.UUID, limit int) ([]models.AIConversation, error) {
-	query := `
-		SELECT id, athlete_id, program_id, conversation_type, messages,
-		       coach_context_enabled, coach_feedback_incorporated,
-		       created_at, updated_at
-		FROM ai_conversations 
-		WHERE athlete_id = $1 
-		ORDER BY updated_at DESC 
-		LIMIT $2`
-
-	rows, err := r.db.Query(query, athleteID, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get AI conversations: %w", err)
-	}
-	defer rows.Close()
-
-	var conversations []models.AIConversation
-	for rows.Next() {
-		var conversation models.AIConversation
-		var messagesJSON, feedbackJSON []byte
-
-		err := rows.Scan(
-			&conversation.ID, &conversation.AthleteID, &conversation.ProgramID,
-			&conversation.ConversationType, &messagesJSON,
-			&conversation.CoachContextEnabled, &feedbackJSON,
-			&conversation.CreatedAt, &conversation.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan AI conversation: %w", err)
-		}
-
-		if len(messagesJSON) > 0 {
-			json.Unmarshal(messagesJSON, &conversation.Messages)
-		}
-		if len(feedbackJSON) > 0 {
-			json.Unmarshal(feedbackJSON, &conversation.CoachFeedbackIncorp)
-		}
-
-		conversations = append(conversations, conversation)
-	}
-
-	return conversations, nil
-}
-
 func (r *ProgramRepository) GetProgramTemplates(category string, experienceLevel string) ([]models.ProgramTemplate, error) {
 	var query strings.Builder
 	var args []interface{}
@@ -488,6 +444,35 @@ func (r *ProgramRepository) GetProgramTemplates(category string, experienceLevel
 	return templates, nil
 }
 
+// GetProgramTemplateByID returns a template by ID, public or not
+func (r *ProgramRepository) GetProgramTemplateByID(id uuid.UUID) (*models.ProgramTemplate, error) {
+	query := `
+		SELECT id, name, description, category, experience_level, phase,
+		       weeks_duration, days_per_week, template_data, is_public,
+		       created_by, created_at
+		FROM program_templates
+		WHERE id = $1`
+
+	template := &models.ProgramTemplate{}
+	var templateDataJSON []byte
+
+	err := r.db.QueryRow(query, id).Scan(
+		&template.ID, &template.Name, &template.Description, &template.Category,
+		&template.ExperienceLevel, &template.Phase, &template.WeeksDuration,
+		&template.DaysPerWeek, &templateDataJSON, &template.IsPublic,
+		&template.CreatedBy, &template.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get program template: %w", err)
+	}
+
+	if len(templateDataJSON) > 0 {
+		json.Unmarshal(templateDataJSON, &template.TemplateData)
+	}
+
+	return template, nil
+}
+
 // GetActiveApprovedProgramByAthleteID returns the active approved program for an athlete
 func (r *ProgramRepository) GetActiveApprovedProgramByAthleteID(athleteID uuid.UUID) (*models.Program, error) {
 	query := `
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// ErrSessionNotInTrash is returned when restoring a session that isn't deleted
+var ErrSessionNotInTrash = errors.New("session is not in the trash")
+
+// GetDeletedSessions returns the athlete's sessions in the trash, most recently
+// deleted first, with what was logged in them
+func (r *ProgramRepository) GetDeletedSessions(athleteID uuid.UUID) ([]models.TrainingSession, error) {
+	query := `
+		SELECT id, program_id, athlete_id, week_number, day_number, session_name,
+		       scheduled_date, completed_at, notes, rpe_rating, duration_minutes,
+		       created_at, updated_at, deleted_at, deleted_reason
+		FROM training_sessions
+		WHERE athlete_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`
+
+	rows, err := r.db.Query(query, athleteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.TrainingSession{}
+	for rows.Next() {
+		var session models.TrainingSession
+		err := rows.Scan(
+			&session.ID, &session.ProgramID, &session.AthleteID,
+			&session.WeekNumber, &session.DayNumber, &session.SessionName,
+			&session.ScheduledDate, &session.CompletedAt, &session.Notes,
+			&session.RPERating, &session.DurationMins,
+			&session.CreatedAt, &session.UpdatedAt, &session.DeletedAt, &session.DeletedReason,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get deleted sessions: %w", err)
+	}
+
+	for i := range sessions {
+		exercises, err := r.GetExercisesBySessionID(sessions[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get exercises for session %s: %w", sessions[i].ID, err)
+		}
+		sessions[i].Exercises = exercises
+	}
+
+	return sessions, nil
+}
+
+// RestoreSession takes a session out of the trash. Unless force is set, a
+// session whose program day has since been taken, or whose program has been
+// generated again, is left in the trash and the conflicts are returned.
+func (r *ProgramRepository) RestoreSession(sessionID uuid.UUID, force bool) ([]models.RestoreConflict, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var programID uuid.UUID
+	var weekNumber, dayNumber int
+	var isAdhoc bool
+	var deletedAt *time.Time
+	err = tx.QueryRow(`
+		SELECT program_id, week_number, day_number, COALESCE(is_adhoc, FALSE), deleted_at
+		FROM training_sessions
+		WHERE id = $1
+		FOR UPDATE`, sessionID,
+	).Scan(&programID, &weekNumber, &dayNumber, &isAdhoc, &deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if deletedAt == nil {
+		return nil, ErrSessionNotInTrash
+	}
+
+	conflicts := []models.RestoreConflict{}
+	if !isAdhoc {
+		var takenBy uuid.UUID
+		err := tx.QueryRow(`
+			SELECT id FROM training_sessions
+			WHERE program_id = $1 AND week_number = $2 AND day_number = $3
+			  AND id != $4 AND deleted_at IS NULL AND COALESCE(is_adhoc, FALSE) = FALSE
+			ORDER BY created_at DESC
+			LIMIT 1`, programID, weekNumber, dayNumber, sessionID,
+		).Scan(&takenBy)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to check program day: %w", err)
+		}
+		if err == nil {
+			conflicts = append(conflicts, models.RestoreConflict{
+				Type:      models.RestoreConflictSlotTaken,
+				Message:   fmt.Sprintf("Week %d day %d already has another session", weekNumber, dayNumber),
+				SessionID: &takenBy,
+			})
+		}
+
+		var regenerated bool
+		err = tx.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM training_sessions
+				WHERE program_id = $1 AND created_at > $2 AND COALESCE(is_adhoc, FALSE) = FALSE
+			)`, programID, deletedAt,
+		).Scan(&regenerated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check program: %w", err)
+		}
+		if regenerated {
+			conflicts = append(conflicts, models.RestoreConflict{
+				Type:    models.RestoreConflictProgramRegenerated,
+				Message: "The program's sessions were generated again after this session was deleted",
+			})
+		}
+	}
+
+	if len(conflicts) > 0 && !force {
+		return conflicts, nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE training_sessions
+		SET deleted_at = NULL, deleted_reason = NULL, modified_at = NOW()
+		WHERE id = $1`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil, nil
+}
+
+// PurgeDeletedSessions permanently removes sessions that have been in the trash
+// longer than retention, along with their exercises, sets and history. A
+// retention that isn't positive deletes nothing.
+func (r *ProgramRepository) PurgeDeletedSessions(retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	result, err := r.db.Exec(`
+		DELETE FROM training_sessions
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1`,
+		time.Now().Add(-retention),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted sessions: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// ErrNothingToUndo is returned when a set has no correction left to undo
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+const setRevisionColumns = `id, set_id, session_id, exercise_id, action, changed_by, before, after,
+	reverts_revision_id, undone_at, created_at`
+
+// UpdateCompletedSet saves an edited set and records the change in its history
+func (r *ProgramRepository) UpdateCompletedSet(set *models.CompletedSet, changedBy uuid.UUID) (*models.SetRevision, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, sessionID, err := lockCompletedSet(tx, set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeCompletedSet(tx, set); err != nil {
+		return nil, err
+	}
+
+	after := *set
+	revision := &models.SetRevision{
+		SetID:      set.ID,
+		SessionID:  sessionID,
+		ExerciseID: before.ExerciseID,
+		Action:     models.SetRevisionUpdated,
+		ChangedBy:  changedBy,
+		Before:     before,
+		After:      &after,
+	}
+	if err := recordSetRevision(tx, revision); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return revision, nil
+}
+
+// DeleteCompletedSet removes a set. The set is kept in its history so the delete
+// can be undone.
+func (r *ProgramRepository) DeleteCompletedSet(setID, changedBy uuid.UUID) (*models.SetRevision, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, sessionID, err := lockCompletedSet(tx, setID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM completed_sets WHERE id = $1`, setID); err != nil {
+		return nil, fmt.Errorf("failed to delete set: %w", err)
+	}
+
+	revision := &models.SetRevision{
+		SetID:      setID,
+		SessionID:  sessionID,
+		ExerciseID: before.ExerciseID,
+		Action:     models.SetRevisionDeleted,
+		ChangedBy:  changedBy,
+		Before:     before,
+	}
+	if err := recordSetRevision(tx, revision); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return revision, nil
+}
+
+// UndoSetChange reverts the latest edit or delete of a set in the session that
+// hasn't been undone yet, so repeated undos walk back through the set's history.
+// It returns the set as restored and the revision recording the undo.
+func (r *ProgramRepository) UndoSetChange(sessionID, setID, changedBy uuid.UUID) (*models.CompletedSet, *models.SetRevision, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + setRevisionColumns + `
+		FROM completed_set_revisions
+		WHERE session_id = $1 AND set_id = $2 AND action IN ($3, $4) AND undone_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE`
+
+	undone, err := scanSetRevision(tx.QueryRow(query, sessionID, setID, models.SetRevisionUpdated, models.SetRevisionDeleted))
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrNothingToUndo
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get set history: %w", err)
+	}
+	if undone.Before == nil {
+		return nil, nil, fmt.Errorf("revision %s has no earlier state", undone.ID)
+	}
+
+	restored := *undone.Before
+	revision := &models.SetRevision{
+		SetID:             setID,
+		SessionID:         undone.SessionID,
+		ExerciseID:        undone.ExerciseID,
+		Action:            models.SetRevisionReverted,
+		ChangedBy:         changedBy,
+		After:             &restored,
+		RevertsRevisionID: &undone.ID,
+	}
+
+	switch undone.Action {
+	case models.SetRevisionUpdated:
+		current, _, err := lockCompletedSet(tx, setID)
+		if err != nil {
+			return nil, nil, err
+		}
+		revision.Before = current
+
+		if err := writeCompletedSet(tx, &restored); err != nil {
+			return nil, nil, err
+		}
+	case models.SetRevisionDeleted:
+		var exists bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM exercises WHERE id = $1)`, restored.ExerciseID).Scan(&exists)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check exercise: %w", err)
+		}
+		if !exists {
+			return nil, nil, fmt.Errorf("exercise of deleted set no longer exists")
+		}
+
+		mediaURLsJSON, _ := json.Marshal(restored.MediaURLs)
+		_, err = tx.Exec(`
+			INSERT INTO completed_sets (id, exercise_id, set_number, reps_completed, weight_kg,
+			                           rpe_actual, video_id, notes, set_type, media_urls,
+			                           exercise_notes, round_number, mean_velocity_mps, peak_velocity_mps,
+			                           velocity_loss_pct, rep_velocities, completed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`,
+			restored.ID, restored.ExerciseID, restored.SetNumber, restored.RepsCompleted, restored.WeightKg,
+			restored.RPEActual, restored.VideoID, restored.Notes, restored.SetType, mediaURLsJSON,
+			restored.ExerciseNotes, restored.RoundNumber, restored.MeanVelocityMps, restored.PeakVelocityMps,
+			restored.VelocityLossPct, marshalRepVelocities(restored.RepVelocities), restored.CompletedAt,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to restore set: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE completed_set_revisions SET undone_at = NOW() WHERE id = $1`, undone.ID); err != nil {
+		return nil, nil, fmt.Errorf("failed to mark revision undone: %w", err)
+	}
+
+	if err := recordSetRevision(tx, revision); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &restored, revision, nil
+}
+
+// GetSetRevisions returns the correction history of a session, or of one of its
+// sets, oldest first
+func (r *ProgramRepository) GetSetRevisions(sessionID uuid.UUID, setID *uuid.UUID) ([]models.SetRevision, error) {
+	query := `SELECT ` + setRevisionColumns + `
+		FROM completed_set_revisions
+		WHERE session_id = $1 AND ($2::uuid IS NULL OR set_id = $2)
+		ORDER BY created_at`
+
+	rows, err := r.db.Query(query, sessionID, setID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get set history: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.SetRevision{}
+	for rows.Next() {
+		revision, err := scanSetRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan set revision: %w", err)
+		}
+		revisions = append(revisions, *revision)
+	}
+
+	return revisions, nil
+}
+
+// lockCompletedSet loads a set for update along with the session it belongs to
+func lockCompletedSet(tx *sql.Tx, setID uuid.UUID) (*models.CompletedSet, uuid.UUID, error) {
+	query := `
+		SELECT cs.id, cs.exercise_id, cs.set_number, cs.reps_completed, cs.weight_kg,
+		       cs.rpe_actual, cs.video_id, cs.notes, cs.set_type, cs.media_urls,
+		       cs.exercise_notes, cs.round_number, cs.mean_velocity_mps, cs.peak_velocity_mps,
+		       cs.velocity_loss_pct, cs.rep_velocities, cs.completed_at, e.session_id
+		FROM completed_sets cs
+		JOIN exercises e ON e.id = cs.exercise_id
+		WHERE cs.id = $1
+		FOR UPDATE OF cs`
+
+	var set models.CompletedSet
+	var mediaURLsJSON, repVelocitiesJSON []byte
+	var sessionID uuid.UUID
+	err := tx.QueryRow(query, setID).Scan(
+		&set.ID, &set.ExerciseID, &set.SetNumber, &set.RepsCompleted, &set.WeightKg,
+		&set.RPEActual, &set.VideoID, &set.Notes, &set.SetType, &mediaURLsJSON,
+		&set.ExerciseNotes, &set.RoundNumber, &set.MeanVelocityMps, &set.PeakVelocityMps,
+		&set.VelocityLossPct, &repVelocitiesJSON, &set.CompletedAt, &sessionID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, uuid.Nil, fmt.Errorf("set not found")
+		}
+		return nil, uuid.Nil, fmt.Errorf("failed to get set: %w", err)
+	}
+
+	if len(mediaURLsJSON) > 0 {
+		json.Unmarshal(mediaURLsJSON, &set.MediaURLs)
+	}
+	if len(repVelocitiesJSON) > 0 {
+		json.Unmarshal(repVelocitiesJSON, &set.RepVelocities)
+	}
+
+	return &set, sessionID, nil
+}
+
+func writeCompletedSet(tx *sql.Tx, set *models.CompletedSet) error {
+	if set.SetType == "" {
+		set.SetType = models.SetTypeWorking
+	}
+	set.FillVelocity()
+	mediaURLsJSON, _ := json.Marshal(set.MediaURLs)
+
+	_, err := tx.Exec(`
+		UPDATE completed_sets SET
+			set_number = $2, reps_completed = $3, weight_kg = $4, rpe_actual = $5,
+			video_id = $6, notes = $7, set_type = $8, media_urls = $9,
+			exercise_notes = $10, round_number = $11, mean_velocity_mps = $12,
+			peak_velocity_mps = $13, velocity_loss_pct = $14, rep_velocities = $15,
+			modified_at = NOW()
+		WHERE id = $1`,
+		set.ID, set.SetNumber, set.RepsCompleted, set.WeightKg, set.RPEActual,
+		set.VideoID, set.Notes, set.SetType, mediaURLsJSON, set.ExerciseNotes,
+		set.RoundNumber, set.MeanVelocityMps, set.PeakVelocityMps, set.VelocityLossPct,
+		marshalRepVelocities(set.RepVelocities),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update set: %w", err)
+	}
+
+	return nil
+}
+
+// recordSetRevision stores a correction. clock_timestamp() rather than NOW() keeps
+// several corrections made in one transaction, such as an offline sync, in order.
+func recordSetRevision(tx *sql.Tx, revision *models.SetRevision) error {
+	var beforeJSON, afterJSON []byte
+	if revision.Before != nil {
+		beforeJSON, _ = json.Marshal(revision.Before)
+	}
+	if revision.After != nil {
+		afterJSON, _ = json.Marshal(revision.After)
+	}
+
+	err := tx.QueryRow(`
+		INSERT INTO completed_set_revisions (set_id, session_id, exercise_id, action, changed_by,
+		                                     before, after, reverts_revision_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, clock_timestamp())
+		RETURNING id, created_at`,
+		revision.SetID, revision.SessionID, revision.ExerciseID, revision.Action, revision.ChangedBy,
+		beforeJSON, afterJSON, revision.RevertsRevisionID,
+	).Scan(&revision.ID, &revision.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record set revision: %w", err)
+	}
+
+	return nil
+}
+
+func scanSetRevision(scanner interface{ Scan(...interface{}) error }) (*models.SetRevision, error) {
+	var revision models.SetRevision
+	var beforeJSON, afterJSON []byte
+	err := scanner.Scan(
+		&revision.ID, &revision.SetID, &revision.SessionID, &revision.ExerciseID,
+		&revision.Action, &revision.ChangedBy, &beforeJSON, &afterJSON,
+		&revision.RevertsRevisionID, &revision.UndoneAt, &revision.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(beforeJSON) > 0 {
+		revision.Before = &models.CompletedSet{}
+		json.Unmarshal(beforeJSON, revision.Before)
+	}
+	if len(afterJSON) > 0 {
+		revision.After = &models.CompletedSet{}
+		json.Unmarshal(afterJSON, revision.After)
+	}
+
+	return &revision, nil
+}
@@ -0,0 +1,440 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// syncOutcome is the result of applying one mutation; err is reserved for
+// database failures, which abort the whole batch
+type syncOutcome struct {
+	status    string
+	reason    string
+	sessionID *uuid.UUID
+}
+
+func rejected(reason string) syncOutcome {
+	return syncOutcome{status: models.SyncStatusRejected, reason: reason}
+}
+
+// ApplySyncMutations applies an athlete's offline mutations in one transaction,
+// in client timestamp order. Mutations already processed return their recorded
+// result. A mutation loses to a server-side edit made after its client timestamp;
+// timestamps in the future are clamped to now so a fast device clock can't win
+// every conflict. It returns one result per mutation, in request order, and the
+// sessions the batch touched.
+func (r *ProgramRepository) ApplySyncMutations(athleteID uuid.UUID, mutations []models.SyncMutation) ([]models.SyncMutationResult, []uuid.UUID, error) {
+	order := make([]int, len(mutations))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return mutations[order[a]].ClientTimestamp.Before(mutations[order[b]].ClientTimestamp)
+	})
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	results := make([]models.SyncMutationResult, len(mutations))
+	touched := make(map[uuid.UUID]bool)
+	var sessionIDs []uuid.UUID
+
+	for _, i := range order {
+		mutation := mutations[i]
+		if mutation.ClientTimestamp.After(now) {
+			mutation.ClientTimestamp = now
+		}
+
+		result, err := applySyncMutation(tx, athleteID, mutation)
+		if err != nil {
+			return nil, nil, err
+		}
+		results[i] = result
+
+		if result.SessionID != nil && !touched[*result.SessionID] {
+			touched[*result.SessionID] = true
+			sessionIDs = append(sessionIDs, *result.SessionID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return results, sessionIDs, nil
+}
+
+func applySyncMutation(tx *sql.Tx, athleteID uuid.UUID, mutation models.SyncMutation) (models.SyncMutationResult, error) {
+	result := models.SyncMutationResult{MutationID: mutation.ID}
+
+	// Claim the mutation ID first; a concurrent sync of the same batch blocks here
+	// until this transaction finishes and then sees it as a duplicate
+	res, err := tx.Exec(`
+		INSERT INTO sync_mutations (id, athlete_id, mutation_type, client_timestamp, status)
+		VALUES ($1, $2, $3, $4, 'pending')
+		ON CONFLICT (id) DO NOTHING`,
+		mutation.ID, athleteID, mutation.Type, mutation.ClientTimestamp,
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to record sync mutation: %w", err)
+	}
+	claimed, err := res.RowsAffected()
+	if err != nil {
+		return result, fmt.Errorf("failed to record sync mutation: %w", err)
+	}
+
+	if claimed == 0 {
+		return recordedSyncResult(tx, athleteID, mutation.ID)
+	}
+
+	var outcome syncOutcome
+	switch mutation.Type {
+	case models.SyncSetUpsert:
+		outcome, err = syncUpsertSet(tx, athleteID, mutation)
+	case models.SyncSetDelete:
+		outcome, err = syncDeleteSet(tx, athleteID, mutation)
+	case models.SyncSessionComplete:
+		outcome, err = syncCompleteSession(tx, athleteID, mutation)
+	default:
+		outcome = rejected("unknown mutation type")
+	}
+	if err != nil {
+		return result, err
+	}
+
+	_, err = tx.Exec(`UPDATE sync_mutations SET status = $2, reason = $3, session_id = $4 WHERE id = $1`,
+		mutation.ID, outcome.status, nullString(outcome.reason), outcome.sessionID)
+	if err != nil {
+		return result, fmt.Errorf("failed to record sync mutation: %w", err)
+	}
+
+	result.Status = outcome.status
+	result.Reason = outcome.reason
+	result.SessionID = outcome.sessionID
+	return result, nil
+}
+
+func recordedSyncResult(tx *sql.Tx, athleteID, mutationID uuid.UUID) (models.SyncMutationResult, error) {
+	result := models.SyncMutationResult{MutationID: mutationID, Duplicate: true}
+
+	var owner uuid.UUID
+	var reason sql.NullString
+	err := tx.QueryRow(`SELECT athlete_id, status, reason, session_id FROM sync_mutations WHERE id = $1`, mutationID).
+		Scan(&owner, &result.Status, &reason, &result.SessionID)
+	if err != nil {
+		return result, fmt.Errorf("failed to get sync mutation: %w", err)
+	}
+
+	if owner != athleteID {
+		return models.SyncMutationResult{
+			MutationID: mutationID,
+			Status:     models.SyncStatusRejected,
+			Reason:     "mutation id already used",
+		}, nil
+	}
+
+	result.Reason = reason.String
+	return result, nil
+}
+
+func syncUpsertSet(tx *sql.Tx, athleteID uuid.UUID, mutation models.SyncMutation) (syncOutcome, error) {
+	set := mutation.Set
+	if set == nil {
+		return rejected("set is required"), nil
+	}
+	if set.SetType == "" {
+		set.SetType = models.SetTypeWorking
+	}
+	// Checked here rather than left to the enum insert so one bad set can't fail
+	// the whole batch
+	if !set.SetType.Valid() {
+		return rejected(fmt.Sprintf("unknown set_type %q", set.SetType)), nil
+	}
+
+	var exerciseID, sessionID, owner uuid.UUID
+	var modifiedAt time.Time
+	var sessionDeletedAt *time.Time
+	err := tx.QueryRow(`
+		SELECT cs.exercise_id, cs.modified_at, ts.id, ts.athlete_id, ts.deleted_at
+		FROM completed_sets cs
+		JOIN exercises e ON e.id = cs.exercise_id
+		JOIN training_sessions ts ON ts.id = e.session_id
+		WHERE cs.id = $1
+		FOR UPDATE OF cs`, set.ID,
+	).Scan(&exerciseID, &modifiedAt, &sessionID, &owner, &sessionDeletedAt)
+
+	mediaURLsJSON, _ := json.Marshal(set.MediaURLs)
+	velocity := models.CompletedSet{
+		MeanVelocityMps: set.MeanVelocityMps,
+		PeakVelocityMps: set.PeakVelocityMps,
+		VelocityLossPct: set.VelocityLossPct,
+		RepVelocities:   set.RepVelocities,
+	}
+	velocity.FillVelocity()
+	repVelocitiesJSON := marshalRepVelocities(velocity.RepVelocities)
+
+	if err == sql.ErrNoRows {
+		if set.ExerciseID == uuid.Nil {
+			return rejected("exercise_id is required"), nil
+		}
+
+		var deletedAt *time.Time
+		err := tx.QueryRow(`
+			SELECT ts.id, ts.athlete_id, ts.deleted_at
+			FROM exercises e
+			JOIN training_sessions ts ON ts.id = e.session_id
+			WHERE e.id = $1
+			FOR UPDATE OF ts`, set.ExerciseID,
+		).Scan(&sessionID, &owner, &deletedAt)
+		if err == sql.ErrNoRows || (err == nil && owner != athleteID) {
+			return rejected("exercise not found"), nil
+		}
+		if err != nil {
+			return syncOutcome{}, fmt.Errorf("failed to get exercise: %w", err)
+		}
+		if deletedAt != nil {
+			return syncOutcome{status: models.SyncStatusRejected, reason: "session was deleted", sessionID: &sessionID}, nil
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO completed_sets (id, exercise_id, set_number, reps_completed, weight_kg,
+			                           rpe_actual, video_id, notes, set_type, media_urls,
+			                           exercise_notes, mean_velocity_mps, peak_velocity_mps,
+			                           velocity_loss_pct, rep_velocities, completed_at, modified_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $16)`,
+			set.ID, set.ExerciseID, set.SetNumber, set.RepsCompleted, set.WeightKg,
+			set.RPEActual, set.VideoID, set.Notes, set.SetType, mediaURLsJSON,
+			set.ExerciseNotes, velocity.MeanVelocityMps, velocity.PeakVelocityMps,
+			velocity.VelocityLossPct, repVelocitiesJSON, mutation.ClientTimestamp,
+		)
+		if err != nil {
+			return syncOutcome{}, fmt.Errorf("failed to insert synced set: %w", err)
+		}
+
+		return syncOutcome{status: models.SyncStatusApplied, sessionID: &sessionID}, nil
+	}
+	if err != nil {
+		return syncOutcome{}, fmt.Errorf("failed to get set: %w", err)
+	}
+
+	if owner != athleteID {
+		return rejected("set not found"), nil
+	}
+	if sessionDeletedAt != nil {
+		return syncOutcome{status: models.SyncStatusRejected, reason: "session was deleted", sessionID: &sessionID}, nil
+	}
+	if set.ExerciseID != uuid.Nil && set.ExerciseID != exerciseID {
+		return syncOutcome{status: models.SyncStatusRejected, reason: "set cannot move to another exercise", sessionID: &sessionID}, nil
+	}
+	if modifiedAt.After(mutation.ClientTimestamp) {
+		return syncOutcome{status: models.SyncStatusConflict, reason: "set was changed on the server after this edit", sessionID: &sessionID}, nil
+	}
+
+	before, _, err := lockCompletedSet(tx, set.ID)
+	if err != nil {
+		return syncOutcome{}, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE completed_sets SET
+			set_number = $2, reps_completed = $3, weight_kg = $4, rpe_actual = $5,
+			video_id = $6, notes = $7, set_type = $8, media_urls = $9,
+			exercise_notes = $10, mean_velocity_mps = $11, peak_velocity_mps = $12,
+			velocity_loss_pct = $13, rep_velocities = $14, modified_at = $15
+		WHERE id = $1`,
+		set.ID, set.SetNumber, set.RepsCompleted, set.WeightKg, set.RPEActual,
+		set.VideoID, set.Notes, set.SetType, mediaURLsJSON, set.ExerciseNotes,
+		velocity.MeanVelocityMps, velocity.PeakVelocityMps, velocity.VelocityLossPct,
+		repVelocitiesJSON, mutation.ClientTimestamp,
+	)
+	if err != nil {
+		return syncOutcome{}, fmt.Errorf("failed to update synced set: %w", err)
+	}
+
+	after, _, err := lockCompletedSet(tx, set.ID)
+	if err != nil {
+		return syncOutcome{}, err
+	}
+
+	err = recordSetRevision(tx, &models.SetRevision{
+		SetID:      set.ID,
+		SessionID:  sessionID,
+		ExerciseID: exerciseID,
+		Action:     models.SetRevisionUpdated,
+		ChangedBy:  athleteID,
+		Before:     before,
+		After:      after,
+	})
+	if err != nil {
+		return syncOutcome{}, err
+	}
+
+	return syncOutcome{status: models.SyncStatusApplied, sessionID: &sessionID}, nil
+}
+
+func syncDeleteSet(tx *sql.Tx, athleteID uuid.UUID, mutation models.SyncMutation) (syncOutcome, error) {
+	if mutation.Set == nil {
+		return rejected("set is required"), nil
+	}
+
+	var sessionID, owner uuid.UUID
+	var modifiedAt time.Time
+	var sessionDeletedAt *time.Time
+	err := tx.QueryRow(`
+		SELECT cs.modified_at, ts.id, ts.athlete_id, ts.deleted_at
+		FROM completed_sets cs
+		JOIN exercises e ON e.id = cs.exercise_id
+		JOIN training_sessions ts ON ts.id = e.session_id
+		WHERE cs.id = $1
+		FOR UPDATE OF cs`, mutation.Set.ID,
+	).Scan(&modifiedAt, &sessionID, &owner, &sessionDeletedAt)
+	if err == sql.ErrNoRows {
+		// Already gone, e.g. created and deleted offline before it was ever synced
+		return syncOutcome{status: models.SyncStatusApplied}, nil
+	}
+	if err != nil {
+		return syncOutcome{}, fmt.Errorf("failed to get set: %w", err)
+	}
+
+	if owner != athleteID {
+		return rejected("set not found"), nil
+	}
+	if sessionDeletedAt != nil {
+		return syncOutcome{status: models.SyncStatusRejected, reason: "session was deleted", sessionID: &sessionID}, nil
+	}
+	if modifiedAt.After(mutation.ClientTimestamp) {
+		return syncOutcome{status: models.SyncStatusConflict, reason: "set was changed on the server after this delete", sessionID: &sessionID}, nil
+	}
+
+	before, _, err := lockCompletedSet(tx, mutation.Set.ID)
+	if err != nil {
+		return syncOutcome{}, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM completed_sets WHERE id = $1`, mutation.Set.ID); err != nil {
+		return syncOutcome{}, fmt.Errorf("failed to delete synced set: %w", err)
+	}
+
+	err = recordSetRevision(tx, &models.SetRevision{
+		SetID:      mutation.Set.ID,
+		SessionID:  sessionID,
+		ExerciseID: before.ExerciseID,
+		Action:     models.SetRevisionDeleted,
+		ChangedBy:  athleteID,
+		Before:     before,
+	})
+	if err != nil {
+		return syncOutcome{}, err
+	}
+
+	return syncOutcome{status: models.SyncStatusApplied, sessionID: &sessionID}, nil
+}
+
+func syncCompleteSession(tx *sql.Tx, athleteID uuid.UUID, mutation models.SyncMutation) (syncOutcome, error) {
+	session := mutation.Session
+	if session == nil {
+		return rejected("session is required"), nil
+	}
+
+	var owner uuid.UUID
+	var modifiedAt time.Time
+	var deletedAt *time.Time
+	err := tx.QueryRow(`
+		SELECT athlete_id, modified_at, deleted_at
+		FROM training_sessions
+		WHERE id = $1
+		FOR UPDATE`, session.ID,
+	).Scan(&owner, &modifiedAt, &deletedAt)
+	if err == sql.ErrNoRows || (err == nil && owner != athleteID) {
+		return rejected("session not found"), nil
+	}
+	if err != nil {
+		return syncOutcome{}, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	sessionID := session.ID
+	if deletedAt != nil {
+		return syncOutcome{status: models.SyncStatusRejected, reason: "session was deleted", sessionID: &sessionID}, nil
+	}
+	if modifiedAt.After(mutation.ClientTimestamp) {
+		return syncOutcome{status: models.SyncStatusConflict, reason: "session was changed on the server after this edit", sessionID: &sessionID}, nil
+	}
+
+	completedAt := mutation.ClientTimestamp
+	if session.CompletedAt != nil && !session.CompletedAt.After(mutation.ClientTimestamp) {
+		completedAt = *session.CompletedAt
+	}
+
+	_, err = tx.Exec(`
+		UPDATE training_sessions SET
+			completed_at = $2,
+			notes = COALESCE($3, notes),
+			rpe_rating = COALESCE($4, rpe_rating),
+			duration_minutes = COALESCE($5, duration_minutes),
+			modified_at = $6
+		WHERE id = $1`,
+		session.ID, completedAt, session.Notes, session.RPERating, session.DurationMins,
+		mutation.ClientTimestamp,
+	)
+	if err != nil {
+		return syncOutcome{}, fmt.Errorf("failed to complete synced session: %w", err)
+	}
+
+	return syncOutcome{status: models.SyncStatusApplied, sessionID: &sessionID}, nil
+}
+
+// GetTrainingSessionByID returns the session with its exercises and completed sets
+func (r *ProgramRepository) GetTrainingSessionByID(sessionID uuid.UUID) (*models.TrainingSession, error) {
+	query := `
+		SELECT id, program_id, athlete_id, week_number, day_number, session_name,
+		       scheduled_date, completed_at, notes, rpe_rating, duration_minutes,
+		       created_at, updated_at, deleted_at, deleted_reason
+		FROM training_sessions
+		WHERE id = $1`
+
+	session := &models.TrainingSession{}
+	err := r.db.QueryRow(query, sessionID).Scan(
+		&session.ID, &session.ProgramID, &session.AthleteID,
+		&session.WeekNumber, &session.DayNumber, &session.SessionName,
+		&session.ScheduledDate, &session.CompletedAt, &session.Notes,
+		&session.RPERating, &session.DurationMins,
+		&session.CreatedAt, &session.UpdatedAt, &session.DeletedAt, &session.DeletedReason,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	exercises, err := r.GetExercisesBySessionID(session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercises for session %s: %w", session.ID, err)
+	}
+	session.Exercises = exercises
+
+	groups, err := r.GetExerciseGroupsBySessionID(session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercise groups for session %s: %w", session.ID, err)
+	}
+	session.Groups = groups
+
+	return session, nil
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
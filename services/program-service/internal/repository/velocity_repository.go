@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+// GetVelocitySets returns the athlete's sets logged with bar speed, oldest
+// first. Sets of unfinished sessions are included so a day's warm-ups can be
+// read before the session ends.
+func (r *ProgramRepository) GetVelocitySets(athleteID uuid.UUID, startDate, endDate time.Time, liftType *models.LiftType, exerciseName *string) ([]models.VelocitySetData, error) {
+	query := `
+		SELECT cs.id, ts.id, COALESCE(ts.completed_at, cs.completed_at) AS performed_at,
+		       e.exercise_name, e.lift_type, cs.set_type, cs.weight_kg, cs.reps_completed,
+		       cs.rpe_actual, cs.mean_velocity_mps, cs.peak_velocity_mps, cs.velocity_loss_pct,
+		       cs.rep_velocities
+		FROM completed_sets cs
+		JOIN exercises e ON cs.exercise_id = e.id
+		JOIN training_sessions ts ON e.session_id = ts.id
+		WHERE ts.athlete_id = $1
+		  AND COALESCE(ts.completed_at, cs.completed_at) BETWEEN $2 AND $3
+		  AND ts.deleted_at IS NULL
+		  AND cs.mean_velocity_mps IS NOT NULL
+		  AND cs.weight_kg > 0
+		  AND ($4::lift_type IS NULL OR e.lift_type = $4)
+		  AND ($5::text IS NULL OR LOWER(e.exercise_name) = LOWER($5))
+		ORDER BY performed_at, cs.set_number`
+
+	rows, err := r.db.Query(query, athleteID, startDate, endDate, liftType, exerciseName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get velocity data: %w", err)
+	}
+	defer rows.Close()
+
+	sets := []models.VelocitySetData{}
+	for rows.Next() {
+		var set models.VelocitySetData
+		var repVelocitiesJSON []byte
+		err := rows.Scan(
+			&set.SetID, &set.SessionID, &set.Date,
+			&set.ExerciseName, &set.LiftType, &set.SetType, &set.WeightKg, &set.RepsCompleted,
+			&set.RPE, &set.MeanVelocityMps, &set.PeakVelocityMps, &set.VelocityLossPct,
+			&repVelocitiesJSON,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan velocity data: %w", err)
+		}
+
+		if len(repVelocitiesJSON) > 0 {
+			json.Unmarshal(repVelocitiesJSON, &set.RepVelocities)
+		}
+
+		sets = append(sets, set)
+	}
+
+	return sets, nil
+}
+
+// ImportSetVelocities attaches imported bar speed to an exercise's sets. A set
+// already logged under the same number keeps its load and reps and gets the
+// velocity readings, recorded as a correction so it can be undone; any other
+// set is logged as new.
+func (r *ProgramRepository) ImportSetVelocities(exerciseID uuid.UUID, sets []models.CompletedSet, changedBy uuid.UUID) ([]models.CompletedSet, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var sessionID uuid.UUID
+	if err := tx.QueryRow(`SELECT session_id FROM exercises WHERE id = $1 FOR UPDATE`, exerciseID).Scan(&sessionID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("exercise not found")
+		}
+		return nil, fmt.Errorf("failed to lock exercise: %w", err)
+	}
+
+	imported := make([]models.CompletedSet, 0, len(sets))
+	for _, set := range sets {
+		set.ExerciseID = exerciseID
+
+		var existingID uuid.UUID
+		err := tx.QueryRow(`SELECT id FROM completed_sets WHERE exercise_id = $1 AND set_number = $2`,
+			exerciseID, set.SetNumber).Scan(&existingID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to find set: %w", err)
+		}
+
+		if err == sql.ErrNoRows {
+			if err := insertCompletedSet(tx, &set); err != nil {
+				return nil, err
+			}
+			imported = append(imported, set)
+			continue
+		}
+
+		before, _, err := lockCompletedSet(tx, existingID)
+		if err != nil {
+			return nil, err
+		}
+
+		after := *before
+		after.MeanVelocityMps = set.MeanVelocityMps
+		after.PeakVelocityMps = set.PeakVelocityMps
+		after.VelocityLossPct = set.VelocityLossPct
+		after.RepVelocities = set.RepVelocities
+		if err := writeCompletedSet(tx, &after); err != nil {
+			return nil, err
+		}
+
+		err = recordSetRevision(tx, &models.SetRevision{
+			SetID:      after.ID,
+			SessionID:  sessionID,
+			ExerciseID: exerciseID,
+			Action:     models.SetRevisionUpdated,
+			ChangedBy:  changedBy,
+			Before:     before,
+			After:      &after,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		imported = append(imported, after)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return imported, nil
+}
+
+func insertCompletedSet(tx *sql.Tx, set *models.CompletedSet) error {
+	if set.SetType == "" {
+		set.SetType = models.SetTypeWorking
+	}
+	set.FillVelocity()
+	mediaURLsJSON, _ := json.Marshal(set.MediaURLs)
+
+	err := tx.QueryRow(`
+		INSERT INTO completed_sets (exercise_id, set_number, reps_completed, weight_kg,
+		                           rpe_actual, video_id, notes, set_type, media_urls, exercise_notes,
+		                           round_number, mean_velocity_mps, peak_velocity_mps, velocity_loss_pct,
+		                           rep_velocities)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id, completed_at`,
+		set.ExerciseID, set.SetNumber, set.RepsCompleted, set.WeightKg,
+		set.RPEActual, set.VideoID, set.Notes, set.SetType, mediaURLsJSON, set.ExerciseNotes,
+		set.RoundNumber, set.MeanVelocityMps, set.PeakVelocityMps, set.VelocityLossPct,
+		marshalRepVelocities(set.RepVelocities),
+	).Scan(&set.ID, &set.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to log set: %w", err)
+	}
+
+	return nil
+}
+
+// marshalRepVelocities stores no readings as an empty array rather than null
+func marshalRepVelocities(reps []models.RepVelocity) []byte {
+	if len(reps) == 0 {
+		return []byte("[]")
+	}
+	repsJSON, _ := json.Marshal(reps)
+	return repsJSON
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/powerlifting-coach-app/program-service/internal/repository"
+)
+
+// AIUsageService enforces the per-user daily and monthly AI token quotas.
+// Windows are calendar days and months in UTC.
+type AIUsageService struct {
+	programRepo  *repository.ProgramRepository
+	dailyLimit   int
+	monthlyLimit int
+}
+
+func NewAIUsageService(programRepo *repository.ProgramRepository, dailyLimit, monthlyLimit int) *AIUsageService {
+	return &AIUsageService{
+		programRepo:  programRepo,
+		dailyLimit:   dailyLimit,
+		monthlyLimit: monthlyLimit,
+	}
+}
+
+// QuotaStatus reports the user's daily and monthly positions
+func (s *AIUsageService) QuotaStatus(userID uuid.UUID) ([]models.AIQuotaStatus, error) {
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	daily, err := s.status(userID, "daily", s.dailyLimit, dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	monthly, err := s.status(userID, "monthly", s.monthlyLimit, monthStart, monthStart.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	return []models.AIQuotaStatus{daily, monthly}, nil
+}
+
+// CheckQuota returns the first exhausted quota, or nil when the user may make
+// another call. Quotas are checked before a call, so one call may overshoot.
+func (s *AIUsageService) CheckQuota(userID uuid.UUID) (*models.AIQuotaStatus, error) {
+	if s.dailyLimit <= 0 && s.monthlyLimit <= 0 {
+		return nil, nil
+	}
+
+	statuses, err := s.QuotaStatus(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range statuses {
+		if statuses[i].Exceeded() {
+			return &statuses[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *AIUsageService) status(userID uuid.UUID, period string, limit int, start, resetsAt time.Time) (models.AIQuotaStatus, error) {
+	status := models.AIQuotaStatus{
+		Period:   period,
+		Limit:    limit,
+		ResetsAt: resetsAt,
+	}
+
+	used, err := s.programRepo.GetAITokensUsedSince(userID, start)
+	if err != nil {
+		return status, err
+	}
+	status.Used = used
+
+	if limit > 0 && used < limit {
+		status.Remaining = limit - used
+	}
+
+	return status, nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/powerlifting-coach-app/program-service/internal/ai"
+)
+
+// maxVideoBytes bounds how much of a video is downloaded for analysis
+const maxVideoBytes = 200 << 20
+
+// frameWidth is the width frames are scaled to; enough to judge positions
+// without spending image tokens on detail the model doesn't need
+const frameWidth = 640
+
+// FrameExtractor pulls still frames out of a video with ffmpeg
+type FrameExtractor struct {
+	ffmpegPath  string
+	ffprobePath string
+	httpClient  *http.Client
+}
+
+func NewFrameExtractor(ffmpegPath, ffprobePath string) *FrameExtractor {
+	return &FrameExtractor{
+		ffmpegPath:  ffmpegPath,
+		ffprobePath: ffprobePath,
+		httpClient:  &http.Client{},
+	}
+}
+
+// ExtractFrames downloads the video and samples count frames evenly across it,
+// trimming the first and last few percent where the lifter is usually walking in
+// or racking. The model picks the setup, bottom and lockout frames among them.
+func (e *FrameExtractor) ExtractFrames(ctx context.Context, videoURL string, count int) ([]ai.Frame, float64, error) {
+	path, err := e.download(ctx, videoURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.Remove(path)
+
+	duration, err := e.duration(ctx, path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	frames := make([]ai.Frame, 0, count)
+	for _, ts := range sampleTimestamps(duration, count) {
+		image, err := e.frameAt(ctx, path, ts)
+		if err != nil {
+			return nil, 0, err
+		}
+		frames = append(frames, ai.Frame{TimestampSeconds: ts, JPEG: image})
+	}
+
+	return frames, duration, nil
+}
+
+func (e *FrameExtractor) download(ctx context.Context, videoURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, videoURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create download request: %w", err)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download video: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download video: status %d", resp.StatusCode)
+	}
+
+	file, err := os.CreateTemp("", "form-analysis-*.mp4")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, io.LimitReader(resp.Body, maxVideoBytes+1))
+	if err == nil && written > maxVideoBytes {
+		err = fmt.Errorf("video is larger than %d bytes", maxVideoBytes)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to download video: %w", err)
+	}
+
+	return file.Name(), nil
+}
+
+func (e *FrameExtractor) duration(ctx context.Context, path string) (float64, error) {
+	cmd := exec.CommandContext(ctx, e.ffprobePath,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe duration failed: %w", err)
+	}
+
+	duration, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("failed to parse duration %q", strings.TrimSpace(string(output)))
+	}
+
+	return duration, nil
+}
+
+func (e *FrameExtractor) frameAt(ctx context.Context, path string, ts float64) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, e.ffmpegPath,
+		"-v", "error",
+		"-ss", strconv.FormatFloat(ts, 'f', 3, 64),
+		"-i", path,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:-2", frameWidth),
+		"-q:v", "4",
+		"-f", "image2",
+		"-c:v", "mjpeg",
+		"pipe:1",
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg frame extraction failed: %w, stderr: %s", err, stderr.String())
+	}
+	if stdout.Len() == 0 {
+		return nil, fmt.Errorf("ffmpeg produced no frame at %.2fs", ts)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// sampleTimestamps spreads count timestamps over the middle 90% of the video
+func sampleTimestamps(duration float64, count int) []float64 {
+	if count < 1 {
+		count = 1
+	}
+
+	start := duration * 0.05
+	span := duration * 0.9
+	timestamps := make([]float64, count)
+	for i := range timestamps {
+		if count == 1 {
+			timestamps[i] = duration / 2
+			continue
+		}
+		timestamps[i] = start + span*float64(i)/float64(count-1)
+	}
+	return timestamps
+}
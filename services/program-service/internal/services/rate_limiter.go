@@ -0,0 +1,66 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is an in-memory fixed-window limiter keyed by user. Counts are per
+// instance, which is enough to stop a single client hammering an endpoint.
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewRateLimiter allows limit requests per key in each window; a limit of 0
+// disables limiting
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*rateWindow),
+	}
+}
+
+// Allow counts a request for key. When the limit is reached it returns false and
+// how long until the window resets.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		if len(l.windows) > 10000 {
+			l.sweep(now)
+		}
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+
+	w.count++
+	return true, 0
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,6 +23,13 @@ func NewWorkoutGenerator(programRepo *repository.ProgramRepository) *WorkoutGene
 
 // GenerateWorkoutsFromProgram creates training sessions and exercises from approved program data
 func (wg *WorkoutGenerator) GenerateWorkoutsFromProgram(program *models.Program) error {
+	return wg.GenerateWorkoutsWithMaxes(program, nil)
+}
+
+// GenerateWorkoutsWithMaxes creates the program's sessions like
+// GenerateWorkoutsFromProgram, also turning percentage targets into loads from
+// the athlete's best lifts
+func (wg *WorkoutGenerator) GenerateWorkoutsWithMaxes(program *models.Program, maxes *models.BestLifts) error {
 	if program.ProgramData == nil {
 		return fmt.Errorf("program data is nil")
 	}
@@ -78,7 +86,7 @@ func (wg *WorkoutGenerator) GenerateWorkoutsFromProgram(program *models.Program)
 				continue
 			}
 
-			if err := wg.createTrainingSession(program, int(weekNumber), workout); err != nil {
+			if err := wg.createTrainingSession(program, int(weekNumber), workout, maxes); err != nil {
 				log.Error().
 					Err(err).
 					Int("week", int(weekNumber)).
@@ -100,6 +108,7 @@ func (wg *WorkoutGenerator) createTrainingSession(
 	program *models.Program,
 	weekNumber int,
 	workout map[string]interface{},
+	maxes *models.BestLifts,
 ) error {
 	// Extract workout details
 	dayNumber, ok := workout["day"].(float64)
@@ -142,6 +151,9 @@ func (wg *WorkoutGenerator) createTrainingSession(
 		return fmt.Errorf("exercises is not an array")
 	}
 
+	groups := wg.createExerciseGroups(session.ID, workout)
+	groupPositions := make(map[string]int)
+
 	for idx, exerciseRaw := range exercises {
 		exerciseData, ok := exerciseRaw.(map[string]interface{})
 		if !ok {
@@ -149,7 +161,18 @@ func (wg *WorkoutGenerator) createTrainingSession(
 			continue
 		}
 
-		if err := wg.createExercise(session.ID, idx+1, exerciseData); err != nil {
+		var group *models.ExerciseGroup
+		var groupPosition int
+		if label, _ := exerciseData["group"].(string); label != "" {
+			if group = groups[label]; group != nil {
+				groupPositions[label]++
+				groupPosition = groupPositions[label]
+			} else {
+				log.Warn().Str("group", label).Str("exercise", fmt.Sprint(exerciseData["name"])).Msg("Exercise references an unknown group")
+			}
+		}
+
+		if err := wg.createExercise(session.ID, idx+1, exerciseData, group, groupPosition, maxes); err != nil {
 			log.Error().
 				Err(err).
 				Str("session_id", session.ID.String()).
@@ -163,10 +186,77 @@ func (wg *WorkoutGenerator) createTrainingSession(
 	return nil
 }
 
+// createExerciseGroups creates the workout's supersets, circuits, EMOMs and
+// clusters, keyed by label
+func (wg *WorkoutGenerator) createExerciseGroups(sessionID uuid.UUID, workout map[string]interface{}) map[string]*models.ExerciseGroup {
+	groups := make(map[string]*models.ExerciseGroup)
+
+	groupsRaw, _ := workout["groups"].([]interface{})
+	for idx, groupRaw := range groupsRaw {
+		groupData, ok := groupRaw.(map[string]interface{})
+		if !ok {
+			log.Warn().Int("index", idx).Msg("Invalid group structure, skipping")
+			continue
+		}
+
+		label, _ := groupData["label"].(string)
+		if label == "" {
+			log.Warn().Int("index", idx).Msg("Group without a label, skipping")
+			continue
+		}
+
+		groupType, _ := groupData["type"].(string)
+		if groupType == "" {
+			groupType = string(models.GroupTypeSuperset)
+		}
+
+		rounds, _ := groupData["rounds"].(float64)
+		if rounds < 1 {
+			rounds = 1
+		}
+
+		group := &models.ExerciseGroup{
+			SessionID:       sessionID,
+			Label:           label,
+			GroupType:       models.ExerciseGroupType(groupType),
+			Rounds:          int(rounds),
+			RestSeconds:     positiveInt(groupData["restSeconds"]),
+			IntervalSeconds: positiveInt(groupData["intervalSeconds"]),
+		}
+		if notes, _ := groupData["notes"].(string); notes != "" {
+			group.Notes = &notes
+		}
+
+		if err := wg.programRepo.CreateExerciseGroup(group); err != nil {
+			log.Error().
+				Err(err).
+				Str("session_id", sessionID.String()).
+				Str("group", label).
+				Msg("Failed to create exercise group")
+			continue
+		}
+		groups[label] = group
+	}
+
+	return groups
+}
+
+func positiveInt(v interface{}) *int {
+	f, ok := v.(float64)
+	if !ok || f <= 0 {
+		return nil
+	}
+	i := int(f)
+	return &i
+}
+
 func (wg *WorkoutGenerator) createExercise(
 	sessionID uuid.UUID,
 	order int,
 	exerciseData map[string]interface{},
+	group *models.ExerciseGroup,
+	groupPosition int,
+	maxes *models.BestLifts,
 ) error {
 	// Extract exercise details
 	name, ok := exerciseData["name"].(string)
@@ -232,20 +322,34 @@ func (wg *WorkoutGenerator) createExercise(
 		restSeconds = &restInt
 	}
 
+	// Velocity loss cutoff ending each set
+	var velocityLossCutoff *float64
+	if cutoff, ok := exerciseData["velocityLossCutoff"].(float64); ok && cutoff > 0 && cutoff < 100 {
+		velocityLossCutoff = &cutoff
+	}
+
 	// Create exercise
 	exercise := &models.Exercise{
-		SessionID:        sessionID,
-		ExerciseOrder:    order,
-		LiftType:         models.LiftType(liftType),
-		ExerciseName:     name,
-		TargetSets:       int(sets),
-		TargetReps:       reps,
-		TargetWeightKg:   nil, // Will be calculated based on athlete's maxes
-		TargetRPE:        targetRPE,
-		TargetPercentage: targetPercentage,
-		RestSeconds:      restSeconds,
-		Notes:            notesPtr,
-		Tempo:            tempoPtr,
+		SessionID:             sessionID,
+		ExerciseOrder:         order,
+		LiftType:              models.LiftType(liftType),
+		ExerciseName:          name,
+		TargetSets:            int(sets),
+		TargetReps:            reps,
+		TargetWeightKg:        targetWeight(models.LiftType(liftType), targetPercentage, maxes),
+		TargetRPE:             targetRPE,
+		TargetPercentage:      targetPercentage,
+		RestSeconds:           restSeconds,
+		Notes:                 notesPtr,
+		Tempo:                 tempoPtr,
+		VelocityLossCutoffPct: velocityLossCutoff,
+	}
+
+	// Grouped exercises give their sets per round
+	if group != nil {
+		exercise.TargetSets = int(sets) * group.Rounds
+		exercise.GroupID = &group.ID
+		exercise.GroupPosition = &groupPosition
 	}
 
 	if err := wg.programRepo.CreateExercise(exercise); err != nil {
@@ -255,6 +359,30 @@ func (wg *WorkoutGenerator) createExercise(
 	return nil
 }
 
+// targetWeight is the percentage of the athlete's best lift, rounded to the
+// nearest 2.5 kg, or nil without a percentage or a best for the lift
+func targetWeight(liftType models.LiftType, percentage *float64, maxes *models.BestLifts) *float64 {
+	if percentage == nil || maxes == nil {
+		return nil
+	}
+
+	var best float64
+	switch liftType {
+	case models.LiftTypeSquat:
+		best = maxes.SquatKg
+	case models.LiftTypeBench:
+		best = maxes.BenchKg
+	case models.LiftTypeDeadlift:
+		best = maxes.DeadliftKg
+	}
+	if best <= 0 {
+		return nil
+	}
+
+	weight := math.Round(best**percentage/100/2.5) * 2.5
+	return &weight
+}
+
 func (wg *WorkoutGenerator) calculateScheduledDate(startDate time.Time, weekNumber int, dayNumber int) time.Time {
 	// Week 1 starts on the start date
 	// Calculate days from start
@@ -0,0 +1,297 @@
+// Package velocity reads bar-speed tracker exports and fits load-velocity
+// profiles from tracked sets.
+package velocity
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+const kgPerLb = 0.45359237
+
+// ImportedSet is one set read from a tracker export. Reps is empty when the
+// export has a row per set rather than per rep.
+type ImportedSet struct {
+	DeviceSetNumber int      `json:"device_set_number"`
+	Exercise        string   `json:"exercise,omitempty"`
+	WeightKg        *float64 `json:"weight_kg"`
+	MeanVelocityMps *float64 `json:"mean_velocity_mps"`
+	PeakVelocityMps *float64 `json:"peak_velocity_mps"`
+	// RepCount is the export's rep count for a set-level row
+	RepCount *int                 `json:"rep_count,omitempty"`
+	Reps     []models.RepVelocity `json:"reps"`
+}
+
+// Header names as RepOne, Vitruve, GymAware, Metric and most spreadsheet exports
+// write them, once lowercased with units and punctuation stripped
+var columnAliases = map[string][]string{
+	"set":      {"set", "setnumber", "setno", "setindex", "setnum"},
+	"rep":      {"rep", "repnumber", "repno", "repindex", "repnum"},
+	"reps":     {"reps", "repcount", "totalreps"},
+	"exercise": {"exercise", "exercisename", "movement", "lift"},
+	"load":     {"load", "weight", "mass", "barweight", "loadweight"},
+	"unit":     {"unit", "units", "weightunit", "loadunit"},
+	"mean": {
+		"meanvelocity", "avgvelocity", "averagevelocity", "meanconcentricvelocity",
+		"avgconcentricvelocity", "averageconcentricvelocity", "mcv", "mv", "velocity",
+	},
+	"peak": {"peakvelocity", "maxvelocity", "peakconcentricvelocity", "maxconcentricvelocity", "pv"},
+}
+
+type csvColumns struct {
+	index         map[string]int
+	loadInLb      bool
+	velocityScale map[string]float64
+}
+
+// ParseCSV reads a tracker export. Rows are reps when the export has a rep
+// column and sets otherwise; without a set column a new set starts whenever
+// the rep count starts over. Loads in pounds and velocities in cm/s are
+// converted to kg and m/s.
+func ParseCSV(r io.Reader) ([]ImportedSet, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := mapColumns(header)
+	if _, ok := columns.index["mean"]; !ok {
+		return nil, errors.New("no mean velocity column found")
+	}
+
+	_, perRep := columns.index["rep"]
+
+	var sets []ImportedSet
+	var current *ImportedSet
+	lastRep := 0
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if blankRecord(record) {
+			continue
+		}
+
+		mean, err := columns.float(record, "mean")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if mean == nil {
+			// Trackers write a row for reps they failed to read
+			continue
+		}
+		peak, err := columns.float(record, "peak")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		load, err := columns.load(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		setNumber, err := columns.int(record, "set")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rep, err := columns.int(record, "rep")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		exercise := columns.text(record, "exercise")
+
+		if !perRep {
+			repCount, err := columns.int(record, "reps")
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			sets = append(sets, ImportedSet{
+				DeviceSetNumber: valueOr(setNumber, len(sets)+1),
+				Exercise:        exercise,
+				WeightKg:        load,
+				MeanVelocityMps: mean,
+				PeakVelocityMps: peak,
+				RepCount:        repCount,
+			})
+			continue
+		}
+
+		repNumber := valueOr(rep, lastRep+1)
+		newSet := current == nil || current.Exercise != exercise
+		if setNumber != nil {
+			newSet = newSet || *setNumber != current.DeviceSetNumber
+		} else {
+			newSet = newSet || repNumber <= lastRep
+		}
+		if newSet {
+			sets = append(sets, ImportedSet{
+				DeviceSetNumber: valueOr(setNumber, len(sets)+1),
+				Exercise:        exercise,
+				WeightKg:        load,
+			})
+			current = &sets[len(sets)-1]
+		}
+		if current.WeightKg == nil {
+			current.WeightKg = load
+		}
+
+		current.Reps = append(current.Reps, models.RepVelocity{
+			Rep:          repNumber,
+			MeanVelocity: *mean,
+			PeakVelocity: peak,
+		})
+		lastRep = repNumber
+	}
+
+	if len(sets) == 0 {
+		return nil, errors.New("no velocity readings found")
+	}
+
+	return sets, nil
+}
+
+// Exercises lists the distinct exercise names in an export, in file order
+func Exercises(sets []ImportedSet) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, set := range sets {
+		key := strings.ToLower(set.Exercise)
+		if set.Exercise == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, set.Exercise)
+	}
+	return names
+}
+
+func mapColumns(header []string) csvColumns {
+	columns := csvColumns{index: make(map[string]int), velocityScale: make(map[string]float64)}
+	for i, raw := range header {
+		name := normalizeHeader(raw)
+		lower := strings.ToLower(raw)
+		for column, aliases := range columnAliases {
+			if _, taken := columns.index[column]; taken {
+				continue
+			}
+			for _, alias := range aliases {
+				if name != alias {
+					continue
+				}
+				columns.index[column] = i
+				switch column {
+				case "load":
+					columns.loadInLb = strings.Contains(lower, "lb")
+				case "mean", "peak":
+					columns.velocityScale[column] = 1
+					if strings.Contains(lower, "cm/s") {
+						columns.velocityScale[column] = 0.01
+					}
+				}
+			}
+		}
+	}
+	return columns
+}
+
+// normalizeHeader drops a trailing unit such as "(m/s)" or "[kg]" and
+// everything but letters and digits
+func normalizeHeader(raw string) string {
+	name := strings.ToLower(raw)
+	if i := strings.IndexAny(name, "(["); i > 0 {
+		name = name[:i]
+	}
+	for _, unit := range []string{"kg", "lbs", "lb", "m/s", "cm/s"} {
+		name = strings.TrimSuffix(strings.TrimSpace(name), " "+unit)
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (cols csvColumns) text(record []string, column string) string {
+	i, ok := cols.index[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func (cols csvColumns) float(record []string, column string) (*float64, error) {
+	raw := cols.text(record, column)
+	if raw == "" || raw == "-" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", "."), 64)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("invalid %s value %q", column, raw)
+	}
+	if factor, ok := cols.velocityScale[column]; ok {
+		value = math.Round(value*factor*1000) / 1000
+	}
+	return &value, nil
+}
+
+func (cols csvColumns) int(record []string, column string) (*int, error) {
+	raw := cols.text(record, column)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return nil, fmt.Errorf("invalid %s number %q", column, raw)
+	}
+	return &value, nil
+}
+
+func (cols csvColumns) load(record []string) (*float64, error) {
+	load, err := cols.float(record, "load")
+	if err != nil || load == nil {
+		return load, err
+	}
+	unit := strings.ToLower(cols.text(record, "unit"))
+	if cols.loadInLb || strings.HasPrefix(unit, "lb") {
+		kg := math.Round(*load*kgPerLb*100) / 100
+		return &kg, nil
+	}
+	return load, nil
+}
+
+func blankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func valueOr(value *int, fallback int) int {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
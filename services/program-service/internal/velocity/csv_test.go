@@ -0,0 +1,126 @@
+package velocity
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+func float(v float64) *float64 { return &v }
+
+func integer(v int) *int { return &v }
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []ImportedSet
+		wantErr string
+	}{
+		{
+			name: "per-rep rows with a set column",
+			csv: "Set,Rep,Exercise,Load (kg),Mean Velocity (m/s),Peak Velocity (m/s)\n" +
+				"1,1,Squat,100,0.62,0.9\n" +
+				"1,2,Squat,100,0.58,\n" +
+				"2,1,Squat,120,0.45,0.7\n",
+			want: []ImportedSet{
+				{DeviceSetNumber: 1, Exercise: "Squat", WeightKg: float(100), Reps: []models.RepVelocity{
+					{Rep: 1, MeanVelocity: 0.62, PeakVelocity: float(0.9)},
+					{Rep: 2, MeanVelocity: 0.58},
+				}},
+				{DeviceSetNumber: 2, Exercise: "Squat", WeightKg: float(120), Reps: []models.RepVelocity{
+					{Rep: 1, MeanVelocity: 0.45, PeakVelocity: float(0.7)},
+				}},
+			},
+		},
+		{
+			name: "per-rep rows start a new set when the rep count starts over",
+			csv: "Rep,Load,MV\n" +
+				"1,100,0.6\n" +
+				"2,100,0.55\n" +
+				"1,110,0.5\n",
+			want: []ImportedSet{
+				{DeviceSetNumber: 1, WeightKg: float(100), Reps: []models.RepVelocity{
+					{Rep: 1, MeanVelocity: 0.6},
+					{Rep: 2, MeanVelocity: 0.55},
+				}},
+				{DeviceSetNumber: 2, WeightKg: float(110), Reps: []models.RepVelocity{
+					{Rep: 1, MeanVelocity: 0.5},
+				}},
+			},
+		},
+		{
+			name: "per-set rows in lb and cm/s",
+			csv: "Set,Reps,Weight (lb),Avg Velocity (cm/s)\n" +
+				"1,5,225,55\n" +
+				"2,3,315,41.5\n",
+			want: []ImportedSet{
+				{DeviceSetNumber: 1, WeightKg: float(102.06), MeanVelocityMps: float(0.55), RepCount: integer(5)},
+				{DeviceSetNumber: 2, WeightKg: float(142.88), MeanVelocityMps: float(0.415), RepCount: integer(3)},
+			},
+		},
+		{
+			name: "unit column and unread rows",
+			csv: "Load,Unit,Velocity\n" +
+				"100,lbs,0.5\n" +
+				"100,lbs,-\n" +
+				"\n" +
+				"60,kg,\"0,8\"\n",
+			want: []ImportedSet{
+				{DeviceSetNumber: 1, WeightKg: float(45.36), MeanVelocityMps: float(0.5)},
+				{DeviceSetNumber: 2, WeightKg: float(60), MeanVelocityMps: float(0.8)},
+			},
+		},
+		{
+			name:    "missing mean velocity column",
+			csv:     "Set,Rep,Load,Peak Velocity\n1,1,100,0.9\n",
+			wantErr: "no mean velocity column found",
+		},
+		{
+			name:    "empty file",
+			csv:     "",
+			wantErr: "file is empty",
+		},
+		{
+			name:    "no readings",
+			csv:     "Set,Mean Velocity\n1,-\n",
+			wantErr: "no velocity readings found",
+		},
+		{
+			name:    "invalid value",
+			csv:     "Set,Mean Velocity\n1,fast\n",
+			wantErr: `line 2: invalid mean value "fast"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCSV(strings.NewReader(tt.csv))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCSV: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("ParseCSV = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestExercises(t *testing.T) {
+	sets := []ImportedSet{{Exercise: "Squat"}, {Exercise: ""}, {Exercise: "Bench"}, {Exercise: "squat"}}
+
+	if got := Exercises(sets); !reflect.DeepEqual(got, []string{"Squat", "Bench"}) {
+		t.Errorf("Exercises = %v, want [Squat Bench]", got)
+	}
+}
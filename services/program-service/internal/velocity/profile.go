@@ -0,0 +1,221 @@
+package velocity
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+	"github.com/powerlifting-coach-app/program-service/internal/numeric"
+)
+
+// Default minimum velocity thresholds in m/s: the speed of a true 1RM. These are
+// typical values from the research literature; an athlete's own max singles
+// replace them once there are any.
+var defaultMinVelocities = map[models.LiftType]float64{
+	models.LiftTypeSquat:    0.30,
+	models.LiftTypeBench:    0.17,
+	models.LiftTypeDeadlift: 0.15,
+}
+
+// Singles at or above this RPE count as maximal when working out an athlete's
+// own minimum velocity threshold
+const maxEffortRPE = 9.5
+
+// Point is one tracked set: its load and the speed of its fastest rep
+type Point struct {
+	Date        time.Time
+	WeightKg    float64
+	VelocityMps float64
+	Reps        int
+	RPE         *float64
+}
+
+// SetPoint is the set's fastest rep, which best reflects what the load took out
+// of the athlete; the set mean is used when there are no per-rep readings
+func SetPoint(set models.VelocitySetData) Point {
+	velocity := set.MeanVelocityMps
+	for _, rep := range set.RepVelocities {
+		if rep.MeanVelocity > velocity {
+			velocity = rep.MeanVelocity
+		}
+	}
+	return Point{
+		Date:        set.Date,
+		WeightKg:    set.WeightKg,
+		VelocityMps: velocity,
+		Reps:        set.RepsCompleted,
+		RPE:         set.RPE,
+	}
+}
+
+// MinVelocity picks the velocity a 1RM is expected to move at: the given
+// override, else the athlete's maximal singles, else the lift's default. It
+// returns nil when none of those are available.
+func MinVelocity(liftType models.LiftType, points []Point, override *float64) (*float64, string) {
+	if override != nil {
+		return override, "request"
+	}
+
+	var total float64
+	var count int
+	for _, point := range points {
+		if point.Reps == 1 && point.RPE != nil && *point.RPE >= maxEffortRPE {
+			total += point.VelocityMps
+			count++
+		}
+	}
+	if count > 0 {
+		mean := numeric.Round(total/float64(count), 3)
+		return &mean, "athlete"
+	}
+
+	if value, ok := defaultMinVelocities[liftType]; ok {
+		return &value, "default"
+	}
+	return nil, ""
+}
+
+// FitProfile fits velocity = intercept + slope * load by least squares and
+// estimates the 1RM as the load at minVelocity. The daily estimates keep the
+// profile's slope and refit the intercept to each day's sets, so a handful of
+// warm-ups is enough to read the day's readiness.
+func FitProfile(exerciseName string, liftType models.LiftType, points []Point, minVelocity *float64, source string) models.LoadVelocityProfile {
+	profile := models.LoadVelocityProfile{
+		ExerciseName:      exerciseName,
+		LiftType:          liftType,
+		Sets:              len(points),
+		MinVelocityMps:    minVelocity,
+		MinVelocitySource: source,
+		Daily:             []models.DailyVelocityEstimate{},
+	}
+	if len(points) < 2 {
+		return profile
+	}
+
+	var sumX, sumY float64
+	for _, point := range points {
+		sumX += point.WeightKg
+		sumY += point.VelocityMps
+	}
+	n := float64(len(points))
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy, syy float64
+	for _, point := range points {
+		dx, dy := point.WeightKg-meanX, point.VelocityMps-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	// Every set at one load, or faster at heavier loads: nothing to extrapolate
+	if sxx == 0 || sxy >= 0 {
+		return profile
+	}
+
+	slope := sxy / sxx
+	intercept := meanY - slope*meanX
+	profile.SlopeMpsPerKg = numeric.Ptr(numeric.Round(slope, 5))
+	profile.InterceptMps = numeric.Ptr(numeric.Round(intercept, 3))
+	if syy > 0 {
+		profile.RSquared = numeric.Ptr(numeric.Round(sxy*sxy/(sxx*syy), 3))
+	}
+	if minVelocity == nil {
+		return profile
+	}
+
+	profile.Estimated1RMKg = loadAt(*minVelocity, intercept, slope)
+
+	byDay := make(map[time.Time][]Point)
+	for _, point := range points {
+		day := time.Date(point.Date.Year(), point.Date.Month(), point.Date.Day(), 0, 0, 0, 0, time.UTC)
+		byDay[day] = append(byDay[day], point)
+	}
+	for day, dayPoints := range byDay {
+		var offset, heaviest float64
+		for _, point := range dayPoints {
+			offset += point.VelocityMps - slope*point.WeightKg
+			heaviest = math.Max(heaviest, point.WeightKg)
+		}
+		dayIntercept := offset / float64(len(dayPoints))
+		estimate := loadAt(*minVelocity, dayIntercept, slope)
+		if estimate == nil {
+			continue
+		}
+		profile.Daily = append(profile.Daily, models.DailyVelocityEstimate{
+			Date:           day,
+			Sets:           len(dayPoints),
+			HeaviestKg:     heaviest,
+			Estimated1RMKg: *estimate,
+		})
+	}
+	sort.Slice(profile.Daily, func(i, j int) bool {
+		return profile.Daily[i].Date.Before(profile.Daily[j].Date)
+	})
+
+	return profile
+}
+
+// Trends summarises tracked sets per exercise per day, oldest first
+func Trends(sets []models.VelocitySetData) []models.VelocityTrendPoint {
+	type key struct {
+		day      time.Time
+		exercise string
+	}
+	index := make(map[key]int)
+	lossCounts := make(map[int]int)
+	trends := []models.VelocityTrendPoint{}
+
+	for _, set := range sets {
+		day := time.Date(set.Date.Year(), set.Date.Month(), set.Date.Day(), 0, 0, 0, 0, time.UTC)
+		k := key{day, set.ExerciseName}
+		i, ok := index[k]
+		if !ok {
+			i = len(trends)
+			index[k] = i
+			trends = append(trends, models.VelocityTrendPoint{
+				Date:         day,
+				ExerciseName: set.ExerciseName,
+				LiftType:     set.LiftType,
+			})
+		}
+
+		trend := &trends[i]
+		trend.AverageVelocityMps = (trend.AverageVelocityMps*float64(trend.Sets) + set.MeanVelocityMps) / float64(trend.Sets+1)
+		trend.Sets++
+		trend.BestVelocityMps = math.Max(trend.BestVelocityMps, set.MeanVelocityMps)
+		if set.WeightKg > trend.TopWeightKg || (set.WeightKg == trend.TopWeightKg && set.MeanVelocityMps > trend.VelocityAtTopWeightMps) {
+			trend.TopWeightKg = set.WeightKg
+			trend.VelocityAtTopWeightMps = set.MeanVelocityMps
+		}
+		if set.VelocityLossPct != nil {
+			count := lossCounts[i]
+			total := 0.0
+			if trend.AverageVelocityLossPct != nil {
+				total = *trend.AverageVelocityLossPct * float64(count)
+			}
+			trend.AverageVelocityLossPct = numeric.Ptr((total + *set.VelocityLossPct) / float64(count+1))
+			lossCounts[i] = count + 1
+		}
+	}
+
+	for i := range trends {
+		trends[i].AverageVelocityMps = numeric.Round(trends[i].AverageVelocityMps, 3)
+		if trends[i].AverageVelocityLossPct != nil {
+			trends[i].AverageVelocityLossPct = numeric.Ptr(numeric.Round(*trends[i].AverageVelocityLossPct, 2))
+		}
+	}
+	sort.SliceStable(trends, func(i, j int) bool {
+		return trends[i].Date.Before(trends[j].Date)
+	})
+
+	return trends
+}
+
+func loadAt(velocity, intercept, slope float64) *float64 {
+	load := (velocity - intercept) / slope
+	if load <= 0 {
+		return nil
+	}
+	return numeric.Ptr(numeric.Round(load, 1))
+}
@@ -0,0 +1,192 @@
+package velocity
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/powerlifting-coach-app/program-service/internal/models"
+)
+
+var (
+	day1 = time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	day2 = time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+)
+
+func TestSetPoint(t *testing.T) {
+	tests := []struct {
+		name string
+		set  models.VelocitySetData
+		want float64
+	}{
+		{
+			name: "fastest rep",
+			set: models.VelocitySetData{MeanVelocityMps: 0.5, RepVelocities: []models.RepVelocity{
+				{Rep: 1, MeanVelocity: 0.56}, {Rep: 2, MeanVelocity: 0.52}, {Rep: 3, MeanVelocity: 0.42},
+			}},
+			want: 0.56,
+		},
+		{
+			name: "set mean without rep readings",
+			set:  models.VelocitySetData{MeanVelocityMps: 0.5},
+			want: 0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SetPoint(tt.set).VelocityMps; got != tt.want {
+				t.Errorf("VelocityMps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinVelocity(t *testing.T) {
+	singles := []Point{
+		{WeightKg: 200, VelocityMps: 0.28, Reps: 1, RPE: float(10)},
+		{WeightKg: 195, VelocityMps: 0.32, Reps: 1, RPE: float(9.5)},
+		// Not maximal: a single at RPE 9 and a double at RPE 10
+		{WeightKg: 190, VelocityMps: 0.4, Reps: 1, RPE: float(9)},
+		{WeightKg: 190, VelocityMps: 0.25, Reps: 2, RPE: float(10)},
+	}
+
+	tests := []struct {
+		name       string
+		liftType   models.LiftType
+		points     []Point
+		override   *float64
+		want       *float64
+		wantSource string
+	}{
+		{name: "override", liftType: models.LiftTypeSquat, points: singles, override: float(0.35), want: float(0.35), wantSource: "request"},
+		{name: "athlete's max singles", liftType: models.LiftTypeSquat, points: singles, want: float(0.3), wantSource: "athlete"},
+		{name: "lift default", liftType: models.LiftTypeBench, points: singles[2:], want: float(0.17), wantSource: "default"},
+		{name: "no default", liftType: models.LiftTypeAccessory, want: nil, wantSource: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, source := MinVelocity(tt.liftType, tt.points, tt.override)
+			if !reflect.DeepEqual(got, tt.want) || source != tt.wantSource {
+				t.Errorf("MinVelocity = %v (%q), want %v (%q)", deref(got), source, deref(tt.want), tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestFitProfile(t *testing.T) {
+	// v = 1.23 - 0.005 * load overall, with day 2 running 0.06 m/s faster than
+	// day 1 at the same loads
+	points := []Point{
+		{Date: day1.Add(17 * time.Hour), WeightKg: 100, VelocityMps: 0.70},
+		{Date: day1.Add(18 * time.Hour), WeightKg: 140, VelocityMps: 0.50},
+		{Date: day2.Add(9 * time.Hour), WeightKg: 100, VelocityMps: 0.76},
+		{Date: day2.Add(10 * time.Hour), WeightKg: 140, VelocityMps: 0.56},
+	}
+
+	tests := []struct {
+		name        string
+		points      []Point
+		minVelocity *float64
+		want        models.LoadVelocityProfile
+	}{
+		{
+			name:        "fit with daily estimates",
+			points:      points,
+			minVelocity: float(0.3),
+			want: models.LoadVelocityProfile{
+				Sets:           4,
+				SlopeMpsPerKg:  float(-0.005),
+				InterceptMps:   float(1.23),
+				RSquared:       float(0.917),
+				MinVelocityMps: float(0.3),
+				Estimated1RMKg: float(186),
+				Daily: []models.DailyVelocityEstimate{
+					{Date: day1, Sets: 2, HeaviestKg: 140, Estimated1RMKg: 180},
+					{Date: day2, Sets: 2, HeaviestKg: 140, Estimated1RMKg: 192},
+				},
+			},
+		},
+		{
+			name:   "fit without a minimum velocity",
+			points: points,
+			want: models.LoadVelocityProfile{
+				Sets:          4,
+				SlopeMpsPerKg: float(-0.005),
+				InterceptMps:  float(1.23),
+				RSquared:      float(0.917),
+				Daily:         []models.DailyVelocityEstimate{},
+			},
+		},
+		{
+			name:        "too few sets",
+			points:      points[:1],
+			minVelocity: float(0.3),
+			want:        models.LoadVelocityProfile{Sets: 1, MinVelocityMps: float(0.3), Daily: []models.DailyVelocityEstimate{}},
+		},
+		{
+			name: "every set at one load",
+			points: []Point{
+				{Date: day1, WeightKg: 100, VelocityMps: 0.7},
+				{Date: day1, WeightKg: 100, VelocityMps: 0.6},
+			},
+			minVelocity: float(0.3),
+			want:        models.LoadVelocityProfile{Sets: 2, MinVelocityMps: float(0.3), Daily: []models.DailyVelocityEstimate{}},
+		},
+		{
+			name: "faster at heavier loads",
+			points: []Point{
+				{Date: day1, WeightKg: 100, VelocityMps: 0.5},
+				{Date: day1, WeightKg: 140, VelocityMps: 0.6},
+			},
+			minVelocity: float(0.3),
+			want:        models.LoadVelocityProfile{Sets: 2, MinVelocityMps: float(0.3), Daily: []models.DailyVelocityEstimate{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.ExerciseName = "Back Squat"
+			tt.want.LiftType = models.LiftTypeSquat
+			if tt.minVelocity != nil {
+				tt.want.MinVelocitySource = "default"
+			}
+
+			got := FitProfile("Back Squat", models.LiftTypeSquat, tt.points, tt.minVelocity, tt.want.MinVelocitySource)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FitProfile = slope %v intercept %v r² %v 1RM %v daily %+v, want slope %v intercept %v r² %v 1RM %v daily %+v",
+					deref(got.SlopeMpsPerKg), deref(got.InterceptMps), deref(got.RSquared), deref(got.Estimated1RMKg), got.Daily,
+					deref(tt.want.SlopeMpsPerKg), deref(tt.want.InterceptMps), deref(tt.want.RSquared), deref(tt.want.Estimated1RMKg), tt.want.Daily)
+			}
+		})
+	}
+}
+
+func TestTrends(t *testing.T) {
+	sets := []models.VelocitySetData{
+		{Date: day2.Add(17 * time.Hour), ExerciseName: "Back Squat", LiftType: models.LiftTypeSquat, WeightKg: 100, MeanVelocityMps: 0.6, VelocityLossPct: float(10)},
+		{Date: day2.Add(17 * time.Hour), ExerciseName: "Back Squat", LiftType: models.LiftTypeSquat, WeightKg: 120, MeanVelocityMps: 0.5},
+		{Date: day2.Add(18 * time.Hour), ExerciseName: "Back Squat", LiftType: models.LiftTypeSquat, WeightKg: 120, MeanVelocityMps: 0.52, VelocityLossPct: float(20)},
+		{Date: day2.Add(18 * time.Hour), ExerciseName: "Bench Press", LiftType: models.LiftTypeBench, WeightKg: 80, MeanVelocityMps: 0.4},
+		{Date: day1.Add(9 * time.Hour), ExerciseName: "Back Squat", LiftType: models.LiftTypeSquat, WeightKg: 90, MeanVelocityMps: 0.7},
+	}
+
+	want := []models.VelocityTrendPoint{
+		{Date: day1, ExerciseName: "Back Squat", LiftType: models.LiftTypeSquat, Sets: 1, BestVelocityMps: 0.7, AverageVelocityMps: 0.7, TopWeightKg: 90, VelocityAtTopWeightMps: 0.7},
+		// (0.6 + 0.5 + 0.52) / 3 = 0.54, and the faster of the two 120kg sets
+		{Date: day2, ExerciseName: "Back Squat", LiftType: models.LiftTypeSquat, Sets: 3, BestVelocityMps: 0.6, AverageVelocityMps: 0.54, AverageVelocityLossPct: float(15), TopWeightKg: 120, VelocityAtTopWeightMps: 0.52},
+		{Date: day2, ExerciseName: "Bench Press", LiftType: models.LiftTypeBench, Sets: 1, BestVelocityMps: 0.4, AverageVelocityMps: 0.4, TopWeightKg: 80, VelocityAtTopWeightMps: 0.4},
+	}
+
+	if got := Trends(sets); !reflect.DeepEqual(got, want) {
+		t.Errorf("Trends = %+v, want %+v", got, want)
+	}
+}
+
+func deref(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
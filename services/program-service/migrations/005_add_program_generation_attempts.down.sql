@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_generation_attempts_failed;
+DROP INDEX IF EXISTS idx_generation_attempts_athlete;
+
+DROP TABLE IF EXISTS program_generation_attempts;
@@ -0,0 +1,18 @@
+-- Record every AI program generation/repair attempt for debugging
+CREATE TABLE IF NOT EXISTS program_generation_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    athlete_id UUID NOT NULL,
+    program_id UUID REFERENCES programs(id) ON DELETE SET NULL,
+    source VARCHAR(50) NOT NULL, -- 'generate', 'generate_stream', 'from_chat'
+    attempt_number INTEGER NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    raw_response TEXT,
+    validation_errors JSONB DEFAULT '[]',
+    error TEXT,
+    succeeded BOOLEAN NOT NULL DEFAULT FALSE,
+    duration_ms INTEGER,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_generation_attempts_athlete ON program_generation_attempts(athlete_id, created_at DESC);
+CREATE INDEX idx_generation_attempts_failed ON program_generation_attempts(created_at DESC) WHERE succeeded = FALSE;
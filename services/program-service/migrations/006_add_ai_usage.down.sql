@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_ai_usage_created;
+DROP INDEX IF EXISTS idx_ai_usage_user_created;
+
+DROP TABLE IF EXISTS ai_usage;
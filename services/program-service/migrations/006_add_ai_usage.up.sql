@@ -0,0 +1,15 @@
+-- Per-call AI token accounting used for quotas and usage reports
+CREATE TABLE IF NOT EXISTS ai_usage (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    feature VARCHAR(50) NOT NULL, -- 'generate', 'chat', 'form_analysis', 'openai_compat'
+    model VARCHAR(100) NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    estimated_cost_usd DECIMAL(12,6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_ai_usage_user_created ON ai_usage(user_id, created_at DESC);
+CREATE INDEX idx_ai_usage_created ON ai_usage(created_at DESC);
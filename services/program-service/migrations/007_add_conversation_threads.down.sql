@@ -0,0 +1,21 @@
+ALTER TABLE ai_conversations ADD COLUMN IF NOT EXISTS messages JSONB NOT NULL DEFAULT '[]';
+
+UPDATE ai_conversations c SET messages = COALESCE((
+    SELECT jsonb_agg(jsonb_build_object(
+        'id', m.id,
+        'role', m.role,
+        'content', m.content,
+        'timestamp', m.created_at,
+        'prompt_templates', m.prompt_templates
+    ) ORDER BY m.seq)
+    FROM ai_messages m
+    WHERE m.conversation_id = c.id
+), '[]'::jsonb);
+
+DROP INDEX IF EXISTS idx_ai_conversations_athlete_updated;
+DROP TABLE IF EXISTS ai_messages;
+
+ALTER TABLE ai_conversations
+    DROP COLUMN IF EXISTS last_message_at,
+    DROP COLUMN IF EXISTS archived_at,
+    DROP COLUMN IF EXISTS title;
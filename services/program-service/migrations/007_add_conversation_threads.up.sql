@@ -0,0 +1,48 @@
+-- Named conversation threads with messages stored one row per message
+
+ALTER TABLE ai_conversations
+    ADD COLUMN IF NOT EXISTS title VARCHAR(255) NOT NULL DEFAULT 'New conversation',
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS last_message_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS ai_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    seq BIGSERIAL NOT NULL,
+    conversation_id UUID NOT NULL REFERENCES ai_conversations(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('user', 'assistant', 'system')),
+    content TEXT NOT NULL,
+    prompt_templates JSONB NOT NULL DEFAULT '[]',
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', content)) STORED,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_ai_messages_conversation ON ai_messages(conversation_id, seq);
+CREATE INDEX idx_ai_messages_search ON ai_messages USING GIN(search_vector);
+CREATE INDEX idx_ai_conversations_athlete_updated ON ai_conversations(athlete_id, updated_at DESC);
+
+-- Move existing messages out of the JSON array, keeping their IDs and order
+INSERT INTO ai_messages (id, conversation_id, role, content, prompt_templates, created_at)
+SELECT
+    CASE WHEN m.elem->>'id' ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$'
+         THEN (m.elem->>'id')::uuid ELSE gen_random_uuid() END,
+    c.id,
+    COALESCE(m.elem->>'role', 'user'),
+    COALESCE(m.elem->>'content', ''),
+    COALESCE(m.elem->'prompt_templates', '[]'::jsonb),
+    COALESCE((m.elem->>'timestamp')::timestamptz, c.created_at)
+FROM ai_conversations c
+CROSS JOIN LATERAL jsonb_array_elements(c.messages) WITH ORDINALITY AS m(elem, ord)
+ORDER BY c.id, m.ord;
+
+-- Title existing conversations after their first user message
+UPDATE ai_conversations c SET
+    title = COALESCE((
+        SELECT LEFT(m.content, 60)
+        FROM ai_messages m
+        WHERE m.conversation_id = c.id AND m.role = 'user' AND m.content != ''
+        ORDER BY m.seq
+        LIMIT 1
+    ), c.title),
+    last_message_at = (SELECT MAX(created_at) FROM ai_messages m WHERE m.conversation_id = c.id);
+
+ALTER TABLE ai_conversations DROP COLUMN messages;
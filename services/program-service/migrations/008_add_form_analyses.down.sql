@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_form_analyses_athlete_created;
+
+DROP TABLE IF EXISTS form_analyses;
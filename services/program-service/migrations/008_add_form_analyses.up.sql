@@ -0,0 +1,21 @@
+-- AI form analyses of lift videos; one per video so redelivered media.processed
+-- events don't analyze (and bill) the same video twice
+CREATE TABLE IF NOT EXISTS form_analyses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    video_id UUID NOT NULL UNIQUE,
+    athlete_id UUID NOT NULL,
+    exercise VARCHAR(50) NOT NULL,
+    load DECIMAL(10,2),
+    status VARCHAR(20) NOT NULL DEFAULT 'processing' CHECK (status IN ('processing', 'completed', 'failed')),
+    score DECIMAL(3,1) CHECK (score >= 0 AND score <= 10),
+    confidence DECIMAL(3,2) CHECK (confidence >= 0 AND confidence <= 1),
+    summary TEXT,
+    key_frames JSONB NOT NULL DEFAULT '[]',
+    issues JSONB NOT NULL DEFAULT '[]',
+    ai_model VARCHAR(100),
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_form_analyses_athlete_created ON form_analyses(athlete_id, created_at DESC);
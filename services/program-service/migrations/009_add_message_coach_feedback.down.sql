@@ -0,0 +1,3 @@
+ALTER TABLE ai_messages
+DROP COLUMN IF EXISTS coach_feedback_applied,
+DROP COLUMN IF EXISTS coach_feedback_included;
@@ -0,0 +1,5 @@
+-- Coach feedback IDs included in the prompt for an assistant message, and the
+-- ones the response cited as acted on
+ALTER TABLE ai_messages
+ADD COLUMN IF NOT EXISTS coach_feedback_included JSONB NOT NULL DEFAULT '[]',
+ADD COLUMN IF NOT EXISTS coach_feedback_applied JSONB NOT NULL DEFAULT '[]';
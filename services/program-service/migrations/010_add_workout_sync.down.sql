@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_sync_mutations_athlete_created;
+
+DROP TABLE IF EXISTS sync_mutations;
+
+ALTER TABLE training_sessions DROP COLUMN IF EXISTS modified_at;
+ALTER TABLE completed_sets DROP COLUMN IF EXISTS modified_at;
@@ -0,0 +1,29 @@
+-- Offline workout sync. modified_at is the logical time of the last edit: the
+-- client timestamp for synced mutations, NOW() for server-side edits. A queued
+-- mutation older than the row's modified_at loses to the server version.
+ALTER TABLE completed_sets ADD COLUMN IF NOT EXISTS modified_at TIMESTAMP WITH TIME ZONE;
+UPDATE completed_sets SET modified_at = COALESCE(completed_at, NOW()) WHERE modified_at IS NULL;
+ALTER TABLE completed_sets
+    ALTER COLUMN modified_at SET DEFAULT NOW(),
+    ALTER COLUMN modified_at SET NOT NULL;
+
+ALTER TABLE training_sessions ADD COLUMN IF NOT EXISTS modified_at TIMESTAMP WITH TIME ZONE;
+UPDATE training_sessions SET modified_at = COALESCE(completed_at, created_at, NOW()) WHERE modified_at IS NULL;
+ALTER TABLE training_sessions
+    ALTER COLUMN modified_at SET DEFAULT NOW(),
+    ALTER COLUMN modified_at SET NOT NULL;
+
+-- One row per client mutation ID so replayed batches return the original result
+-- instead of applying twice
+CREATE TABLE IF NOT EXISTS sync_mutations (
+    id UUID PRIMARY KEY,
+    athlete_id UUID NOT NULL,
+    mutation_type VARCHAR(50) NOT NULL,
+    client_timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'applied', 'conflict', 'rejected')),
+    reason TEXT,
+    session_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_sync_mutations_athlete_created ON sync_mutations(athlete_id, created_at DESC);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_session_rest_periods_active;
+DROP INDEX IF EXISTS idx_session_rest_periods_session;
+
+DROP TABLE IF EXISTS session_rest_periods;
+
+ALTER TABLE training_sessions DROP COLUMN IF EXISTS started_at;
@@ -0,0 +1,17 @@
+-- Live session mode: when the athlete started the session and the rest periods
+-- they timed between sets
+ALTER TABLE training_sessions ADD COLUMN IF NOT EXISTS started_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS session_rest_periods (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES training_sessions(id) ON DELETE CASCADE,
+    exercise_id UUID REFERENCES exercises(id) ON DELETE SET NULL,
+    after_set_id UUID REFERENCES completed_sets(id) ON DELETE SET NULL,
+    target_seconds INTEGER CHECK (target_seconds > 0),
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    stopped_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_session_rest_periods_session ON session_rest_periods(session_id, started_at);
+-- At most one running timer per session
+CREATE UNIQUE INDEX idx_session_rest_periods_active ON session_rest_periods(session_id) WHERE stopped_at IS NULL;
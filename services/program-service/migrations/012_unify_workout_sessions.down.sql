@@ -0,0 +1,28 @@
+-- Migrated workouts stay in training_sessions; only the empty legacy table is
+-- restored
+CREATE TABLE IF NOT EXISTS workout_sessions (
+    workout_session_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    program_id UUID REFERENCES programs(id) ON DELETE SET NULL,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    duration_minutes INTEGER,
+    exercises_summary JSONB,
+    notes TEXT,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_user_id ON workout_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_program_id ON workout_sessions(program_id);
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_status ON workout_sessions(status);
+
+DROP TRIGGER IF EXISTS update_workout_sessions_updated_at ON workout_sessions;
+CREATE TRIGGER update_workout_sessions_updated_at
+    BEFORE UPDATE ON workout_sessions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+DROP INDEX IF EXISTS idx_training_sessions_client_workout_id;
+
+ALTER TABLE training_sessions DROP COLUMN IF EXISTS client_workout_id;
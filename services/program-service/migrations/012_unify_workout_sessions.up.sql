@@ -0,0 +1,97 @@
+-- Workouts reported through workout.* events are stored as training sessions
+-- with normalized exercises and sets. client_workout_id links an event's
+-- workout_id to the session it was recorded against.
+ALTER TABLE training_sessions ADD COLUMN IF NOT EXISTS client_workout_id UUID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_training_sessions_client_workout_id
+    ON training_sessions(client_workout_id) WHERE client_workout_id IS NOT NULL;
+
+-- Move existing workout_sessions rows across. A workout whose id is a training
+-- session of the same athlete fills in that session; any other workout becomes
+-- an ad hoc session in its program, or the athlete's current one.
+UPDATE training_sessions ts SET
+    started_at = COALESCE(ts.started_at, ws.started_at),
+    completed_at = COALESCE(ts.completed_at, ws.completed_at),
+    duration_minutes = COALESCE(ts.duration_minutes, ws.duration_minutes),
+    notes = COALESCE(ts.notes, NULLIF(ws.notes, '')),
+    modified_at = NOW()
+FROM workout_sessions ws
+WHERE ws.workout_session_id = ts.id AND ws.user_id = ts.athlete_id;
+
+INSERT INTO training_sessions (id, program_id, athlete_id, week_number, day_number, session_name,
+                               scheduled_date, started_at, completed_at, duration_minutes, notes,
+                               is_adhoc, client_workout_id)
+SELECT ws.workout_session_id,
+       COALESCE(ws.program_id, (
+           SELECT p.id FROM programs p
+           WHERE p.athlete_id = ws.user_id
+           ORDER BY p.is_active DESC, p.created_at DESC
+           LIMIT 1)),
+       ws.user_id, 0, 0, 'Ad hoc workout',
+       COALESCE(ws.started_at, ws.completed_at, ws.created_at)::date,
+       ws.started_at, ws.completed_at, ws.duration_minutes, NULLIF(ws.notes, ''),
+       TRUE, ws.workout_session_id
+FROM workout_sessions ws
+WHERE NOT EXISTS (SELECT 1 FROM training_sessions ts WHERE ts.id = ws.workout_session_id)
+  AND (ws.program_id IS NOT NULL OR EXISTS (SELECT 1 FROM programs p WHERE p.athlete_id = ws.user_id));
+
+-- exercises_summary is [{"name": ..., "sets": [{"weight", "reps", "rpe", "completed"}]}]
+INSERT INTO exercises (session_id, exercise_order, lift_type, exercise_name, target_sets)
+SELECT ts.id,
+       COALESCE((SELECT MAX(e.exercise_order) FROM exercises e WHERE e.session_id = ts.id), 0) + ex.ord,
+       (CASE
+           WHEN ex.value->>'name' ILIKE '%squat%' THEN 'squat'
+           WHEN ex.value->>'name' ILIKE '%bench%' THEN 'bench'
+           WHEN ex.value->>'name' ILIKE '%deadlift%' THEN 'deadlift'
+           ELSE 'accessory'
+       END)::lift_type,
+       ex.value->>'name',
+       CASE WHEN jsonb_typeof(ex.value->'sets') = 'array' THEN jsonb_array_length(ex.value->'sets') ELSE 0 END
+FROM workout_sessions ws
+JOIN training_sessions ts ON ts.id = ws.workout_session_id AND ts.athlete_id = ws.user_id
+CROSS JOIN LATERAL jsonb_array_elements(
+    CASE WHEN jsonb_typeof(ws.exercises_summary) = 'array' THEN ws.exercises_summary ELSE '[]'::jsonb END
+) WITH ORDINALITY AS ex(value, ord)
+WHERE COALESCE(ex.value->>'name', '') <> ''
+  AND NOT EXISTS (
+      SELECT 1 FROM exercises e
+      WHERE e.session_id = ts.id AND LOWER(e.exercise_name) = LOWER(ex.value->>'name'));
+
+-- Sets are only added to exercises with nothing logged, so sets recorded through
+-- the REST API stay authoritative
+INSERT INTO completed_sets (exercise_id, set_number, reps_completed, weight_kg, rpe_actual, completed_at)
+SELECT e.id,
+       s.ord,
+       NULLIF(s.value->>'reps', '')::numeric::int,
+       COALESCE(NULLIF(s.value->>'weight', '')::numeric, 0),
+       NULLIF(s.value->>'rpe', '')::numeric,
+       COALESCE(ws.completed_at, ws.updated_at, NOW())
+FROM workout_sessions ws
+JOIN training_sessions ts ON ts.id = ws.workout_session_id AND ts.athlete_id = ws.user_id
+CROSS JOIN LATERAL jsonb_array_elements(
+    CASE WHEN jsonb_typeof(ws.exercises_summary) = 'array' THEN ws.exercises_summary ELSE '[]'::jsonb END
+) AS ex(value)
+JOIN exercises e ON e.session_id = ts.id AND LOWER(e.exercise_name) = LOWER(ex.value->>'name')
+CROSS JOIN LATERAL jsonb_array_elements(
+    CASE WHEN jsonb_typeof(ex.value->'sets') = 'array' THEN ex.value->'sets' ELSE '[]'::jsonb END
+) WITH ORDINALITY AS s(value, ord)
+WHERE COALESCE((s.value->>'completed')::boolean, TRUE)
+  AND COALESCE(NULLIF(s.value->>'reps', '')::numeric, 0) > 0
+  AND NOT EXISTS (SELECT 1 FROM completed_sets cs WHERE cs.exercise_id = e.id);
+
+DELETE FROM workout_sessions ws
+USING training_sessions ts
+WHERE ts.id = ws.workout_session_id AND ts.athlete_id = ws.user_id;
+
+-- Rows that could not be placed (no program, or an id owned by another athlete)
+-- are left behind rather than lost
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM workout_sessions) THEN
+        DROP TRIGGER IF EXISTS update_workout_sessions_updated_at ON workout_sessions;
+        DROP INDEX IF EXISTS idx_workout_sessions_user_id;
+        DROP INDEX IF EXISTS idx_workout_sessions_program_id;
+        DROP INDEX IF EXISTS idx_workout_sessions_status;
+        DROP TABLE workout_sessions;
+    END IF;
+END $$;
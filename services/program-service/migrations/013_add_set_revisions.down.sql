@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_completed_set_revisions_session;
+DROP INDEX IF EXISTS idx_completed_set_revisions_set;
+
+DROP TABLE IF EXISTS completed_set_revisions;
@@ -0,0 +1,18 @@
+-- Corrections to logged sets. Each row holds the set as it was and as it became
+-- so a change can be audited and undone; deleted sets live on here until restored.
+CREATE TABLE IF NOT EXISTS completed_set_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    set_id UUID NOT NULL,
+    session_id UUID NOT NULL REFERENCES training_sessions(id) ON DELETE CASCADE,
+    exercise_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('updated', 'deleted', 'reverted')),
+    changed_by UUID NOT NULL,
+    before JSONB,
+    after JSONB,
+    reverts_revision_id UUID REFERENCES completed_set_revisions(id) ON DELETE SET NULL,
+    undone_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_completed_set_revisions_set ON completed_set_revisions(set_id, created_at);
+CREATE INDEX idx_completed_set_revisions_session ON completed_set_revisions(session_id, created_at);
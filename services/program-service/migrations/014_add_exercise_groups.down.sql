@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_exercises_group;
+DROP INDEX IF EXISTS idx_exercise_groups_session;
+
+ALTER TABLE completed_sets DROP COLUMN IF EXISTS round_number;
+
+ALTER TABLE exercises
+    DROP COLUMN IF EXISTS group_position,
+    DROP COLUMN IF EXISTS group_id;
+
+DROP TABLE IF EXISTS exercise_groups;
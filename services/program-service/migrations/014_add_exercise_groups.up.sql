@@ -0,0 +1,25 @@
+-- Supersets, circuits, EMOMs and clusters. Exercises in a group are done in
+-- group_position order each round; rest_seconds is the rest after a round and
+-- interval_seconds the EMOM interval or the rest between a cluster's mini-sets.
+CREATE TABLE IF NOT EXISTS exercise_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES training_sessions(id) ON DELETE CASCADE,
+    label VARCHAR(10) NOT NULL,
+    group_type VARCHAR(20) NOT NULL CHECK (group_type IN ('superset', 'circuit', 'emom', 'cluster')),
+    rounds INTEGER NOT NULL DEFAULT 1 CHECK (rounds > 0),
+    rest_seconds INTEGER CHECK (rest_seconds >= 0),
+    interval_seconds INTEGER CHECK (interval_seconds > 0),
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (session_id, label)
+);
+
+ALTER TABLE exercises
+    ADD COLUMN IF NOT EXISTS group_id UUID REFERENCES exercise_groups(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS group_position INTEGER CHECK (group_position > 0);
+
+-- The round of its group a set was done in
+ALTER TABLE completed_sets ADD COLUMN IF NOT EXISTS round_number INTEGER CHECK (round_number > 0);
+
+CREATE INDEX idx_exercise_groups_session ON exercise_groups(session_id);
+CREATE INDEX idx_exercises_group ON exercises(group_id) WHERE group_id IS NOT NULL;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_completed_sets_velocity;
+
+ALTER TABLE exercises DROP COLUMN IF EXISTS velocity_loss_cutoff_pct;
+
+ALTER TABLE completed_sets
+    DROP COLUMN IF EXISTS rep_velocities,
+    DROP COLUMN IF EXISTS velocity_loss_pct,
+    DROP COLUMN IF EXISTS peak_velocity_mps,
+    DROP COLUMN IF EXISTS mean_velocity_mps;
@@ -0,0 +1,15 @@
+-- Bar speed from velocity trackers. rep_velocities holds the per-rep readings;
+-- the set columns are the average rep, the fastest peak and the drop from the
+-- fastest rep to the last one.
+ALTER TABLE completed_sets
+    ADD COLUMN IF NOT EXISTS mean_velocity_mps NUMERIC(5,3) CHECK (mean_velocity_mps >= 0),
+    ADD COLUMN IF NOT EXISTS peak_velocity_mps NUMERIC(5,3) CHECK (peak_velocity_mps >= 0),
+    ADD COLUMN IF NOT EXISTS velocity_loss_pct NUMERIC(5,2) CHECK (velocity_loss_pct >= 0 AND velocity_loss_pct <= 100),
+    ADD COLUMN IF NOT EXISTS rep_velocities JSONB NOT NULL DEFAULT '[]';
+
+-- Stop the set once bar speed has dropped this far from the fastest rep
+ALTER TABLE exercises
+    ADD COLUMN IF NOT EXISTS velocity_loss_cutoff_pct NUMERIC(5,2)
+        CHECK (velocity_loss_cutoff_pct > 0 AND velocity_loss_cutoff_pct < 100);
+
+CREATE INDEX idx_completed_sets_velocity ON completed_sets(exercise_id) WHERE mean_velocity_mps IS NOT NULL;
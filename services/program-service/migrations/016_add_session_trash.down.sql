@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_training_sessions_trash;
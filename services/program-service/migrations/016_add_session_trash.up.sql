@@ -0,0 +1,3 @@
+-- Deleted sessions stay in the athlete's trash until they are restored or the
+-- retention window passes and the purge job removes them for good
+CREATE INDEX IF NOT EXISTS idx_training_sessions_trash ON training_sessions(athlete_id, deleted_at) WHERE deleted_at IS NOT NULL;
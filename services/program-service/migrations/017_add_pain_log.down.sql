@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS update_pain_episodes_updated_at ON pain_episodes;
+
+DROP INDEX IF EXISTS idx_pain_reports_session;
+DROP INDEX IF EXISTS idx_pain_reports_episode;
+DROP INDEX IF EXISTS idx_pain_episodes_active;
+DROP INDEX IF EXISTS idx_pain_episodes_athlete;
+
+DROP TABLE IF EXISTS pain_reports;
+DROP TABLE IF EXISTS pain_episodes;
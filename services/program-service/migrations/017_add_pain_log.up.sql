@@ -0,0 +1,35 @@
+-- Pain and injury episodes. An episode is one issue in one body region; its
+-- reports are the 0-10 scores logged over its course, optionally tied to the
+-- session or set that brought it on. An episode is active until resolved_on.
+CREATE TABLE IF NOT EXISTS pain_episodes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    athlete_id UUID NOT NULL,
+    body_region VARCHAR(30) NOT NULL,
+    side VARCHAR(10) CHECK (side IN ('left', 'right', 'both')),
+    triggering_movement VARCHAR(255),
+    lift_type lift_type,
+    started_on DATE NOT NULL DEFAULT CURRENT_DATE,
+    resolved_on DATE CHECK (resolved_on >= started_on),
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS pain_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    episode_id UUID NOT NULL REFERENCES pain_episodes(id) ON DELETE CASCADE,
+    pain_score INTEGER NOT NULL CHECK (pain_score >= 0 AND pain_score <= 10),
+    session_id UUID REFERENCES training_sessions(id) ON DELETE SET NULL,
+    set_id UUID REFERENCES completed_sets(id) ON DELETE SET NULL,
+    notes TEXT,
+    reported_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pain_episodes_athlete ON pain_episodes(athlete_id, started_on);
+CREATE INDEX idx_pain_episodes_active ON pain_episodes(athlete_id) WHERE resolved_on IS NULL;
+CREATE INDEX idx_pain_reports_episode ON pain_reports(episode_id, reported_at);
+CREATE INDEX idx_pain_reports_session ON pain_reports(session_id) WHERE session_id IS NOT NULL;
+
+CREATE TRIGGER update_pain_episodes_updated_at
+    BEFORE UPDATE ON pain_episodes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
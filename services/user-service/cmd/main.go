@@ -11,9 +11,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/powerlifting-coach-app/user-service/internal/clients"
 	"github.com/powerlifting-coach-app/user-service/internal/config"
 	"github.com/powerlifting-coach-app/user-service/internal/database"
 	"github.com/powerlifting-coach-app/user-service/internal/handlers"
+	"github.com/powerlifting-coach-app/user-service/internal/queue"
 	"github.com/powerlifting-coach-app/user-service/internal/repository"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -41,7 +43,21 @@ func main() {
 	}
 
 	userRepo := repository.NewUserRepository(db.DB)
-	userHandlers := handlers.NewUserHandlers(userRepo)
+
+	eventConsumer, err := queue.NewEventConsumer(cfg.RabbitMQURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create event consumer")
+	}
+	defer eventConsumer.Close()
+
+	relationshipEventHandlers := handlers.NewRelationshipEventHandlers(userRepo)
+	eventConsumer.RegisterHandler("coach.relationship.changed", relationshipEventHandlers.HandleRelationshipChanged)
+
+	if err := eventConsumer.StartConsuming("user-service.events", []string{"coach.relationship.changed"}); err != nil {
+		log.Fatal().Err(err).Msg("Failed to start consuming events")
+	}
+
+	userHandlers := handlers.NewUserHandlers(userRepo, eventConsumer, clients.NewCoachClient(cfg.CoachService))
 
 	router := gin.Default()
 
@@ -0,0 +1,72 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CoachClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// AccessCodeEligibility is coach-service's answer to whether redeeming an
+// access code would start the coach's relationship with the athlete
+type AccessCodeEligibility struct {
+	Eligible      bool       `json:"eligible"`
+	Reason        string     `json:"reason"`
+	CooldownUntil *time.Time `json:"cooldown_until"`
+}
+
+func NewCoachClient(baseURL string) *CoachClient {
+	return &CoachClient{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// CheckAccessCode asks coach-service, as the calling coach, whether the code
+// would be applied
+func (c *CoachClient) CheckAccessCode(ctx context.Context, authToken string, athleteID uuid.UUID, accessCode string) (*AccessCodeEligibility, error) {
+	body, err := json.Marshal(map[string]string{
+		"athlete_id":  athleteID.String(),
+		"access_code": accessCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/relationships/access-code/check", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", authToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coach service returned status %d", resp.StatusCode)
+	}
+
+	var eligibility AccessCodeEligibility
+	if err := json.NewDecoder(resp.Body).Decode(&eligibility); err != nil {
+		return nil, err
+	}
+
+	return &eligibility, nil
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/user-service/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// RelationshipChangedEvent is published by coach-service whenever a coaching
+// relationship starts, ends or has its scopes changed
+type RelationshipChangedEvent struct {
+	EventType     string `json:"event_type"`
+	SourceService string `json:"source_service"`
+	Data          struct {
+		RelationshipID uuid.UUID `json:"relationship_id"`
+		CoachID        uuid.UUID `json:"coach_id"`
+		AthleteID      uuid.UUID `json:"athlete_id"`
+		Status         string    `json:"status"`
+	} `json:"data"`
+}
+
+type RelationshipEventHandlers struct {
+	userRepo *repository.UserRepository
+}
+
+func NewRelationshipEventHandlers(userRepo *repository.UserRepository) *RelationshipEventHandlers {
+	return &RelationshipEventHandlers{
+		userRepo: userRepo,
+	}
+}
+
+// HandleRelationshipChanged keeps coach_athlete_access in step with the
+// relationship. Pending requests aren't coached yet and are ignored.
+func (h *RelationshipEventHandlers) HandleRelationshipChanged(ctx context.Context, payload []byte) error {
+	var event RelationshipChangedEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	data := event.Data
+	if data.CoachID == uuid.Nil || data.AthleteID == uuid.Nil {
+		return fmt.Errorf("coach_id and athlete_id are required")
+	}
+
+	switch data.Status {
+	case "active", "terminated":
+	default:
+		log.Debug().Str("relationship_id", data.RelationshipID.String()).Str("status", data.Status).Msg("Ignoring relationship change")
+		return nil
+	}
+
+	return h.userRepo.SyncCoachAccess(data.CoachID, data.AthleteID, data.Status == "active")
+}
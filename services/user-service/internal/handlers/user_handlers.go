@@ -2,22 +2,32 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/powerlifting-coach-app/user-service/internal/clients"
 	"github.com/powerlifting-coach-app/user-service/internal/models"
 	"github.com/powerlifting-coach-app/user-service/internal/repository"
 	"github.com/rs/zerolog/log"
 )
 
+type EventPublisher interface {
+	PublishEvent(routingKey string, event interface{}) error
+}
+
 type UserHandlers struct {
-	userRepo *repository.UserRepository
+	userRepo    *repository.UserRepository
+	publisher   EventPublisher
+	coachClient *clients.CoachClient
 }
 
-func NewUserHandlers(userRepo *repository.UserRepository) *UserHandlers {
+func NewUserHandlers(userRepo *repository.UserRepository, publisher EventPublisher, coachClient *clients.CoachClient) *UserHandlers {
 	return &UserHandlers{
-		userRepo: userRepo,
+		userRepo:    userRepo,
+		publisher:   publisher,
+		coachClient: coachClient,
 	}
 }
 
@@ -198,6 +208,11 @@ func (h *UserHandlers) GenerateAccessCode(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"access_code": accessCode})
 }
 
+// GrantAccess redeems an athlete's access code. coach-service turns the
+// published access.granted event into a coaching relationship, which then
+// shows up in GetMyAthletes. Because that happens asynchronously, coach-service
+// is asked first whether the code would be applied, so a code it would ignore
+// is refused here rather than accepted and silently dropped.
 func (h *UserHandlers) GrantAccess(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
@@ -229,18 +244,64 @@ func (h *UserHandlers) GrantAccess(c *gin.Context) {
 		return
 	}
 
-	if err := h.userRepo.GrantCoachAccess(userUUID, athlete.ID, req.AccessCode); err != nil {
+	eligibility, err := h.coachClient.CheckAccessCode(c.Request.Context(), c.GetHeader("Authorization"), athlete.ID, req.AccessCode)
+	if err != nil {
+		log.Error().Err(err).Str("coach_id", userID).Str("athlete_id", athlete.ID.String()).Msg("Failed to check access code with coach service")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Could not check the access code, please try again"})
+		return
+	}
+	if !eligibility.Eligible {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":          accessCodeRefusal(eligibility),
+			"reason":         eligibility.Reason,
+			"cooldown_until": eligibility.CooldownUntil,
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	event := map[string]interface{}{
+		"schema_version":      "1.0.0",
+		"event_type":          "access.granted",
+		"client_generated_id": uuid.New().String(),
+		"user_id":             userID,
+		"timestamp":           now.Format(time.RFC3339),
+		"source_service":      "user-service",
+		"coach_id":            userUUID.String(),
+		"athlete_id":          athlete.ID.String(),
+		"access_code":         req.AccessCode,
+		"granted_at":          now.Format(time.RFC3339),
+	}
+	if err := h.publisher.PublishEvent("access.granted", event); err != nil {
 		log.Error().Err(err).Str("coach_id", userID).Str("athlete_id", athlete.ID.String()).Msg("Failed to grant access")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grant access"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Access granted successfully",
+		"message": "Access code accepted",
 		"athlete": h.buildUserResponse(athlete),
 	})
 }
 
+// accessCodeRefusal explains why coach-service would not apply an access code
+func accessCodeRefusal(eligibility *clients.AccessCodeEligibility) string {
+	switch eligibility.Reason {
+	case "already_active":
+		return "You already coach this athlete"
+	case "code_already_used":
+		return "This access code was already used to coach this athlete; ask them for a new one"
+	case "cooldown":
+		if eligibility.CooldownUntil != nil {
+			return "This athlete ended your coaching relationship recently; try again after " + eligibility.CooldownUntil.UTC().Format("2006-01-02")
+		}
+		return "This athlete ended your coaching relationship recently; try again later"
+	default:
+		return "This access code can't be applied"
+	}
+}
+
+// GetMyAthletes lists the coach's athletes as last synced from coach-service
 func (h *UserHandlers) GetMyAthletes(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
@@ -0,0 +1,209 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/utils"
+	"github.com/rs/zerolog/log"
+	"github.com/streadway/amqp"
+)
+
+type EventConsumer struct {
+	connection *amqp.Connection
+	channel    *amqp.Channel
+	handlers   map[string]EventHandler
+}
+
+type EventHandler func(ctx context.Context, payload []byte) error
+
+type BaseEvent struct {
+	SchemaVersion     string                 `json:"schema_version"`
+	EventType         string                 `json:"event_type"`
+	ClientGeneratedID string                 `json:"client_generated_id"`
+	UserID            string                 `json:"user_id"`
+	Timestamp         string                 `json:"timestamp"`
+	SourceService     string                 `json:"source_service"`
+	Data              map[string]interface{} `json:"data"`
+}
+
+const AppEventsExchange = "app.events"
+
+func NewEventConsumer(url string) (*EventConsumer, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+
+	consumer := &EventConsumer{
+		connection: conn,
+		channel:    ch,
+		handlers:   make(map[string]EventHandler),
+	}
+
+	if err := consumer.setupExchange(); err != nil {
+		consumer.Close()
+		return nil, fmt.Errorf("failed to setup exchange: %w", err)
+	}
+
+	return consumer, nil
+}
+
+func (c *EventConsumer) setupExchange() error {
+	// Setup Dead Letter Queue
+	if err := utils.SetupDLQ(c.channel); err != nil {
+		return fmt.Errorf("failed to setup DLQ: %w", err)
+	}
+
+	err := c.channel.ExchangeDeclare(
+		AppEventsExchange,
+		"topic",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	return nil
+}
+
+func (c *EventConsumer) RegisterHandler(eventType string, handler EventHandler) {
+	c.handlers[eventType] = handler
+	log.Info().Str("event_type", eventType).Msg("Registered event handler")
+}
+
+func (c *EventConsumer) StartConsuming(queueName string, routingKeys []string) error {
+	// Declare queue with single active consumer
+	_, err := utils.DeclareQueueWithSingleActiveConsumer(c.channel, queueName)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+
+	for _, key := range routingKeys {
+		err = c.channel.QueueBind(
+			queueName,
+			key,
+			AppEventsExchange,
+			false,
+			nil,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to bind queue to %s: %w", key, err)
+		}
+		log.Info().Str("routing_key", key).Str("queue", queueName).Msg("Bound queue to routing key")
+	}
+
+	err = c.channel.Qos(10, 0, false)
+	if err != nil {
+		return fmt.Errorf("failed to set QoS: %w", err)
+	}
+
+	msgs, err := c.channel.Consume(
+		queueName,
+		"",
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start consuming: %w", err)
+	}
+
+	go func() {
+		for msg := range msgs {
+			c.handleMessage(msg)
+		}
+	}()
+
+	log.Info().Str("queue", queueName).Msg("Event consumer started")
+	return nil
+}
+
+func (c *EventConsumer) handleMessage(msg amqp.Delivery) {
+	var event BaseEvent
+	if err := json.Unmarshal(msg.Body, &event); err != nil {
+		log.Error().Err(err).Msg("Failed to unmarshal event")
+		msg.Nack(false, false)
+		return
+	}
+
+	handler, exists := c.handlers[event.EventType]
+	if !exists {
+		log.Warn().Str("event_type", event.EventType).Msg("No handler registered for event type")
+		msg.Ack(false)
+		return
+	}
+
+	ctx := context.Background()
+	err := handler(ctx, msg.Body)
+	if err != nil {
+		retryCount := utils.GetRetryCount(msg)
+		log.Error().
+			Err(err).
+			Str("event_type", event.EventType).
+			Str("client_generated_id", event.ClientGeneratedID).
+			Int("retry_count", retryCount).
+			Msg("Failed to handle event")
+
+		// Handle failure with retry logic
+		if handleErr := utils.HandleMessageFailure(c.channel, msg, AppEventsExchange, event.EventType); handleErr != nil {
+			log.Error().Err(handleErr).Msg("Failed to handle message failure")
+			// Fallback to simple nack without requeue to avoid infinite loops
+			msg.Nack(false, false)
+		}
+		return
+	}
+
+	msg.Ack(false)
+	log.Info().
+		Str("event_type", event.EventType).
+		Str("client_generated_id", event.ClientGeneratedID).
+		Msg("Event processed successfully")
+}
+
+func (c *EventConsumer) PublishEvent(routingKey string, event interface{}) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = c.channel.Publish(
+		AppEventsExchange,
+		routingKey,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			Body:         body,
+			DeliveryMode: amqp.Persistent,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	log.Info().Str("routing_key", routingKey).Msg("Event published")
+	return nil
+}
+
+func (c *EventConsumer) Close() {
+	if c.channel != nil {
+		c.channel.Close()
+	}
+	if c.connection != nil {
+		c.connection.Close()
+	}
+}
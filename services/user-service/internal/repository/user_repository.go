@@ -280,16 +280,20 @@ func (r *UserRepository) GetAthleteByAccessCode(accessCode string) (*models.User
 	return user, nil
 }
 
-func (r *UserRepository) GrantCoachAccess(coachID, athleteID uuid.UUID, accessCode string) error {
+// SyncCoachAccess mirrors a coach-service relationship into coach_athlete_access,
+// which only lists who a coach currently coaches. coach-service decides that.
+func (r *UserRepository) SyncCoachAccess(coachID, athleteID uuid.UUID, active bool) error {
 	query := `
-		INSERT INTO coach_athlete_access (coach_id, athlete_id, access_code)
+		INSERT INTO coach_athlete_access (coach_id, athlete_id, is_active)
 		VALUES ($1, $2, $3)
-		ON CONFLICT (coach_id, athlete_id) 
-		DO UPDATE SET access_code = $3, granted_at = NOW(), is_active = TRUE`
-	
-	_, err := r.db.Exec(query, coachID, athleteID, accessCode)
+		ON CONFLICT (coach_id, athlete_id)
+		DO UPDATE SET is_active = $3,
+		              granted_at = CASE WHEN $3 AND NOT coach_athlete_access.is_active
+		                                THEN NOW() ELSE coach_athlete_access.granted_at END`
+
+	_, err := r.db.Exec(query, coachID, athleteID, active)
 	if err != nil {
-		return fmt.Errorf("failed to grant coach access: %w", err)
+		return fmt.Errorf("failed to sync coach access: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,2 @@
+DELETE FROM coach_athlete_access WHERE access_code IS NULL;
+ALTER TABLE coach_athlete_access ALTER COLUMN access_code SET NOT NULL;
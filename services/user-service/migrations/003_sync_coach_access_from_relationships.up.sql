@@ -0,0 +1,34 @@
+-- coach_athlete_access now mirrors coach-service relationships, which may be
+-- started without an access code.
+ALTER TABLE coach_athlete_access ALTER COLUMN access_code DROP NOT NULL;
+
+-- Carry access granted with codes over to coach-service relationships, as
+-- coach-service migration 007 does. Whichever of the two runs second finds both
+-- tables and makes the copy, so the grants survive either deploy order.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_attribute
+               WHERE attrelid = to_regclass('coach_athlete_relationships')
+                 AND attname = 'source' AND NOT attisdropped) THEN
+        INSERT INTO coach_athlete_relationships (coach_id, athlete_id, status, requested_at,
+                                                 accepted_at, source, access_code)
+        SELECT coach_id, athlete_id, 'active', COALESCE(granted_at, NOW()), COALESCE(granted_at, NOW()),
+               'access_code', access_code
+        FROM coach_athlete_access
+        WHERE is_active = TRUE AND access_code IS NOT NULL
+          AND (expires_at IS NULL OR expires_at > NOW())
+        ON CONFLICT (coach_id, athlete_id) DO NOTHING;
+    END IF;
+END $$;
+
+-- Bring the mirror in line with relationships that already exist
+DO $$
+BEGIN
+    IF to_regclass('coach_athlete_relationships') IS NOT NULL THEN
+        INSERT INTO coach_athlete_access (coach_id, athlete_id, granted_at, is_active)
+        SELECT coach_id, athlete_id, COALESCE(accepted_at, requested_at), status = 'active'
+        FROM coach_athlete_relationships
+        WHERE status IN ('active', 'terminated')
+        ON CONFLICT (coach_id, athlete_id) DO UPDATE SET is_active = EXCLUDED.is_active;
+    END IF;
+END $$;
@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/powerlifting-coach-app/video-service/internal/clients"
 	"github.com/powerlifting-coach-app/video-service/internal/config"
 	"github.com/powerlifting-coach-app/video-service/internal/database"
 	"github.com/powerlifting-coach-app/video-service/internal/handlers"
@@ -71,6 +72,8 @@ func main() {
 	commentHandlers.SetPublisher(eventPublisher)
 	mediaHandlers := handlers.NewMediaEventHandlers(db.DB, eventPublisher)
 	metadataHandlers := handlers.NewMetadataHandlers(db.DB, eventPublisher)
+	videoRepo := repository.NewVideoRepository(db.DB)
+	formFeedbackHandlers := handlers.NewFormFeedbackHandlers(videoRepo)
 
 	eventConsumer.RegisterHandler("feed.post.created", feedHandlers.HandleFeedPostCreated)
 	eventConsumer.RegisterHandler("feed.post.updated", feedHandlers.HandleFeedPostUpdated)
@@ -79,6 +82,7 @@ func main() {
 	eventConsumer.RegisterHandler("interaction.liked", commentHandlers.HandleInteractionLiked)
 	eventConsumer.RegisterHandler("media.upload_requested", mediaHandlers.HandleMediaUploadRequested)
 	eventConsumer.RegisterHandler("media.uploaded", mediaHandlers.HandleMediaUploaded)
+	eventConsumer.RegisterHandler("form.analyzed", formFeedbackHandlers.HandleFormAnalyzed)
 
 	routingKeys := []string{
 		"feed.post.created",
@@ -88,6 +92,7 @@ func main() {
 		"interaction.liked",
 		"media.upload_requested",
 		"media.uploaded",
+		"form.analyzed",
 	}
 
 	if err := eventConsumer.StartConsuming("video-service.events", routingKeys); err != nil {
@@ -99,11 +104,11 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to start metadata consumer")
 	}
 
-	videoRepo := repository.NewVideoRepository(db.DB)
 	videoHandlers := handlers.NewVideoHandlers(
 		videoRepo, spacesClient, queueClient,
 		cfg.MaxFileSize, cfg.AllowedExtensions,
 	)
+	videoHandlers.SetCoachClient(clients.NewCoachClient(cfg.CoachService))
 
 	router := gin.Default()
 
@@ -139,6 +144,7 @@ func main() {
 				videos.POST("/upload", videoHandlers.GetUploadURL)
 				videos.POST("/:id/complete", videoHandlers.CompleteUpload)
 				videos.GET("/", videoHandlers.GetMyVideos)
+				videos.GET("/athletes", videoHandlers.GetAthleteVideos)
 				videos.GET("/:id", videoHandlers.GetVideo)
 				videos.DELETE("/:id", videoHandlers.DeleteVideo)
 			}
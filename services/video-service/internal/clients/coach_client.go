@@ -0,0 +1,93 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CoachClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// ScopeViewVideos is what an athlete grants a coach to see their videos
+const ScopeViewVideos = "view_videos"
+
+type CoachingAssignment struct {
+	ID        uuid.UUID `json:"id"`
+	CoachID   uuid.UUID `json:"coach_id"`
+	AthleteID uuid.UUID `json:"athlete_id"`
+	Status    string    `json:"status"`
+	Scopes    []string  `json:"scopes"`
+	StartDate time.Time `json:"start_date"`
+}
+
+type CoachingListResponse struct {
+	Assignments []CoachingAssignment `json:"assignments"`
+}
+
+func NewCoachClient(baseURL string) *CoachClient {
+	return &CoachClient{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// GetCoachedAthletes returns the athletes the caller actively coaches who let
+// them see their videos
+func (c *CoachClient) GetCoachedAthletes(ctx context.Context, authToken string) (map[uuid.UUID]bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/coaches/athletes", c.baseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", authToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coach service returned status %d", resp.StatusCode)
+	}
+
+	var assignmentsResp CoachingListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&assignmentsResp); err != nil {
+		return nil, err
+	}
+
+	athletes := make(map[uuid.UUID]bool)
+	for _, assignment := range assignmentsResp.Assignments {
+		if assignment.Status != "active" {
+			continue
+		}
+		for _, scope := range assignment.Scopes {
+			if scope == ScopeViewVideos {
+				athletes[assignment.AthleteID] = true
+				break
+			}
+		}
+	}
+
+	return athletes, nil
+}
+
+// HasCoachAccess reports whether the caller actively coaches the athlete and
+// may see their videos
+func (c *CoachClient) HasCoachAccess(ctx context.Context, authToken string, athleteID uuid.UUID) (bool, error) {
+	athletes, err := c.GetCoachedAthletes(ctx, authToken)
+	if err != nil {
+		return false, err
+	}
+	return athletes[athleteID], nil
+}
@@ -22,6 +22,7 @@ type Config struct {
 	AllowedExtensions   []string
 	FFmpegPath          string
 	ThumbnailSize       int
+	CoachService        string
 }
 
 func Load() *Config {
@@ -45,6 +46,7 @@ func Load() *Config {
 		AllowedExtensions: []string{".mp4", ".mov", ".avi", ".mkv", ".webm"},
 		FFmpegPath:        getEnv("FFMPEG_PATH", "ffmpeg"),
 		ThumbnailSize:     thumbnailSize,
+		CoachService:      getEnv("COACH_SERVICE", "http://coach-service:8085"),
 	}
 }
 
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/powerlifting-coach-app/video-service/internal/models"
+	"github.com/powerlifting-coach-app/video-service/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+type FormFeedbackHandlers struct {
+	videoRepo *repository.VideoRepository
+}
+
+func NewFormFeedbackHandlers(videoRepo *repository.VideoRepository) *FormFeedbackHandlers {
+	return &FormFeedbackHandlers{
+		videoRepo: videoRepo,
+	}
+}
+
+// FormAnalyzedEvent is published flat by program-service, with the analysis
+// fields next to the envelope fields rather than under data
+type FormAnalyzedEvent struct {
+	EventType  string             `json:"event_type"`
+	AnalysisID uuid.UUID          `json:"analysis_id"`
+	VideoID    uuid.UUID          `json:"video_id"`
+	AthleteID  uuid.UUID          `json:"athlete_id"`
+	Exercise   string             `json:"exercise"`
+	Score      float64            `json:"score"`
+	Confidence float64            `json:"confidence"`
+	Feedback   string             `json:"feedback"`
+	Issues     []models.FormIssue `json:"issues"`
+	AIModel    string             `json:"ai_model"`
+}
+
+// HandleFormAnalyzed stores the analysis as the video's FormFeedback
+func (h *FormFeedbackHandlers) HandleFormAnalyzed(ctx context.Context, payload []byte) error {
+	var event FormAnalyzedEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	if event.AnalysisID == uuid.Nil || event.VideoID == uuid.Nil {
+		return fmt.Errorf("form.analyzed event is missing analysis_id or video_id")
+	}
+
+	feedback := &models.FormFeedback{
+		VideoID:         event.VideoID,
+		AnalysisID:      &event.AnalysisID,
+		FeedbackText:    event.Feedback,
+		ConfidenceScore: &event.Confidence,
+		Issues:          event.Issues,
+	}
+	if event.AIModel != "" {
+		feedback.AIModel = &event.AIModel
+	}
+
+	created, err := h.videoRepo.CreateFormFeedback(feedback)
+	if err != nil {
+		return err
+	}
+
+	if !created {
+		log.Info().Str("analysis_id", event.AnalysisID.String()).Msg("Form feedback already stored")
+		return nil
+	}
+
+	log.Info().
+		Str("video_id", event.VideoID.String()).
+		Str("analysis_id", event.AnalysisID.String()).
+		Int("issues", len(event.Issues)).
+		Msg("Form feedback stored")
+
+	return nil
+}
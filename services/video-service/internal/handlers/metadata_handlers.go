@@ -90,9 +90,12 @@ func (h *MetadataHandlers) HandleVideoMetadata(payload []byte) error {
 			"timestamp":           time.Now().UTC().Format(time.RFC3339),
 			"source_service":      "video-service",
 			"data": map[string]interface{}{
-				"video_id":      msg.VideoID,
-				"media_url":     msg.ProcessedURL,
-				"thumbnail_url": msg.ThumbnailURL,
+				"video_id":       msg.VideoID,
+				"media_url":      msg.ProcessedURL,
+				"thumbnail_url":  msg.ThumbnailURL,
+				"movement_label": movementLabel,
+				"weight":         nullFloat(weight),
+				"rpe":            nullFloat(rpe),
 			},
 		}
 		if err := h.publisher.PublishEvent("media.processed", mediaProcessedEvent); err != nil {
@@ -126,3 +129,10 @@ func (h *MetadataHandlers) HandleVideoMetadata(payload []byte) error {
 
 	return nil
 }
+
+func nullFloat(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/middleware"
+	"github.com/powerlifting-coach-app/video-service/internal/clients"
 	"github.com/powerlifting-coach-app/video-service/internal/models"
 	"github.com/powerlifting-coach-app/video-service/internal/queue"
 	"github.com/powerlifting-coach-app/video-service/internal/repository"
@@ -24,6 +25,7 @@ type VideoHandlers struct {
 	queueClient  *queue.RabbitMQClient
 	maxFileSize  int64
 	allowedExts  []string
+	coachClient  *clients.CoachClient
 }
 
 func NewVideoHandlers(
@@ -42,6 +44,10 @@ func NewVideoHandlers(
 	}
 }
 
+func (h *VideoHandlers) SetCoachClient(coachClient *clients.CoachClient) {
+	h.coachClient = coachClient
+}
+
 func (h *VideoHandlers) GetUploadURL(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
@@ -191,6 +197,62 @@ func (h *VideoHandlers) GetMyVideos(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetAthleteVideos lists the processed videos that athletes the caller coaches
+// uploaded in the last `days` days (28 by default). athlete_id may be repeated
+// or comma separated; athletes the caller doesn't coach are left out.
+func (h *VideoHandlers) GetAthleteVideos(c *gin.Context) {
+	if middleware.GetUserType(c) != "coach" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only coaches can list athlete videos"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "28"))
+	if err != nil || days < 1 || days > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 90"})
+		return
+	}
+
+	if h.coachClient == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Coach access is not available"})
+		return
+	}
+	coached, err := h.coachClient.GetCoachedAthletes(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get coached athletes")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to check coach access"})
+		return
+	}
+
+	var athleteIDs []uuid.UUID
+	for _, raw := range c.QueryArray("athlete_id") {
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			athleteID, err := uuid.Parse(part)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid athlete ID: " + part})
+				return
+			}
+			if coached[athleteID] {
+				athleteIDs = append(athleteIDs, athleteID)
+			}
+		}
+	}
+
+	videos := []models.Video{}
+	if len(athleteIDs) > 0 {
+		videos, err = h.videoRepo.GetReadyVideosByAthleteIDs(athleteIDs, time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get athlete videos")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get videos"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"videos": videos})
+}
+
 func (h *VideoHandlers) GetVideo(c *gin.Context) {
 	videoIDStr := c.Param("id")
 	videoID, err := uuid.Parse(videoIDStr)
@@ -208,8 +270,7 @@ func (h *VideoHandlers) GetVideo(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	
 	// Check if user has access (owner or coach with access)
-	if video.AthleteID.String() != userID {
-		// TODO: Check if user is a coach with access to this athlete
+	if video.AthleteID.String() != userID && !h.isCoachOf(c, video.AthleteID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -335,4 +396,19 @@ func getContentTypeFromExtension(ext string) string {
 	default:
 		return "video/mp4"
 	}
-}
\ No newline at end of file
+}
+
+// isCoachOf reports whether the caller actively coaches the athlete and may
+// see their videos
+func (h *VideoHandlers) isCoachOf(c *gin.Context, athleteID uuid.UUID) bool {
+	if h.coachClient == nil || middleware.GetUserType(c) != "coach" {
+		return false
+	}
+
+	hasAccess, err := h.coachClient.HasCoachAccess(c.Request.Context(), c.GetHeader("Authorization"), athleteID)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to check coach access")
+		return false
+	}
+	return hasAccess
+}
@@ -37,6 +37,7 @@ type Video struct {
 type FormFeedback struct {
 	ID              uuid.UUID              `json:"id" db:"id"`
 	VideoID         uuid.UUID              `json:"video_id" db:"video_id"`
+	AnalysisID      *uuid.UUID             `json:"analysis_id" db:"analysis_id"`
 	FeedbackText    string                 `json:"feedback_text" db:"feedback_text"`
 	ConfidenceScore *float64               `json:"confidence_score" db:"confidence_score"`
 	Issues          []FormIssue            `json:"issues" db:"issues"`
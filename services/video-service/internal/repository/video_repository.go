@@ -4,8 +4,10 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/powerlifting-coach-app/video-service/internal/models"
 )
 
@@ -185,29 +187,86 @@ func (r *VideoRepository) GetVideosByAthleteID(athleteID uuid.UUID, page, pageSi
 	return videos, totalCount, nil
 }
 
-func (r *VideoRepository) CreateFormFeedback(feedback *models.FormFeedback) error {
+// GetReadyVideosByAthleteIDs returns the processed videos the athletes uploaded
+// since a point in time, newest first
+func (r *VideoRepository) GetReadyVideosByAthleteIDs(athleteIDs []uuid.UUID, since time.Time) ([]models.Video, error) {
+	query := `
+		SELECT id, athlete_id, filename, original_filename, file_size, content_type,
+		       duration_seconds, original_url, processed_url, thumbnail_url,
+		       public_share_token, status, processing_error, metadata,
+		       created_at, updated_at, processed_at
+		FROM videos
+		WHERE athlete_id = ANY($1::uuid[])
+		  AND status = 'ready'
+		  AND created_at >= $2
+		ORDER BY created_at DESC`
+
+	ids := make([]string, len(athleteIDs))
+	for i, id := range athleteIDs {
+		ids[i] = id.String()
+	}
+
+	rows, err := r.db.Query(query, pq.Array(ids), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get athlete videos: %w", err)
+	}
+	defer rows.Close()
+
+	videos := []models.Video{}
+	for rows.Next() {
+		var video models.Video
+		var metadataJSON []byte
+
+		err := rows.Scan(
+			&video.ID, &video.AthleteID, &video.Filename, &video.OriginalFilename,
+			&video.FileSize, &video.ContentType, &video.DurationSeconds,
+			&video.OriginalURL, &video.ProcessedURL, &video.ThumbnailURL,
+			&video.PublicShareToken, &video.Status, &video.ProcessingError,
+			&metadataJSON, &video.CreatedAt, &video.UpdatedAt, &video.ProcessedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan video: %w", err)
+		}
+
+		if len(metadataJSON) > 0 {
+			json.Unmarshal(metadataJSON, &video.Metadata)
+		}
+
+		videos = append(videos, video)
+	}
+
+	return videos, nil
+}
+
+// CreateFormFeedback stores AI feedback for a video. Feedback for an analysis_id
+// that is already stored is ignored and reported as created=false.
+func (r *VideoRepository) CreateFormFeedback(feedback *models.FormFeedback) (bool, error) {
 	issuesJSON, _ := json.Marshal(feedback.Issues)
 
 	query := `
-		INSERT INTO form_feedback (video_id, feedback_text, confidence_score, issues, ai_model)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO form_feedback (video_id, analysis_id, feedback_text, confidence_score, issues, ai_model)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (analysis_id) DO NOTHING
 		RETURNING id, created_at`
 
 	err := r.db.QueryRow(query,
-		feedback.VideoID, feedback.FeedbackText, feedback.ConfidenceScore,
+		feedback.VideoID, feedback.AnalysisID, feedback.FeedbackText, feedback.ConfidenceScore,
 		issuesJSON, feedback.AIModel,
 	).Scan(&feedback.ID, &feedback.CreatedAt)
 
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create form feedback: %w", err)
+		return false, fmt.Errorf("failed to create form feedback: %w", err)
 	}
 
-	return nil
+	return true, nil
 }
 
 func (r *VideoRepository) GetFormFeedbackByVideoID(videoID uuid.UUID) ([]models.FormFeedback, error) {
 	query := `
-		SELECT id, video_id, feedback_text, confidence_score, issues, ai_model, created_at
+		SELECT id, video_id, analysis_id, feedback_text, confidence_score, issues, ai_model, created_at
 		FROM form_feedback 
 		WHERE video_id = $1 
 		ORDER BY created_at DESC`
@@ -224,7 +283,7 @@ func (r *VideoRepository) GetFormFeedbackByVideoID(videoID uuid.UUID) ([]models.
 		var issuesJSON []byte
 
 		err := rows.Scan(
-			&feedback.ID, &feedback.VideoID, &feedback.FeedbackText,
+			&feedback.ID, &feedback.VideoID, &feedback.AnalysisID, &feedback.FeedbackText,
 			&feedback.ConfidenceScore, &issuesJSON, &feedback.AIModel, &feedback.CreatedAt,
 		)
 		if err != nil {
@@ -0,0 +1,2 @@
+ALTER TABLE form_feedback
+DROP COLUMN IF EXISTS analysis_id;
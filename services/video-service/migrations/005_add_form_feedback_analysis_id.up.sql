@@ -0,0 +1,4 @@
+-- Links feedback to the program-service analysis that produced it, so a
+-- redelivered form.analyzed event is stored once
+ALTER TABLE form_feedback
+ADD COLUMN IF NOT EXISTS analysis_id UUID UNIQUE;
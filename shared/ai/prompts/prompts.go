@@ -0,0 +1,251 @@
+// Package prompts loads, validates and renders the {{variable}} prompt templates
+// in shared/ai/templates.
+//
+// Template files are named <name>[.v<version>][.<variant>].txt. A file without a
+// version is version 1 and a file without a variant is variant "a", so
+// coach_dm_response.txt is coach_dm_response@v1/a. The latest version of a
+// template is used unless a version is pinned; when a version has several
+// variants, callers are assigned one deterministically by a subject key (usually
+// the user ID) so each user consistently sees the same variant.
+package prompts
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PierreStephaneVoltaire/powerlifting-coach-app/shared/ai/templates"
+)
+
+const defaultVariant = "a"
+
+var (
+	variablePattern = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_]+)\s*\}\}`)
+	fileNamePattern = regexp.MustCompile(`^([a-z0-9_]+)(?:\.v([0-9]+))?(?:\.([a-z0-9_]+))?\.txt$`)
+)
+
+// Template is a single version/variant of a named prompt
+type Template struct {
+	Name      string
+	Version   int
+	Variant   string
+	Body      string
+	Variables []string
+}
+
+// ID identifies the exact template that produced a prompt, e.g. "program_chat_system@v2/b"
+func (t *Template) ID() string {
+	return fmt.Sprintf("%s@v%d/%s", t.Name, t.Version, t.Variant)
+}
+
+// Render substitutes every variable. It fails if any variable is missing from
+// vars; empty values are allowed for optional sections.
+func (t *Template) Render(vars map[string]string) (string, error) {
+	var missing []string
+	for _, v := range t.Variables {
+		if _, ok := vars[v]; !ok {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("template %s: missing variables: %s", t.ID(), strings.Join(missing, ", "))
+	}
+
+	return variablePattern.ReplaceAllStringFunc(t.Body, func(m string) string {
+		return vars[variablePattern.FindStringSubmatch(m)[1]]
+	}), nil
+}
+
+// Rendered is a rendered prompt together with the template that produced it
+type Rendered struct {
+	TemplateID string
+	Text       string
+}
+
+// Registry holds every loaded template
+type Registry struct {
+	mu        sync.RWMutex
+	templates map[string]map[int]map[string]*Template // name -> version -> variant
+	pinned    map[string]int
+}
+
+// LoadDefault loads the templates embedded from shared/ai/templates
+func LoadDefault() (*Registry, error) {
+	return Load(templates.FS)
+}
+
+// Load reads every *.txt template at the root of fsys
+func Load(fsys fs.FS) (*Registry, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates: %w", err)
+	}
+
+	r := &Registry{
+		templates: make(map[string]map[int]map[string]*Template),
+		pinned:    make(map[string]int),
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".txt" {
+			continue
+		}
+
+		m := fileNamePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid template file name %q", entry.Name())
+		}
+
+		version := 1
+		if m[2] != "" {
+			version, _ = strconv.Atoi(m[2])
+		}
+		variant := defaultVariant
+		if m[3] != "" {
+			variant = m[3]
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", entry.Name(), err)
+		}
+
+		tmpl, err := parse(m[1], version, variant, string(data))
+		if err != nil {
+			return nil, err
+		}
+
+		if r.templates[tmpl.Name] == nil {
+			r.templates[tmpl.Name] = make(map[int]map[string]*Template)
+		}
+		if r.templates[tmpl.Name][version] == nil {
+			r.templates[tmpl.Name][version] = make(map[string]*Template)
+		}
+		r.templates[tmpl.Name][version][variant] = tmpl
+	}
+
+	return r, nil
+}
+
+func parse(name string, version int, variant, body string) (*Template, error) {
+	body = strings.TrimSuffix(body, "\n")
+
+	// Stray braces usually mean a typo such as {{user_id} and would otherwise be
+	// sent to the model verbatim
+	stripped := variablePattern.ReplaceAllString(body, "")
+	if strings.Contains(stripped, "{{") || strings.Contains(stripped, "}}") {
+		return nil, fmt.Errorf("template %s@v%d/%s: malformed variable placeholder", name, version, variant)
+	}
+
+	seen := make(map[string]bool)
+	var vars []string
+	for _, m := range variablePattern.FindAllStringSubmatch(body, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			vars = append(vars, m[1])
+		}
+	}
+
+	return &Template{
+		Name:      name,
+		Version:   version,
+		Variant:   variant,
+		Body:      body,
+		Variables: vars,
+	}, nil
+}
+
+// Names lists the loaded template names
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pin makes Select use the given version instead of the latest one, e.g. to roll
+// back a prompt change without a deploy of the templates
+func (r *Registry) Pin(name string, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.templates[name][version]; !ok {
+		return fmt.Errorf("template %s has no version %d", name, version)
+	}
+	r.pinned[name] = version
+	return nil
+}
+
+// Get returns a specific version and variant
+func (r *Registry) Get(name string, version int, variant string) (*Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tmpl, ok := r.templates[name][version][variant]
+	if !ok {
+		return nil, fmt.Errorf("template %s@v%d/%s not found", name, version, variant)
+	}
+	return tmpl, nil
+}
+
+// Select returns the active version of a template and, when that version has
+// several variants, the variant assigned to subjectKey
+func (r *Registry) Select(name, subjectKey string) (*Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+
+	version, ok := r.pinned[name]
+	if !ok {
+		for v := range versions {
+			if v > version {
+				version = v
+			}
+		}
+	}
+
+	variants := versions[version]
+	keys := make([]string, 0, len(variants))
+	for k := range variants {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 1 {
+		return variants[keys[0]], nil
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + subjectKey))
+	return variants[keys[h.Sum32()%uint32(len(keys))]], nil
+}
+
+// Render selects a template for subjectKey and renders it
+func (r *Registry) Render(name, subjectKey string, vars map[string]string) (Rendered, error) {
+	tmpl, err := r.Select(name, subjectKey)
+	if err != nil {
+		return Rendered{}, err
+	}
+
+	text, err := tmpl.Render(vars)
+	if err != nil {
+		return Rendered{}, err
+	}
+
+	return Rendered{TemplateID: tmpl.ID(), Text: text}, nil
+}
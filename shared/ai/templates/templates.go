@@ -0,0 +1,8 @@
+// Package templates embeds the prompt templates so every service ships the same
+// copies. File names follow <name>[.v<version>][.<variant>].txt; see shared/ai/prompts.
+package templates
+
+import "embed"
+
+//go:embed *.txt
+var FS embed.FS
@@ -0,0 +1,316 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type Sex string
+
+const (
+	SexMale   Sex = "male"
+	SexFemale Sex = "female"
+)
+
+type Equipment string
+
+const (
+	EquipmentClassic  Equipment = "classic"  // raw / sleeves
+	EquipmentEquipped Equipment = "equipped" // single-ply
+)
+
+type Event string
+
+const (
+	EventTotal Event = "SBD"
+	EventBench Event = "B"
+)
+
+// ParseSex accepts the common spellings used by federations and the frontend
+func ParseSex(s string) (Sex, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "m", "male", "man", "men":
+		return SexMale, nil
+	case "f", "female", "woman", "women":
+		return SexFemale, nil
+	}
+	return "", fmt.Errorf("unknown sex %q", s)
+}
+
+// ParseEquipment defaults to classic when empty
+func ParseEquipment(s string) (Equipment, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "classic", "raw", "sleeves", "wraps":
+		return EquipmentClassic, nil
+	case "equipped", "single-ply", "single_ply":
+		return EquipmentEquipped, nil
+	}
+	return "", fmt.Errorf("unknown equipment %q", s)
+}
+
+// ParseEvent defaults to a full-power total when empty
+func ParseEvent(s string) (Event, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "", "SBD", "TOTAL":
+		return EventTotal, nil
+	case "B", "BENCH":
+		return EventBench, nil
+	}
+	return "", fmt.Errorf("unknown event %q", s)
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Min(math.Max(v, min), max)
+}
+
+func poly(x float64, coeffs []float64) float64 {
+	sum := 0.0
+	for i, c := range coeffs {
+		sum += c * math.Pow(x, float64(i))
+	}
+	return sum
+}
+
+var (
+	dotsMale   = []float64{-307.75076, 24.0900756, -0.1918759221, 0.0007391293, -0.000001093}
+	dotsFemale = []float64{-57.96288, 13.6175032, -0.1126655495, 0.0005158568, -0.0000010706}
+)
+
+// DOTS returns the DOTS score for a lifted amount in kg. Bodyweight is clamped to
+// 40-210 kg for men and 40-150 kg for women as specified by the formula.
+func DOTS(sex Sex, bodyweightKg, liftedKg float64) float64 {
+	if bodyweightKg <= 0 || liftedKg <= 0 {
+		return 0
+	}
+
+	var denom float64
+	switch sex {
+	case SexMale:
+		denom = poly(clamp(bodyweightKg, 40, 210), dotsMale)
+	case SexFemale:
+		denom = poly(clamp(bodyweightKg, 40, 150), dotsFemale)
+	default:
+		return 0
+	}
+
+	return liftedKg * 500 / denom
+}
+
+var (
+	wilksMale   = []float64{-216.0475144, 16.2606339, -0.002388645, -0.00113732, 7.01863e-06, -1.291e-08}
+	wilksFemale = []float64{594.31747775582, -27.23842536447, 0.82112226871, -0.00930733913, 4.731582e-05, -9.054e-08}
+)
+
+// Wilks returns the original (pre-2020) Wilks score
+func Wilks(sex Sex, bodyweightKg, liftedKg float64) float64 {
+	if bodyweightKg <= 0 || liftedKg <= 0 {
+		return 0
+	}
+
+	var denom float64
+	switch sex {
+	case SexMale:
+		denom = poly(clamp(bodyweightKg, 40, 201.9), wilksMale)
+	case SexFemale:
+		denom = poly(clamp(bodyweightKg, 26.51, 154.53), wilksFemale)
+	default:
+		return 0
+	}
+
+	return liftedKg * 500 / denom
+}
+
+var (
+	wilks2020Male   = []float64{47.46178854, 8.472061379, 0.07369410346, -0.001395833811, 7.07665973070743e-06, -1.20804336482315e-08}
+	wilks2020Female = []float64{-125.4255398, 13.71219419, -0.03307250631, -0.001050400051, 9.38773881462799e-06, -2.3334613884954e-08}
+)
+
+// Wilks2020 returns the revised Wilks score adopted in 2020, which is scaled to 600
+func Wilks2020(sex Sex, bodyweightKg, liftedKg float64) float64 {
+	if bodyweightKg <= 0 || liftedKg <= 0 {
+		return 0
+	}
+
+	var denom float64
+	switch sex {
+	case SexMale:
+		denom = poly(clamp(bodyweightKg, 40, 200.95), wilks2020Male)
+	case SexFemale:
+		denom = poly(clamp(bodyweightKg, 40, 150.95), wilks2020Female)
+	default:
+		return 0
+	}
+
+	return liftedKg * 600 / denom
+}
+
+type glParams struct {
+	a, b, c float64
+}
+
+var ipfGLParams = map[Sex]map[Equipment]map[Event]glParams{
+	SexMale: {
+		EquipmentClassic: {
+			EventTotal: {1199.72839, 1025.18162, 0.00921},
+			EventBench: {320.98041, 281.40258, 0.01008},
+		},
+		EquipmentEquipped: {
+			EventTotal: {1236.25115, 1449.21864, 0.01644},
+			EventBench: {381.22073, 733.79378, 0.02398},
+		},
+	},
+	SexFemale: {
+		EquipmentClassic: {
+			EventTotal: {610.32796, 1045.59282, 0.03048},
+			EventBench: {142.40398, 442.52671, 0.04724},
+		},
+		EquipmentEquipped: {
+			EventTotal: {758.63878, 949.31382, 0.02435},
+			EventBench: {221.82209, 357.00377, 0.02937},
+		},
+	},
+}
+
+// IPFGL returns IPF GL (Goodlift) points. The IPF only publishes coefficients for
+// the full-power total and for bench-only, so those are the two supported events.
+// Bodyweights under 35 kg are outside the formula's domain and score zero.
+func IPFGL(sex Sex, equipment Equipment, event Event, bodyweightKg, liftedKg float64) float64 {
+	if bodyweightKg < 35 || liftedKg <= 0 {
+		return 0
+	}
+
+	params, ok := ipfGLParams[sex][equipment][event]
+	if !ok {
+		return 0
+	}
+
+	denom := params.a - params.b*math.Exp(-params.c*bodyweightKg)
+	if denom <= 0 {
+		return 0
+	}
+
+	return liftedKg * 100 / denom
+}
+
+// fosterCoefficients covers juniors from age 14 to 22 (index 0 = age 14)
+var fosterCoefficients = []float64{1.23, 1.18, 1.13, 1.08, 1.06, 1.04, 1.03, 1.02, 1.01}
+
+// mccullochCoefficients covers masters from age 40 to 90 (index 0 = age 40,
+// which the table leaves unadjusted)
+var mccullochCoefficients = []float64{
+	1.000, 1.010, 1.020, 1.031, 1.043, 1.055, 1.068, 1.082, 1.097, 1.113,
+	1.130, 1.147, 1.165, 1.184, 1.204, 1.225, 1.246, 1.268, 1.291, 1.315,
+	1.340, 1.366, 1.393, 1.421, 1.450, 1.480, 1.511, 1.543, 1.576, 1.610,
+	1.645, 1.681, 1.718, 1.756, 1.795, 1.835, 1.876, 1.918, 1.961, 2.005,
+	2.050, 2.096, 2.143, 2.190, 2.238, 2.287, 2.337, 2.388, 2.440, 2.494,
+	2.549,
+}
+
+// FosterCoefficient returns the junior age multiplier. Ages below 14 use the
+// age-14 value and ages 23 and up return 1.
+func FosterCoefficient(age int) float64 {
+	if age >= 14+len(fosterCoefficients) {
+		return 1
+	}
+	if age < 14 {
+		age = 14
+	}
+	return fosterCoefficients[age-14]
+}
+
+// McCullochCoefficient returns the masters age multiplier. Ages below 40 return 1
+// and ages above 90 use the age-90 value.
+func McCullochCoefficient(age int) float64 {
+	if age < 40 {
+		return 1
+	}
+	idx := age - 40
+	if idx >= len(mccullochCoefficients) {
+		idx = len(mccullochCoefficients) - 1
+	}
+	return mccullochCoefficients[idx]
+}
+
+// AgeCoefficient combines Foster (juniors) and McCulloch (masters) so any age
+// can be age-adjusted with a single multiplier.
+func AgeCoefficient(age int) float64 {
+	if age <= 0 {
+		return 1
+	}
+	if age < 23 {
+		return FosterCoefficient(age)
+	}
+	return McCullochCoefficient(age)
+}
+
+type Input struct {
+	Sex          Sex
+	Equipment    Equipment
+	Event        Event
+	BodyweightKg float64
+	LiftedKg     float64
+	Age          *int
+}
+
+type Scores struct {
+	LiftedKg         float64   `json:"lifted_kg"`
+	BodyweightKg     float64   `json:"bodyweight_kg"`
+	Sex              Sex       `json:"sex"`
+	Equipment        Equipment `json:"equipment"`
+	Event            Event     `json:"event"`
+	DOTS             float64   `json:"dots"`
+	Wilks            float64   `json:"wilks"`
+	Wilks2020        float64   `json:"wilks_2020"`
+	IPFGLPoints      float64   `json:"ipf_gl_points"`
+	AgeCoefficient   *float64  `json:"age_coefficient,omitempty"`
+	AgeAdjustedDOTS  *float64  `json:"age_adjusted_dots,omitempty"`
+	AgeAdjustedIPFGL *float64  `json:"age_adjusted_ipf_gl_points,omitempty"`
+}
+
+// Calculate runs every formula for the input. Results are rounded to two decimals
+// the same way meet results publish them.
+func Calculate(in Input) (Scores, error) {
+	if in.Sex != SexMale && in.Sex != SexFemale {
+		return Scores{}, fmt.Errorf("sex must be %q or %q", SexMale, SexFemale)
+	}
+	if in.BodyweightKg <= 0 {
+		return Scores{}, fmt.Errorf("bodyweight must be positive")
+	}
+	if in.LiftedKg <= 0 {
+		return Scores{}, fmt.Errorf("lifted weight must be positive")
+	}
+	if in.Equipment == "" {
+		in.Equipment = EquipmentClassic
+	}
+	if in.Event == "" {
+		in.Event = EventTotal
+	}
+
+	scores := Scores{
+		LiftedKg:     in.LiftedKg,
+		BodyweightKg: in.BodyweightKg,
+		Sex:          in.Sex,
+		Equipment:    in.Equipment,
+		Event:        in.Event,
+		DOTS:         round2(DOTS(in.Sex, in.BodyweightKg, in.LiftedKg)),
+		Wilks:        round2(Wilks(in.Sex, in.BodyweightKg, in.LiftedKg)),
+		Wilks2020:    round2(Wilks2020(in.Sex, in.BodyweightKg, in.LiftedKg)),
+		IPFGLPoints:  round2(IPFGL(in.Sex, in.Equipment, in.Event, in.BodyweightKg, in.LiftedKg)),
+	}
+
+	if in.Age != nil {
+		coeff := AgeCoefficient(*in.Age)
+		dots := round2(scores.DOTS * coeff)
+		gl := round2(scores.IPFGLPoints * coeff)
+		scores.AgeCoefficient = &coeff
+		scores.AgeAdjustedDOTS = &dots
+		scores.AgeAdjustedIPFGL = &gl
+	}
+
+	return scores, nil
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,207 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+)
+
+// Reference vectors are computed from the published coefficient sets (DOTS,
+// Wilks 1995 and 2020 as used by OpenPowerlifting, IPF GL 2020) and checked to
+// the two decimals meet results use.
+
+func assertPoints(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 0.005 {
+		t.Errorf("%s = %.4f, want %.2f", name, got, want)
+	}
+}
+
+func TestDOTS(t *testing.T) {
+	tests := []struct {
+		name         string
+		sex          Sex
+		bodyweightKg float64
+		liftedKg     float64
+		want         float64
+	}{
+		{"men 100kg", SexMale, 100, 1000, 615.52},
+		{"men 59.8kg", SexMale, 59.8, 608, 514.59},
+		{"men 93.7kg", SexMale, 93.7, 1032.5, 654.58},
+		{"men 140kg", SexMale, 140, 1000, 548.02},
+		{"women 60kg", SexFemale, 60, 500, 554.27},
+		{"women 51.7kg", SexFemale, 51.7, 408, 499.32},
+		{"women 57kg", SexFemale, 57, 400, 458.28},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertPoints(t, "DOTS", DOTS(tt.sex, tt.bodyweightKg, tt.liftedKg), tt.want)
+		})
+	}
+}
+
+func TestDOTSClampsBodyweight(t *testing.T) {
+	if got, want := DOTS(SexMale, 250, 1000), DOTS(SexMale, 210, 1000); got != want {
+		t.Errorf("men above 210kg = %.2f, want the 210kg score %.2f", got, want)
+	}
+	if got, want := DOTS(SexFemale, 180, 500), DOTS(SexFemale, 150, 500); got != want {
+		t.Errorf("women above 150kg = %.2f, want the 150kg score %.2f", got, want)
+	}
+	if got := DOTS(SexMale, 0, 500); got != 0 {
+		t.Errorf("zero bodyweight = %.2f, want 0", got)
+	}
+}
+
+func TestWilks(t *testing.T) {
+	tests := []struct {
+		name         string
+		sex          Sex
+		bodyweightKg float64
+		liftedKg     float64
+		want         float64
+	}{
+		{"men 100kg", SexMale, 100, 1000, 608.59},
+		{"men 59.8kg", SexMale, 59.8, 608, 520.13},
+		{"men 90kg", SexMale, 90, 700, 446.88},
+		{"men 140kg", SexMale, 140, 1000, 558.81},
+		{"women 60kg", SexFemale, 60, 500, 557.44},
+		{"women 51.7kg", SexFemale, 51.7, 408, 510.90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertPoints(t, "Wilks", Wilks(tt.sex, tt.bodyweightKg, tt.liftedKg), tt.want)
+		})
+	}
+}
+
+func TestWilks2020(t *testing.T) {
+	tests := []struct {
+		name         string
+		sex          Sex
+		bodyweightKg float64
+		liftedKg     float64
+		want         float64
+	}{
+		{"men 100kg", SexMale, 100, 1000, 729.36},
+		{"men 59.8kg", SexMale, 59.8, 608, 607.61},
+		{"men 90kg", SexMale, 90, 700, 536.90},
+		{"women 60kg", SexFemale, 60, 500, 659.52},
+		{"women 57kg", SexFemale, 57, 400, 546.39},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertPoints(t, "Wilks2020", Wilks2020(tt.sex, tt.bodyweightKg, tt.liftedKg), tt.want)
+		})
+	}
+}
+
+func TestIPFGL(t *testing.T) {
+	tests := []struct {
+		name         string
+		sex          Sex
+		equipment    Equipment
+		event        Event
+		bodyweightKg float64
+		liftedKg     float64
+		want         float64
+	}{
+		{"men classic total", SexMale, EquipmentClassic, EventTotal, 93, 700, 91.57},
+		{"men classic bench", SexMale, EquipmentClassic, EventBench, 83, 180, 90.41},
+		{"men equipped total", SexMale, EquipmentEquipped, EventTotal, 120, 1000, 96.65},
+		{"men equipped bench", SexMale, EquipmentEquipped, EventBench, 105, 300, 93.15},
+		{"women classic total", SexFemale, EquipmentClassic, EventTotal, 63, 450, 98.45},
+		{"women classic bench", SexFemale, EquipmentClassic, EventBench, 57, 100, 88.93},
+		{"women equipped total", SexFemale, EquipmentEquipped, EventTotal, 72, 600, 100.98},
+		{"women equipped bench", SexFemale, EquipmentEquipped, EventBench, 84, 180, 93.98},
+		{"under 35kg", SexFemale, EquipmentClassic, EventTotal, 34, 300, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IPFGL(tt.sex, tt.equipment, tt.event, tt.bodyweightKg, tt.liftedKg)
+			assertPoints(t, "IPFGL", got, tt.want)
+		})
+	}
+}
+
+func TestAgeCoefficients(t *testing.T) {
+	tests := []struct {
+		age  int
+		want float64
+	}{
+		{10, 1.23},
+		{14, 1.23},
+		{18, 1.06},
+		{22, 1.01},
+		{23, 1},
+		{39, 1},
+		{40, 1},
+		{41, 1.01},
+		{50, 1.13},
+		{60, 1.34},
+		{70, 1.645},
+		{80, 2.05},
+		{90, 2.549},
+		{95, 2.549},
+	}
+
+	for _, tt := range tests {
+		if got := AgeCoefficient(tt.age); got != tt.want {
+			t.Errorf("AgeCoefficient(%d) = %.3f, want %.3f", tt.age, got, tt.want)
+		}
+	}
+
+	if got := FosterCoefficient(30); got != 1 {
+		t.Errorf("FosterCoefficient(30) = %.3f, want 1", got)
+	}
+	if got := McCullochCoefficient(30); got != 1 {
+		t.Errorf("McCullochCoefficient(30) = %.3f, want 1", got)
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	age := 50
+	scores, err := Calculate(Input{
+		Sex:          SexMale,
+		BodyweightKg: 93,
+		LiftedKg:     700,
+		Age:          &age,
+	})
+	if err != nil {
+		t.Fatalf("Calculate: %v", err)
+	}
+
+	if scores.Equipment != EquipmentClassic || scores.Event != EventTotal {
+		t.Errorf("defaults = %s/%s, want classic/SBD", scores.Equipment, scores.Event)
+	}
+	if scores.IPFGLPoints != 91.57 {
+		t.Errorf("IPFGLPoints = %.2f, want 91.57", scores.IPFGLPoints)
+	}
+	if scores.AgeCoefficient == nil || *scores.AgeCoefficient != 1.13 {
+		t.Fatalf("AgeCoefficient = %v, want 1.13", scores.AgeCoefficient)
+	}
+	if want := round2(scores.DOTS * 1.13); *scores.AgeAdjustedDOTS != want {
+		t.Errorf("AgeAdjustedDOTS = %.2f, want %.2f", *scores.AgeAdjustedDOTS, want)
+	}
+}
+
+func TestCalculateRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Input
+	}{
+		{"missing sex", Input{BodyweightKg: 80, LiftedKg: 500}},
+		{"zero bodyweight", Input{Sex: SexFemale, LiftedKg: 300}},
+		{"zero lifted", Input{Sex: SexMale, BodyweightKg: 80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Calculate(tt.in); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}